@@ -1,266 +1,597 @@
-// The iextool command prints stock data for a list of stock symbols.
-// go run cmd/iextool/iextool.go -token TOKEN
+// The iextool command gets stock data from IEX and manages the chart cache.
+//
+//	PONZI_IEX_API_TOKEN=TOKEN go run cmd/iextool/iextool.go quote AAPL MSFT
+//	go run cmd/iextool/iextool.go -format csv chart -range 5y SPY
+//	go run cmd/iextool/iextool.go cache ls
+//	go run cmd/iextool/iextool.go cache purge -last 10 SPY
+//	go run cmd/iextool/iextool.go -format json cache export SPY
+//	go run cmd/iextool/iextool.go -record_dir testdata quote AAPL
+//	go run cmd/iextool/iextool.go -replay_dir testdata quote AAPL
+//
+// The API token is read from the PONZI_IEX_API_TOKEN environment variable or the
+// credentials file that the app saves.
+//
+// It exits with status 1 if a command fails and 2 if it is used incorrectly.
 package main
 
 import (
 	"context"
 	"expvar"
-	_ "expvar"
 	"flag"
 	"fmt"
-	"log"
-	"strconv"
+	"io"
+	"os"
 	"strings"
-	"time"
 
+	"github.com/btmura/ponzi2/internal/app/credentials"
+	"github.com/btmura/ponzi2/internal/errs"
 	"github.com/btmura/ponzi2/internal/stock/iex"
 )
 
 var (
-	// port             = flag.Int("port", 9000, "Port number to export metrics.")
-	token            = flag.String("token", "", "API token required on requests.")
+	token            = flag.String("token", "", "Deprecated: API token required on requests. Set PONZI_IEX_API_TOKEN instead.")
+	format           = flag.String("format", "table", "Output format: table, json, or csv.")
 	enableChartCache = flag.Bool("enable_chart_cache", true, "Whether to enable the chart cache.")
-	dumpAPIResponses = flag.Bool("dump_api_responses", false, "Dump API responses to txt files.")
+	dumpAPIResponses = flag.Bool("dump_api_responses", false, "Deprecated: Record API responses to "+iex.DefaultRecordDir+" for -replay_dir. Use -record_dir instead.")
+	printStats       = flag.Bool("stats", false, "Print the IEX client stats to stderr when done.")
+	recordDir        = flag.String("record_dir", "", "Directory to record API responses to for -replay_dir.")
+	replayDir        = flag.String("replay_dir", "", "Directory of API responses recorded with -record_dir to use instead of the network.")
 )
 
+// Exit codes.
+const (
+	exitOK    = 0
+	exitError = 1
+	exitUsage = 2
+)
+
+// chartCache is the chart cache that the cache commands manage.
 type chartCache interface {
 	Get(ctx context.Context, key iex.ChartCacheKey) (*iex.ChartCacheValue, error)
 	Put(ctx context.Context, key iex.ChartCacheKey, val *iex.ChartCacheValue) error
+	Keys(ctx context.Context) ([]iex.ChartCacheKey, error)
+	Delete(ctx context.Context, key iex.ChartCacheKey) error
+	Evict(ctx context.Context, policy iex.ChartCachePolicy) (int, error)
+	Stats(ctx context.Context) (*iex.ChartCacheStats, error)
+	Close() error
+}
+
+// env has what commands need to run.
+type env struct {
+	// client gets data from IEX.
+	client *iex.Client
+
+	// cache is the chart cache. Nil if the cache is disabled.
+	cache chartCache
+
+	// token is the API token to include on requests. Empty if there is none.
+	token string
+
+	// replaying is whether requests are served from recorded responses and don't need a token.
+	replaying bool
+
+	// format is the format to print results in.
+	format outputFormat
+
+	// out is where results are printed.
+	out io.Writer
+}
+
+// command is a subcommand like quote.
+type command struct {
+	// name is the name of the command with its parent like "cache ls".
+	name string
+
+	// args describes the arguments after the flags.
+	args string
+
+	// desc describes what the command does.
+	desc string
+
+	// setup defines the command's flags on the flag set and returns the function to run it.
+	setup func(fs *flag.FlagSet) func(ctx context.Context, e *env, args []string) error
+}
+
+// usageError is returned when a command is used incorrectly.
+type usageError struct {
+	msg string
+}
+
+func (u *usageError) Error() string {
+	return u.msg
+}
+
+func usageErrorf(format string, a ...interface{}) error {
+	return &usageError{fmt.Sprintf(format, a...)}
+}
+
+var commands = []*command{
+	{name: "quote", args: "SYMBOL...", desc: "Print quotes.", setup: quoteCommand},
+	{name: "chart", args: "SYMBOL...", desc: "Print chart points.", setup: chartCommand},
+	{name: "cache ls", desc: "List chart cache entries.", setup: cacheListCommand},
+	{name: "cache purge", args: "[SYMBOL...]", desc: "Remove chart cache entries or their last points.", setup: cachePurgeCommand},
+	{name: "cache export", args: "SYMBOL...", desc: "Print cached chart points.", setup: cacheExportCommand},
+	{name: "cache stats", desc: "Print the number and size of chart cache entries.", setup: cacheStatsCommand},
+	{name: "cache evict", desc: "Remove expired and least recently used chart cache entries beyond the limits.", setup: cacheEvictCommand},
 }
 
 func main() {
+	flag.Usage = usage
 	flag.Parse()
+	os.Exit(run(context.Background(), flag.Args()))
+}
 
-	if *token == "" {
-		log.Fatal("token cannot be empty")
+func usage() {
+	w := flag.CommandLine.Output()
+	fmt.Fprintf(w, "Usage: iextool [flags] COMMAND [command flags] [args]\n\nCommands:\n")
+	for _, c := range commands {
+		fmt.Fprintf(w, "  %s\n", strings.TrimSpace(c.name+" "+c.args))
+		fmt.Fprintf(w, "    \t%s\n", c.desc)
 	}
+	fmt.Fprintf(w, "\nFlags:\n")
+	flag.PrintDefaults()
+}
 
-	// fmt.Printf("Serving metrics at http://localhost:%d/debug/vars\n", *port)
-	// go func() {
-	// 	if err := http.ListenAndServe(fmt.Sprintf(":%d", *port), nil); err != nil {
-	// 		fmt.Printf("http.ListenAndServe failed: %v\n", err)
-	// 	}
-	// }()
-
-	ctx := context.Background()
+// run runs the command named by the args and returns the exit code.
+func run(ctx context.Context, args []string) int {
+	c, args := findCommand(args)
+	if c == nil {
+		if len(args) == 0 {
+			fmt.Fprintln(os.Stderr, "missing command")
+		} else {
+			fmt.Fprintf(os.Stderr, "unknown command: %s\n", strings.Join(args, " "))
+		}
+		usage()
+		return exitUsage
+	}
 
-	var client *iex.Client
-	var cache chartCache
-	var err error
+	fs := flag.NewFlagSet(c.name, flag.ContinueOnError)
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "Usage: iextool [flags] %s [command flags] %s\n\n%s\n", c.name, c.args, c.desc)
+		fs.PrintDefaults()
+	}
+	runCommand := c.setup(fs)
+	if err := fs.Parse(args); err != nil {
+		if err == flag.ErrHelp {
+			return exitOK
+		}
+		return exitUsage
+	}
 
-	switch {
-	case *enableChartCache:
-		fmt.Println("Using GOB ChartCache...")
-		cache, err = iex.OpenGOBChartCache()
-		if err != nil {
-			log.Fatal(err)
+	e, err := newEnv()
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		if _, ok := err.(*usageError); ok {
+			return exitUsage
 		}
-		client = iex.NewClient(cache, *dumpAPIResponses)
+		return exitError
+	}
 
-	default:
-		fmt.Println("Using No-op ChartCache...")
-		cache = new(iex.NoOpChartCache)
-		client = iex.NewClient(cache, *dumpAPIResponses)
+	err = runCommand(ctx, e, fs.Args())
+
+	// Save any entries that the command added.
+	if e.cache != nil {
+		if cerr := e.cache.Close(); cerr != nil && err == nil {
+			err = cerr
+		}
 	}
 
-	for {
+	if *printStats {
 		expvar.Do(func(kv expvar.KeyValue) {
 			if strings.HasPrefix(kv.Key, "iex") {
-				fmt.Println(kv)
+				fmt.Fprintln(os.Stderr, kv)
 			}
 		})
+	}
 
-		fmt.Println()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "%s: %v\n", c.name, err)
+		if _, ok := err.(*usageError); ok {
+			fs.Usage()
+			return exitUsage
+		}
+		return exitError
+	}
 
-		fmt.Printf("Enter comma-separated symbols: ")
+	return exitOK
+}
 
-		symbolLine := ""
-		if _, err := fmt.Scanln(&symbolLine); err != nil {
-			fmt.Println(err)
+// findCommand returns the command named by the leading args and the remaining args.
+func findCommand(args []string) (*command, []string) {
+	for _, c := range commands {
+		words := strings.Fields(c.name)
+		if len(args) < len(words) {
 			continue
 		}
-		symbolLine = strings.ToUpper(symbolLine)
-		symbols := strings.Split(symbolLine, ",")
+		if strings.Join(args[:len(words)], " ") == c.name {
+			return c, args[len(words):]
+		}
+	}
+	return nil, args
+}
 
-		const (
-			getAPIDataAction     = "Get API Data"
-			clearCacheDataAction = "Clear Cache Data"
-		)
+func newEnv() (*env, error) {
+	f, err := parseOutputFormat(*format)
+	if err != nil {
+		return nil, usageErrorf("%v", err)
+	}
 
-		action := pick("Pick an action", getAPIDataAction, clearCacheDataAction).(string)
+	e := &env{token: *token, replaying: *replayDir != "", format: f, out: os.Stdout}
 
-		switch action {
-		case getAPIDataAction:
-			getAPIData(ctx, client, symbols)
+	if e.token == "" {
+		creds, err := credentials.Load()
+		if err != nil {
+			return nil, err
+		}
+		e.token = creds.IEXAPIToken
+	}
+
+	var opts []iex.ClientOption
+	if *dumpAPIResponses && *recordDir == "" {
+		fmt.Fprintln(os.Stderr, "-dump_api_responses is deprecated, use -record_dir instead")
+		opts = append(opts, iex.RecordDir(iex.DefaultRecordDir))
+	}
+	if *recordDir != "" {
+		opts = append(opts, iex.RecordDir(*recordDir))
+	}
+	if *replayDir != "" {
+		opts = append(opts, iex.ReplayDir(*replayDir))
+	}
 
-		case clearCacheDataAction:
-			clearCacheData(ctx, cache, symbols, *token)
+	if *enableChartCache {
+		c, err := iex.OpenGOBChartCache(iex.DefaultChartCachePolicy)
+		if err != nil {
+			return nil, err
 		}
+		e.cache = c
+	}
+
+	// Don't mix replayed data into the chart cache.
+	if e.cache != nil && !e.replaying {
+		e.client = iex.NewClient(e.cache, opts...)
+	} else {
+		e.client = iex.NewClient(new(iex.NoOpChartCache), opts...)
 	}
+
+	return e, nil
 }
 
-func getAPIData(ctx context.Context, client *iex.Client, symbols []string) {
-	dataRange := pick("Pick a range", iex.TwoYears, iex.OneDay).(iex.Range)
+func quoteCommand(fs *flag.FlagSet) func(ctx context.Context, e *env, args []string) error {
+	return func(ctx context.Context, e *env, args []string) error {
+		symbols, err := symbolArgs(args)
+		if err != nil {
+			return err
+		}
+
+		if e.token == "" && !e.replaying {
+			return usageErrorf("missing API token, set %s", credentials.IEXAPITokenEnvVar)
+		}
 
-	qReq := &iex.GetQuotesRequest{
-		Token:   *token,
-		Symbols: symbols,
-	}
+		quotes, err := e.client.GetQuotes(ctx, &iex.GetQuotesRequest{
+			Token:   e.token,
+			Symbols: symbols,
+		})
+		if err != nil {
+			return err
+		}
 
-	fmt.Printf("Quotes: %+v\n\n", qReq)
+		var rs []record
+		got := map[string]bool{}
+		for _, q := range quotes {
+			rs = append(rs, newQuoteRecord(q))
+			got[q.Symbol] = true
+		}
 
-	quotes, err := client.GetQuotes(ctx, qReq)
-	if err != nil {
-		fmt.Println(err)
-		return
-	}
+		if err := writeRecords(e.out, e.format, quoteHeader, rs); err != nil {
+			return err
+		}
 
-	formatTime := func(t time.Time) string {
-		return t.Format("1/2/06 03:04 AM")
+		return missingSymbols(symbols, got)
 	}
+}
 
-	for i, q := range quotes {
-		fmt.Printf("%d: %q %q LP: %.2f LS: %v LT: %s, LU: %s, LV: %d "+
-			"O: %.2f H: %.2f L: %.2f C: %.2f CH: %.2f CHP: %.2f\n",
-			i,
-			q.Symbol,
-			q.CompanyName,
-			q.LatestPrice,
-			q.LatestSource,
-			formatTime(q.LatestTime),
-			formatTime(q.LatestUpdate),
-			q.LatestVolume,
-			q.Open,
-			q.High,
-			q.Low,
-			q.Close,
-			q.Change,
-			q.ChangePercent)
-	}
+func chartCommand(fs *flag.FlagSet) func(ctx context.Context, e *env, args []string) error {
+	rangeName := fs.String("range", "2y", "Range of data: 1d, 2y, 5y, or max.")
+	last := fs.Int("last", 0, "Number of most recent points to print per symbol. Zero prints all of them.")
 
-	fmt.Println()
+	return func(ctx context.Context, e *env, args []string) error {
+		symbols, err := symbolArgs(args)
+		if err != nil {
+			return err
+		}
 
-	cReq := &iex.GetChartsRequest{
-		Token:   *token,
-		Symbols: symbols,
-		Range:   dataRange,
-	}
+		r, err := parseRange(*rangeName)
+		if err != nil {
+			return err
+		}
 
-	fmt.Printf("Charts: %+v\n\n", cReq)
+		if e.token == "" && !e.replaying {
+			return usageErrorf("missing API token, set %s", credentials.IEXAPITokenEnvVar)
+		}
 
-	charts, err := client.GetCharts(ctx, cReq)
-	if err != nil {
-		fmt.Println(err)
-		return
+		charts, err := e.client.GetCharts(ctx, &iex.GetChartsRequest{
+			Token:   e.token,
+			Symbols: symbols,
+			Range:   r,
+		})
+		if err != nil {
+			return err
+		}
+
+		var rs []record
+		got := map[string]bool{}
+		for _, ch := range charts {
+			rs = append(rs, chartPointRecords(ch, *last)...)
+			got[ch.Symbol] = true
+		}
+
+		if err := writeRecords(e.out, e.format, chartPointHeader, rs); err != nil {
+			return err
+		}
+
+		return missingSymbols(symbols, got)
 	}
+}
 
-	const last = 20
-
-	for i, ch := range charts {
-		fmt.Printf("%d: %s\n", i, ch.Symbol)
-		for j, p := range ch.ChartPoints {
-			if j >= len(ch.ChartPoints)-last {
-				fmt.Printf("\t%d: %s O: %.2f H: %.2f L: %.2f C: %.2f V: %d CH: %.2f CHP: %.2f\n",
-					j,
-					formatTime(p.Date),
-					p.Open,
-					p.High,
-					p.Low,
-					p.Close,
-					p.Volume,
-					p.Change,
-					p.ChangePercent)
+func cacheListCommand(fs *flag.FlagSet) func(ctx context.Context, e *env, args []string) error {
+	return func(ctx context.Context, e *env, args []string) error {
+		if len(args) != 0 {
+			return usageErrorf("unexpected args: %s", strings.Join(args, " "))
+		}
+
+		if e.cache == nil {
+			return errs.Errorf("chart cache is disabled")
+		}
+
+		keys, err := e.cache.Keys(ctx)
+		if err != nil {
+			return err
+		}
+
+		var rs []record
+		for _, k := range keys {
+			v, err := e.cache.Get(ctx, k)
+			if err != nil {
+				return err
+			}
+			if v == nil {
+				continue
 			}
+			rs = append(rs, newCacheEntryRecord(k, v))
 		}
-	}
 
-	fmt.Println()
+		return writeRecords(e.out, e.format, cacheEntryHeader, rs)
+	}
 }
 
-func clearCacheData(ctx context.Context, cache chartCache, symbols []string, token string) {
-	numPoints := pick("Pick how many points to clear", "10", "25", "50", "All").(string)
+func cachePurgeCommand(fs *flag.FlagSet) func(ctx context.Context, e *env, args []string) error {
+	all := fs.Bool("all", false, "Purge the entries of all symbols.")
+	last := fs.Int("last", 0, "Number of most recent points to remove instead of whole entries, so they are fetched again.")
+
+	return func(ctx context.Context, e *env, args []string) error {
+		if *all == (len(args) != 0) {
+			return usageErrorf("pass either -all or symbols")
+		}
+
+		if *last < 0 {
+			return usageErrorf("bad -last: %d", *last)
+		}
+
+		if e.cache == nil {
+			return errs.Errorf("chart cache is disabled")
+		}
 
-	for i := range symbols {
-		key := iex.ChartCacheKey{
-			Token:    token,
-			Symbol:   symbols[i],
-			Interval: iex.DailyInterval,
+		symbols, err := symbolArgs(args)
+		if err != nil && !*all {
+			return err
 		}
 
-		val, err := cache.Get(ctx, key)
+		keys, err := e.cache.Keys(ctx)
 		if err != nil {
-			fmt.Println(err)
-			continue
+			return err
 		}
 
-		chartPoints := val.Chart.ChartPoints
-		if len(chartPoints) != 0 {
-			switch {
-			case numPoints == "All":
-				chartPoints = nil
+		for _, k := range keys {
+			if !*all && !containsString(symbols, k.Symbol) {
+				continue
+			}
 
-			default:
-				n, err := strconv.Atoi(numPoints)
-				if err != nil {
-					fmt.Println(err)
-					return
+			if *last == 0 {
+				if err := e.cache.Delete(ctx, k); err != nil {
+					return err
 				}
-				trimIndex := len(chartPoints) - n
-				if trimIndex < 0 {
-					trimIndex = 0
-				}
-				chartPoints = chartPoints[:trimIndex]
+				fmt.Fprintf(os.Stderr, "purged %s %v\n", k.Symbol, k.Interval)
+				continue
+			}
+
+			v, err := e.cache.Get(ctx, k)
+			if err != nil {
+				return err
+			}
+			if v == nil || v.Chart == nil {
+				continue
+			}
+
+			ps := v.Chart.ChartPoints
+			n := len(ps) - *last
+			if n < 0 {
+				n = 0
 			}
+			v.Chart.ChartPoints = ps[:n]
+
+			if err := e.cache.Put(ctx, k, v); err != nil {
+				return err
+			}
+			fmt.Fprintf(os.Stderr, "purged %d points of %s %v\n", len(ps)-n, k.Symbol, k.Interval)
 		}
 
-		val = &iex.ChartCacheValue{
-			Chart: &iex.Chart{
-				Symbol:      symbols[i],
-				ChartPoints: chartPoints,
-			},
-			LastUpdateTime: time.Time{},
+		return nil
+	}
+}
+
+func cacheExportCommand(fs *flag.FlagSet) func(ctx context.Context, e *env, args []string) error {
+	intervalName := fs.String("interval", "daily", "Interval of the cached charts: daily or minute.")
+
+	return func(ctx context.Context, e *env, args []string) error {
+		symbols, err := symbolArgs(args)
+		if err != nil {
+			return err
 		}
 
-		if err := cache.Put(ctx, key, val); err != nil {
-			fmt.Println(err)
-			continue
+		var interval iex.ChartInterval
+		switch strings.ToLower(*intervalName) {
+		case "daily":
+			interval = iex.DailyInterval
+		case "minute":
+			interval = iex.MinuteInterval
+		default:
+			return usageErrorf("bad -interval: %q, want daily or minute", *intervalName)
+		}
+
+		if e.cache == nil {
+			return errs.Errorf("chart cache is disabled")
+		}
+
+		var rs []record
+		got := map[string]bool{}
+		for _, sym := range symbols {
+			v, err := e.cache.Get(ctx, iex.ChartCacheKey{Symbol: sym, Interval: interval})
+			if err != nil {
+				return err
+			}
+			if v == nil || v.Chart == nil {
+				continue
+			}
+
+			rs = append(rs, chartPointRecords(v.Chart, 0)...)
+			got[sym] = true
 		}
+
+		if err := writeRecords(e.out, e.format, chartPointHeader, rs); err != nil {
+			return err
+		}
+
+		return missingSymbols(symbols, got)
 	}
 }
 
-func pick(prompt string, choices ...interface{}) interface{} {
-	selected := 0
-	for {
-		fmt.Printf("%s [%d]:\n", prompt, selected)
-		for i, v := range choices {
-			fmt.Printf("[%d] %v\n", i, v)
+func cacheStatsCommand(fs *flag.FlagSet) func(ctx context.Context, e *env, args []string) error {
+	return func(ctx context.Context, e *env, args []string) error {
+		if len(args) != 0 {
+			return usageErrorf("unexpected args: %v", args)
 		}
 
-		line := ""
-		if _, err := fmt.Scanln(&line); err != nil {
-			fmt.Println(err)
-			continue
+		if e.cache == nil {
+			return errs.Errorf("chart cache is disabled")
 		}
 
-		line = strings.TrimSpace(line)
-		if line == "" {
-			break
+		st, err := e.cache.Stats(ctx)
+		if err != nil {
+			return err
+		}
+
+		return writeRecords(e.out, e.format, cacheStatsHeader, []record{newCacheStatsRecord(st)})
+	}
+}
+
+func cacheEvictCommand(fs *flag.FlagSet) func(ctx context.Context, e *env, args []string) error {
+	p := iex.DefaultChartCachePolicy
+	maxEntries := fs.Int("max_entries", p.MaxEntries, "Most entries to keep. Zero means no limit.")
+	maxBytes := fs.Int64("max_bytes", p.MaxBytes, "Most bytes of entries to keep. Zero means no limit.")
+	maxAge := fs.Duration("max_age", p.MaxAge, "How long to keep entries after their last update. Zero means no limit.")
+
+	return func(ctx context.Context, e *env, args []string) error {
+		if len(args) != 0 {
+			return usageErrorf("unexpected args: %v", args)
 		}
 
-		i, err := strconv.Atoi(line)
+		if *maxEntries < 0 || *maxBytes < 0 || *maxAge < 0 {
+			return usageErrorf("limits must not be negative")
+		}
+
+		if e.cache == nil {
+			return errs.Errorf("chart cache is disabled")
+		}
+
+		n, err := e.cache.Evict(ctx, iex.ChartCachePolicy{
+			MaxEntries: *maxEntries,
+			MaxBytes:   *maxBytes,
+			MaxAge:     *maxAge,
+		})
 		if err != nil {
-			fmt.Println(err)
-			continue
+			return err
 		}
 
-		if i < 0 || i >= len(choices) {
-			fmt.Printf("Enter a value from %d to %d\n", 0, len(choices)-1)
-			continue
+		fmt.Fprintf(os.Stderr, "evicted %d entries\n", n)
+		return nil
+	}
+}
+
+// symbolArgs returns the IEX spellings of the symbols in the args like SHOP-CT for SHOP.TO,
+// so they match the symbols in responses and the chart cache. Symbols can also be separated by commas.
+func symbolArgs(args []string) ([]string, error) {
+	var symbols []string
+	for _, a := range args {
+		for _, s := range strings.Split(a, ",") {
+			if s = strings.TrimSpace(s); s == "" {
+				continue
+			}
+
+			is, err := iex.FormatSymbol(s)
+			if err != nil {
+				return nil, usageErrorf("%v", err)
+			}
+
+			if !containsString(symbols, is) {
+				symbols = append(symbols, is)
+			}
 		}
+	}
 
-		selected = i
-		break
+	if len(symbols) == 0 {
+		return nil, usageErrorf("missing symbols")
+	}
+
+	return symbols, nil
+}
+
+// missingSymbols returns an error listing the symbols without data.
+func missingSymbols(symbols []string, got map[string]bool) error {
+	var missing []string
+	for _, s := range symbols {
+		if !got[s] {
+			missing = append(missing, s)
+		}
 	}
 
-	return choices[selected]
+	if len(missing) != 0 {
+		return errs.Errorf("no data for %s", strings.Join(missing, ", "))
+	}
+
+	return nil
+}
+
+// parseRange parses a range name like 2y.
+func parseRange(name string) (iex.Range, error) {
+	switch strings.ToLower(name) {
+	case "1d":
+		return iex.OneDay, nil
+	case "2y":
+		return iex.TwoYears, nil
+	case "5y":
+		return iex.FiveYears, nil
+	case "max":
+		return iex.Max, nil
+	default:
+		return iex.RangeUnspecified, usageErrorf("bad -range: %q, want 1d, 2y, 5y, or max", name)
+	}
+}
+
+func containsString(a []string, s string) bool {
+	for _, v := range a {
+		if v == s {
+			return true
+		}
+	}
+	return false
 }
@@ -0,0 +1,300 @@
+package main
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/btmura/ponzi2/internal/errs"
+	"github.com/btmura/ponzi2/internal/stock/iex"
+)
+
+// outputFormat is the format to print results in.
+type outputFormat int
+
+// outputFormat values.
+const (
+	outputFormatUnspecified outputFormat = iota
+	tableFormat
+	jsonFormat
+	csvFormat
+)
+
+// parseOutputFormat parses a format name like table, json, or csv.
+func parseOutputFormat(name string) (outputFormat, error) {
+	switch strings.ToLower(name) {
+	case "table":
+		return tableFormat, nil
+	case "json":
+		return jsonFormat, nil
+	case "csv":
+		return csvFormat, nil
+	default:
+		return outputFormatUnspecified, errs.Errorf("bad format: %q, want table, json, or csv", name)
+	}
+}
+
+// record is a row of output. Records are encoded as objects in JSON.
+type record interface {
+	// values returns the record's values in the same order as its header.
+	values() []string
+}
+
+// writeRecords prints the records in the format with the header for tables and CSV.
+func writeRecords(w io.Writer, format outputFormat, header []string, rs []record) error {
+	switch format {
+	case tableFormat:
+		tw := tabwriter.NewWriter(w, 0, 8, 2, ' ', 0)
+		fmt.Fprintln(tw, strings.ToUpper(strings.Join(header, "\t")))
+		for _, r := range rs {
+			fmt.Fprintln(tw, strings.Join(r.values(), "\t"))
+		}
+		return tw.Flush()
+
+	case jsonFormat:
+		if rs == nil {
+			rs = []record{}
+		}
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(rs)
+
+	case csvFormat:
+		cw := csv.NewWriter(w)
+		if err := cw.Write(header); err != nil {
+			return err
+		}
+		for _, r := range rs {
+			if err := cw.Write(r.values()); err != nil {
+				return err
+			}
+		}
+		cw.Flush()
+		return cw.Error()
+
+	default:
+		return errs.Errorf("bad format: %v", format)
+	}
+}
+
+var quoteHeader = []string{
+	"symbol",
+	"company_name",
+	"latest_price",
+	"latest_source",
+	"latest_time",
+	"latest_update",
+	"latest_volume",
+	"open",
+	"high",
+	"low",
+	"close",
+	"change",
+	"change_percent",
+}
+
+// quoteRecord is a quote printed by the quote command.
+type quoteRecord struct {
+	Symbol        string    `json:"symbol"`
+	CompanyName   string    `json:"company_name"`
+	LatestPrice   float32   `json:"latest_price"`
+	LatestSource  string    `json:"latest_source"`
+	LatestTime    time.Time `json:"latest_time"`
+	LatestUpdate  time.Time `json:"latest_update"`
+	LatestVolume  int       `json:"latest_volume"`
+	Open          float32   `json:"open"`
+	High          float32   `json:"high"`
+	Low           float32   `json:"low"`
+	Close         float32   `json:"close"`
+	Change        float32   `json:"change"`
+	ChangePercent float32   `json:"change_percent"`
+}
+
+func newQuoteRecord(q *iex.Quote) *quoteRecord {
+	return &quoteRecord{
+		Symbol:        q.Symbol,
+		CompanyName:   q.CompanyName,
+		LatestPrice:   q.LatestPrice,
+		LatestSource:  q.LatestSource.String(),
+		LatestTime:    q.LatestTime,
+		LatestUpdate:  q.LatestUpdate,
+		LatestVolume:  q.LatestVolume,
+		Open:          q.Open,
+		High:          q.High,
+		Low:           q.Low,
+		Close:         q.Close,
+		Change:        q.Change,
+		ChangePercent: q.ChangePercent,
+	}
+}
+
+func (q *quoteRecord) values() []string {
+	return []string{
+		q.Symbol,
+		q.CompanyName,
+		formatFloat(q.LatestPrice),
+		q.LatestSource,
+		formatTime(q.LatestTime),
+		formatTime(q.LatestUpdate),
+		strconv.Itoa(q.LatestVolume),
+		formatFloat(q.Open),
+		formatFloat(q.High),
+		formatFloat(q.Low),
+		formatFloat(q.Close),
+		formatFloat(q.Change),
+		formatFloat(q.ChangePercent),
+	}
+}
+
+var chartPointHeader = []string{
+	"symbol",
+	"date",
+	"open",
+	"high",
+	"low",
+	"close",
+	"volume",
+	"change",
+	"change_percent",
+}
+
+// chartPointRecord is a chart point printed by the chart and cache export commands.
+type chartPointRecord struct {
+	Symbol        string    `json:"symbol"`
+	Date          time.Time `json:"date"`
+	Open          float32   `json:"open"`
+	High          float32   `json:"high"`
+	Low           float32   `json:"low"`
+	Close         float32   `json:"close"`
+	Volume        int       `json:"volume"`
+	Change        float32   `json:"change"`
+	ChangePercent float32   `json:"change_percent"`
+}
+
+// chartPointRecords returns records for the last points of the chart. Zero last means all of them.
+func chartPointRecords(ch *iex.Chart, last int) []record {
+	ps := ch.ChartPoints
+	if last > 0 && len(ps) > last {
+		ps = ps[len(ps)-last:]
+	}
+
+	var rs []record
+	for _, p := range ps {
+		rs = append(rs, &chartPointRecord{
+			Symbol:        ch.Symbol,
+			Date:          p.Date,
+			Open:          p.Open,
+			High:          p.High,
+			Low:           p.Low,
+			Close:         p.Close,
+			Volume:        p.Volume,
+			Change:        p.Change,
+			ChangePercent: p.ChangePercent,
+		})
+	}
+	return rs
+}
+
+func (p *chartPointRecord) values() []string {
+	return []string{
+		p.Symbol,
+		formatTime(p.Date),
+		formatFloat(p.Open),
+		formatFloat(p.High),
+		formatFloat(p.Low),
+		formatFloat(p.Close),
+		strconv.Itoa(p.Volume),
+		formatFloat(p.Change),
+		formatFloat(p.ChangePercent),
+	}
+}
+
+var cacheEntryHeader = []string{
+	"symbol",
+	"interval",
+	"range",
+	"points",
+	"first_date",
+	"last_date",
+	"last_update",
+}
+
+// cacheEntryRecord is a chart cache entry printed by the cache ls command.
+type cacheEntryRecord struct {
+	Symbol     string    `json:"symbol"`
+	Interval   string    `json:"interval"`
+	Range      string    `json:"range"`
+	Points     int       `json:"points"`
+	FirstDate  time.Time `json:"first_date"`
+	LastDate   time.Time `json:"last_date"`
+	LastUpdate time.Time `json:"last_update"`
+}
+
+func newCacheEntryRecord(key iex.ChartCacheKey, val *iex.ChartCacheValue) *cacheEntryRecord {
+	r := &cacheEntryRecord{
+		Symbol:     key.Symbol,
+		Interval:   key.Interval.String(),
+		Range:      val.Range.String(),
+		LastUpdate: val.LastUpdateTime,
+	}
+	if ch := val.Chart; ch != nil && len(ch.ChartPoints) != 0 {
+		r.Points = len(ch.ChartPoints)
+		r.FirstDate = ch.ChartPoints[0].Date
+		r.LastDate = ch.ChartPoints[len(ch.ChartPoints)-1].Date
+	}
+	return r
+}
+
+func (c *cacheEntryRecord) values() []string {
+	return []string{
+		c.Symbol,
+		c.Interval,
+		c.Range,
+		strconv.Itoa(c.Points),
+		formatTime(c.FirstDate),
+		formatTime(c.LastDate),
+		formatTime(c.LastUpdate),
+	}
+}
+
+var cacheStatsHeader = []string{
+	"entries",
+	"bytes",
+}
+
+// cacheStatsRecord is the chart cache stats printed by the cache stats command.
+type cacheStatsRecord struct {
+	Entries int   `json:"entries"`
+	Bytes   int64 `json:"bytes"`
+}
+
+func newCacheStatsRecord(st *iex.ChartCacheStats) *cacheStatsRecord {
+	return &cacheStatsRecord{
+		Entries: st.Entries,
+		Bytes:   st.Bytes,
+	}
+}
+
+func (c *cacheStatsRecord) values() []string {
+	return []string{
+		strconv.Itoa(c.Entries),
+		strconv.FormatInt(c.Bytes, 10),
+	}
+}
+
+func formatFloat(v float32) string {
+	return strconv.FormatFloat(float64(v), 'f', -1, 32)
+}
+
+// formatTime formats times as RFC 3339 and zero times as empty strings.
+func formatTime(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+	return t.Format(time.RFC3339)
+}
@@ -2,34 +2,142 @@ package main
 
 import (
 	"flag"
+	"net"
+	"net/http"
+	"time"
 
 	"github.com/btmura/ponzi2/internal/app"
+	"github.com/btmura/ponzi2/internal/app/credentials"
+	"github.com/btmura/ponzi2/internal/app/watchlist"
 	"github.com/btmura/ponzi2/internal/logger"
+	"github.com/btmura/ponzi2/internal/stock/csvfile"
 	"github.com/btmura/ponzi2/internal/stock/iex"
+	"github.com/btmura/ponzi2/internal/stock/iex/iextest"
 )
 
 var (
-	iexAPIToken         = flag.String("iex_api_token", "", "IEX API Token required on requests.")
+	iexAPIToken         = flag.String("iex_api_token", "", "Deprecated: IEX API Token required on requests. Set PONZI_IEX_API_TOKEN or enter it in the app instead.")
 	enableIEXChartCache = flag.Bool("enable_iex_chart_cache", true, "Whether to enable the IEX chart cache.")
-	dumpIEXAPIResponses = flag.Bool("dump_iex_api_responses", false, "Dump API responses to txt files.")
+	dumpIEXAPIResponses = flag.Bool("dump_iex_api_responses", false, "Deprecated: Record API responses to "+iex.DefaultRecordDir+" for -replay_dir. Use -record_dir instead.")
+	iexBaseURL          = flag.String("iex_base_url", iex.CloudBaseURL, "Base URL of the IEX API like "+iex.SandboxBaseURL+" for the sandbox.")
+	fakeIEXAddr         = flag.String("fake_iex_addr", "", "Address like localhost:9000 to serve a fake IEX API with synthetic data on and use instead of IEX.")
+	fakeIEXLatency      = flag.Duration("fake_iex_latency", 0, "How long the fake IEX API waits before responding.")
+	fakeIEXFailureRate  = flag.Float64("fake_iex_failure_rate", 0, "Fraction of fake IEX API requests from 0 to 1 that fail with 503 errors.")
+	csvDataDir          = flag.String("csv_data_dir", "", "Directory of SYMBOL.csv files with daily bars to use instead of IEX.")
+	recordDir           = flag.String("record_dir", "", "Directory to record IEX API responses to for -replay_dir.")
+	replayDir           = flag.String("replay_dir", "", "Directory of IEX API responses recorded with -record_dir to use instead of the network.")
+	importWatchlist     = flag.String("import_watchlist", "", "File of symbols to add to a watchlist before exiting.")
+	exportWatchlist     = flag.String("export_watchlist", "", "File to write the symbols of a watchlist to before exiting.")
+	watchlistFormat     = flag.String("watchlist_format", "", "Format of the watchlist file: text, csv, tradingview, or thinkorswim. Guessed if empty.")
+	watchlistName       = flag.String("watchlist_name", "", "Name of the watchlist to import to or export from. Uses the current watchlist if empty.")
+	addLot              = flag.String("add_lot", "", "Portfolio lot to add before exiting like SYMBOL,SHARES,PRICE[,YYYY-MM-DD]. Fails while ponzi2 is running.")
 )
 
 func main() {
 	flag.Parse()
 
-	switch {
-	case *enableIEXChartCache:
-		cache, err := iex.OpenGOBChartCache()
+	if *addLot != "" {
+		if err := app.AddLot(*addLot); err != nil {
+			logger.Fatal(err)
+		}
+		return
+	}
+
+	if *importWatchlist != "" || *exportWatchlist != "" {
+		format := watchlist.FormatUnspecified
+		if *watchlistFormat != "" {
+			f, err := watchlist.ParseFormat(*watchlistFormat)
+			if err != nil {
+				logger.Fatal(err)
+			}
+			format = f
+		}
+
+		if *importWatchlist != "" {
+			if err := app.ImportWatchlist(*importWatchlist, format, *watchlistName); err != nil {
+				logger.Fatal(err)
+			}
+		}
+
+		if *exportWatchlist != "" {
+			if err := app.ExportWatchlist(*exportWatchlist, format, *watchlistName); err != nil {
+				logger.Fatal(err)
+			}
+		}
+		return
+	}
+
+	creds, err := app.ResolveCredentials(*iexAPIToken)
+	if err != nil {
+		logger.Fatal(err)
+	}
+
+	opts := []iex.ClientOption{iex.BaseURL(*iexBaseURL)}
+	if *fakeIEXAddr != "" {
+		baseURL, err := startFakeIEXServer(*fakeIEXAddr, *fakeIEXLatency, *fakeIEXFailureRate)
 		if err != nil {
 			logger.Fatal(err)
 		}
-		c := iex.NewClient(cache, *dumpIEXAPIResponses)
-		a := app.New(c, *iexAPIToken)
+		opts = append(opts, iex.BaseURL(baseURL))
+
+		// Use a copy, so the fake token is never saved.
+		if creds.IEXAPIToken == "" {
+			creds = &credentials.Credentials{IEXAPIToken: iextest.Token}
+		}
+	}
+	if *dumpIEXAPIResponses && *recordDir == "" {
+		logger.Infof("-dump_iex_api_responses is deprecated, use -record_dir instead")
+		*recordDir = iex.DefaultRecordDir
+	}
+	if *recordDir != "" {
+		opts = append(opts, iex.RecordDir(*recordDir))
+	}
+	if *replayDir != "" {
+		opts = append(opts, iex.ReplayDir(*replayDir))
+	}
+
+	switch {
+	case *csvDataDir != "":
+		a := app.New(csvfile.NewProvider(*csvDataDir), creds)
 		logger.Fatal(a.Run())
 
+	// Don't mix replayed, fake, or sandbox data into the chart cache.
+	case *enableIEXChartCache && *replayDir == "" && *fakeIEXAddr == "" && *iexBaseURL == iex.CloudBaseURL:
+		cache, err := iex.OpenGOBChartCache(iex.DefaultChartCachePolicy)
+		if err != nil {
+			logger.Fatal(err)
+		}
+		c := iex.NewClient(cache, opts...)
+		a := app.New(iex.NewProvider(c), creds)
+		err = a.Run()
+		if cerr := cache.Close(); cerr != nil {
+			logger.Errorf("closing chart cache failed: %v", cerr)
+		}
+		logger.Fatal(err)
+
 	default:
-		c := iex.NewClient(new(iex.NoOpChartCache), *dumpIEXAPIResponses)
-		a := app.New(c, *iexAPIToken)
+		c := iex.NewClient(new(iex.NoOpChartCache), opts...)
+		a := app.New(iex.NewProvider(c), creds)
 		logger.Fatal(a.Run())
 	}
 }
+
+// startFakeIEXServer serves a fake IEX API on the address in the background and returns its base URL.
+func startFakeIEXServer(addr string, latency time.Duration, failureRate float64) (string, error) {
+	l, err := net.Listen("tcp", addr)
+	if err != nil {
+		return "", err
+	}
+
+	s := iextest.NewServer(
+		iextest.Latency(latency),
+		iextest.FailureRate(failureRate, http.StatusServiceUnavailable))
+
+	go func() {
+		logger.Error(http.Serve(l, s))
+	}()
+
+	baseURL := "http://" + l.Addr().String()
+	logger.Infof("serving fake IEX API at %s", baseURL)
+	return baseURL, nil
+}
@@ -0,0 +1,108 @@
+// The ponzichart command renders stock charts to PNG files without showing a window.
+//
+//	PONZI_IEX_API_TOKEN=TOKEN go run cmd/ponzichart/ponzichart.go -symbols AAPL,MSFT -interval weekly
+//
+// On Linux, it renders with EGL and doesn't need X11 or Wayland, so it runs on headless servers
+// and in cron jobs. It needs libEGL and a driver with OpenGL 4.5 like Mesa (libegl1 and
+// libgl1-mesa-dri on Debian and Ubuntu), whose llvmpipe software renderer works without a GPU.
+// On other platforms, it needs a desktop session to create a hidden window.
+package main
+
+import (
+	"flag"
+	"image"
+	"strings"
+
+	"github.com/btmura/ponzi2/internal/app"
+	"github.com/btmura/ponzi2/internal/app/model"
+	"github.com/btmura/ponzi2/internal/app/view/offscreen"
+	"github.com/btmura/ponzi2/internal/errs"
+	"github.com/btmura/ponzi2/internal/logger"
+	"github.com/btmura/ponzi2/internal/stock"
+	"github.com/btmura/ponzi2/internal/stock/csvfile"
+	"github.com/btmura/ponzi2/internal/stock/iex"
+)
+
+var (
+	iexAPIToken         = flag.String("iex_api_token", "", "Deprecated: IEX API Token required on requests. Set PONZI_IEX_API_TOKEN instead.")
+	enableIEXChartCache = flag.Bool("enable_iex_chart_cache", true, "Whether to enable the IEX chart cache.")
+	csvDataDir          = flag.String("csv_data_dir", "", "Directory of SYMBOL.csv files with daily bars to use instead of IEX.")
+	symbols             = flag.String("symbols", "", "Comma separated symbols to render. Renders a watchlist if empty.")
+	watchlistName       = flag.String("watchlist_name", "", "Name of the watchlist to render if there are no symbols. Uses the current watchlist if empty.")
+	intervalName        = flag.String("interval", "daily", "Interval of the charts: intraday, daily, weekly, or monthly.")
+	width               = flag.Int("width", 1280, "Width of the images in pixels.")
+	height              = flag.Int("height", 720, "Height of the images in pixels.")
+	outputDir           = flag.String("output_dir", ".", "Directory to write the SYMBOL.png files to.")
+)
+
+func main() {
+	flag.Parse()
+
+	interval, err := parseInterval(*intervalName)
+	if err != nil {
+		logger.Fatal(err)
+	}
+
+	var syms []string
+	if *symbols != "" {
+		for _, s := range strings.Split(*symbols, ",") {
+			sym, err := stock.NormalizeSymbol(s)
+			if err != nil {
+				logger.Fatal(err)
+			}
+			syms = append(syms, sym)
+		}
+	}
+
+	var provider stock.Provider
+	var cache *iex.GOBChartCache
+	switch {
+	case *csvDataDir != "":
+		provider = csvfile.NewProvider(*csvDataDir)
+
+	case *enableIEXChartCache:
+		cache, err = iex.OpenGOBChartCache(iex.DefaultChartCachePolicy)
+		if err != nil {
+			logger.Fatal(err)
+		}
+		provider = iex.NewProvider(iex.NewClient(cache))
+
+	default:
+		provider = iex.NewProvider(iex.NewClient(new(iex.NoOpChartCache)))
+	}
+
+	creds, err := app.ResolveCredentials(*iexAPIToken)
+	if err != nil {
+		logger.Fatal(err)
+	}
+
+	o, err := offscreen.New(image.Pt(*width, *height))
+	if err != nil {
+		logger.Fatal(err)
+	}
+
+	a := app.New(provider, creds)
+	err = a.RenderCharts(syms, *watchlistName, interval, o, *outputDir)
+	o.Close()
+
+	// Save the charts fetched for the images, so that the next run is faster.
+	if cache != nil {
+		if cerr := cache.Close(); cerr != nil {
+			logger.Errorf("closing chart cache failed: %v", cerr)
+		}
+	}
+
+	if err != nil {
+		logger.Fatal(err)
+	}
+}
+
+// parseInterval parses an interval name like daily.
+func parseInterval(name string) (model.Interval, error) {
+	for _, i := range []model.Interval{model.Intraday, model.Daily, model.Weekly, model.Monthly} {
+		if strings.EqualFold(i.String(), name) {
+			return i, nil
+		}
+	}
+	return model.IntervalUnspecified, errs.Errorf("bad interval: %q", name)
+}
@@ -2,35 +2,355 @@
 package app
 
 import (
+	"bytes"
 	"context"
+	"image/png"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
 
+	"github.com/btmura/ponzi2/internal/app/config"
 	"github.com/btmura/ponzi2/internal/app/controller"
+	"github.com/btmura/ponzi2/internal/app/credentials"
+	"github.com/btmura/ponzi2/internal/app/model"
+	"github.com/btmura/ponzi2/internal/app/watchlist"
 	"github.com/btmura/ponzi2/internal/errs"
+	"github.com/btmura/ponzi2/internal/logger"
+	"github.com/btmura/ponzi2/internal/stock"
 	"github.com/btmura/ponzi2/internal/stock/iex"
 )
 
 // App runs a GUI.
 type App struct {
-	client iexClientInterface
-	token  string
+	provider stock.Provider
+	creds    *credentials.Credentials
 }
 
-// iexClientInterface is implemented by clients in the iex package to get stock data.
-type iexClientInterface interface {
-	GetQuotes(ctx context.Context, req *iex.GetQuotesRequest) ([]*iex.Quote, error)
-	GetCharts(ctx context.Context, req *iex.GetChartsRequest) ([]*iex.Chart, error)
-}
-
-// New returns a new App.
-func New(client iexClientInterface, token string) *App {
-	return &App{client, token}
+// New returns a new App that gets stock data from the given provider
+// using the API tokens in the resolved credentials.
+func New(provider stock.Provider, creds *credentials.Credentials) *App {
+	if creds == nil {
+		creds = &credentials.Credentials{}
+	}
+	if s, ok := provider.(stock.APITokenSetter); ok {
+		s.SetAPIToken(creds.IEXAPIToken)
+	}
+	return &App{provider, creds}
 }
 
 // Run runs the app. Should be called from main.
 func (a *App) Run() error {
-	if a.client == nil {
-		return errs.Errorf("nil client")
+	if a.provider == nil {
+		return errs.Errorf("nil provider")
+	}
+
+	// Lock the config, since the app saves the whole config and would overwrite changes from other processes.
+	unlock, err := config.Lock()
+	if err == config.ErrLocked {
+		return errs.Errorf("ponzi2 is already running: %v", err)
+	}
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err := unlock(); err != nil {
+			logger.Errorf("unlocking config failed: %v", err)
+		}
+	}()
+
+	return controller.New(a.provider, a.creds).RunLoop()
+}
+
+// editConfig loads the user's config, passes it to the edit function, and saves it.
+// It refuses to run while the app is running, since the app would overwrite the changes.
+func editConfig(edit func(cfg *config.Config) error) error {
+	unlock, err := config.Lock()
+	if err == config.ErrLocked {
+		return errs.Errorf("quit ponzi2 first, since it would overwrite the changes: %v", err)
+	}
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err := unlock(); err != nil {
+			logger.Errorf("unlocking config failed: %v", err)
+		}
+	}()
+
+	cfg, err := config.Load()
+	if err != nil {
+		return err
+	}
+
+	if err := edit(cfg); err != nil {
+		return err
+	}
+
+	return config.Save(cfg)
+}
+
+// ResolveCredentials returns the credentials from the environment or the credentials file.
+// A non-empty flag token takes precedence but is deprecated, since it leaks into shell
+// history and process listings.
+func ResolveCredentials(flagIEXAPIToken string) (*credentials.Credentials, error) {
+	creds, err := credentials.Load()
+	if err != nil {
+		return nil, err
+	}
+
+	if flagIEXAPIToken != "" {
+		logger.Infof("-iex_api_token is deprecated, set %s or enter the token in the app instead", credentials.IEXAPITokenEnvVar)
+		if err := iex.ValidateToken(flagIEXAPIToken); err != nil {
+			return nil, errs.Errorf("-iex_api_token: %v", err)
+		}
+		creds.IEXAPIToken = flagIEXAPIToken
+	}
+
+	return creds, nil
+}
+
+// ImportWatchlist adds the symbols in a file to a watchlist in the user's config.
+// It guesses the format if it is unspecified. It uses the current watchlist if
+// the name is empty and creates the watchlist if it doesn't exist. It refuses to run while the app is running.
+func ImportWatchlist(path string, format watchlist.Format, name string) error {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return err
+	}
+
+	if format == watchlist.FormatUnspecified {
+		format = watchlist.Detect(string(data))
+	}
+
+	symbols, err := watchlist.Read(bytes.NewReader(data), format)
+	if err != nil {
+		return errs.Errorf("reading %s failed: %v", path, err)
+	}
+
+	return editConfig(func(cfg *config.Config) error {
+		w, err := configWatchlist(cfg, name, true)
+		if err != nil {
+			return err
+		}
+
+		added := 0
+		for _, s := range symbols {
+			if !containsStock(w.Stocks, s) {
+				w.Stocks = append(w.Stocks, &config.Stock{Symbol: s})
+				added++
+			}
+		}
+
+		logger.Infof("imported %d of %d symbols from %s into %q", added, len(symbols), path, w.Name)
+
+		return nil
+	})
+}
+
+// RenderCharts renders the charts of the symbols to SYMBOL.png files in the directory
+// with the image renderer. It renders the symbols of the watchlist with the name
+// if there are no symbols. Charts use the chart settings in the user's config.
+// Should be called from main.
+func (a *App) RenderCharts(symbols []string, watchlistName string, interval model.Interval, imageRenderer controller.ChartImageRenderer, dirPath string) error {
+	if a.provider == nil {
+		return errs.Errorf("nil provider")
+	}
+
+	cfg, err := config.Load()
+	if err != nil {
+		return err
+	}
+
+	if len(symbols) == 0 {
+		w, err := configWatchlist(cfg, watchlistName, false)
+		if err != nil {
+			return err
+		}
+		for _, s := range w.Stocks {
+			symbols = append(symbols, s.Symbol)
+		}
+	}
+
+	if len(symbols) == 0 {
+		return errs.Errorf("no symbols to render")
+	}
+
+	r, err := controller.NewChartRenderer(a.provider, cfg, imageRenderer)
+	if err != nil {
+		return err
+	}
+
+	ctx := context.Background()
+
+	var failed []string
+	for _, s := range symbols {
+		path := filepath.Join(dirPath, s+".png")
+		if err := renderChart(ctx, r, s, interval, path); err != nil {
+			logger.Errorf("rendering %s failed: %v", s, err)
+			failed = append(failed, s)
+			continue
+		}
+		logger.Infof("rendered %s to %s", s, path)
+	}
+
+	if len(failed) != 0 {
+		return errs.Errorf("failed to render %d of %d charts: %s", len(failed), len(symbols), strings.Join(failed, ", "))
+	}
+
+	return nil
+}
+
+func renderChart(ctx context.Context, r *controller.ChartRenderer, symbol string, interval model.Interval, path string) error {
+	img, err := r.Render(ctx, symbol, interval)
+	if err != nil {
+		return err
+	}
+
+	var b bytes.Buffer
+	if err := png.Encode(&b, img); err != nil {
+		return err
+	}
+
+	// Write to a temporary file and rename it, so that readers never see a partial image.
+	tmpPath := path + ".tmp"
+	if err := ioutil.WriteFile(tmpPath, b.Bytes(), 0644); err != nil {
+		return err
+	}
+	return os.Rename(tmpPath, path)
+}
+
+// AddLot adds a portfolio lot described like "SYMBOL,SHARES,PRICE[,YYYY-MM-DD]" to the user's config.
+// Lots can also be added in the app in lot mode, which works while the app is running unlike this.
+func AddLot(spec string) error {
+	parts := strings.Split(spec, ",")
+	if n := len(parts); n < 3 || n > 4 {
+		return errs.Errorf("bad lot: %q, want SYMBOL,SHARES,PRICE[,YYYY-MM-DD]", spec)
+	}
+
+	symbol, err := stock.NormalizeSymbol(parts[0])
+	if err != nil {
+		return err
+	}
+
+	parseFloat := func(s string) (float32, error) {
+		v, err := strconv.ParseFloat(strings.TrimSpace(s), 32)
+		return float32(v), err
+	}
+
+	shares, err := parseFloat(parts[1])
+	if err != nil {
+		return errs.Errorf("bad lot shares: %v", err)
 	}
 
-	return controller.New(a.client, a.token).RunLoop()
+	price, err := parseFloat(parts[2])
+	if err != nil {
+		return errs.Errorf("bad lot price: %v", err)
+	}
+
+	var date time.Time
+	if len(parts) == 4 {
+		date, err = time.Parse("2006-01-02", strings.TrimSpace(parts[3]))
+		if err != nil {
+			return errs.Errorf("bad lot date: %v", err)
+		}
+	}
+
+	if err := model.ValidateLot(&model.Lot{Shares: shares, Price: price, Date: date}); err != nil {
+		return err
+	}
+
+	return editConfig(func(cfg *config.Config) error {
+		cfg.Lots = append(cfg.Lots, &config.Lot{
+			Symbol: symbol,
+			Shares: shares,
+			Price:  price,
+			Date:   date,
+		})
+
+		logger.Infof("added lot of %g %s at %.2f", shares, symbol, price)
+
+		return nil
+	})
+}
+
+// ExportWatchlist writes the symbols of a watchlist in the user's config to a file.
+// It picks the format from the file extension if it is unspecified.
+// It uses the current watchlist if the name is empty.
+func ExportWatchlist(path string, format watchlist.Format, name string) error {
+	if format == watchlist.FormatUnspecified {
+		format = watchlist.FormatFromPath(path)
+	}
+
+	cfg, err := config.Load()
+	if err != nil {
+		return err
+	}
+
+	w, err := configWatchlist(cfg, name, false)
+	if err != nil {
+		return err
+	}
+
+	var symbols []string
+	for _, s := range w.Stocks {
+		symbols = append(symbols, s.Symbol)
+	}
+
+	var b bytes.Buffer
+	if err := watchlist.Write(&b, format, symbols); err != nil {
+		return err
+	}
+
+	logger.Infof("exporting %d symbols from %q to %s", len(symbols), w.Name, path)
+
+	return ioutil.WriteFile(path, b.Bytes(), 0644)
+}
+
+// configWatchlist returns the named or current watchlist in the config,
+// optionally creating it if it doesn't exist.
+func configWatchlist(cfg *config.Config, name string, create bool) (*config.Watchlist, error) {
+	if name == "" {
+		name = cfg.CurrentWatchlist
+	}
+
+	if name == "" && len(cfg.Watchlists) != 0 {
+		name = cfg.Watchlists[0].Name
+	}
+
+	if name == "" {
+		name = model.DefaultWatchlistName
+	}
+
+	for _, w := range cfg.Watchlists {
+		if w.Name == name {
+			return w, nil
+		}
+	}
+
+	if !create {
+		return nil, errs.Errorf("no watchlist named %q", name)
+	}
+
+	if err := model.ValidateWatchlistName(name); err != nil {
+		return nil, err
+	}
+
+	w := &config.Watchlist{Name: name}
+	cfg.Watchlists = append(cfg.Watchlists, w)
+	if cfg.CurrentWatchlist == "" {
+		cfg.CurrentWatchlist = name
+	}
+	return w, nil
+}
+
+func containsStock(stocks []*config.Stock, symbol string) bool {
+	for _, s := range stocks {
+		if s.Symbol == symbol {
+			return true
+		}
+	}
+	return false
 }
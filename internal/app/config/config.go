@@ -1,99 +1,377 @@
 // Package config provides functions for loading and saving the user's configuration.
+//
+// Configs are saved as JSON, so that users can diff, edit, and check them into their dotfiles.
+// Configs saved as gob by older versions are migrated to JSON the first time they are loaded.
 package config
 
 import (
-	"encoding/gob"
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
 	"os"
 	"os/user"
-	"path"
 	"path/filepath"
+	"time"
 
 	"github.com/btmura/ponzi2/internal/app/model"
+	"github.com/btmura/ponzi2/internal/app/view"
 	"github.com/btmura/ponzi2/internal/app/view/chart"
+	"github.com/btmura/ponzi2/internal/errs"
 	"github.com/btmura/ponzi2/internal/logger"
 )
 
+// Version is the version of the config schema. Increment it when making changes
+// that older versions can't read, and migrate older configs in Load.
+const Version = 5
+
+const (
+	// fileName is the name of the JSON config file.
+	fileName = "config.json"
+
+	// gobFileName is the name of the gob config file saved by older versions.
+	gobFileName = "config.gob"
+
+	// migratedGobFileName is the name the gob config file is renamed to after migration.
+	migratedGobFileName = "config.gob.bak"
+)
+
 // Config configures the app.
 type Config struct {
-	CurrentStock *Stock
-	Stocks       []*Stock
-	Settings     Settings
+	// Version is the schema version of the config. Set by Save.
+	Version int `json:"version"`
+
+	CurrentStock *Stock `json:"currentStock,omitempty"`
+
+	// Stocks are the sidebar stocks saved by version 1 configs before there were watchlists.
+	// Load moves them into a watchlist.
+	Stocks []*Stock `json:"stocks,omitempty"`
+
+	// Watchlists are the user's named lists of stocks in the order shown.
+	Watchlists []*Watchlist `json:"watchlists,omitempty"`
+
+	// CurrentWatchlist is the name of the watchlist shown in the sidebar.
+	CurrentWatchlist string `json:"currentWatchlist,omitempty"`
+
+	Settings Settings `json:"settings"`
+
+	// Drawings are the user's chart drawings in the order they were added.
+	Drawings []*Drawing `json:"drawings,omitempty"`
+
+	// Alerts are the user's alerts in the order they were added.
+	Alerts []*Alert `json:"alerts,omitempty"`
+
+	// Lots are the user's portfolio lots in the order they were added.
+	Lots []*Lot `json:"lots,omitempty"`
+
+	// Adjustments are the stocks whose charts aren't just split-adjusted.
+	Adjustments []*Adjustment `json:"adjustments,omitempty"`
 }
 
 // Stock identifies a single stock by symbol.
 type Stock struct {
-	Symbol string
+	Symbol string `json:"symbol"`
+}
+
+// Watchlist is a named list of stocks.
+type Watchlist struct {
+	Name   string   `json:"name"`
+	Stocks []*Stock `json:"stocks,omitempty"`
+}
+
+// Drawing is a user drawing on a stock's charts anchored to dates and prices.
+type Drawing struct {
+	Symbol string            `json:"symbol"`
+	Type   model.DrawingType `json:"type"`
+	Start  Point             `json:"start"`
+	End    Point             `json:"end"`
+}
+
+// Point is a date and price on a chart.
+type Point struct {
+	Date  time.Time `json:"date"`
+	Price float32   `json:"price"`
+}
+
+// Alert is a user alert on a stock with whether it has triggered.
+type Alert struct {
+	Symbol                 string                  `json:"symbol"`
+	Type                   model.AlertType         `json:"type"`
+	Value                  float32                 `json:"value,omitempty"`
+	MovingAverageType      model.MovingAverageType `json:"movingAverageType,omitempty"`
+	MovingAverageIntervals int                     `json:"movingAverageIntervals,omitempty"`
+	LastPrice              float32                 `json:"lastPrice,omitempty"`
+	Triggered              bool                    `json:"triggered,omitempty"`
+	TriggerTime            time.Time               `json:"triggerTime"`
+	TriggerPrice           float32                 `json:"triggerPrice,omitempty"`
+}
+
+// Lot is a purchase of shares of a stock in the user's portfolio.
+type Lot struct {
+	Symbol string    `json:"symbol"`
+	Shares float32   `json:"shares"`
+	Price  float32   `json:"price"`
+	Date   time.Time `json:"date"`
+}
+
+// Adjustment is how a stock's chart prices are adjusted.
+type Adjustment struct {
+	Symbol string           `json:"symbol"`
+	Type   model.Adjustment `json:"type"`
 }
 
 // Settings has the user's settings.
 type Settings struct {
-	ChartSettings ChartSettings
+	ChartSettings ChartSettings `json:"chartSettings"`
 }
 
 // ChartSettings has the user's chart settings.
 type ChartSettings struct {
-	PriceStyle chart.PriceStyle
-	Interval   model.Interval
+	PriceStyle chart.PriceStyle `json:"priceStyle,omitempty"`
+	Interval   model.Interval   `json:"interval,omitempty"`
+
+	// MovingAverages are the moving averages to show on charts.
+	// Intervals without any moving averages show the default ones.
+	MovingAverages []*MovingAverage `json:"movingAverages,omitempty"`
+
+	// Bands are the price bands to show on charts.
+	// Intervals without any price bands show the default ones.
+	Bands []*Band `json:"bands,omitempty"`
+
+	// Indicators are the indicators to show below the volume from top to bottom.
+	// Charts show the default indicators if there are none.
+	Indicators []chart.Indicator `json:"indicators,omitempty"`
+}
+
+// MovingAverage is a moving average to show on charts of an interval.
+type MovingAverage struct {
+	Interval  model.Interval          `json:"interval"`
+	Type      model.MovingAverageType `json:"type"`
+	Intervals int                     `json:"intervals"`
+	Color     view.Color              `json:"color"`
+}
+
+// Band is a price band to show on charts of an interval.
+type Band struct {
+	Interval  model.Interval `json:"interval"`
+	Type      model.BandType `json:"type"`
+	Intervals int            `json:"intervals"`
+	Width     float32        `json:"width"`
+	Color     view.Color     `json:"color"`
 }
 
 // Load loads the user's config from disk.
 func Load() (*Config, error) {
-	cfgPath, err := userConfigPath()
+	dirPath, err := userConfigDir()
 	if err != nil {
 		return nil, err
 	}
+	return load(dirPath)
+}
+
+// Save saves the user's config to disk.
+func Save(cfg *Config) error {
+	dirPath, err := userConfigDir()
+	if err != nil {
+		return err
+	}
+	return save(dirPath, cfg)
+}
+
+func load(dirPath string) (*Config, error) {
+	cfgPath := filepath.Join(dirPath, fileName)
 
 	logger.Infof("loading from %s", cfgPath)
 
-	file, err := os.Open(cfgPath)
+	data, err := ioutil.ReadFile(cfgPath)
 	if os.IsNotExist(err) {
-		return &Config{}, nil
+		return migrateGob(dirPath)
 	}
 	if err != nil {
 		return nil, err
 	}
-	defer func() {
-		if err := file.Close(); err != nil {
-			logger.Errorf("closing in load failed: %v", err)
-		}
-	}()
 
 	cfg := &Config{}
-	dec := gob.NewDecoder(file)
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.DisallowUnknownFields()
 	if err := dec.Decode(cfg); err != nil {
-		return nil, err
+		return nil, errs.Errorf("parsing %s failed: %v", cfgPath, err)
+	}
+
+	switch {
+	case cfg.Version <= 0:
+		return nil, errs.Errorf("%s: missing version", cfgPath)
+
+	case cfg.Version > Version:
+		return nil, errs.Errorf("%s: version %d is newer than the supported version %d", cfgPath, cfg.Version, Version)
 	}
+
+	if err := validate(cfg); err != nil {
+		return nil, errs.Errorf("%s: %v", cfgPath, err)
+	}
+
+	migrateStocks(cfg)
+
 	return cfg, nil
 }
 
-// Save saves the user's config to disk.
-func Save(cfg *Config) error {
-	cfgPath, err := userConfigPath()
+// migrateStocks moves the stocks of configs saved before watchlists into a default watchlist.
+func migrateStocks(cfg *Config) {
+	if len(cfg.Stocks) == 0 {
+		return
+	}
+
+	if len(cfg.Watchlists) == 0 {
+		cfg.Watchlists = []*Watchlist{{Name: model.DefaultWatchlistName}}
+		cfg.CurrentWatchlist = model.DefaultWatchlistName
+	}
+
+	w := cfg.Watchlists[0]
+	w.Stocks = append(w.Stocks, cfg.Stocks...)
+	cfg.Stocks = nil
+}
+
+// migrateGob loads the gob config saved by older versions, saves it as JSON,
+// and renames the gob config, so that the migration only happens once.
+// It returns an empty config if there is no gob config.
+func migrateGob(dirPath string) (*Config, error) {
+	gobPath := filepath.Join(dirPath, gobFileName)
+
+	cfg, err := loadGob(gobPath)
+	if os.IsNotExist(err) {
+		return &Config{Version: Version}, nil
+	}
 	if err != nil {
-		return err
+		return nil, errs.Errorf("migrating %s failed: %v", gobPath, err)
+	}
+
+	if err := validate(cfg); err != nil {
+		return nil, errs.Errorf("migrating %s failed: %v", gobPath, err)
 	}
 
+	migrateStocks(cfg)
+
+	logger.Infof("migrating %s to %s", gobPath, fileName)
+
+	if err := save(dirPath, cfg); err != nil {
+		return nil, errs.Errorf("migrating %s failed: %v", gobPath, err)
+	}
+
+	if err := os.Rename(gobPath, filepath.Join(dirPath, migratedGobFileName)); err != nil {
+		return nil, errs.Errorf("migrating %s failed: %v", gobPath, err)
+	}
+
+	return cfg, nil
+}
+
+func save(dirPath string, cfg *Config) error {
+	cfgPath := filepath.Join(dirPath, fileName)
+
 	logger.Infof("saving to %s", cfgPath)
 
-	file, err := os.OpenFile(cfgPath, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0660)
+	c := *cfg
+	c.Version = Version
+
+	data, err := json.MarshalIndent(&c, "", "  ")
 	if err != nil {
 		return err
 	}
-	defer func() {
-		if err := file.Close(); err != nil {
-			logger.Errorf("closing in save failed: %v", err)
-		}
-	}()
+	data = append(data, '\n')
 
-	return gob.NewEncoder(file).Encode(cfg)
+	// Write to a temporary file and rename it, so that a crash never leaves a partial config.
+	tmpPath := cfgPath + ".tmp"
+	if err := ioutil.WriteFile(tmpPath, data, 0660); err != nil {
+		return err
+	}
+	return os.Rename(tmpPath, cfgPath)
 }
 
-func userConfigPath() (string, error) {
-	dirPath, err := userConfigDir()
-	if err != nil {
-		return "", err
+// validate returns an error describing the first invalid part of the config.
+func validate(cfg *Config) error {
+	if s := cfg.CurrentStock; s != nil {
+		if err := model.ValidateSymbol(s.Symbol); err != nil {
+			return errs.Errorf("currentStock: %v", err)
+		}
+	}
+
+	for i, s := range cfg.Stocks {
+		if s == nil {
+			return errs.Errorf("stocks[%d]: missing stock", i)
+		}
+		if err := model.ValidateSymbol(s.Symbol); err != nil {
+			return errs.Errorf("stocks[%d]: %v", i, err)
+		}
+	}
+
+	names := map[string]bool{}
+	for i, w := range cfg.Watchlists {
+		if w == nil {
+			return errs.Errorf("watchlists[%d]: missing watchlist", i)
+		}
+		if err := model.ValidateWatchlistName(w.Name); err != nil {
+			return errs.Errorf("watchlists[%d]: %v", i, err)
+		}
+		if names[w.Name] {
+			return errs.Errorf("watchlists[%d]: duplicate name %q", i, w.Name)
+		}
+		names[w.Name] = true
+
+		for j, s := range w.Stocks {
+			if s == nil {
+				return errs.Errorf("watchlists[%d].stocks[%d]: missing stock", i, j)
+			}
+			if err := model.ValidateSymbol(s.Symbol); err != nil {
+				return errs.Errorf("watchlists[%d].stocks[%d]: %v", i, j, err)
+			}
+		}
 	}
-	return path.Join(dirPath, "config.gob"), nil
+
+	if n := cfg.CurrentWatchlist; n != "" && !names[n] {
+		return errs.Errorf("currentWatchlist: no watchlist named %q", n)
+	}
+
+	for i, d := range cfg.Drawings {
+		if d == nil {
+			return errs.Errorf("drawings[%d]: missing drawing", i)
+		}
+		if err := model.ValidateSymbol(d.Symbol); err != nil {
+			return errs.Errorf("drawings[%d]: %v", i, err)
+		}
+	}
+
+	for i, a := range cfg.Alerts {
+		if a == nil {
+			return errs.Errorf("alerts[%d]: missing alert", i)
+		}
+		if err := model.ValidateSymbol(a.Symbol); err != nil {
+			return errs.Errorf("alerts[%d]: %v", i, err)
+		}
+	}
+
+	for i, l := range cfg.Lots {
+		if l == nil {
+			return errs.Errorf("lots[%d]: missing lot", i)
+		}
+		if err := model.ValidateSymbol(l.Symbol); err != nil {
+			return errs.Errorf("lots[%d]: %v", i, err)
+		}
+		if err := model.ValidateLot(&model.Lot{Shares: l.Shares, Price: l.Price, Date: l.Date}); err != nil {
+			return errs.Errorf("lots[%d]: %v", i, err)
+		}
+	}
+
+	for i, a := range cfg.Adjustments {
+		if a == nil {
+			return errs.Errorf("adjustments[%d]: missing adjustment", i)
+		}
+		if err := model.ValidateSymbol(a.Symbol); err != nil {
+			return errs.Errorf("adjustments[%d]: %v", i, err)
+		}
+		if err := model.ValidateAdjustment(a.Type); err != nil {
+			return errs.Errorf("adjustments[%d]: %v", i, err)
+		}
+	}
+
+	return nil
 }
 
 func userConfigDir() (string, error) {
@@ -0,0 +1,288 @@
+package config
+
+import (
+	"encoding/gob"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/btmura/ponzi2/internal/app/model"
+	"github.com/btmura/ponzi2/internal/app/view"
+	"github.com/btmura/ponzi2/internal/app/view/chart"
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestSaveLoad(t *testing.T) {
+	dir := tempDir(t)
+	defer os.RemoveAll(dir)
+
+	want := &Config{
+		Version:      Version,
+		CurrentStock: &Stock{Symbol: "SPY"},
+		Watchlists: []*Watchlist{
+			{Name: "Holdings", Stocks: []*Stock{{Symbol: "AAPL"}, {Symbol: "MSFT"}}},
+			{Name: "Breakouts", Stocks: []*Stock{{Symbol: "SPY"}}},
+		},
+		CurrentWatchlist: "Breakouts",
+		Settings: Settings{
+			ChartSettings: ChartSettings{
+				PriceStyle: chart.Candlestick,
+				Interval:   model.Weekly,
+				MovingAverages: []*MovingAverage{
+					{Interval: model.Daily, Type: model.Exponential, Intervals: 21, Color: view.Purple},
+				},
+				Bands: []*Band{
+					{Interval: model.Daily, Type: model.BollingerBands, Intervals: 20, Width: 2, Color: view.Blue},
+				},
+				Indicators: []chart.Indicator{chart.MACD},
+			},
+		},
+		Drawings: []*Drawing{
+			{
+				Symbol: "SPY",
+				Type:   model.TrendLine,
+				Start:  Point{Date: time.Date(2019, time.March, 1, 0, 0, 0, 0, time.UTC), Price: 270},
+				End:    Point{Date: time.Date(2019, time.April, 1, 0, 0, 0, 0, time.UTC), Price: 280},
+			},
+		},
+		Alerts: []*Alert{
+			{Symbol: "AAPL", Type: model.PriceAbove, Value: 200, LastPrice: 190},
+		},
+		Lots: []*Lot{
+			{Symbol: "AAPL", Shares: 10, Price: 150.25, Date: time.Date(2019, time.March, 1, 0, 0, 0, 0, time.UTC)},
+		},
+		Adjustments: []*Adjustment{
+			{Symbol: "SPY", Type: model.TotalReturn},
+		},
+	}
+
+	if err := save(dir, want); err != nil {
+		t.Fatalf("save should not return an error: %v", err)
+	}
+
+	got, err := load(dir)
+	if err != nil {
+		t.Fatalf("load should not return an error: %v", err)
+	}
+
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("diff (-want, +got)\n%s", diff)
+	}
+}
+
+func TestLoad(t *testing.T) {
+	for _, tt := range []struct {
+		desc    string
+		json    string
+		want    *Config
+		wantErr bool
+	}{
+		{
+			desc: "enums are names",
+			json: `{
+				"version": 1,
+				"currentStock": {"symbol": "SPY"},
+				"settings": {"chartSettings": {"priceStyle": "Bar", "interval": "Daily"}}
+			}`,
+			want: &Config{
+				Version:      1,
+				CurrentStock: &Stock{Symbol: "SPY"},
+				Settings: Settings{
+					ChartSettings: ChartSettings{PriceStyle: chart.Bar, Interval: model.Daily},
+				},
+			},
+		},
+		{
+			desc: "version 1 stocks move into a watchlist",
+			json: `{"version": 1, "stocks": [{"symbol": "SPY"}, {"symbol": "QQQ"}]}`,
+			want: &Config{
+				Version: 1,
+				Watchlists: []*Watchlist{
+					{Name: model.DefaultWatchlistName, Stocks: []*Stock{{Symbol: "SPY"}, {Symbol: "QQQ"}}},
+				},
+				CurrentWatchlist: model.DefaultWatchlistName,
+			},
+		},
+		{
+			desc:    "missing version",
+			json:    `{"currentStock": {"symbol": "SPY"}}`,
+			wantErr: true,
+		},
+		{
+			desc:    "newer version",
+			json:    `{"version": 999}`,
+			wantErr: true,
+		},
+		{
+			desc:    "bad symbol",
+			json:    `{"version": 1, "stocks": [{"symbol": "SPY"}, {"symbol": "spy"}]}`,
+			wantErr: true,
+		},
+		{
+			desc:    "bad watchlist symbol",
+			json:    `{"version": 2, "watchlists": [{"name": "Holdings", "stocks": [{"symbol": "spy"}]}]}`,
+			wantErr: true,
+		},
+		{
+			desc:    "duplicate watchlist names",
+			json:    `{"version": 2, "watchlists": [{"name": "Holdings"}, {"name": "Holdings"}]}`,
+			wantErr: true,
+		},
+		{
+			desc:    "unknown current watchlist",
+			json:    `{"version": 2, "watchlists": [{"name": "Holdings"}], "currentWatchlist": "Breakouts"}`,
+			wantErr: true,
+		},
+		{
+			desc:    "bad lot shares",
+			json:    `{"version": 3, "lots": [{"symbol": "SPY", "shares": 0, "price": 100, "date": "2019-03-01T00:00:00Z"}]}`,
+			wantErr: true,
+		},
+		{
+			desc:    "unspecified adjustment",
+			json:    `{"version": 4, "adjustments": [{"symbol": "SPY", "type": "AdjustmentUnspecified"}]}`,
+			wantErr: true,
+		},
+		{
+			desc:    "bad enum",
+			json:    `{"version": 1, "settings": {"chartSettings": {"interval": "Hourly"}}}`,
+			wantErr: true,
+		},
+		{
+			desc:    "unknown field",
+			json:    `{"version": 1, "stonks": []}`,
+			wantErr: true,
+		},
+	} {
+		t.Run(tt.desc, func(t *testing.T) {
+			dir := tempDir(t)
+			defer os.RemoveAll(dir)
+
+			if err := ioutil.WriteFile(filepath.Join(dir, fileName), []byte(tt.json), 0660); err != nil {
+				t.Fatalf("WriteFile should not return an error: %v", err)
+			}
+
+			got, gotErr := load(dir)
+
+			if diff := cmp.Diff(tt.want, got); diff != "" {
+				t.Errorf("diff (-want, +got)\n%s", diff)
+			}
+
+			if (gotErr != nil) != tt.wantErr {
+				t.Errorf("got error: %v, wanted err: %t", gotErr, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestLoad_NoConfig(t *testing.T) {
+	dir := tempDir(t)
+	defer os.RemoveAll(dir)
+
+	got, err := load(dir)
+	if err != nil {
+		t.Fatalf("load should not return an error: %v", err)
+	}
+
+	if diff := cmp.Diff(&Config{Version: Version}, got); diff != "" {
+		t.Errorf("diff (-want, +got)\n%s", diff)
+	}
+}
+
+func TestLoad_MigrateGob(t *testing.T) {
+	dir := tempDir(t)
+	defer os.RemoveAll(dir)
+
+	writeGob(t, dir, &gobConfig{
+		CurrentStock: &gobStock{Symbol: "SPY"},
+		Stocks:       []*gobStock{{Symbol: "AAPL"}},
+		Settings: gobSettings{
+			ChartSettings: gobChartSettings{
+				PriceStyle: int(chart.Candlestick),
+				Interval:   int(model.Weekly),
+				Indicators: []int{int(chart.RelativeStrengthIndex)},
+			},
+		},
+	})
+
+	want := &Config{
+		Version:      Version,
+		CurrentStock: &Stock{Symbol: "SPY"},
+		Watchlists: []*Watchlist{
+			{Name: model.DefaultWatchlistName, Stocks: []*Stock{{Symbol: "AAPL"}}},
+		},
+		CurrentWatchlist: model.DefaultWatchlistName,
+		Settings: Settings{
+			ChartSettings: ChartSettings{
+				PriceStyle: chart.Candlestick,
+				Interval:   model.Weekly,
+				Indicators: []chart.Indicator{chart.RelativeStrengthIndex},
+			},
+		},
+	}
+
+	got, err := load(dir)
+	if err != nil {
+		t.Fatalf("load should not return an error: %v", err)
+	}
+
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("diff (-want, +got)\n%s", diff)
+	}
+
+	if _, err := os.Stat(filepath.Join(dir, gobFileName)); !os.IsNotExist(err) {
+		t.Errorf("load should rename the gob config after migrating it: %v", err)
+	}
+
+	// Loading again should read the migrated JSON config.
+	got, err = load(dir)
+	if err != nil {
+		t.Fatalf("load should not return an error: %v", err)
+	}
+
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("diff (-want, +got)\n%s", diff)
+	}
+}
+
+func TestLoad_MigrateGobBadSymbol(t *testing.T) {
+	dir := tempDir(t)
+	defer os.RemoveAll(dir)
+
+	writeGob(t, dir, &gobConfig{
+		Stocks: []*gobStock{{Symbol: "SPY"}, {Symbol: ""}},
+	})
+
+	if _, err := load(dir); err == nil {
+		t.Errorf("load should return an error if the gob config has a bad symbol.")
+	}
+
+	if _, err := os.Stat(filepath.Join(dir, gobFileName)); err != nil {
+		t.Errorf("load should keep the gob config if it can't be migrated: %v", err)
+	}
+}
+
+func tempDir(t *testing.T) string {
+	t.Helper()
+	dir, err := ioutil.TempDir("", "config")
+	if err != nil {
+		t.Fatalf("TempDir should not return an error: %v", err)
+	}
+	return dir
+}
+
+func writeGob(t *testing.T, dir string, g *gobConfig) {
+	t.Helper()
+
+	file, err := os.Create(filepath.Join(dir, gobFileName))
+	if err != nil {
+		t.Fatalf("Create should not return an error: %v", err)
+	}
+	defer file.Close()
+
+	if err := gob.NewEncoder(file).Encode(g); err != nil {
+		t.Fatalf("Encode should not return an error: %v", err)
+	}
+}
@@ -0,0 +1,166 @@
+package config
+
+import (
+	"encoding/gob"
+	"os"
+	"time"
+
+	"github.com/btmura/ponzi2/internal/app/model"
+	"github.com/btmura/ponzi2/internal/app/view"
+	"github.com/btmura/ponzi2/internal/app/view/chart"
+	"github.com/btmura/ponzi2/internal/logger"
+)
+
+// The gob types mirror the config types saved by older versions.
+// Enums are plain ints, because gob would otherwise expect the text
+// encoding of enums that implement encoding.TextMarshaler.
+
+type gobConfig struct {
+	CurrentStock *gobStock
+	Stocks       []*gobStock
+	Settings     gobSettings
+	Drawings     []*gobDrawing
+	Alerts       []*gobAlert
+}
+
+type gobStock struct {
+	Symbol string
+}
+
+type gobDrawing struct {
+	Symbol string
+	Type   int
+	Start  gobPoint
+	End    gobPoint
+}
+
+type gobPoint struct {
+	Date  time.Time
+	Price float32
+}
+
+type gobAlert struct {
+	Symbol                 string
+	Type                   int
+	Value                  float32
+	MovingAverageType      int
+	MovingAverageIntervals int
+	Triggered              bool
+	TriggerTime            time.Time
+	TriggerPrice           float32
+}
+
+type gobSettings struct {
+	ChartSettings gobChartSettings
+}
+
+type gobChartSettings struct {
+	PriceStyle     int
+	Interval       int
+	MovingAverages []*gobMovingAverage
+	Bands          []*gobBand
+	Indicators     []int
+}
+
+type gobMovingAverage struct {
+	Interval  int
+	Type      int
+	Intervals int
+	Color     view.Color
+}
+
+type gobBand struct {
+	Interval  int
+	Type      int
+	Intervals int
+	Width     float32
+	Color     view.Color
+}
+
+// loadGob loads a config saved as gob by older versions.
+func loadGob(gobPath string) (*Config, error) {
+	file, err := os.Open(gobPath)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		if err := file.Close(); err != nil {
+			logger.Errorf("closing in load failed: %v", err)
+		}
+	}()
+
+	g := &gobConfig{}
+	if err := gob.NewDecoder(file).Decode(g); err != nil {
+		return nil, err
+	}
+	return g.config(), nil
+}
+
+func (g *gobConfig) config() *Config {
+	cfg := &Config{
+		Version: Version,
+		Settings: Settings{
+			ChartSettings: ChartSettings{
+				PriceStyle: chart.PriceStyle(g.Settings.ChartSettings.PriceStyle),
+				Interval:   model.Interval(g.Settings.ChartSettings.Interval),
+			},
+		},
+	}
+
+	if s := g.CurrentStock; s != nil {
+		cfg.CurrentStock = &Stock{Symbol: s.Symbol}
+	}
+
+	for _, s := range g.Stocks {
+		cfg.Stocks = append(cfg.Stocks, &Stock{Symbol: s.Symbol})
+	}
+
+	for _, d := range g.Drawings {
+		cfg.Drawings = append(cfg.Drawings, &Drawing{
+			Symbol: d.Symbol,
+			Type:   model.DrawingType(d.Type),
+			Start:  Point{Date: d.Start.Date, Price: d.Start.Price},
+			End:    Point{Date: d.End.Date, Price: d.End.Price},
+		})
+	}
+
+	for _, a := range g.Alerts {
+		cfg.Alerts = append(cfg.Alerts, &Alert{
+			Symbol:                 a.Symbol,
+			Type:                   model.AlertType(a.Type),
+			Value:                  a.Value,
+			MovingAverageType:      model.MovingAverageType(a.MovingAverageType),
+			MovingAverageIntervals: a.MovingAverageIntervals,
+			Triggered:              a.Triggered,
+			TriggerTime:            a.TriggerTime,
+			TriggerPrice:           a.TriggerPrice,
+		})
+	}
+
+	cs := &cfg.Settings.ChartSettings
+
+	for _, ma := range g.Settings.ChartSettings.MovingAverages {
+		cs.MovingAverages = append(cs.MovingAverages, &MovingAverage{
+			Interval:  model.Interval(ma.Interval),
+			Type:      model.MovingAverageType(ma.Type),
+			Intervals: ma.Intervals,
+			Color:     ma.Color,
+		})
+	}
+
+	for _, b := range g.Settings.ChartSettings.Bands {
+		cs.Bands = append(cs.Bands, &Band{
+			Interval:  model.Interval(b.Interval),
+			Type:      model.BandType(b.Type),
+			Intervals: b.Intervals,
+			Width:     b.Width,
+			Color:     b.Color,
+		})
+	}
+
+	for _, i := range g.Settings.ChartSettings.Indicators {
+		cs.Indicators = append(cs.Indicators, chart.Indicator(i))
+	}
+
+	return cfg
+}
@@ -0,0 +1,40 @@
+package config
+
+import (
+	"errors"
+	"path/filepath"
+)
+
+// lockFileName is the name of the file that the running app locks, so that other
+// processes don't change the config underneath it.
+const lockFileName = "config.lock"
+
+// ErrLocked is returned by Lock when another process like a running app has the config locked.
+var ErrLocked = errors.New("config is locked by another process like a running ponzi2")
+
+// Lock locks the config for the rest of the process, so that other processes like the
+// command line flags that edit the config refuse to run. The app saves its whole config
+// whenever it changes and would overwrite their changes. Call the returned function to unlock.
+// The operating system releases the lock if the process dies, so crashes don't leave stale locks.
+func Lock() (unlock func() error, err error) {
+	dirPath, err := userConfigDir()
+	if err != nil {
+		return nil, err
+	}
+	return lock(dirPath)
+}
+
+func lock(dirPath string) (unlock func() error, err error) {
+	// lockFile is implemented per platform and returns nil if the platform can't lock files.
+	f, err := lockFile(filepath.Join(dirPath, lockFileName))
+	if err != nil {
+		return nil, err
+	}
+
+	if f == nil {
+		return func() error { return nil }, nil
+	}
+
+	// Leave the file for the next lock, since removing it could race with another process locking it.
+	return f.Close, nil
+}
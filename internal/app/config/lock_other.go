@@ -0,0 +1,10 @@
+//go:build !linux && !darwin && !windows
+// +build !linux,!darwin,!windows
+
+package config
+
+import "os"
+
+func lockFile(path string) (*os.File, error) {
+	return nil, nil
+}
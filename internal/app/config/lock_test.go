@@ -0,0 +1,39 @@
+package config
+
+import (
+	"os"
+	"runtime"
+	"testing"
+)
+
+func TestLock(t *testing.T) {
+	if runtime.GOOS != "linux" && runtime.GOOS != "darwin" && runtime.GOOS != "windows" {
+		t.Skipf("locking is not supported on %s", runtime.GOOS)
+	}
+
+	dir := tempDir(t)
+	defer os.RemoveAll(dir)
+
+	unlock, err := lock(dir)
+	if err != nil {
+		t.Fatalf("lock should not return an error: %v", err)
+	}
+
+	// Other processes and flags should not be able to lock the config while it is locked.
+	if _, err := lock(dir); err != ErrLocked {
+		t.Errorf("lock should return ErrLocked, got: %v", err)
+	}
+
+	if err := unlock(); err != nil {
+		t.Fatalf("unlock should not return an error: %v", err)
+	}
+
+	unlock, err = lock(dir)
+	if err != nil {
+		t.Fatalf("lock after unlock should not return an error: %v", err)
+	}
+
+	if err := unlock(); err != nil {
+		t.Errorf("unlock should not return an error: %v", err)
+	}
+}
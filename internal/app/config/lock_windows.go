@@ -0,0 +1,27 @@
+package config
+
+import (
+	"os"
+	"syscall"
+)
+
+// errorSharingViolation is returned when opening a file that another process has open without sharing.
+const errorSharingViolation syscall.Errno = 32
+
+func lockFile(path string) (*os.File, error) {
+	p, err := syscall.UTF16PtrFromString(path)
+	if err != nil {
+		return nil, err
+	}
+
+	// Open the file without sharing, so that other processes can't open it until it's closed.
+	h, err := syscall.CreateFile(p, syscall.GENERIC_READ|syscall.GENERIC_WRITE, 0, nil, syscall.OPEN_ALWAYS, syscall.FILE_ATTRIBUTE_NORMAL, 0)
+	if err == errorSharingViolation {
+		return nil, ErrLocked
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return os.NewFile(uintptr(h), path), nil
+}
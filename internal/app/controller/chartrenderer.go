@@ -0,0 +1,182 @@
+package controller
+
+import (
+	"context"
+	"image"
+
+	"github.com/btmura/ponzi2/internal/app/config"
+	"github.com/btmura/ponzi2/internal/app/model"
+	"github.com/btmura/ponzi2/internal/app/view/chart"
+	"github.com/btmura/ponzi2/internal/errs"
+	"github.com/btmura/ponzi2/internal/stock"
+)
+
+// ChartRenderer renders stock charts into images without showing a window.
+// Charts look like the ones in the app with the user's chart settings,
+// drawings, alerts, lots, and adjustments from the config.
+type ChartRenderer struct {
+	// provider gets the stock data.
+	provider stock.Provider
+
+	// model has the user's drawings, alerts, lots, and adjustments.
+	model *model.Model
+
+	// priceStyle is the user's price style.
+	priceStyle chart.PriceStyle
+
+	// movingAverageSettings are the moving averages to show per interval.
+	movingAverageSettings map[model.Interval][]*chart.MovingAverageSetting
+
+	// bandSettings are the price bands to show per interval.
+	bandSettings map[model.Interval][]*chart.BandSetting
+
+	// indicators are the indicators to show below the volume.
+	indicators []chart.Indicator
+
+	// imageRenderer renders the charts into images.
+	imageRenderer ChartImageRenderer
+}
+
+// ChartImageRenderer renders charts with their data already set into images.
+// It is implemented by offscreen.Offscreen, which the controller doesn't import,
+// so that the app doesn't need the libraries used to render without a window.
+type ChartImageRenderer interface {
+	RenderChart(ch *chart.Chart) *image.RGBA
+}
+
+// NewChartRenderer returns a ChartRenderer that renders charts into images with the ChartImageRenderer.
+func NewChartRenderer(provider stock.Provider, cfg *config.Config, imageRenderer ChartImageRenderer) (*ChartRenderer, error) {
+	if provider == nil {
+		return nil, errs.Errorf("nil provider")
+	}
+
+	if cfg == nil {
+		return nil, errs.Errorf("nil config")
+	}
+
+	if imageRenderer == nil {
+		return nil, errs.Errorf("nil image renderer")
+	}
+
+	settings := cfg.Settings.ChartSettings
+
+	priceStyle := chart.Bar
+	if p := settings.PriceStyle; p != chart.PriceStyleUnspecified {
+		priceStyle = p
+	}
+
+	m := model.New()
+	restoreAnnotations(m, cfg)
+
+	return &ChartRenderer{
+		provider:              provider,
+		model:                 m,
+		priceStyle:            priceStyle,
+		movingAverageSettings: movingAverageSettings(settings.MovingAverages),
+		bandSettings:          bandSettings(settings.Bands),
+		indicators:            indicators(settings.Indicators),
+		imageRenderer:         imageRenderer,
+	}, nil
+}
+
+// Render gets the stock's data and renders its chart for the interval.
+func (r *ChartRenderer) Render(ctx context.Context, symbol string, interval model.Interval) (*image.RGBA, error) {
+	d := new(dataRequestBuilder)
+	if err := d.add([]string{symbol}, interval); err != nil {
+		return nil, err
+	}
+
+	reqs, err := d.dataRequests()
+	if err != nil {
+		return nil, err
+	}
+
+	if len(reqs) != 1 {
+		return nil, errs.Errorf("got %d data requests, want 1", len(reqs))
+	}
+	req := reqs[0]
+
+	quotes, err := r.provider.GetQuotes(ctx, req.quotesRequest)
+	if err != nil {
+		return nil, err
+	}
+
+	var charts []*stock.Chart
+	switch req.group {
+	case intraday:
+		charts, err = r.provider.GetIntradayCharts(ctx, req.chartsRequest)
+	default:
+		charts, err = r.provider.GetDailyCharts(ctx, req.chartsRequest)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var sq *stock.Quote
+	for _, q := range quotes {
+		if q.Symbol == symbol {
+			sq = q
+		}
+	}
+
+	var sc *stock.Chart
+	for _, ch := range charts {
+		if ch.Symbol == symbol {
+			sc = ch
+		}
+	}
+
+	if sq == nil || sc == nil {
+		return nil, errs.Errorf("no stock data for %q", symbol)
+	}
+
+	q, err := modelQuote(sq)
+	if err != nil {
+		return nil, err
+	}
+
+	a, err := r.model.Adjustment(symbol)
+	if err != nil {
+		return nil, err
+	}
+
+	mas := modelMovingAverageSettings(r.movingAverageSettings)[interval]
+	bs := modelBandSettings(r.bandSettings)[interval]
+	mc := modelChart(interval, sq, adjustedChart(sc, a), mas, bs)
+	if mc == nil {
+		return nil, errs.Errorf("bad interval: %v", interval)
+	}
+
+	ds, err := r.model.Drawings(symbol)
+	if err != nil {
+		return nil, err
+	}
+
+	as, err := r.model.Alerts(symbol)
+	if err != nil {
+		return nil, err
+	}
+
+	ls, err := r.model.Lots(symbol)
+	if err != nil {
+		return nil, err
+	}
+
+	ch := chart.NewChart(r.priceStyle)
+	defer ch.Close()
+
+	ch.SetData(chart.Data{
+		Symbol:                symbol,
+		Quote:                 q,
+		Chart:                 mc,
+		MovingAverageSettings: r.movingAverageSettings[interval],
+		BandSettings:          r.bandSettings[interval],
+		Indicators:            r.indicators,
+		Drawings:              ds,
+		Alerts:                as,
+		Position:              model.NewPosition(symbol, ls, q),
+		Adjustment:            a,
+	})
+
+	return r.imageRenderer.RenderChart(ch), nil
+}
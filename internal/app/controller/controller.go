@@ -5,13 +5,20 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"os"
+	"strings"
 
 	"github.com/btmura/ponzi2/internal/app/config"
+	"github.com/btmura/ponzi2/internal/app/credentials"
 	"github.com/btmura/ponzi2/internal/app/model"
+	"github.com/btmura/ponzi2/internal/app/notify"
 	"github.com/btmura/ponzi2/internal/app/view/chart"
+	"github.com/btmura/ponzi2/internal/app/view/status"
 	"github.com/btmura/ponzi2/internal/app/view/ui"
+	"github.com/btmura/ponzi2/internal/app/watchlist"
 	"github.com/btmura/ponzi2/internal/errs"
 	"github.com/btmura/ponzi2/internal/logger"
+	"github.com/btmura/ponzi2/internal/stock"
 	"github.com/btmura/ponzi2/internal/stock/iex"
 )
 
@@ -29,6 +36,15 @@ type Controller struct {
 	// chartPriceStyle is the current price style for charts and thumbnails.
 	chartPriceStyle chart.PriceStyle
 
+	// movingAverageSettings are the moving averages to show per interval.
+	movingAverageSettings map[model.Interval][]*chart.MovingAverageSetting
+
+	// bandSettings are the price bands to show per interval.
+	bandSettings map[model.Interval][]*chart.BandSetting
+
+	// indicators are the indicators to show below the volume.
+	indicators []chart.Indicator
+
 	// stockRefresher offers methods to refresh one or many stocks.
 	stockRefresher *stockRefresher
 
@@ -37,23 +53,32 @@ type Controller struct {
 
 	// eventController offers methods to queue and process events in the main loop.
 	eventController *eventController
-}
 
-// iexClientInterface is implemented by clients in the iex package to get stock data.
-type iexClientInterface interface {
-	GetQuotes(ctx context.Context, req *iex.GetQuotesRequest) ([]*iex.Quote, error)
-	GetCharts(ctx context.Context, req *iex.GetChartsRequest) ([]*iex.Chart, error)
+	// creds are the user's API tokens.
+	creds *credentials.Credentials
+
+	// apiTokenSetter sets the API token of the provider. Nil if the provider doesn't need one.
+	apiTokenSetter stock.APITokenSetter
+
+	// promptedForAPIToken is whether the user was already asked for a missing API token.
+	promptedForAPIToken bool
 }
 
-// New creates a new Controller.
-func New(iexClient iexClientInterface, token string) *Controller {
+// New creates a new Controller that uses the API tokens in the credentials.
+func New(provider stock.Provider, creds *credentials.Credentials) *Controller {
+	if creds == nil {
+		creds = &credentials.Credentials{}
+	}
+	s, _ := provider.(stock.APITokenSetter)
 	c := &Controller{
-		model:       model.New(),
-		ui:          ui.New(),
-		configSaver: newConfigSaver(),
+		model:          model.New(),
+		ui:             ui.New(),
+		configSaver:    newConfigSaver(),
+		creds:          creds,
+		apiTokenSetter: s,
 	}
 	c.eventController = newEventController(c)
-	c.stockRefresher = newStockRefresher(iexClient, token, c.eventController)
+	c.stockRefresher = newStockRefresher(provider, c.eventController)
 	return c
 }
 
@@ -88,6 +113,18 @@ func (c *Controller) RunLoop() error {
 	}
 	c.setChartInterval(interval)
 
+	c.movingAverageSettings = movingAverageSettings(settings.MovingAverages)
+	c.stockRefresher.setMovingAverageSettings(modelMovingAverageSettings(c.movingAverageSettings))
+
+	c.bandSettings = bandSettings(settings.Bands)
+	c.stockRefresher.setBandSettings(modelBandSettings(c.bandSettings))
+
+	c.indicators = indicators(settings.Indicators)
+
+	// Restore the user's drawings, alerts, lots, and adjustments before adding stocks, so the charts include them.
+	restoreAnnotations(c.model, cfg)
+	c.stockRefresher.setAdjustments(c.adjustments())
+
 	// Add the user's stocks to the UI.
 	if cfg.CurrentStock != nil {
 		if s := cfg.CurrentStock.Symbol; s != "" {
@@ -97,15 +134,57 @@ func (c *Controller) RunLoop() error {
 		}
 	}
 
-	for _, cs := range cfg.Stocks {
-		if s := cs.Symbol; s != "" {
+	// Restore the user's watchlists and add the current one's stocks to the sidebar.
+	for i, w := range cfg.Watchlists {
+		if i == 0 {
+			if err := c.model.RenameWatchlist(c.model.CurrentWatchlist(), w.Name); err != nil {
+				return err
+			}
+			continue
+		}
+		if err := c.model.AddWatchlist(w.Name); err != nil {
+			return err
+		}
+	}
+
+	if n := cfg.CurrentWatchlist; n != "" {
+		if _, err := c.model.SetCurrentWatchlist(n); err != nil {
+			return err
+		}
+	}
+
+	for _, w := range cfg.Watchlists {
+		var symbols []string
+		for _, cs := range w.Stocks {
+			if s := cs.Symbol; s != "" {
+				symbols = append(symbols, s)
+			}
+		}
+
+		if w.Name != c.model.CurrentWatchlist() {
+			if err := c.model.SetWatchlistSymbols(w.Name, symbols); err != nil {
+				return err
+			}
+			continue
+		}
+
+		for _, s := range symbols {
 			if err := c.addChartThumb(ctx, s); err != nil {
 				return err
 			}
 		}
 	}
 
+	c.ui.SetWatchlists(c.model.WatchlistNames(), c.model.CurrentWatchlist())
+
 	c.ui.SetInputSymbolSubmittedCallback(func(symbol string) {
+		// Accept other spellings like BRK-B and BRK/B for BRK.B.
+		symbol, err := stock.NormalizeSymbol(symbol)
+		if err != nil {
+			logger.Errorf("NormalizeSymbol: %v", err)
+			return
+		}
+
 		if err := c.setChart(ctx, symbol); err != nil {
 			logger.Errorf("setChart: %v", err)
 		}
@@ -117,6 +196,48 @@ func (c *Controller) RunLoop() error {
 		}
 	})
 
+	c.ui.SetWatchlistSwitchCallback(func(name string) {
+		if err := c.setCurrentWatchlist(ctx, name); err != nil {
+			logger.Errorf("setCurrentWatchlist: %v", err)
+		}
+	})
+
+	c.ui.SetWatchlistAddCallback(func(name string) {
+		if err := c.addWatchlist(ctx, name); err != nil {
+			logger.Errorf("addWatchlist: %v", err)
+		}
+	})
+
+	c.ui.SetWatchlistRenameCallback(func(oldName, newName string) {
+		if err := c.renameWatchlist(oldName, newName); err != nil {
+			logger.Errorf("renameWatchlist: %v", err)
+		}
+	})
+
+	c.ui.SetWatchlistRemoveCallback(func(name string) {
+		if err := c.removeWatchlist(ctx, name); err != nil {
+			logger.Errorf("removeWatchlist: %v", err)
+		}
+	})
+
+	c.ui.SetWatchlistPasteCallback(func(text string) {
+		if err := c.pasteWatchlistSymbols(ctx, text); err != nil {
+			logger.Errorf("pasteWatchlistSymbols: %v", err)
+		}
+	})
+
+	c.ui.SetWatchlistCopyCallback(func() {
+		if err := c.copyWatchlistSymbols(); err != nil {
+			logger.Errorf("copyWatchlistSymbols: %v", err)
+		}
+	})
+
+	c.ui.SetAPITokenSubmittedCallback(func(token string) {
+		if err := c.setAPIToken(ctx, token); err != nil {
+			logger.Errorf("setAPIToken: %v", err)
+		}
+	})
+
 	c.ui.SetChartPriceStyleButtonClickCallback(func(newPriceStyle chart.PriceStyle) {
 		if newPriceStyle == chart.PriceStyleUnspecified {
 			logger.Error("unspecified price style")
@@ -145,6 +266,48 @@ func (c *Controller) RunLoop() error {
 		}
 	})
 
+	c.ui.SetChartAdjustmentButtonClickCallback(func(symbol string) {
+		if err := c.toggleAdjustment(ctx, symbol); err != nil {
+			logger.Errorf("toggleAdjustment: %v", err)
+		}
+	})
+
+	c.ui.SetChartDrawingAddCallback(func(symbol string, d *model.Drawing) {
+		if err := c.addDrawing(symbol, d); err != nil {
+			logger.Errorf("addDrawing: %v", err)
+		}
+	})
+
+	c.ui.SetChartDrawingRemoveCallback(func(symbol string, index int) {
+		if err := c.removeDrawing(symbol, index); err != nil {
+			logger.Errorf("removeDrawing: %v", err)
+		}
+	})
+
+	c.ui.SetChartAlertAddCallback(func(symbol string, a *model.Alert) {
+		if err := c.addAlert(symbol, a); err != nil {
+			logger.Errorf("addAlert: %v", err)
+		}
+	})
+
+	c.ui.SetChartAlertRemoveCallback(func(symbol string, index int) {
+		if err := c.removeAlert(symbol, index); err != nil {
+			logger.Errorf("removeAlert: %v", err)
+		}
+	})
+
+	c.ui.SetChartLotAddCallback(func(symbol string, l *model.Lot) {
+		if err := c.addLot(symbol, l); err != nil {
+			logger.Errorf("addLot: %v", err)
+		}
+	})
+
+	c.ui.SetChartLotRemoveCallback(func(symbol string, index int) {
+		if err := c.removeLot(symbol, index); err != nil {
+			logger.Errorf("removeLot: %v", err)
+		}
+	})
+
 	c.ui.SetThumbRemoveButtonClickCallback(func(symbol string) {
 		if err := c.removeChartThumb(symbol); err != nil {
 			logger.Errorf("removeChartThumb: %v", err)
@@ -276,6 +439,140 @@ func (c *Controller) setSidebarSymbols(symbols []string) error {
 	return nil
 }
 
+// setCurrentWatchlist replaces the thumbnails in the sidebar with the stocks of another watchlist.
+func (c *Controller) setCurrentWatchlist(ctx context.Context, name string) error {
+	changed, err := c.model.SetCurrentWatchlist(name)
+	if err != nil {
+		return err
+	}
+
+	if !changed {
+		return nil
+	}
+
+	if err := c.showCurrentWatchlist(ctx); err != nil {
+		return err
+	}
+
+	c.configSaver.save(c.makeConfig())
+
+	return nil
+}
+
+// showCurrentWatchlist replaces the thumbnails in the sidebar with the current watchlist's and refreshes them.
+func (c *Controller) showCurrentWatchlist(ctx context.Context) error {
+	c.ui.ClearChartThumbs()
+	c.ui.SetWatchlists(c.model.WatchlistNames(), c.model.CurrentWatchlist())
+
+	for _, s := range c.model.SidebarSymbols() {
+		c.ui.AddChartThumb(s, c.chartData(s, c.chartInterval))
+	}
+
+	d := new(dataRequestBuilder)
+	if err := d.add(c.model.SidebarSymbols(), c.chartInterval); err != nil {
+		return err
+	}
+	return c.stockRefresher.refresh(ctx, d)
+}
+
+// addWatchlist adds an empty watchlist and switches to it.
+func (c *Controller) addWatchlist(ctx context.Context, name string) error {
+	if err := c.model.AddWatchlist(name); err != nil {
+		return err
+	}
+	return c.setCurrentWatchlist(ctx, name)
+}
+
+func (c *Controller) renameWatchlist(oldName, newName string) error {
+	if err := c.model.RenameWatchlist(oldName, newName); err != nil {
+		return err
+	}
+
+	c.ui.SetWatchlists(c.model.WatchlistNames(), c.model.CurrentWatchlist())
+	c.configSaver.save(c.makeConfig())
+
+	return nil
+}
+
+func (c *Controller) removeWatchlist(ctx context.Context, name string) error {
+	current := c.model.CurrentWatchlist()
+
+	removed, err := c.model.RemoveWatchlist(name)
+	if err != nil {
+		return err
+	}
+
+	if !removed {
+		return nil
+	}
+
+	if name == current {
+		if err := c.showCurrentWatchlist(ctx); err != nil {
+			return err
+		}
+	} else {
+		c.ui.SetWatchlists(c.model.WatchlistNames(), c.model.CurrentWatchlist())
+	}
+
+	c.configSaver.save(c.makeConfig())
+
+	return nil
+}
+
+// pasteWatchlistSymbols adds the symbols in text of any supported format to the current watchlist.
+func (c *Controller) pasteWatchlistSymbols(ctx context.Context, text string) error {
+	symbols, err := watchlist.Read(strings.NewReader(text), watchlist.Detect(text))
+	if err != nil {
+		return err
+	}
+
+	for _, s := range symbols {
+		if err := c.addChartThumb(ctx, s); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// copyWatchlistSymbols puts the symbols of the current watchlist on the clipboard one per line.
+func (c *Controller) copyWatchlistSymbols() error {
+	var b strings.Builder
+	if err := watchlist.Write(&b, watchlist.PlainText, c.model.SidebarSymbols()); err != nil {
+		return err
+	}
+	c.ui.SetClipboardText(b.String())
+	return nil
+}
+
+// setAPIToken saves the token the user entered and refreshes the stocks with it.
+func (c *Controller) setAPIToken(ctx context.Context, token string) error {
+	token = strings.TrimSpace(token)
+	if err := iex.ValidateToken(token); err != nil {
+		return err
+	}
+
+	if c.apiTokenSetter == nil {
+		return errs.Errorf("stock data provider doesn't use API tokens")
+	}
+
+	c.creds.IEXAPIToken = token
+	if err := credentials.Save(c.creds); err != nil {
+		return err
+	}
+
+	if os.Getenv(credentials.IEXAPITokenEnvVar) != "" {
+		logger.Infof("%s will override the saved token on the next start", credentials.IEXAPITokenEnvVar)
+	}
+
+	c.apiTokenSetter.SetAPIToken(token)
+
+	// Ask again if the new token is rejected too.
+	c.promptedForAPIToken = false
+
+	return c.refreshAllStocks(ctx)
+}
+
 func (c *Controller) setChartPriceStyle(newPriceStyle chart.PriceStyle) {
 	if newPriceStyle == chart.PriceStyleUnspecified {
 		logger.Error("unspecified price style")
@@ -316,13 +613,193 @@ func (c *Controller) setChartInterval(newInterval model.Interval) {
 	c.configSaver.save(c.makeConfig())
 }
 
+// toggleAdjustment switches the symbol's charts between split-adjusted and total return prices.
+func (c *Controller) toggleAdjustment(ctx context.Context, symbol string) error {
+	old, err := c.model.Adjustment(symbol)
+	if err != nil {
+		return err
+	}
+
+	adjustment := model.TotalReturn
+	if old == model.TotalReturn {
+		adjustment = model.SplitAdjusted
+	}
+
+	if _, err := c.model.SetAdjustment(symbol, adjustment); err != nil {
+		return err
+	}
+
+	c.stockRefresher.setAdjustments(c.adjustments())
+
+	data := c.chartData(symbol, c.chartInterval)
+	c.ui.SetData(symbol, data)
+	c.configSaver.save(c.makeConfig())
+
+	// Refresh to recalculate the charts with the other prices.
+	return c.stockRefresher.refreshOne(ctx, symbol, c.chartInterval)
+}
+
+// adjustments returns a new map of the symbols that aren't split-adjusted to their adjustments.
+func (c *Controller) adjustments() map[string]model.Adjustment {
+	m := map[string]model.Adjustment{}
+	for _, s := range c.model.AdjustmentSymbols() {
+		a, err := c.model.Adjustment(s)
+		if err != nil {
+			logger.Errorf("Adjustment: %v", err)
+			continue
+		}
+		m[s] = a
+	}
+	return m
+}
+
+func (c *Controller) addDrawing(symbol string, d *model.Drawing) error {
+	if err := c.model.AddDrawing(symbol, d); err != nil {
+		return err
+	}
+
+	data := c.chartData(symbol, c.chartInterval)
+	c.ui.SetData(symbol, data)
+	c.configSaver.save(c.makeConfig())
+
+	return nil
+}
+
+func (c *Controller) removeDrawing(symbol string, index int) error {
+	removed, err := c.model.RemoveDrawing(symbol, index)
+	if err != nil {
+		return err
+	}
+
+	if !removed {
+		return nil
+	}
+
+	data := c.chartData(symbol, c.chartInterval)
+	c.ui.SetData(symbol, data)
+	c.configSaver.save(c.makeConfig())
+
+	return nil
+}
+
+func (c *Controller) addAlert(symbol string, a *model.Alert) error {
+	// The model starts the alert from the current price, so it only triggers when the price crosses its value.
+	if err := c.model.AddAlert(symbol, a); err != nil {
+		return err
+	}
+
+	data := c.chartData(symbol, c.chartInterval)
+	c.ui.SetData(symbol, data)
+	c.configSaver.save(c.makeConfig())
+
+	return nil
+}
+
+func (c *Controller) removeAlert(symbol string, index int) error {
+	removed, err := c.model.RemoveAlert(symbol, index)
+	if err != nil {
+		return err
+	}
+
+	if !removed {
+		return nil
+	}
+
+	data := c.chartData(symbol, c.chartInterval)
+	c.ui.SetData(symbol, data)
+	c.configSaver.save(c.makeConfig())
+
+	return nil
+}
+
+func (c *Controller) addLot(symbol string, l *model.Lot) error {
+	if err := c.model.AddLot(symbol, l); err != nil {
+		return err
+	}
+
+	data := c.chartData(symbol, c.chartInterval)
+	c.ui.SetData(symbol, data)
+	c.configSaver.save(c.makeConfig())
+
+	return nil
+}
+
+func (c *Controller) removeLot(symbol string, index int) error {
+	removed, err := c.model.RemoveLot(symbol, index)
+	if err != nil {
+		return err
+	}
+
+	if !removed {
+		return nil
+	}
+
+	data := c.chartData(symbol, c.chartInterval)
+	c.ui.SetData(symbol, data)
+	c.configSaver.save(c.makeConfig())
+
+	return nil
+}
+
+// checkAlerts checks the symbol's alerts and notifies the user of any that triggered.
+func (c *Controller) checkAlerts(symbol string) error {
+	triggered, err := c.model.CheckAlerts(symbol)
+	if err != nil {
+		return err
+	}
+
+	if len(triggered) == 0 {
+		return nil
+	}
+
+	for _, a := range triggered {
+		msg := fmt.Sprintf("%s at %.2f", status.Alert(a), a.TriggerPrice)
+		if err := notify.Send(symbol+" alert", msg); err != nil {
+			logger.Errorf("notify.Send: %v", err)
+		}
+	}
+
+	c.configSaver.save(c.makeConfig())
+
+	return nil
+}
+
 func (c *Controller) chartData(symbol string, interval model.Interval) chart.Data {
 	if symbol == "" {
 		logger.Error("missing symbol")
 		return chart.Data{}
 	}
 
-	data := chart.Data{Symbol: symbol}
+	data := chart.Data{
+		Symbol:                symbol,
+		MovingAverageSettings: c.movingAverageSettings[interval],
+		BandSettings:          c.bandSettings[interval],
+		Indicators:            c.indicators,
+	}
+
+	ds, err := c.model.Drawings(symbol)
+	if err != nil {
+		logger.Errorf("Drawings: %v", err)
+	}
+	data.Drawings = ds
+
+	as, err := c.model.Alerts(symbol)
+	if err != nil {
+		logger.Errorf("Alerts: %v", err)
+	}
+	data.Alerts = as
+
+	p, err := c.model.Position(symbol)
+	if err != nil {
+		logger.Errorf("Position: %v", err)
+	}
+	data.Position = p
+
+	a, err := c.model.Adjustment(symbol)
+	if err != nil {
+		logger.Errorf("Adjustment: %v", err)
+	}
+	data.Adjustment = a
 
 	st, err := c.model.Stock(symbol)
 	if err != nil {
@@ -367,6 +844,12 @@ func (c *Controller) refreshAllStocks(ctx context.Context) error {
 		return err
 	}
 
+	// Moving average cross alerts are checked against daily charts,
+	// so refresh those too when another interval is shown.
+	if err := d.add(c.model.MovingAverageAlertSymbols(), model.Daily); err != nil {
+		return err
+	}
+
 	return c.stockRefresher.refresh(ctx, d)
 }
 
@@ -403,6 +886,10 @@ func (c *Controller) onStockUpdate(symbol string, q *model.Quote, ch *model.Char
 	}
 
 	if q != nil || ch != nil {
+		if err := c.checkAlerts(symbol); err != nil {
+			return err
+		}
+
 		data := c.chartData(symbol, c.chartInterval)
 		c.ui.SetData(symbol, data)
 	}
@@ -416,8 +903,17 @@ func (c *Controller) onStockUpdateError(symbol string, updateErr error) error {
 
 	var errorMessage string
 	switch {
-	case errors.Is(updateErr, iex.ErrMissingAPIToken):
-		errorMessage = "Missing API token. Visit ponzi2.io/install."
+	case errors.Is(updateErr, stock.ErrMissingAPIToken):
+		errorMessage = "Missing API token. Press CTRL+K to enter it."
+
+		c.promptForAPIToken()
+
+	case errors.Is(updateErr, stock.ErrUnauthorized):
+		errorMessage = "API token was rejected. Press CTRL+K to enter another one."
+		c.promptForAPIToken()
+
+	case errors.Is(updateErr, stock.ErrThrottled):
+		errorMessage = "Too many requests. Refresh again later."
 
 	default:
 		errorMessage = fmt.Sprintf("ERROR: %v", updateErr)
@@ -427,6 +923,15 @@ func (c *Controller) onStockUpdateError(symbol string, updateErr error) error {
 	return nil
 }
 
+// promptForAPIToken asks the user for an API token once instead of for every stock that failed.
+func (c *Controller) promptForAPIToken() {
+	if c.apiTokenSetter == nil || c.promptedForAPIToken {
+		return
+	}
+	c.ui.ShowAPITokenPrompt()
+	c.promptedForAPIToken = true
+}
+
 // onRefreshAllStocksRequest implements the eventHandler interface.
 func (c *Controller) onRefreshAllStocksRequest(ctx context.Context) error {
 	return c.refreshAllStocks(ctx)
@@ -440,8 +945,10 @@ func (c *Controller) onEventAdded() {
 func nextInterval(interval model.Interval, zoomChange chart.ZoomChange) model.Interval {
 	// zoomIntervals are the ranges from most zoomed out to most zoomed in.
 	var zoomIntervals = []model.Interval{
+		model.Monthly,
 		model.Weekly,
 		model.Daily,
+		model.Intraday,
 	}
 
 	// Find the current zoom range.
@@ -472,10 +979,249 @@ func (c *Controller) makeConfig() *config.Config {
 	if s := c.model.CurrentSymbol(); s != "" {
 		cfg.CurrentStock = &config.Stock{Symbol: s}
 	}
-	for _, s := range c.model.SidebarSymbols() {
-		cfg.Stocks = append(cfg.Stocks, &config.Stock{Symbol: s})
+	for _, w := range c.model.Watchlists() {
+		cw := &config.Watchlist{Name: w.Name}
+		for _, s := range w.Symbols {
+			cw.Stocks = append(cw.Stocks, &config.Stock{Symbol: s})
+		}
+		cfg.Watchlists = append(cfg.Watchlists, cw)
 	}
+	cfg.CurrentWatchlist = c.model.CurrentWatchlist()
 	cfg.Settings.ChartSettings.PriceStyle = c.chartPriceStyle
 	cfg.Settings.ChartSettings.Interval = c.chartInterval
+	for _, interval := range movingAverageIntervals {
+		for _, ma := range c.movingAverageSettings[interval] {
+			cfg.Settings.ChartSettings.MovingAverages = append(cfg.Settings.ChartSettings.MovingAverages, &config.MovingAverage{
+				Interval:  interval,
+				Type:      ma.Type,
+				Intervals: ma.Intervals,
+				Color:     ma.Color,
+			})
+		}
+	}
+	for _, interval := range movingAverageIntervals {
+		for _, b := range c.bandSettings[interval] {
+			cfg.Settings.ChartSettings.Bands = append(cfg.Settings.ChartSettings.Bands, &config.Band{
+				Interval:  interval,
+				Type:      b.Type,
+				Intervals: b.Intervals,
+				Width:     b.Width,
+				Color:     b.Color,
+			})
+		}
+	}
+	cfg.Settings.ChartSettings.Indicators = append([]chart.Indicator(nil), c.indicators...)
+	for _, s := range c.model.DrawingSymbols() {
+		ds, err := c.model.Drawings(s)
+		if err != nil {
+			logger.Errorf("Drawings: %v", err)
+			continue
+		}
+		for _, d := range ds {
+			cfg.Drawings = append(cfg.Drawings, &config.Drawing{
+				Symbol: s,
+				Type:   d.Type,
+				Start:  config.Point{Date: d.Start.Date, Price: d.Start.Price},
+				End:    config.Point{Date: d.End.Date, Price: d.End.Price},
+			})
+		}
+	}
+	for _, s := range c.model.AlertSymbols() {
+		as, err := c.model.Alerts(s)
+		if err != nil {
+			logger.Errorf("Alerts: %v", err)
+			continue
+		}
+		for _, a := range as {
+			cfg.Alerts = append(cfg.Alerts, &config.Alert{
+				Symbol:                 s,
+				Type:                   a.Type,
+				Value:                  a.Value,
+				MovingAverageType:      a.MovingAverageType,
+				MovingAverageIntervals: a.MovingAverageIntervals,
+				LastPrice:              a.LastPrice,
+				Triggered:              a.Triggered,
+				TriggerTime:            a.TriggerTime,
+				TriggerPrice:           a.TriggerPrice,
+			})
+		}
+	}
+	for _, s := range c.model.LotSymbols() {
+		ls, err := c.model.Lots(s)
+		if err != nil {
+			logger.Errorf("Lots: %v", err)
+			continue
+		}
+		for _, l := range ls {
+			cfg.Lots = append(cfg.Lots, &config.Lot{
+				Symbol: s,
+				Shares: l.Shares,
+				Price:  l.Price,
+				Date:   l.Date,
+			})
+		}
+	}
+	for _, s := range c.model.AdjustmentSymbols() {
+		a, err := c.model.Adjustment(s)
+		if err != nil {
+			logger.Errorf("Adjustment: %v", err)
+			continue
+		}
+		cfg.Adjustments = append(cfg.Adjustments, &config.Adjustment{
+			Symbol: s,
+			Type:   a,
+		})
+	}
 	return cfg
 }
+
+// restoreAnnotations adds the user's drawings, alerts, lots, and adjustments in the config to the model.
+func restoreAnnotations(m *model.Model, cfg *config.Config) {
+	for _, d := range cfg.Drawings {
+		md := &model.Drawing{
+			Type:  d.Type,
+			Start: model.DrawingPoint{Date: d.Start.Date, Price: d.Start.Price},
+			End:   model.DrawingPoint{Date: d.End.Date, Price: d.End.Price},
+		}
+		if err := m.AddDrawing(d.Symbol, md); err != nil {
+			logger.Errorf("skipping bad drawing: %v", err)
+		}
+	}
+
+	for _, a := range cfg.Alerts {
+		ma := &model.Alert{
+			Type:                   a.Type,
+			Value:                  a.Value,
+			MovingAverageType:      a.MovingAverageType,
+			MovingAverageIntervals: a.MovingAverageIntervals,
+			LastPrice:              a.LastPrice,
+			Triggered:              a.Triggered,
+			TriggerTime:            a.TriggerTime,
+			TriggerPrice:           a.TriggerPrice,
+		}
+		if err := m.AddAlert(a.Symbol, ma); err != nil {
+			logger.Errorf("skipping bad alert: %v", err)
+		}
+	}
+
+	for _, l := range cfg.Lots {
+		ml := &model.Lot{
+			Shares: l.Shares,
+			Price:  l.Price,
+			Date:   l.Date,
+		}
+		if err := m.AddLot(l.Symbol, ml); err != nil {
+			logger.Errorf("skipping bad lot: %v", err)
+		}
+	}
+
+	for _, a := range cfg.Adjustments {
+		if _, err := m.SetAdjustment(a.Symbol, a.Type); err != nil {
+			logger.Errorf("skipping bad adjustment: %v", err)
+		}
+	}
+}
+
+// movingAverageIntervals are the intervals that show moving averages.
+var movingAverageIntervals = []model.Interval{
+	model.Daily,
+	model.Weekly,
+	model.Monthly,
+}
+
+// movingAverageSettings returns the configured moving averages per interval
+// using the defaults for intervals without any configured moving averages.
+func movingAverageSettings(mas []*config.MovingAverage) map[model.Interval][]*chart.MovingAverageSetting {
+	m := map[model.Interval][]*chart.MovingAverageSetting{}
+	for _, ma := range mas {
+		if ma.Interval == model.IntervalUnspecified || ma.Type == model.MovingAverageTypeUnspecified || ma.Intervals <= 0 {
+			logger.Errorf("skipping bad moving average: %+v", ma)
+			continue
+		}
+
+		m[ma.Interval] = append(m[ma.Interval], &chart.MovingAverageSetting{
+			MovingAverageSetting: model.MovingAverageSetting{
+				Type:      ma.Type,
+				Intervals: ma.Intervals,
+			},
+			Color: ma.Color,
+		})
+	}
+
+	for _, interval := range movingAverageIntervals {
+		if len(m[interval]) == 0 {
+			m[interval] = chart.DefaultMovingAverageSettings(interval)
+		}
+	}
+
+	return m
+}
+
+// bandSettings returns the configured price bands per interval
+// using the defaults for intervals without any configured price bands.
+func bandSettings(bs []*config.Band) map[model.Interval][]*chart.BandSetting {
+	m := map[model.Interval][]*chart.BandSetting{}
+	for _, b := range bs {
+		if b.Interval == model.IntervalUnspecified || b.Type == model.BandTypeUnspecified || b.Intervals <= 0 || b.Width <= 0 {
+			logger.Errorf("skipping bad band: %+v", b)
+			continue
+		}
+
+		m[b.Interval] = append(m[b.Interval], &chart.BandSetting{
+			BandSetting: model.BandSetting{
+				Type:      b.Type,
+				Intervals: b.Intervals,
+				Width:     b.Width,
+			},
+			Color: b.Color,
+		})
+	}
+
+	for _, interval := range movingAverageIntervals {
+		if len(m[interval]) == 0 {
+			m[interval] = chart.DefaultBandSettings(interval)
+		}
+	}
+
+	return m
+}
+
+// modelMovingAverageSettings returns the moving averages to calculate for the ones to show per interval.
+func modelMovingAverageSettings(settings map[model.Interval][]*chart.MovingAverageSetting) map[model.Interval][]*model.MovingAverageSetting {
+	m := map[model.Interval][]*model.MovingAverageSetting{}
+	for interval, ss := range settings {
+		for _, s := range ss {
+			m[interval] = append(m[interval], &s.MovingAverageSetting)
+		}
+	}
+	return m
+}
+
+// modelBandSettings returns the price bands to calculate for the ones to show per interval.
+func modelBandSettings(settings map[model.Interval][]*chart.BandSetting) map[model.Interval][]*model.BandSetting {
+	m := map[model.Interval][]*model.BandSetting{}
+	for interval, ss := range settings {
+		for _, s := range ss {
+			m[interval] = append(m[interval], &s.BandSetting)
+		}
+	}
+	return m
+}
+
+// indicators returns the configured indicators or the defaults if none are configured.
+func indicators(inds []chart.Indicator) []chart.Indicator {
+	var valid []chart.Indicator
+	for _, ind := range inds {
+		if ind == chart.IndicatorUnspecified {
+			logger.Errorf("skipping bad indicator: %v", ind)
+			continue
+		}
+		valid = append(valid, ind)
+	}
+
+	if len(valid) == 0 {
+		return chart.DefaultIndicators()
+	}
+
+	return valid
+}
@@ -1,21 +1,83 @@
 package controller
 
 import (
+	"math"
 	"sort"
 	"time"
 
 	"github.com/btmura/ponzi2/internal/app/model"
 	"github.com/btmura/ponzi2/internal/errs"
 	"github.com/btmura/ponzi2/internal/logger"
-	"github.com/btmura/ponzi2/internal/stock/iex"
+	"github.com/btmura/ponzi2/internal/stock"
 )
 
 // maxDataWeeks is maximum number of weeks of data to retain.
 const maxDataWeeks = 12 /* months */ * 4 /* weeks = 1 year */
 
-func modelIntradayChart(chart *iex.Chart) *model.Chart {
+// Indicator parameters using the common defaults.
+const (
+	rsiIntervals        = 14
+	macdFastIntervals   = 12
+	macdSlowIntervals   = 26
+	macdSignalIntervals = 9
+	stochasticIntervals = 14
+	stochasticKInterval = 3
+	stochasticDInterval = 3
+)
+
+// modelChart returns the chart for the interval or nil if the interval is unknown.
+func modelChart(interval model.Interval, quote *stock.Quote, chart *stock.Chart, mas []*model.MovingAverageSetting, bs []*model.BandSetting) *model.Chart {
+	switch interval {
+	case model.Intraday:
+		return modelIntradayChart(chart)
+	case model.Daily:
+		return modelDailyChart(quote, chart, mas, bs)
+	case model.Weekly:
+		return modelWeeklyChart(quote, chart, mas, bs)
+	case model.Monthly:
+		return modelMonthlyChart(quote, chart, mas, bs)
+	default:
+		return nil
+	}
+}
+
+// adjustedChart returns a copy of the chart with the adjustment's prices.
+// Bars without total return prices like minute bars keep their split-adjusted prices.
+func adjustedChart(chart *stock.Chart, adjustment model.Adjustment) *stock.Chart {
+	if chart == nil || adjustment != model.TotalReturn {
+		return chart
+	}
+
+	adjusted := chart.DeepCopy()
+	for i, b := range adjusted.Bars {
+		if b.TotalReturnClose <= 0 {
+			continue
+		}
+
+		ratio := b.TotalReturnClose / b.Close
+		b.Open, b.High, b.Low, b.Close = b.TotalReturnOpen, b.TotalReturnHigh, b.TotalReturnLow, b.TotalReturnClose
+
+		// Recalculate changes from the previous close, so that dividends don't show up as drops.
+		if i == 0 || adjusted.Bars[i-1].Close <= 0 {
+			b.Change *= ratio
+			continue
+		}
+
+		prev := adjusted.Bars[i-1].Close
+		b.Change = b.Close - prev
+		b.ChangePercent = b.Change / prev
+	}
+	return adjusted
+}
+
+func modelIntradayChart(chart *stock.Chart) *model.Chart {
 	var ts []*model.TradingSession
-	for _, p := range chart.ChartPoints {
+	for _, p := range chart.Bars {
+		// Skip minutes without any trades, since they have no prices.
+		if p.Open <= 0 || p.High <= 0 || p.Low <= 0 || p.Close <= 0 {
+			continue
+		}
+
 		ts = append(ts, &model.TradingSession{
 			Date:          p.Date,
 			Open:          p.Open,
@@ -31,6 +93,18 @@ func modelIntradayChart(chart *iex.Chart) *model.Chart {
 		return ts[i].Date.Before(ts[j].Date)
 	})
 
+	// Minute bars usually lack changes, so calculate them from the previous
+	// minute's close so that the bars are colored like the daily bars.
+	for i, t := range ts {
+		if i == 0 || t.Change != 0 {
+			continue
+		}
+
+		prev := ts[i-1].Close
+		t.Change = t.Close - prev
+		t.PercentChange = t.Change / prev
+	}
+
 	return &model.Chart{
 		Interval: model.Intraday,
 		TradingSessionSeries: &model.TradingSessionSeries{
@@ -39,59 +113,80 @@ func modelIntradayChart(chart *iex.Chart) *model.Chart {
 	}
 }
 
-func modelDailyChart(quote *iex.Quote, chart *iex.Chart) *model.Chart {
+func modelDailyChart(quote *stock.Quote, chart *stock.Chart, mas []*model.MovingAverageSetting, bs []*model.BandSetting) *model.Chart {
 	ds := modelTradingSessions(quote, chart)
 	ws := weeklyModelTradingSessions(ds)
-	m8 := modelExponentialMovingAverages(ds, 8)
-	m21 := modelExponentialMovingAverages(ds, 21)
-	m50 := modelSimpleMovingAverages(ds, 50)
-	m200 := modelSimpleMovingAverages(ds, 200)
+	ms := modelMovingAverages(ds, mas)
+	bands := modelBands(ds, bs)
 	v50 := modelAverageVolumes(ds, 50)
+	rsi := modelRelativeStrengthIndexSeries(ds)
+	macd := modelMACDSeries(ds)
+	sto := modelStochasticSeries(ds)
 
 	if len(ws) > maxDataWeeks {
 		start := ws[len(ws)-maxDataWeeks:][0].Date
 		ds = trimmedTradingSessions(ds, start)
-		m8 = trimmedMovingAverages(m8, start)
-		m21 = trimmedMovingAverages(m21, start)
-		m50 = trimmedMovingAverages(m50, start)
-		m200 = trimmedMovingAverages(m200, start)
+		for _, m := range ms {
+			m.Values = trimmedMovingAverages(m.Values, start)
+		}
+		for _, b := range bands {
+			b.Values = trimmedBands(b.Values, start)
+		}
 		v50 = trimmedAverageVolumes(v50, start)
+		rsi.Values = trimmedRelativeStrengthIndexes(rsi.Values, start)
+		macd.Values = trimmedMACDs(macd.Values, start)
+		sto.Values = trimmedStochastics(sto.Values, start)
 	}
 
 	return &model.Chart{
-		Interval:             model.Daily,
-		TradingSessionSeries: &model.TradingSessionSeries{TradingSessions: ds},
-		MovingAverageSeriesSet: []*model.MovingAverageSeries{
-			{Type: model.Exponential, Intervals: 8, Values: m8},
-			{Type: model.Exponential, Intervals: 21, Values: m21},
-			{Type: model.Simple, Intervals: 50, Values: m50},
-			{Type: model.Simple, Intervals: 200, Values: m200},
-		},
-		AverageVolumeSeries: &model.AverageVolumeSeries{Values: v50},
+		Interval:                    model.Daily,
+		TradingSessionSeries:        &model.TradingSessionSeries{TradingSessions: ds},
+		MovingAverageSeriesSet:      ms,
+		BandSeriesSet:               bands,
+		AverageVolumeSeries:         &model.AverageVolumeSeries{Values: v50},
+		RelativeStrengthIndexSeries: rsi,
+		MACDSeries:                  macd,
+		StochasticSeries:            sto,
 	}
 }
 
-func modelWeeklyChart(quote *iex.Quote, chart *iex.Chart) *model.Chart {
+func modelWeeklyChart(quote *stock.Quote, chart *stock.Chart, mas []*model.MovingAverageSetting, bs []*model.BandSetting) *model.Chart {
 	ds := modelTradingSessions(quote, chart)
 	ws := weeklyModelTradingSessions(ds)
 
-	m10 := modelSimpleMovingAverages(ws, 10)
-	m40 := modelSimpleMovingAverages(ws, 40)
-
 	v10 := modelAverageVolumes(ws, 10)
 
 	return &model.Chart{
-		Interval:             model.Weekly,
-		TradingSessionSeries: &model.TradingSessionSeries{TradingSessions: ws},
-		MovingAverageSeriesSet: []*model.MovingAverageSeries{
-			{Type: model.Simple, Intervals: 10, Values: m10},
-			{Type: model.Simple, Intervals: 40, Values: m40},
-		},
-		AverageVolumeSeries: &model.AverageVolumeSeries{Values: v10},
+		Interval:                    model.Weekly,
+		TradingSessionSeries:        &model.TradingSessionSeries{TradingSessions: ws},
+		MovingAverageSeriesSet:      modelMovingAverages(ws, mas),
+		BandSeriesSet:               modelBands(ws, bs),
+		AverageVolumeSeries:         &model.AverageVolumeSeries{Values: v10},
+		RelativeStrengthIndexSeries: modelRelativeStrengthIndexSeries(ws),
+		MACDSeries:                  modelMACDSeries(ws),
+		StochasticSeries:            modelStochasticSeries(ws),
 	}
 }
 
-func modelQuote(q *iex.Quote) (*model.Quote, error) {
+func modelMonthlyChart(quote *stock.Quote, chart *stock.Chart, mas []*model.MovingAverageSetting, bs []*model.BandSetting) *model.Chart {
+	ds := modelTradingSessions(quote, chart)
+	ms := monthlyModelTradingSessions(ds)
+
+	v10 := modelAverageVolumes(ms, 10)
+
+	return &model.Chart{
+		Interval:                    model.Monthly,
+		TradingSessionSeries:        &model.TradingSessionSeries{TradingSessions: ms},
+		MovingAverageSeriesSet:      modelMovingAverages(ms, mas),
+		BandSeriesSet:               modelBands(ms, bs),
+		AverageVolumeSeries:         &model.AverageVolumeSeries{Values: v10},
+		RelativeStrengthIndexSeries: modelRelativeStrengthIndexSeries(ms),
+		MACDSeries:                  modelMACDSeries(ms),
+		StochasticSeries:            modelStochasticSeries(ms),
+	}
+}
+
+func modelQuote(q *stock.Quote) (*model.Quote, error) {
 	if q == nil {
 		return nil, errs.Errorf("missing quote")
 	}
@@ -112,32 +207,32 @@ func modelQuote(q *iex.Quote) (*model.Quote, error) {
 	}, nil
 }
 
-func modelSource(src iex.Source) model.Source {
+func modelSource(src stock.Source) model.Source {
 	switch src {
-	case iex.SourceUnspecified:
+	case stock.SourceUnspecified:
 		return model.SourceUnspecified
-	case iex.RealTimePrice:
+	case stock.RealTimePrice:
 		return model.RealTimePrice
-	case iex.FifteenMinuteDelayedPrice:
+	case stock.FifteenMinuteDelayedPrice:
 		return model.FifteenMinuteDelayedPrice
-	case iex.Close:
+	case stock.Close:
 		return model.Close
-	case iex.PreviousClose:
+	case stock.PreviousClose:
 		return model.PreviousClose
-	case iex.Price:
+	case stock.Price:
 		return model.Price
-	case iex.LastTrade:
+	case stock.LastTrade:
 		return model.LastTrade
 	default:
-		logger.Errorf("unrecognized iex source: %v", src)
+		logger.Errorf("unrecognized stock source: %v", src)
 		return model.SourceUnspecified
 	}
 }
 
-func modelTradingSessions(quote *iex.Quote, chart *iex.Chart) []*model.TradingSession {
+func modelTradingSessions(quote *stock.Quote, chart *stock.Chart) []*model.TradingSession {
 	var ts []*model.TradingSession
 
-	for _, p := range chart.ChartPoints {
+	for _, p := range chart.Bars {
 		if p.Open <= 0 || p.High <= 0 || p.Low <= 0 || p.Close <= 0 {
 			logger.Errorf("skipping bad data for %s: %v", chart.Symbol, p)
 			continue
@@ -224,7 +319,22 @@ func modelTradingSessions(quote *iex.Quote, chart *iex.Chart) []*model.TradingSe
 	return ts
 }
 
-func weeklyModelTradingSessions(ds []*model.TradingSession) (ws []*model.TradingSession) {
+func weeklyModelTradingSessions(ds []*model.TradingSession) []*model.TradingSession {
+	return combinedModelTradingSessions(ds, func(t1, t2 time.Time) bool {
+		_, w1 := t1.ISOWeek()
+		_, w2 := t2.ISOWeek()
+		return w1 == w2
+	})
+}
+
+func monthlyModelTradingSessions(ds []*model.TradingSession) []*model.TradingSession {
+	return combinedModelTradingSessions(ds, func(t1, t2 time.Time) bool {
+		return t1.Year() == t2.Year() && t1.Month() == t2.Month()
+	})
+}
+
+// combinedModelTradingSessions combines consecutive trading sessions in the same period.
+func combinedModelTradingSessions(ds []*model.TradingSession, samePeriod func(t1, t2 time.Time) bool) (ws []*model.TradingSession) {
 	for _, p := range ds {
 		// Append if empty series.
 		if len(ws) == 0 {
@@ -233,16 +343,14 @@ func weeklyModelTradingSessions(ds []*model.TradingSession) (ws []*model.Trading
 			continue
 		}
 
-		// Append if different week as previous.
-		_, week := p.Date.ISOWeek()
-		_, prevWeek := ws[len(ws)-1].Date.ISOWeek()
-		if week != prevWeek {
+		// Append if different period as previous.
+		if !samePeriod(p.Date, ws[len(ws)-1].Date) {
 			pCopy := *p
 			ws = append(ws, &pCopy)
 			continue
 		}
 
-		// Combine if same week as previous.
+		// Combine if same period as previous.
 		ls := ws[len(ws)-1]
 		if ls.High < p.High {
 			ls.High = p.High
@@ -264,62 +372,355 @@ func weeklyModelTradingSessions(ds []*model.TradingSession) (ws []*model.Trading
 	return ws
 }
 
+// modelMovingAverages returns a moving average series for each setting.
+func modelMovingAverages(ts []*model.TradingSession, mas []*model.MovingAverageSetting) []*model.MovingAverageSeries {
+	var ms []*model.MovingAverageSeries
+	for _, ma := range mas {
+		var vs []*model.MovingAverageValue
+		switch ma.Type {
+		case model.Simple:
+			vs = modelSimpleMovingAverages(ts, ma.Intervals)
+		case model.Exponential:
+			vs = modelExponentialMovingAverages(ts, ma.Intervals)
+		default:
+			logger.Errorf("bad moving average type: %v", ma.Type)
+			continue
+		}
+
+		ms = append(ms, &model.MovingAverageSeries{
+			Type:      ma.Type,
+			Intervals: ma.Intervals,
+			Values:    vs,
+		})
+	}
+	return ms
+}
+
+// modelBands returns a band series for each setting.
+func modelBands(ts []*model.TradingSession, bs []*model.BandSetting) []*model.BandSeries {
+	var bands []*model.BandSeries
+	for _, b := range bs {
+		var vs []*model.BandValue
+		switch b.Type {
+		case model.BollingerBands:
+			vs = modelBollingerBands(ts, b.Intervals, b.Width)
+		case model.KeltnerChannels:
+			vs = modelKeltnerChannels(ts, b.Intervals, b.Width)
+		default:
+			logger.Errorf("bad band type: %v", b.Type)
+			continue
+		}
+
+		bands = append(bands, &model.BandSeries{
+			Type:      b.Type,
+			Intervals: b.Intervals,
+			Width:     b.Width,
+			Values:    vs,
+		})
+	}
+	return bands
+}
+
+// modelBollingerBands returns bands that are width standard deviations
+// around the n-interval simple moving average of the closes.
+func modelBollingerBands(ts []*model.TradingSession, n int, width float32) []*model.BandValue {
+	cs := closes(ts)
+	avgs := simpleMovingAverages(cs, n)
+
+	var values []*model.BandValue
+	for i := range ts {
+		v := &model.BandValue{Date: ts[i].Date}
+		values = append(values, v)
+
+		if i+1-n < 0 {
+			continue // Not enough data
+		}
+
+		var sum float64
+		for j := 0; j < n; j++ {
+			d := float64(cs[i-j] - avgs[i])
+			sum += d * d
+		}
+		sd := float32(math.Sqrt(sum / float64(n)))
+
+		v.Middle = avgs[i]
+		v.Upper = avgs[i] + width*sd
+		v.Lower = avgs[i] - width*sd
+	}
+	return values
+}
+
+// modelKeltnerChannels returns bands that are width average true ranges
+// around the n-interval exponential moving average of the closes.
+// The average true range is also an n-interval exponential moving average.
+func modelKeltnerChannels(ts []*model.TradingSession, n int, width float32) []*model.BandValue {
+	var trs []float32
+	for i, t := range ts {
+		tr := t.High - t.Low
+		if i > 0 {
+			prev := ts[i-1].Close
+			if d := float32(math.Abs(float64(t.High - prev))); d > tr {
+				tr = d
+			}
+			if d := float32(math.Abs(float64(t.Low - prev))); d > tr {
+				tr = d
+			}
+		}
+		trs = append(trs, tr)
+	}
+
+	avgs := exponentialMovingAverages(closes(ts), n)
+	atrs := exponentialMovingAverages(trs, n)
+
+	var values []*model.BandValue
+	for i := range ts {
+		v := &model.BandValue{Date: ts[i].Date}
+		values = append(values, v)
+
+		if i < n {
+			continue // Not enough data
+		}
+
+		v.Middle = avgs[i]
+		v.Upper = avgs[i] + width*atrs[i]
+		v.Lower = avgs[i] - width*atrs[i]
+	}
+	return values
+}
+
 func modelExponentialMovingAverages(ts []*model.TradingSession, n int) []*model.MovingAverageValue {
+	avgs := exponentialMovingAverages(closes(ts), n)
+
 	var values []*model.MovingAverageValue
+	for i := range ts {
+		values = append(values, &model.MovingAverageValue{
+			Date:  ts[i].Date,
+			Value: avgs[i],
+		})
+	}
+	return values
+}
+
+func modelSimpleMovingAverages(ts []*model.TradingSession, n int) []*model.MovingAverageValue {
+	avgs := simpleMovingAverages(closes(ts), n)
+
+	var ms []*model.MovingAverageValue
+	for i := range ts {
+		ms = append(ms, &model.MovingAverageValue{
+			Date:  ts[i].Date,
+			Value: avgs[i],
+		})
+	}
+	return ms
+}
+
+func closes(ts []*model.TradingSession) []float32 {
+	var cs []float32
+	for _, t := range ts {
+		cs = append(cs, t.Close)
+	}
+	return cs
+}
+
+// exponentialMovingAverages returns the n-interval exponential moving averages of the values.
+// Averages are zero when there are not enough prior values.
+func exponentialMovingAverages(vs []float32, n int) []float32 {
+	avgs := make([]float32, len(vs))
 
 	smoothing := 2.0 / (float32(n) + 1.0)
 
-	value := func(i int) (avg float32) {
+	for i := range vs {
 		var prevEMA float32
 		switch {
 		case i < n:
 			// Not enough points to calculate SMA.
-			return 0
+			continue
 
 		case i == n:
 			// Use yesterday's SMA for today's previous EMA.
 			var sum float32
 			for j := 0; j < n; j++ {
-				sum += ts[i-1-j].Close
+				sum += vs[i-1-j]
 			}
 			prevEMA = sum / float32(n)
 
 		default:
 			// Use prev EMA.
-			prevEMA = values[i-1].Value
+			prevEMA = avgs[i-1]
 		}
-		return ts[i].Close*smoothing + prevEMA*(1-smoothing)
+		avgs[i] = vs[i]*smoothing + prevEMA*(1-smoothing)
 	}
-
-	for i := range ts {
-		values = append(values, &model.MovingAverageValue{
-			Date:  ts[i].Date,
-			Value: value(i),
-		})
-	}
-	return values
+	return avgs
 }
 
-func modelSimpleMovingAverages(ts []*model.TradingSession, n int) []*model.MovingAverageValue {
-	average := func(i, n int) (avg float32) {
+// simpleMovingAverages returns the n-interval simple moving averages of the values.
+// Averages are zero when there are not enough values.
+func simpleMovingAverages(vs []float32, n int) []float32 {
+	avgs := make([]float32, len(vs))
+	for i := range vs {
 		if i+1-n < 0 {
-			return 0 // Not enough data
+			continue // Not enough data
 		}
 		var sum float32
 		for j := 0; j < n; j++ {
-			sum += ts[i-j].Close
+			sum += vs[i-j]
 		}
-		return sum / float32(n)
+		avgs[i] = sum / float32(n)
 	}
+	return avgs
+}
 
-	var ms []*model.MovingAverageValue
+func modelRelativeStrengthIndexSeries(ts []*model.TradingSession) *model.RelativeStrengthIndexSeries {
+	return &model.RelativeStrengthIndexSeries{
+		Intervals: rsiIntervals,
+		Values:    modelRelativeStrengthIndexes(ts, rsiIntervals),
+	}
+}
+
+// modelRelativeStrengthIndexes returns the n-interval relative strength indexes
+// using Wilder's smoothing of the average gains and losses.
+func modelRelativeStrengthIndexes(ts []*model.TradingSession, n int) []*model.RelativeStrengthIndexValue {
+	var values []*model.RelativeStrengthIndexValue
+
+	var avgGain, avgLoss float32
 	for i := range ts {
-		ms = append(ms, &model.MovingAverageValue{
-			Date:  ts[i].Date,
-			Value: average(i, n),
-		})
+		v := &model.RelativeStrengthIndexValue{Date: ts[i].Date}
+		values = append(values, v)
+
+		if i == 0 {
+			continue
+		}
+
+		var gain, loss float32
+		if change := ts[i].Close - ts[i-1].Close; change > 0 {
+			gain = change
+		} else {
+			loss = -change
+		}
+
+		switch {
+		case i < n:
+			// Sum up the changes until there are enough for the first averages.
+			avgGain += gain
+			avgLoss += loss
+			continue
+
+		case i == n:
+			avgGain = (avgGain + gain) / float32(n)
+			avgLoss = (avgLoss + loss) / float32(n)
+
+		default:
+			avgGain = (avgGain*float32(n-1) + gain) / float32(n)
+			avgLoss = (avgLoss*float32(n-1) + loss) / float32(n)
+		}
+
+		if avgLoss == 0 {
+			v.Value = 100
+			continue
+		}
+
+		v.Value = 100 - 100/(1+avgGain/avgLoss)
 	}
-	return ms
+
+	return values
+}
+
+func modelMACDSeries(ts []*model.TradingSession) *model.MACDSeries {
+	return &model.MACDSeries{
+		FastIntervals:   macdFastIntervals,
+		SlowIntervals:   macdSlowIntervals,
+		SignalIntervals: macdSignalIntervals,
+		Values:          modelMACDs(ts, macdFastIntervals, macdSlowIntervals, macdSignalIntervals),
+	}
+}
+
+func modelMACDs(ts []*model.TradingSession, fast, slow, signal int) []*model.MACDValue {
+	cs := closes(ts)
+	fastAvgs := exponentialMovingAverages(cs, fast)
+	slowAvgs := exponentialMovingAverages(cs, slow)
+
+	var values []*model.MACDValue
+	var macds []float32
+	for i := range ts {
+		v := &model.MACDValue{Date: ts[i].Date}
+		values = append(values, v)
+
+		// The slow moving average needs the most data.
+		if i < slow {
+			continue
+		}
+
+		v.MACD = fastAvgs[i] - slowAvgs[i]
+		macds = append(macds, v.MACD)
+	}
+
+	// Calculate the signal line from the MACD values that had enough data.
+	for j, sig := range exponentialMovingAverages(macds, signal) {
+		if j < signal {
+			continue
+		}
+
+		v := values[slow+j]
+		v.Signal = sig
+		v.Histogram = v.MACD - sig
+	}
+
+	return values
+}
+
+func modelStochasticSeries(ts []*model.TradingSession) *model.StochasticSeries {
+	return &model.StochasticSeries{
+		Intervals:  stochasticIntervals,
+		KIntervals: stochasticKInterval,
+		DIntervals: stochasticDInterval,
+		Values:     modelStochastics(ts, stochasticIntervals, stochasticKInterval, stochasticDInterval),
+	}
+}
+
+// modelStochastics returns slow stochastic oscillator values. The fast %K spans n intervals,
+// the slow %K averages k fast %K values, and the %D averages d slow %K values.
+func modelStochastics(ts []*model.TradingSession, n, k, d int) []*model.StochasticValue {
+	var values []*model.StochasticValue
+	for _, t := range ts {
+		values = append(values, &model.StochasticValue{Date: t.Date})
+	}
+
+	// Calculate the fast %K values for each session with n prior sessions.
+	var fastKs []float32
+	for i := n - 1; i < len(ts); i++ {
+		low, high := ts[i].Low, ts[i].High
+		for j := i - n + 1; j < i; j++ {
+			if ts[j].Low < low {
+				low = ts[j].Low
+			}
+			if ts[j].High > high {
+				high = ts[j].High
+			}
+		}
+
+		fastK := float32(50)
+		if high > low {
+			fastK = 100 * (ts[i].Close - low) / (high - low)
+		}
+		fastKs = append(fastKs, fastK)
+	}
+
+	if len(fastKs) < k {
+		return values
+	}
+
+	slowKs := simpleMovingAverages(fastKs, k)[k-1:]
+	ds := simpleMovingAverages(slowKs, d)
+
+	for j, slowK := range slowKs {
+		v := values[n-1+k-1+j]
+		v.K = slowK
+		if j >= d-1 {
+			v.D = ds[j]
+		}
+	}
+
+	return values
 }
 
 func modelAverageVolumes(ts []*model.TradingSession, n int) []*model.AverageVolumeValue {
@@ -362,6 +763,42 @@ func trimmedMovingAverages(vs []*model.MovingAverageValue, start time.Time) []*m
 	return vs
 }
 
+func trimmedBands(vs []*model.BandValue, start time.Time) []*model.BandValue {
+	for i, v := range vs {
+		if v.Date == start {
+			return vs[i:]
+		}
+	}
+	return vs
+}
+
+func trimmedRelativeStrengthIndexes(vs []*model.RelativeStrengthIndexValue, start time.Time) []*model.RelativeStrengthIndexValue {
+	for i, v := range vs {
+		if v.Date == start {
+			return vs[i:]
+		}
+	}
+	return vs
+}
+
+func trimmedMACDs(vs []*model.MACDValue, start time.Time) []*model.MACDValue {
+	for i, v := range vs {
+		if v.Date == start {
+			return vs[i:]
+		}
+	}
+	return vs
+}
+
+func trimmedStochastics(vs []*model.StochasticValue, start time.Time) []*model.StochasticValue {
+	for i, v := range vs {
+		if v.Date == start {
+			return vs[i:]
+		}
+	}
+	return vs
+}
+
 func trimmedAverageVolumes(vs []*model.AverageVolumeValue, start time.Time) []*model.AverageVolumeValue {
 	for i, v := range vs {
 		if v.Date == start {
@@ -5,19 +5,19 @@ import (
 	"time"
 
 	"github.com/btmura/ponzi2/internal/app/model"
-	"github.com/btmura/ponzi2/internal/stock/iex"
+	"github.com/btmura/ponzi2/internal/stock"
 	"github.com/google/go-cmp/cmp"
 )
 
 func TestModelIntradayChart(t *testing.T) {
 	for _, tt := range []struct {
 		desc  string
-		input *iex.Chart
+		input *stock.Chart
 		want  *model.Chart
 	}{
 		{
-			input: &iex.Chart{
-				ChartPoints: []*iex.ChartPoint{
+			input: &stock.Chart{
+				Bars: []*stock.Bar{
 					{
 						Date:   time.Date(2018, time.September, 18, 15, 57, 0, 0, time.UTC),
 						Open:   218.44,
@@ -44,6 +44,57 @@ func TestModelIntradayChart(t *testing.T) {
 				},
 			},
 		},
+		{
+			desc: "skip minutes without trades and calculate changes",
+			input: &stock.Chart{
+				Bars: []*stock.Bar{
+					{
+						Date:   time.Date(2018, time.September, 18, 15, 59, 0, 0, time.UTC),
+						Open:   10,
+						High:   12,
+						Low:    9,
+						Close:  11,
+						Volume: 200,
+					},
+					{
+						Date: time.Date(2018, time.September, 18, 15, 58, 0, 0, time.UTC),
+					},
+					{
+						Date:   time.Date(2018, time.September, 18, 15, 57, 0, 0, time.UTC),
+						Open:   9,
+						High:   11,
+						Low:    8,
+						Close:  10,
+						Volume: 100,
+					},
+				},
+			},
+			want: &model.Chart{
+				Interval: model.Intraday,
+				TradingSessionSeries: &model.TradingSessionSeries{
+					TradingSessions: []*model.TradingSession{
+						{
+							Date:   time.Date(2018, time.September, 18, 15, 57, 0, 0, time.UTC),
+							Open:   9,
+							High:   11,
+							Low:    8,
+							Close:  10,
+							Volume: 100,
+						},
+						{
+							Date:          time.Date(2018, time.September, 18, 15, 59, 0, 0, time.UTC),
+							Open:          10,
+							High:          12,
+							Low:           9,
+							Close:         11,
+							Volume:        200,
+							Change:        1,
+							PercentChange: 0.1,
+						},
+					},
+				},
+			},
+		},
 	} {
 		t.Run(tt.desc, func(t *testing.T) {
 			got := modelIntradayChart(tt.input)
@@ -55,6 +106,55 @@ func TestModelIntradayChart(t *testing.T) {
 	}
 }
 
+func TestAdjustedChart(t *testing.T) {
+	day := func(d int) time.Time { return time.Date(2018, time.September, d, 0, 0, 0, 0, time.UTC) }
+
+	input := &stock.Chart{
+		Symbol: "SPY",
+		Bars: []*stock.Bar{
+			{Date: day(4), Open: 10, High: 20, Low: 5, Close: 10, Change: 1, ChangePercent: 0.1, TotalReturnOpen: 8, TotalReturnHigh: 16, TotalReturnLow: 4, TotalReturnClose: 8},
+			{Date: day(5), Open: 12, High: 24, Low: 6, Close: 12, Change: 2, ChangePercent: 0.2, TotalReturnOpen: 10, TotalReturnHigh: 20, TotalReturnLow: 5, TotalReturnClose: 10},
+			{Date: day(6), Open: 10, High: 20, Low: 5, Close: 10},
+		},
+	}
+
+	for _, tt := range []struct {
+		desc       string
+		adjustment model.Adjustment
+		want       *stock.Chart
+	}{
+		{
+			desc:       "split adjusted",
+			adjustment: model.SplitAdjusted,
+			want:       input,
+		},
+		{
+			desc:       "total return",
+			adjustment: model.TotalReturn,
+			want: &stock.Chart{
+				Symbol: "SPY",
+				Bars: []*stock.Bar{
+					{Date: day(4), Open: 8, High: 16, Low: 4, Close: 8, Change: 0.8, ChangePercent: 0.1, TotalReturnOpen: 8, TotalReturnHigh: 16, TotalReturnLow: 4, TotalReturnClose: 8},
+					{Date: day(5), Open: 10, High: 20, Low: 5, Close: 10, Change: 2, ChangePercent: 0.25, TotalReturnOpen: 10, TotalReturnHigh: 20, TotalReturnLow: 5, TotalReturnClose: 10},
+					{Date: day(6), Open: 10, High: 20, Low: 5, Close: 10},
+				},
+			},
+		},
+	} {
+		t.Run(tt.desc, func(t *testing.T) {
+			got := adjustedChart(input, tt.adjustment)
+
+			if diff := cmp.Diff(tt.want, got); diff != "" {
+				t.Errorf("diff (-want, +got)\n%s", diff)
+			}
+		})
+	}
+
+	if diff := cmp.Diff(float32(10), input.Bars[0].Close); diff != "" {
+		t.Errorf("adjustedChart should not modify its input, diff (-want, +got)\n%s", diff)
+	}
+}
+
 func TestExponentialMovingAverages(t *testing.T) {
 	for _, tt := range []struct {
 		desc                 string
@@ -103,3 +203,241 @@ func TestExponentialMovingAverages(t *testing.T) {
 		})
 	}
 }
+
+func TestMonthlyModelTradingSessions(t *testing.T) {
+	for _, tt := range []struct {
+		desc  string
+		input []*model.TradingSession
+		want  []*model.TradingSession
+	}{
+		{
+			desc: "combine sessions in the same month",
+			input: []*model.TradingSession{
+				{
+					Date:   time.Date(2018, time.September, 28, 0, 0, 0, 0, time.UTC),
+					Open:   10,
+					High:   12,
+					Low:    9,
+					Close:  10,
+					Volume: 100,
+				},
+				{
+					Date:   time.Date(2018, time.October, 1, 0, 0, 0, 0, time.UTC),
+					Open:   10,
+					High:   11,
+					Low:    8,
+					Close:  9,
+					Volume: 100,
+				},
+				{
+					Date:   time.Date(2018, time.October, 31, 0, 0, 0, 0, time.UTC),
+					Open:   9,
+					High:   15,
+					Low:    9,
+					Close:  14,
+					Volume: 200,
+				},
+				{
+					Date:   time.Date(2019, time.October, 1, 0, 0, 0, 0, time.UTC),
+					Open:   20,
+					High:   21,
+					Low:    19,
+					Close:  20,
+					Volume: 50,
+				},
+			},
+			want: []*model.TradingSession{
+				{
+					Date:   time.Date(2018, time.September, 28, 0, 0, 0, 0, time.UTC),
+					Open:   10,
+					High:   12,
+					Low:    9,
+					Close:  10,
+					Volume: 100,
+				},
+				{
+					Date:          time.Date(2018, time.October, 1, 0, 0, 0, 0, time.UTC),
+					Open:          10,
+					High:          15,
+					Low:           8,
+					Close:         14,
+					Volume:        300,
+					Change:        4,
+					PercentChange: 0.4,
+				},
+				{
+					Date:   time.Date(2019, time.October, 1, 0, 0, 0, 0, time.UTC),
+					Open:   20,
+					High:   21,
+					Low:    19,
+					Close:  20,
+					Volume: 50,
+				},
+			},
+		},
+	} {
+		t.Run(tt.desc, func(t *testing.T) {
+			got := monthlyModelTradingSessions(tt.input)
+
+			if diff := cmp.Diff(tt.want, got); diff != "" {
+				t.Errorf("diff (-want, +got)\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestModelRelativeStrengthIndexes(t *testing.T) {
+	date := func(day int) time.Time {
+		return time.Date(2019, time.March, day, 0, 0, 0, 0, time.UTC)
+	}
+
+	for _, tt := range []struct {
+		desc  string
+		input []*model.TradingSession
+		n     int
+		want  []*model.RelativeStrengthIndexValue
+	}{
+		{
+			desc: "wilder smoothing after first averages",
+			input: []*model.TradingSession{
+				{Date: date(1), Close: 10},
+				{Date: date(4), Close: 11},
+				{Date: date(5), Close: 10},
+				{Date: date(6), Close: 12},
+			},
+			n: 2,
+			want: []*model.RelativeStrengthIndexValue{
+				{Date: date(1)},
+				{Date: date(4)},
+				{Date: date(5), Value: 50},
+				{Date: date(6), Value: 100 - 100/float32(6)},
+			},
+		},
+		{
+			desc: "only gains",
+			input: []*model.TradingSession{
+				{Date: date(1), Close: 10},
+				{Date: date(4), Close: 11},
+				{Date: date(5), Close: 12},
+			},
+			n: 2,
+			want: []*model.RelativeStrengthIndexValue{
+				{Date: date(1)},
+				{Date: date(4)},
+				{Date: date(5), Value: 100},
+			},
+		},
+	} {
+		t.Run(tt.desc, func(t *testing.T) {
+			got := modelRelativeStrengthIndexes(tt.input, tt.n)
+
+			if diff := cmp.Diff(tt.want, got); diff != "" {
+				t.Errorf("diff (-want, +got)\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestModelMACDs(t *testing.T) {
+	date := func(day int) time.Time {
+		return time.Date(2019, time.March, day, 0, 0, 0, 0, time.UTC)
+	}
+
+	var input []*model.TradingSession
+	for i := 1; i <= 6; i++ {
+		input = append(input, &model.TradingSession{Date: date(i), Close: 10})
+	}
+	input = append(input, &model.TradingSession{Date: date(7), Close: 13})
+
+	// Fast EMA(1) tracks the close, slow EMA(2) uses a smoothing of 2/3,
+	// and the signal EMA(1) tracks the MACD.
+	want := []*model.MACDValue{
+		{Date: date(1)},
+		{Date: date(2)},
+		{Date: date(3)},
+		{Date: date(4)},
+		{Date: date(5)},
+		{Date: date(6)},
+		{Date: date(7), MACD: 1, Signal: 1},
+	}
+
+	got := modelMACDs(input, 1, 2, 1)
+
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("diff (-want, +got)\n%s", diff)
+	}
+}
+
+func TestModelStochastics(t *testing.T) {
+	date := func(day int) time.Time {
+		return time.Date(2019, time.March, day, 0, 0, 0, 0, time.UTC)
+	}
+
+	input := []*model.TradingSession{
+		{Date: date(1), High: 12, Low: 8, Close: 10},
+		{Date: date(4), High: 14, Low: 10, Close: 14},
+		{Date: date(5), High: 14, Low: 12, Close: 12},
+		{Date: date(6), High: 16, Low: 12, Close: 16},
+	}
+
+	// Fast %K over 2 sessions: 100, 50, 100.
+	want := []*model.StochasticValue{
+		{Date: date(1)},
+		{Date: date(4)},
+		{Date: date(5), K: 75},
+		{Date: date(6), K: 75, D: 75},
+	}
+
+	got := modelStochastics(input, 2, 2, 2)
+
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("diff (-want, +got)\n%s", diff)
+	}
+}
+
+func TestModelBollingerBands(t *testing.T) {
+	date := func(day int) time.Time {
+		return time.Date(2019, time.March, day, 0, 0, 0, 0, time.UTC)
+	}
+
+	input := []*model.TradingSession{
+		{Date: date(1), Close: 10},
+		{Date: date(4), Close: 12},
+		{Date: date(5), Close: 14},
+	}
+
+	want := []*model.BandValue{
+		{Date: date(1)},
+		{Date: date(4), Upper: 13, Middle: 11, Lower: 9},
+		{Date: date(5), Upper: 15, Middle: 13, Lower: 11},
+	}
+
+	got := modelBollingerBands(input, 2, 2)
+
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("diff (-want, +got)\n%s", diff)
+	}
+}
+
+func TestModelKeltnerChannels(t *testing.T) {
+	date := func(day int) time.Time {
+		return time.Date(2019, time.March, day, 0, 0, 0, 0, time.UTC)
+	}
+
+	input := []*model.TradingSession{
+		{Date: date(1), High: 12, Low: 8, Close: 10},
+		{Date: date(4), High: 13, Low: 11, Close: 12},
+	}
+
+	// True range uses the gap from the previous close: 13 - 10 = 3.
+	want := []*model.BandValue{
+		{Date: date(1)},
+		{Date: date(4), Upper: 15, Middle: 12, Lower: 9},
+	}
+
+	got := modelKeltnerChannels(input, 1, 1)
+
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("diff (-want, +got)\n%s", diff)
+	}
+}
@@ -6,16 +6,25 @@ import (
 
 	"github.com/btmura/ponzi2/internal/app/model"
 	"github.com/btmura/ponzi2/internal/errs"
-	"github.com/btmura/ponzi2/internal/logger"
-	"github.com/btmura/ponzi2/internal/stock/iex"
+	"github.com/btmura/ponzi2/internal/stock"
+	"github.com/btmura/ponzi2/internal/stock/market"
 )
 
+// refreshInterval is how often to refresh stocks during market hours.
+const refreshInterval = 5 * time.Minute
+
 type stockRefresher struct {
-	// iexClient fetches stock data to update the model.
-	iexClient iexClientInterface
+	// provider fetches stock data to update the model.
+	provider stock.Provider
+
+	// movingAverageSettings are the moving averages to calculate per interval.
+	movingAverageSettings map[model.Interval][]*model.MovingAverageSetting
+
+	// bandSettings are the price bands to calculate per interval.
+	bandSettings map[model.Interval][]*model.BandSetting
 
-	// token is the IEX API token to be included on requests.
-	token string
+	// adjustments are how to adjust the prices of symbols that aren't split-adjusted.
+	adjustments map[string]model.Adjustment
 
 	// eventController allows the stockRefresher to post stock updates.
 	eventController *eventController
@@ -23,38 +32,49 @@ type stockRefresher struct {
 	// refreshTicker ticks to trigger refreshes during market hours.
 	refreshTicker *time.Ticker
 
+	// calendar has the market hours including holidays and early closes.
+	calendar *market.Calendar
+
 	// enabled enables refreshing stocks when set to true.
 	enabled bool
 }
 
-func newStockRefresher(iexClient iexClientInterface, token string, eventController *eventController) *stockRefresher {
+func newStockRefresher(provider stock.Provider, eventController *eventController) *stockRefresher {
 	return &stockRefresher{
-		iexClient:       iexClient,
-		token:           token,
+		provider:        provider,
 		eventController: eventController,
-		refreshTicker:   time.NewTicker(5 * time.Minute),
+		refreshTicker:   time.NewTicker(refreshInterval),
+		calendar:        market.NYSE,
 	}
 }
 
-// refreshLoop refreshes stocks during market hours.
+// refreshLoop refreshes stocks during market hours and once after the close to get the closing prices.
 func (s *stockRefresher) refreshLoop() {
-	loc, err := time.LoadLocation("America/New_York")
-	if err != nil {
-		logger.Fatalf("time.LoadLocation: %v", err)
-	}
-
 	for t := range s.refreshTicker.C {
-		n := time.Now()
-		openTime := time.Date(n.Year(), n.Month(), n.Day(), 9, 30, 0, 0, loc)
-		closeTime := time.Date(n.Year(), n.Month(), n.Day(), 16, 0, 0, 0, loc)
-
-		if openTime.Weekday() == time.Saturday || openTime.Weekday() == time.Sunday || t.Before(openTime) || t.After(closeTime) {
+		if !s.calendar.IsOpen(t) && !s.calendar.IsOpen(t.Add(-refreshInterval)) {
 			continue
 		}
 
 		s.eventController.addEventLocked(event{refreshAllStocks: true})
 	}
 }
+
+// setMovingAverageSettings sets the moving averages to calculate for future refreshes.
+func (s *stockRefresher) setMovingAverageSettings(settings map[model.Interval][]*model.MovingAverageSetting) {
+	s.movingAverageSettings = settings
+}
+
+// setBandSettings sets the price bands to calculate for future refreshes.
+func (s *stockRefresher) setBandSettings(settings map[model.Interval][]*model.BandSetting) {
+	s.bandSettings = settings
+}
+
+// setAdjustments sets how to adjust the prices of symbols for future refreshes.
+// The map must not be changed afterwards, since refreshes read it in the background.
+func (s *stockRefresher) setAdjustments(adjustments map[string]model.Adjustment) {
+	s.adjustments = adjustments
+}
+
 func (s *stockRefresher) start() {
 	s.enabled = true
 }
@@ -85,7 +105,7 @@ func (s *stockRefresher) refresh(ctx context.Context, d *dataRequestBuilder) err
 		return nil
 	}
 
-	reqs, err := d.dataRequests(s.token)
+	reqs, err := d.dataRequests()
 	if err != nil {
 		return err
 	}
@@ -102,6 +122,10 @@ func (s *stockRefresher) refresh(ctx context.Context, d *dataRequestBuilder) err
 		}
 	}
 
+	mas := s.movingAverageSettings
+	bs := s.bandSettings
+	as := s.adjustments
+
 	for _, req := range reqs {
 		go func(req *dataRequest) {
 			handleErr := func(err error) {
@@ -115,21 +139,27 @@ func (s *stockRefresher) refresh(ctx context.Context, d *dataRequestBuilder) err
 				s.eventController.addEventLocked(es...)
 			}
 
-			quotes, err := s.iexClient.GetQuotes(ctx, req.quotesRequest)
+			quotes, err := s.provider.GetQuotes(ctx, req.quotesRequest)
 			if err != nil {
 				handleErr(err)
 				return
 			}
 
-			charts, err := s.iexClient.GetCharts(ctx, req.chartsRequest)
+			var charts []*stock.Chart
+			switch req.group {
+			case intraday:
+				charts, err = s.provider.GetIntradayCharts(ctx, req.chartsRequest)
+			default:
+				charts, err = s.provider.GetDailyCharts(ctx, req.chartsRequest)
+			}
 			if err != nil {
 				handleErr(err)
 				return
 			}
 
 			type stockData struct {
-				quote *iex.Quote
-				chart *iex.Chart
+				quote *stock.Quote
+				chart *stock.Chart
 			}
 
 			symbol2StockData := map[string]*stockData{}
@@ -164,32 +194,17 @@ func (s *stockRefresher) refresh(ctx context.Context, d *dataRequestBuilder) err
 					continue
 				}
 
+				sc := adjustedChart(stockData.chart, as[sym])
 				for _, interval := range req.intervals {
-					switch interval {
-					case model.Intraday:
-						ch := modelIntradayChart(stockData.chart)
-						es = append(es, event{
-							symbol: sym,
-							quote:  q,
-							chart:  ch,
-						})
-
-					case model.Daily:
-						ch := modelDailyChart(stockData.quote, stockData.chart)
-						es = append(es, event{
-							symbol: sym,
-							quote:  q,
-							chart:  ch,
-						})
-
-					case model.Weekly:
-						ch := modelWeeklyChart(stockData.quote, stockData.chart)
-						es = append(es, event{
-							symbol: sym,
-							quote:  q,
-							chart:  ch,
-						})
+					ch := modelChart(interval, stockData.quote, sc, mas[interval], bs[interval])
+					if ch == nil {
+						continue
 					}
+					es = append(es, event{
+						symbol: sym,
+						quote:  q,
+						chart:  ch,
+					})
 				}
 			}
 
@@ -221,6 +236,7 @@ const (
 	dataRequestGroupUnspecified dataRequestGroup = iota
 	intraday
 	dailyWeekly
+	monthly
 )
 
 func (d dataRequestGroup) Intervals() []model.Interval {
@@ -229,6 +245,8 @@ func (d dataRequestGroup) Intervals() []model.Interval {
 		return []model.Interval{model.Intraday}
 	case dailyWeekly:
 		return []model.Interval{model.Daily, model.Weekly}
+	case monthly:
+		return []model.Interval{model.Monthly}
 	default:
 		return nil
 	}
@@ -238,6 +256,7 @@ var interval2DataRequestGroup = map[model.Interval]dataRequestGroup{
 	model.Intraday: intraday,
 	model.Daily:    dailyWeekly,
 	model.Weekly:   dailyWeekly,
+	model.Monthly:  monthly,
 }
 
 func (d *dataRequestBuilder) add(symbols []string, interval model.Interval) error {
@@ -280,34 +299,35 @@ func (d *dataRequestBuilder) add(symbols []string, interval model.Interval) erro
 
 type dataRequest struct {
 	symbols       []string
+	group         dataRequestGroup
 	intervals     []model.Interval
-	quotesRequest *iex.GetQuotesRequest
-	chartsRequest *iex.GetChartsRequest
+	quotesRequest *stock.GetQuotesRequest
+	chartsRequest *stock.GetChartsRequest
 }
 
-func (d *dataRequestBuilder) dataRequests(token string) ([]*dataRequest, error) {
+func (d *dataRequestBuilder) dataRequests() ([]*dataRequest, error) {
 	var reqs []*dataRequest
 	for group, ss := range d.symbolGroups {
-		var iexRange iex.Range
-
-		switch group {
-		case dailyWeekly:
-			iexRange = iex.TwoYears
-		default:
+		if group == dataRequestGroupUnspecified {
 			return nil, errs.Errorf("bad group: %v", group)
 		}
 
+		// Monthly charts show all the history there is, so they have enough months to show.
+		r := stock.TwoYears
+		if group == monthly {
+			r = stock.Max
+		}
+
 		reqs = append(reqs, &dataRequest{
 			symbols:   ss,
+			group:     group,
 			intervals: group.Intervals(),
-			quotesRequest: &iex.GetQuotesRequest{
-				Token:   token,
+			quotesRequest: &stock.GetQuotesRequest{
 				Symbols: ss,
 			},
-			chartsRequest: &iex.GetChartsRequest{
-				Token:   token,
+			chartsRequest: &stock.GetChartsRequest{
 				Symbols: ss,
-				Range:   iexRange,
+				Range:   r,
 			},
 		})
 	}
@@ -0,0 +1,154 @@
+// Package credentials provides functions for loading and saving the API tokens needed to get stock data.
+//
+// Tokens are read from the environment or from a credentials file in the config directory
+// that only the user can read, so that they don't leak into shell history or process listings.
+package credentials
+
+import (
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"os/user"
+	"path/filepath"
+	"runtime"
+
+	"github.com/btmura/ponzi2/internal/errs"
+	"github.com/btmura/ponzi2/internal/logger"
+	"github.com/btmura/ponzi2/internal/stock/iex"
+)
+
+// IEXAPITokenEnvVar is the environment variable with the IEX API token.
+// It takes precedence over the token in the credentials file.
+const IEXAPITokenEnvVar = "PONZI_IEX_API_TOKEN"
+
+// fileName is the name of the JSON credentials file.
+const fileName = "credentials.json"
+
+// filePerm is the permission of the credentials file. Load rejects files that others can access.
+const filePerm = 0600
+
+// Credentials has the API tokens to get stock data.
+type Credentials struct {
+	// IEXAPIToken is the IEX API token to include on requests.
+	IEXAPIToken string `json:"iexApiToken,omitempty"`
+}
+
+// Load returns the credentials from the environment or, if the environment has no token,
+// from the credentials file. It returns empty credentials if there are none.
+func Load() (*Credentials, error) {
+	dirPath, err := userConfigDir()
+	if err != nil {
+		return nil, err
+	}
+	return load(dirPath, os.Getenv)
+}
+
+// Save saves the credentials to the credentials file that only the user can access.
+func Save(c *Credentials) error {
+	dirPath, err := userConfigDir()
+	if err != nil {
+		return err
+	}
+	return save(dirPath, c)
+}
+
+// Path returns the path of the credentials file.
+func Path() (string, error) {
+	dirPath, err := userConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dirPath, fileName), nil
+}
+
+func load(dirPath string, getenv func(string) string) (*Credentials, error) {
+	// Don't read the file if the environment has the token, so that a bad file doesn't matter.
+	if t := getenv(IEXAPITokenEnvVar); t != "" {
+		if err := iex.ValidateToken(t); err != nil {
+			return nil, errs.Errorf("%s: %v", IEXAPITokenEnvVar, err)
+		}
+		return &Credentials{IEXAPIToken: t}, nil
+	}
+
+	return loadFile(filepath.Join(dirPath, fileName))
+}
+
+func loadFile(path string) (*Credentials, error) {
+	fi, err := os.Stat(path)
+	if os.IsNotExist(err) {
+		return &Credentials{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	// Windows doesn't have Unix permissions, so only check them elsewhere.
+	if runtime.GOOS != "windows" && fi.Mode().Perm()&^filePerm != 0 {
+		return nil, errs.Errorf("%s: permissions %v are too open, run: chmod 600 %s", path, fi.Mode().Perm(), path)
+	}
+
+	logger.Infof("loading credentials from %s", path)
+
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	c := &Credentials{}
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(c); err != nil {
+		return nil, errs.Errorf("parsing %s failed: %v", path, err)
+	}
+
+	if t := c.IEXAPIToken; t != "" {
+		if err := iex.ValidateToken(t); err != nil {
+			return nil, errs.Errorf("%s: %v", path, err)
+		}
+	}
+
+	return c, nil
+}
+
+func save(dirPath string, c *Credentials) error {
+	if c == nil {
+		return errs.Errorf("nil credentials")
+	}
+
+	path := filepath.Join(dirPath, fileName)
+
+	logger.Infof("saving credentials to %s", path)
+
+	data, err := json.MarshalIndent(c, "", "  ")
+	if err != nil {
+		return err
+	}
+	data = append(data, '\n')
+
+	// Write to a temporary file that only the user can access and rename it,
+	// so that a crash never leaves partial credentials.
+	tmpPath := path + ".tmp"
+	if err := ioutil.WriteFile(tmpPath, data, filePerm); err != nil {
+		return err
+	}
+
+	// WriteFile doesn't change the permissions of existing files.
+	if err := os.Chmod(tmpPath, filePerm); err != nil {
+		return err
+	}
+
+	return os.Rename(tmpPath, path)
+}
+
+func userConfigDir() (string, error) {
+	u, err := user.Current()
+	if err != nil {
+		return "", err
+	}
+	p := filepath.Join(u.HomeDir, ".config", "ponzi")
+	if err := os.MkdirAll(p, 0755); err != nil {
+		return "", err
+	}
+	return p, nil
+}
@@ -0,0 +1,155 @@
+package credentials
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestSaveLoad(t *testing.T) {
+	dir := tempDir(t)
+	defer os.RemoveAll(dir)
+
+	want := &Credentials{IEXAPIToken: "abc_123"}
+
+	if err := save(dir, want); err != nil {
+		t.Fatalf("save should not return an error: %v", err)
+	}
+
+	got, err := load(dir, noEnv)
+	if err != nil {
+		t.Fatalf("load should not return an error: %v", err)
+	}
+
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("diff (-want, +got)\n%s", diff)
+	}
+
+	fi, err := os.Stat(filepath.Join(dir, fileName))
+	if err != nil {
+		t.Fatalf("os.Stat should not return an error: %v", err)
+	}
+
+	if runtime.GOOS != "windows" {
+		if diff := cmp.Diff(os.FileMode(filePerm), fi.Mode().Perm()); diff != "" {
+			t.Errorf("diff (-want, +got)\n%s", diff)
+		}
+	}
+}
+
+func TestLoad(t *testing.T) {
+	for _, tt := range []struct {
+		desc    string
+		json    string
+		perm    os.FileMode
+		env     map[string]string
+		want    *Credentials
+		wantErr bool
+	}{
+		{
+			desc: "no file",
+			want: &Credentials{},
+		},
+		{
+			desc: "file",
+			json: `{"iexApiToken": "file_token"}`,
+			perm: 0600,
+			want: &Credentials{IEXAPIToken: "file_token"},
+		},
+		{
+			desc: "environment takes precedence",
+			json: `{"iexApiToken": "file_token"}`,
+			perm: 0600,
+			env:  map[string]string{IEXAPITokenEnvVar: "env_token"},
+			want: &Credentials{IEXAPIToken: "env_token"},
+		},
+		{
+			desc: "environment without file",
+			env:  map[string]string{IEXAPITokenEnvVar: "env_token"},
+			want: &Credentials{IEXAPIToken: "env_token"},
+		},
+		{
+			desc:    "readable by others",
+			json:    `{"iexApiToken": "file_token"}`,
+			perm:    0644,
+			wantErr: runtime.GOOS != "windows",
+			want:    &Credentials{IEXAPIToken: "file_token"},
+		},
+		{
+			desc: "environment with file readable by others",
+			json: `{"iexApiToken": "file_token"}`,
+			perm: 0644,
+			env:  map[string]string{IEXAPITokenEnvVar: "env_token"},
+			want: &Credentials{IEXAPIToken: "env_token"},
+		},
+		{
+			desc: "environment with bad file",
+			json: `{"token": "file_token"}`,
+			perm: 0600,
+			env:  map[string]string{IEXAPITokenEnvVar: "env_token"},
+			want: &Credentials{IEXAPIToken: "env_token"},
+		},
+		{
+			desc:    "bad file token",
+			json:    `{"iexApiToken": "file token"}`,
+			perm:    0600,
+			wantErr: true,
+		},
+		{
+			desc:    "bad environment token",
+			env:     map[string]string{IEXAPITokenEnvVar: "env-token"},
+			wantErr: true,
+		},
+		{
+			desc:    "unknown field",
+			json:    `{"token": "file_token"}`,
+			perm:    0600,
+			wantErr: true,
+		},
+	} {
+		t.Run(tt.desc, func(t *testing.T) {
+			dir := tempDir(t)
+			defer os.RemoveAll(dir)
+
+			if tt.json != "" {
+				path := filepath.Join(dir, fileName)
+				if err := ioutil.WriteFile(path, []byte(tt.json), tt.perm); err != nil {
+					t.Fatalf("ioutil.WriteFile should not return an error: %v", err)
+				}
+				if err := os.Chmod(path, tt.perm); err != nil {
+					t.Fatalf("os.Chmod should not return an error: %v", err)
+				}
+			}
+
+			got, err := load(dir, func(key string) string { return tt.env[key] })
+			if gotErr := err != nil; gotErr != tt.wantErr {
+				t.Fatalf("got error: %v, wanted error: %t", err, tt.wantErr)
+			}
+
+			if tt.wantErr {
+				return
+			}
+
+			if diff := cmp.Diff(tt.want, got); diff != "" {
+				t.Errorf("diff (-want, +got)\n%s", diff)
+			}
+		})
+	}
+}
+
+func noEnv(string) string {
+	return ""
+}
+
+func tempDir(t *testing.T) string {
+	t.Helper()
+	dir, err := ioutil.TempDir("", "credentials")
+	if err != nil {
+		t.Fatalf("ioutil.TempDir should not return an error: %v", err)
+	}
+	return dir
+}
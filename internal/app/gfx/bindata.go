@@ -212,15 +212,15 @@ var _escData = map[string]*_escFile{
 	"/data/shader.frag": {
 		name:    "shader.frag",
 		local:   "data/shader.frag",
-		size:    675,
+		size:    659,
 		modtime: 1337,
 		compressed: `
-H4sIAAAAAAAC/3yR0WvCMBDGn3N/xYEv7RBxRbex4sNQt5eJIA72JjFNNSzmJE3dxvB/HzXadrP0Lcn9
-vu+73HUO0maKDA6GfRRkJUAnkakyEp8XTy+r8fx1vljN5pMp9v9WltP35dti6mu317W6NALQ/JtyF2gS
-3BV5IxyGmBuVkt2hMg5TyzczSmTcgN5VaMZ3ey1tNEEnv1xuG/n7ij9IMTihY9Jkm+CHCk41cYdc77c8
-BlDGq4VX+mtUmI2JbBIDUO48UjR/DoADqQR3XJmgOIX4Ayz7VE5sMbj88fQoeHY15EdgrPTC0SWasbWV
-/COGuqq+gP+682yiSXA+dcu2wxa7lk6KbwblHHt2s+62pvRsPYgdAa7tygdvV1053vgthDEcfwMAAP//
-CQD5EqMCAAA=
+H4sIAAAAAAAC/3yQQU8CMRCFz51fMQmXXUMIElDjhoMB9CIhIZh4I6XbhcbSId0uYgz/3dTK7iort3bm
+e29mXmsvba7IYH/QRUFWArRSmSkj8XH+8LQczZ5n8+V0Np5g93dnMXldvMwnoXd93qtLewCaf1DhIk2C
+Oz9viIMYC6MysltUxmFm+XpKqUwa0JsKzfl2p6XtjdHJQxN7W7F7KfoecyPSZJvguwrONHGHXO82PAFQ
+JqhFUIZvz5uNiGyaAFDhAuIX/xkAe1IpbrkykX/F+Aksf1dObDA63fddFDw/C/geGCu9cHgazdjKSv6W
+QF1VD/+vzh9cWBk5eWiXC8cXjC7s4A+MygQ7dr1q/+PfsfUR7AhwblQWglH15XgVko8TOH4NAEFoHz6T
+AgAA
 `,
 	},
 
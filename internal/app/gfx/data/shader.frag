@@ -5,7 +5,7 @@
 #define FRAG_TEXT_COLOR_MODE 2
 
 layout(location = 5) uniform int fragMode;
-layout(location = 6) uniform sampler2D texture;
+layout(location = 6) uniform sampler2D tex;
 layout(location = 7) uniform vec4 textColor;
 layout(location = 8) uniform float alpha;
 
@@ -21,11 +21,11 @@ void main(void) {
 		break;
 
 	case FRAG_TEXTURE_MODE:
-		fragColor = texture2D(texture, texCoord);
+		fragColor = texture(tex, texCoord);
 		break;
 
 	case FRAG_TEXT_COLOR_MODE:
-		fragColor = vec4(textColor.rgb, texture2D(texture, texCoord).r);
+		fragColor = vec4(textColor.rgb, texture(tex, texCoord).r);
 		break;
 	}
 
@@ -0,0 +1,80 @@
+package gfx
+
+import (
+	"image"
+
+	"github.com/go-gl/gl/v4.5-core/gl"
+
+	"github.com/btmura/ponzi2/internal/errs"
+)
+
+// Framebuffer is an offscreen render target that can be read back into an image.
+type Framebuffer struct {
+	// fbo is the framebuffer object.
+	fbo uint32
+
+	// rbo is the renderbuffer with the framebuffer's colors.
+	rbo uint32
+
+	// size is the width and height of the framebuffer.
+	size image.Point
+}
+
+// NewFramebuffer creates a framebuffer of the given size and binds it,
+// so that rendering goes to it instead of the window.
+func NewFramebuffer(size image.Point) (*Framebuffer, error) {
+	if size.X <= 0 || size.Y <= 0 {
+		return nil, errs.Errorf("bad framebuffer size: %v", size)
+	}
+
+	f := &Framebuffer{size: size}
+
+	gl.GenFramebuffers(1, &f.fbo)
+	gl.BindFramebuffer(gl.FRAMEBUFFER, f.fbo)
+
+	gl.GenRenderbuffers(1, &f.rbo)
+	gl.BindRenderbuffer(gl.RENDERBUFFER, f.rbo)
+	gl.RenderbufferStorage(gl.RENDERBUFFER, gl.RGBA8, int32(size.X), int32(size.Y))
+	gl.FramebufferRenderbuffer(gl.FRAMEBUFFER, gl.COLOR_ATTACHMENT0, gl.RENDERBUFFER, f.rbo)
+
+	if s := gl.CheckFramebufferStatus(gl.FRAMEBUFFER); s != gl.FRAMEBUFFER_COMPLETE {
+		f.Delete()
+		return nil, errs.Errorf("incomplete framebuffer: 0x%x", s)
+	}
+
+	return f, nil
+}
+
+// Image reads the framebuffer's pixels into an opaque image with the origin at the top left.
+func (f *Framebuffer) Image() *image.RGBA {
+	gl.BindFramebuffer(gl.FRAMEBUFFER, f.fbo)
+	gl.Finish()
+
+	w, h := f.size.X, f.size.Y
+	img := image.NewRGBA(image.Rect(0, 0, w, h))
+
+	// OpenGL rows start at the bottom, so read them into a buffer and flip them.
+	buf := make([]uint8, w*h*4)
+	gl.PixelStorei(gl.PACK_ALIGNMENT, 1)
+	gl.ReadPixels(0, 0, int32(w), int32(h), gl.RGBA, gl.UNSIGNED_BYTE, gl.Ptr(buf))
+
+	stride := w * 4
+	for y := 0; y < h; y++ {
+		copy(img.Pix[y*img.Stride:y*img.Stride+stride], buf[(h-1-y)*stride:(h-y)*stride])
+	}
+
+	// Blending leaves partial alpha where translucent shapes were drawn,
+	// but images are shown on their own, so make every pixel opaque.
+	for i := 3; i < len(img.Pix); i += 4 {
+		img.Pix[i] = 0xff
+	}
+
+	return img
+}
+
+// Delete deletes the framebuffer and restores rendering to the window.
+func (f *Framebuffer) Delete() {
+	gl.BindFramebuffer(gl.FRAMEBUFFER, 0)
+	gl.DeleteRenderbuffers(1, &f.rbo)
+	gl.DeleteFramebuffers(1, &f.fbo)
+}
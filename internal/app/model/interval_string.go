@@ -12,11 +12,12 @@ func _() {
 	_ = x[Intraday-1]
 	_ = x[Daily-2]
 	_ = x[Weekly-3]
+	_ = x[Monthly-4]
 }
 
-const _Interval_name = "IntervalUnspecifiedIntradayDailyWeekly"
+const _Interval_name = "IntervalUnspecifiedIntradayDailyWeeklyMonthly"
 
-var _Interval_index = [...]uint8{0, 19, 27, 32, 38}
+var _Interval_index = [...]uint8{0, 19, 27, 32, 38, 45}
 
 func (i Interval) String() string {
 	if i < 0 || i >= Interval(len(_Interval_index)-1) {
@@ -2,28 +2,52 @@
 package model
 
 import (
-	"regexp"
+	"sort"
+	"strings"
 	"time"
 
 	"github.com/btmura/ponzi2/internal/errs"
+	"github.com/btmura/ponzi2/internal/stock"
 )
 
 // now is a function to get the current time. Mocked out in tests to return a fixed time.
 var now = time.Now
 
-// validSymbolRegexp is a regexp that accepts valid stock symbols. Examples: X, FB, SPY, AAPL
-var validSymbolRegexp = regexp.MustCompile("^[A-Z]{1,5}$")
+// DefaultWatchlistName is the name of the watchlist the model starts with.
+const DefaultWatchlistName = "Watchlist"
+
+// maxWatchlistNameLength is the maximum number of characters in a watchlist name.
+const maxWatchlistNameLength = 30
 
 // Model models the app's state.
 type Model struct {
 	// currentSymbol is the symbol of the stock shown in the main area.
 	currentSymbol string
 
-	// sidebarSymbols is an ordered list of symbols shown in the sidebar.
-	sidebarSymbols []string
+	// watchlists are the user's named lists of symbols in order. Never empty.
+	watchlists []*Watchlist
+
+	// currentWatchlist is the watchlist whose symbols are shown in the sidebar.
+	currentWatchlist *Watchlist
 
 	// symbol2Stock is map from symbol to Stock data.
 	symbol2Stock map[string]*Stock
+
+	// symbol2Drawings is a map from symbol to the user's drawings on its charts.
+	// Drawings are kept even if the stock is no longer in the model.
+	symbol2Drawings map[string][]*Drawing
+
+	// symbol2Alerts is a map from symbol to the user's alerts.
+	// Alerts are kept even if the stock is no longer in the model.
+	symbol2Alerts map[string][]*Alert
+
+	// symbol2Lots is a map from symbol to the user's portfolio lots.
+	// Lots are kept even if the stock is no longer in the model.
+	symbol2Lots map[string][]*Lot
+
+	// symbol2Adjustment is a map from symbol to how its chart prices are adjusted.
+	// Symbols without entries are split-adjusted.
+	symbol2Adjustment map[string]Adjustment
 }
 
 // Stock has a stock's symbol and charts.
@@ -38,13 +62,40 @@ type Stock struct {
 	Charts []*Chart
 }
 
+// Watchlist is a named list of symbols shown in the sidebar.
+type Watchlist struct {
+	// Name is the unique name of the watchlist.
+	Name string
+
+	// Symbols are the symbols in the order shown.
+	Symbols []string
+}
+
+// DeepCopy returns a deep copy of the watchlist.
+func (w *Watchlist) DeepCopy() *Watchlist {
+	if w == nil {
+		return nil
+	}
+	deep := *w
+	deep.Symbols = append([]string(nil), w.Symbols...)
+	return &deep
+}
+
 // Chart has multiple series of data to be graphed.
 type Chart struct {
 	Interval               Interval
 	TradingSessionSeries   *TradingSessionSeries
 	MovingAverageSeriesSet []*MovingAverageSeries
+	BandSeriesSet          []*BandSeries
 	AverageVolumeSeries    *AverageVolumeSeries
-	LastUpdateTime         time.Time
+
+	// RelativeStrengthIndexSeries, MACDSeries, and StochasticSeries
+	// are optional indicators. Nil if the chart doesn't have them.
+	RelativeStrengthIndexSeries *RelativeStrengthIndexSeries
+	MACDSeries                  *MACDSeries
+	StochasticSeries            *StochasticSeries
+
+	LastUpdateTime time.Time
 }
 
 // Quote is the latest quote for the stock.
@@ -88,6 +139,22 @@ const (
 	Intraday
 	Daily
 	Weekly
+	Monthly
+)
+
+// Adjustment is how historical prices are adjusted for corporate actions.
+type Adjustment int
+
+// Adjustment values.
+//go:generate stringer -type=Adjustment
+const (
+	AdjustmentUnspecified Adjustment = iota
+
+	// SplitAdjusted prices are adjusted for splits, so that charts don't jump on split dates.
+	SplitAdjusted
+
+	// TotalReturn prices are also adjusted for dividends, so that charts show returns with reinvested dividends.
+	TotalReturn
 )
 
 // TradingSessionSeries is a time series of trading sessions.
@@ -134,12 +201,21 @@ func (s *TradingSession) DeepCopy() *TradingSession {
 	return &deep
 }
 
+// MovingAverageSetting specifies a moving average to calculate for charts.
+type MovingAverageSetting struct {
+	// Type is the moving average type like simple or exponential.
+	Type MovingAverageType
+
+	// Intervals is how many days, weeks, or months a moving average value spans.
+	Intervals int
+}
+
 // MovingAverageSeries is a time series of moving average values.
 type MovingAverageSeries struct {
 	// Type is the moving average type like simple or exponential.
 	Type MovingAverageType
 
-	// Intervals is how many days or weeks a moving average value spans.
+	// Intervals is how many days, weeks, or months a moving average value spans.
 	Intervals int
 
 	// Values are sorted by date in ascending order.
@@ -190,6 +266,85 @@ func (m *MovingAverageValue) DeepCopy() *MovingAverageValue {
 	return &deep
 }
 
+// BandSetting specifies a price band to calculate for charts.
+type BandSetting struct {
+	// Type is the band type like Bollinger Bands or Keltner Channels.
+	Type BandType
+
+	// Intervals is how many days, weeks, or months a band value spans.
+	Intervals int
+
+	// Width is how far the bands are from the middle line in standard deviations
+	// for Bollinger Bands or average true ranges for Keltner Channels.
+	Width float32
+}
+
+// BandSeries is a time series of price bands around a middle line.
+type BandSeries struct {
+	// Type is the band type like Bollinger Bands or Keltner Channels.
+	Type BandType
+
+	// Intervals is how many days, weeks, or months a band value spans.
+	Intervals int
+
+	// Width is how far the bands are from the middle line in standard deviations
+	// for Bollinger Bands or average true ranges for Keltner Channels.
+	Width float32
+
+	// Values are sorted by date in ascending order.
+	Values []*BandValue
+}
+
+// DeepCopy returns a deep copy of the series.
+func (b *BandSeries) DeepCopy() *BandSeries {
+	if b == nil {
+		return nil
+	}
+	deep := *b
+	if len(deep.Values) != 0 {
+		deep.Values = make([]*BandValue, len(b.Values))
+		for i, v := range b.Values {
+			deep.Values[i] = v.DeepCopy()
+		}
+	}
+	return &deep
+}
+
+// BandType is the type of price band.
+type BandType int
+
+// BandType values.
+//go:generate stringer -type=BandType
+const (
+	BandTypeUnspecified BandType = iota
+	BollingerBands
+	KeltnerChannels
+)
+
+// BandValue is a single data point in a BandSeries.
+type BandValue struct {
+	// Date is the start date of the data point.
+	Date time.Time
+
+	// Upper is the upper band value. Zero if there is not enough data.
+	Upper float32
+
+	// Middle is the middle line value. Zero if there is not enough data.
+	Middle float32
+
+	// Lower is the lower band value. Zero if there is not enough data.
+	Lower float32
+}
+
+// DeepCopy returns a deep copy of the value.
+func (b *BandValue) DeepCopy() *BandValue {
+	if b == nil {
+		return nil
+	}
+	deep := *b
+	return &deep
+}
+
 // AverageVolumeSeries is a time series of average volume values.
 type AverageVolumeSeries struct {
 	// Values are sorted by date in ascending order.
@@ -229,10 +384,261 @@ func (a *AverageVolumeValue) DeepCopy() *AverageVolumeValue {
 	return &deep
 }
 
+// RelativeStrengthIndexSeries is a time series of relative strength index values.
+type RelativeStrengthIndexSeries struct {
+	// Intervals is how many days, weeks, or months of changes each value spans.
+	Intervals int
+
+	// Values are sorted by date in ascending order.
+	Values []*RelativeStrengthIndexValue
+}
+
+// DeepCopy returns a deep copy of the series.
+func (r *RelativeStrengthIndexSeries) DeepCopy() *RelativeStrengthIndexSeries {
+	if r == nil {
+		return nil
+	}
+	deep := *r
+	if len(deep.Values) != 0 {
+		deep.Values = make([]*RelativeStrengthIndexValue, len(r.Values))
+		for i, v := range r.Values {
+			deep.Values[i] = v.DeepCopy()
+		}
+	}
+	return &deep
+}
+
+// RelativeStrengthIndexValue is a single data point in a RelativeStrengthIndexSeries.
+type RelativeStrengthIndexValue struct {
+	// Date is the start date of the data point.
+	Date time.Time
+
+	// Value is the relative strength index from 0 to 100.
+	// Zero if there is not enough data.
+	Value float32
+}
+
+// DeepCopy returns a deep copy of the value.
+func (r *RelativeStrengthIndexValue) DeepCopy() *RelativeStrengthIndexValue {
+	if r == nil {
+		return nil
+	}
+	deep := *r
+	return &deep
+}
+
+// MACDSeries is a time series of moving average convergence divergence values.
+type MACDSeries struct {
+	// FastIntervals is the number of intervals of the fast exponential moving average.
+	FastIntervals int
+
+	// SlowIntervals is the number of intervals of the slow exponential moving average.
+	SlowIntervals int
+
+	// SignalIntervals is the number of intervals of the signal line's exponential moving average.
+	SignalIntervals int
+
+	// Values are sorted by date in ascending order.
+	Values []*MACDValue
+}
+
+// DeepCopy returns a deep copy of the series.
+func (m *MACDSeries) DeepCopy() *MACDSeries {
+	if m == nil {
+		return nil
+	}
+	deep := *m
+	if len(deep.Values) != 0 {
+		deep.Values = make([]*MACDValue, len(m.Values))
+		for i, v := range m.Values {
+			deep.Values[i] = v.DeepCopy()
+		}
+	}
+	return &deep
+}
+
+// MACDValue is a single data point in a MACDSeries.
+// All fields besides the date are zero if there is not enough data.
+type MACDValue struct {
+	// Date is the start date of the data point.
+	Date time.Time
+
+	// MACD is the difference between the fast and slow moving averages.
+	MACD float32
+
+	// Signal is the moving average of the MACD.
+	Signal float32
+
+	// Histogram is the difference between the MACD and the signal.
+	Histogram float32
+}
+
+// DeepCopy returns a deep copy of the value.
+func (m *MACDValue) DeepCopy() *MACDValue {
+	if m == nil {
+		return nil
+	}
+	deep := *m
+	return &deep
+}
+
+// StochasticSeries is a time series of slow stochastic oscillator values.
+type StochasticSeries struct {
+	// Intervals is how many days, weeks, or months of highs and lows each value spans.
+	Intervals int
+
+	// KIntervals is how many fast %K values are averaged into each slow %K value.
+	KIntervals int
+
+	// DIntervals is how many slow %K values are averaged into each %D value.
+	DIntervals int
+
+	// Values are sorted by date in ascending order.
+	Values []*StochasticValue
+}
+
+// DeepCopy returns a deep copy of the series.
+func (s *StochasticSeries) DeepCopy() *StochasticSeries {
+	if s == nil {
+		return nil
+	}
+	deep := *s
+	if len(deep.Values) != 0 {
+		deep.Values = make([]*StochasticValue, len(s.Values))
+		for i, v := range s.Values {
+			deep.Values[i] = v.DeepCopy()
+		}
+	}
+	return &deep
+}
+
+// StochasticValue is a single data point in a StochasticSeries.
+// K and D are from 0 to 100 and zero if there is not enough data.
+type StochasticValue struct {
+	// Date is the start date of the data point.
+	Date time.Time
+
+	// K is the slow %K value.
+	K float32
+
+	// D is the %D value.
+	D float32
+}
+
+// DeepCopy returns a deep copy of the value.
+func (s *StochasticValue) DeepCopy() *StochasticValue {
+	if s == nil {
+		return nil
+	}
+	deep := *s
+	return &deep
+}
+
+// Drawing is a user annotation on a stock's charts anchored to dates and prices,
+// so that it can be shown on charts of different intervals.
+type Drawing struct {
+	// Type is the kind of drawing like a trend line.
+	Type DrawingType
+
+	// Start is where the drawing starts. Horizontal lines only use the price.
+	Start DrawingPoint
+
+	// End is where the drawing ends. Horizontal lines ignore it.
+	End DrawingPoint
+}
+
+// DeepCopy returns a deep copy of the drawing.
+func (d *Drawing) DeepCopy() *Drawing {
+	if d == nil {
+		return nil
+	}
+	deep := *d
+	return &deep
+}
+
+// DrawingType is the type of drawing.
+type DrawingType int
+
+// DrawingType values.
+//go:generate stringer -type=DrawingType
+const (
+	DrawingTypeUnspecified DrawingType = iota
+	TrendLine
+	HorizontalLine
+	Rectangle
+)
+
+// DrawingPoint is a point on a chart.
+type DrawingPoint struct {
+	// Date is the date of the trading session at the point.
+	Date time.Time
+
+	// Price is the price at the point.
+	Price float32
+}
+
+// Alert is a user rule that triggers once when a stock's price crosses a condition.
+type Alert struct {
+	// Type is the condition like the price crossing above a value.
+	Type AlertType
+
+	// Value is the price for price alerts or the absolute percent change
+	// like 0.05 for 5% for percent change alerts.
+	Value float32
+
+	// MovingAverageType and MovingAverageIntervals identify the daily
+	// moving average that moving average alerts watch.
+	MovingAverageType      MovingAverageType
+	MovingAverageIntervals int
+
+	// LastPrice is the latest price when the alert was last checked, so that price and
+	// percent change alerts only trigger when the price crosses from one side of the value
+	// to the other. Zero until checked.
+	LastPrice float32
+
+	// Triggered is true if the alert triggered. Triggered alerts are not checked again.
+	Triggered bool
+
+	// TriggerTime is when the alert triggered. Zero if not triggered.
+	TriggerTime time.Time
+
+	// TriggerPrice is the price that triggered the alert. Zero if not triggered.
+	TriggerPrice float32
+}
+
+// DeepCopy returns a deep copy of the alert.
+func (a *Alert) DeepCopy() *Alert {
+	if a == nil {
+		return nil
+	}
+	deep := *a
+	return &deep
+}
+
+// AlertType is the type of alert.
+type AlertType int
+
+// AlertType values.
+//go:generate stringer -type=AlertType
+const (
+	AlertTypeUnspecified AlertType = iota
+	PriceAbove
+	PriceBelow
+	PercentChange
+	MovingAverageCross
+)
+
 // New creates a new Model.
 func New() *Model {
+	w := &Watchlist{Name: DefaultWatchlistName}
 	return &Model{
-		symbol2Stock: map[string]*Stock{},
+		watchlists:        []*Watchlist{w},
+		currentWatchlist:  w,
+		symbol2Stock:      map[string]*Stock{},
+		symbol2Drawings:   map[string][]*Drawing{},
+		symbol2Alerts:     map[string][]*Alert{},
+		symbol2Lots:       map[string][]*Lot{},
+		symbol2Adjustment: map[string]Adjustment{},
 	}
 }
 
@@ -271,7 +677,7 @@ func (m *Model) SetCurrentSymbol(symbol string) (changed bool, err error) {
 // SidebarSymbols returns the sidebar's symbols.
 func (m *Model) SidebarSymbols() []string {
 	var symbols []string
-	for _, s := range m.sidebarSymbols {
+	for _, s := range m.currentWatchlist.Symbols {
 		symbols = append(symbols, s)
 	}
 	return symbols
@@ -283,13 +689,13 @@ func (m *Model) AddSidebarSymbol(symbol string) (added bool, err error) {
 		return false, err
 	}
 
-	for _, s := range m.sidebarSymbols {
+	for _, s := range m.currentWatchlist.Symbols {
 		if s == symbol {
 			return false, nil
 		}
 	}
 
-	m.sidebarSymbols = append(m.sidebarSymbols, symbol)
+	m.currentWatchlist.Symbols = append(m.currentWatchlist.Symbols, symbol)
 
 	// Add a stock placeholder for the new symbol if it doesn't exist.
 	if m.symbol2Stock[symbol] == nil {
@@ -305,9 +711,9 @@ func (m *Model) RemoveSidebarSymbol(symbol string) (removed bool, err error) {
 		return false, err
 	}
 
-	for i, s := range m.sidebarSymbols {
+	for i, s := range m.currentWatchlist.Symbols {
 		if s == symbol {
-			m.sidebarSymbols = append(m.sidebarSymbols[:i], m.sidebarSymbols[i+1:]...)
+			m.currentWatchlist.Symbols = append(m.currentWatchlist.Symbols[:i], m.currentWatchlist.Symbols[i+1:]...)
 			if !m.containsSymbol(symbol) {
 				delete(m.symbol2Stock, symbol)
 			}
@@ -327,7 +733,7 @@ func (m *Model) SetSidebarSymbols(symbols []string) error {
 	}
 
 	leftOver := map[string]bool{}
-	for _, s := range m.sidebarSymbols {
+	for _, s := range m.currentWatchlist.Symbols {
 		leftOver[s] = true
 	}
 
@@ -339,7 +745,7 @@ func (m *Model) SetSidebarSymbols(symbols []string) error {
 		}
 		delete(leftOver, s)
 	}
-	m.sidebarSymbols = newSidebarSymbols
+	m.currentWatchlist.Symbols = newSidebarSymbols
 
 	for s := range leftOver {
 		if !m.containsSymbol(s) {
@@ -350,6 +756,162 @@ func (m *Model) SetSidebarSymbols(symbols []string) error {
 	return nil
 }
 
+// Watchlists returns copies of the watchlists in order.
+func (m *Model) Watchlists() []*Watchlist {
+	var ws []*Watchlist
+	for _, w := range m.watchlists {
+		ws = append(ws, w.DeepCopy())
+	}
+	return ws
+}
+
+// WatchlistNames returns the names of the watchlists in order.
+func (m *Model) WatchlistNames() []string {
+	var names []string
+	for _, w := range m.watchlists {
+		names = append(names, w.Name)
+	}
+	return names
+}
+
+// CurrentWatchlist returns the name of the watchlist shown in the sidebar.
+func (m *Model) CurrentWatchlist() string {
+	return m.currentWatchlist.Name
+}
+
+// SetCurrentWatchlist shows the named watchlist in the sidebar and returns true if it changed.
+func (m *Model) SetCurrentWatchlist(name string) (changed bool, err error) {
+	w := m.watchlist(name)
+	if w == nil {
+		return false, errs.Errorf("no watchlist named %q", name)
+	}
+
+	if w == m.currentWatchlist {
+		return false, nil
+	}
+
+	old := m.currentWatchlist
+	m.currentWatchlist = w
+
+	// Remove the old stocks that are no longer in the model and add placeholders for the new ones.
+	for _, s := range old.Symbols {
+		if !m.containsSymbol(s) {
+			delete(m.symbol2Stock, s)
+		}
+	}
+
+	for _, s := range w.Symbols {
+		if m.symbol2Stock[s] == nil {
+			m.symbol2Stock[s] = &Stock{Symbol: s}
+		}
+	}
+
+	return true, nil
+}
+
+// AddWatchlist adds an empty watchlist after the others. It does not change the current watchlist.
+func (m *Model) AddWatchlist(name string) error {
+	if err := ValidateWatchlistName(name); err != nil {
+		return err
+	}
+
+	if m.watchlist(name) != nil {
+		return errs.Errorf("watchlist %q already exists", name)
+	}
+
+	m.watchlists = append(m.watchlists, &Watchlist{Name: name})
+
+	return nil
+}
+
+// SetWatchlistSymbols replaces the symbols of the named watchlist.
+func (m *Model) SetWatchlistSymbols(name string, symbols []string) error {
+	w := m.watchlist(name)
+	if w == nil {
+		return errs.Errorf("no watchlist named %q", name)
+	}
+
+	if w == m.currentWatchlist {
+		return m.SetSidebarSymbols(symbols)
+	}
+
+	for _, s := range symbols {
+		if err := ValidateSymbol(s); err != nil {
+			return err
+		}
+	}
+
+	w.Symbols = append([]string(nil), symbols...)
+
+	return nil
+}
+
+// RenameWatchlist renames a watchlist.
+func (m *Model) RenameWatchlist(oldName, newName string) error {
+	w := m.watchlist(oldName)
+	if w == nil {
+		return errs.Errorf("no watchlist named %q", oldName)
+	}
+
+	if err := ValidateWatchlistName(newName); err != nil {
+		return err
+	}
+
+	if other := m.watchlist(newName); other != nil && other != w {
+		return errs.Errorf("watchlist %q already exists", newName)
+	}
+
+	w.Name = newName
+
+	return nil
+}
+
+// RemoveWatchlist removes a watchlist and returns true if removed.
+// The last watchlist can't be removed. If the current watchlist is removed,
+// then the one before it becomes the current one.
+func (m *Model) RemoveWatchlist(name string) (removed bool, err error) {
+	i := m.watchlistIndex(name)
+	if i < 0 {
+		return false, nil
+	}
+
+	if len(m.watchlists) == 1 {
+		return false, errs.Errorf("can't remove the last watchlist")
+	}
+
+	w := m.watchlists[i]
+	m.watchlists = append(m.watchlists[:i], m.watchlists[i+1:]...)
+
+	if w == m.currentWatchlist {
+		if i > 0 {
+			i--
+		}
+		if _, err := m.SetCurrentWatchlist(m.watchlists[i].Name); err != nil {
+			return false, err
+		}
+	}
+
+	return true, nil
+}
+
+// watchlist returns the watchlist with the name or nil if there is none.
+func (m *Model) watchlist(name string) *Watchlist {
+	if i := m.watchlistIndex(name); i >= 0 {
+		return m.watchlists[i]
+	}
+	return nil
+}
+
+// watchlistIndex returns the index of the watchlist with the name or -1 if there is none.
+func (m *Model) watchlistIndex(name string) int {
+	for i, w := range m.watchlists {
+		if w.Name == name {
+			return i
+		}
+	}
+	return -1
+}
+
 // Stock returns the stock for the symbol if it is in the model. Nil otherwise.
 func (m *Model) Stock(symbol string) (*Stock, error) {
 	if err := ValidateSymbol(symbol); err != nil {
@@ -413,13 +975,350 @@ func (m *Model) UpdateStockChart(symbol string, chart *Chart) error {
 	return nil
 }
 
+// Drawings returns copies of the drawings for the symbol in the order they were added.
+func (m *Model) Drawings(symbol string) ([]*Drawing, error) {
+	if err := ValidateSymbol(symbol); err != nil {
+		return nil, err
+	}
+
+	var ds []*Drawing
+	for _, d := range m.symbol2Drawings[symbol] {
+		ds = append(ds, d.DeepCopy())
+	}
+	return ds, nil
+}
+
+// DrawingSymbols returns the sorted symbols that have drawings.
+func (m *Model) DrawingSymbols() []string {
+	var symbols []string
+	for s, ds := range m.symbol2Drawings {
+		if len(ds) != 0 {
+			symbols = append(symbols, s)
+		}
+	}
+	sort.Strings(symbols)
+	return symbols
+}
+
+// AddDrawing adds a drawing for the symbol.
+func (m *Model) AddDrawing(symbol string, d *Drawing) error {
+	if err := ValidateSymbol(symbol); err != nil {
+		return err
+	}
+
+	if err := ValidateDrawing(d); err != nil {
+		return err
+	}
+
+	m.symbol2Drawings[symbol] = append(m.symbol2Drawings[symbol], d.DeepCopy())
+
+	return nil
+}
+
+// RemoveDrawing removes the drawing at the index of the symbol's drawings and returns true if removed.
+func (m *Model) RemoveDrawing(symbol string, index int) (removed bool, err error) {
+	if err := ValidateSymbol(symbol); err != nil {
+		return false, err
+	}
+
+	ds := m.symbol2Drawings[symbol]
+	if index < 0 || index >= len(ds) {
+		return false, nil
+	}
+
+	ds = append(ds[:index], ds[index+1:]...)
+	if len(ds) == 0 {
+		delete(m.symbol2Drawings, symbol)
+	} else {
+		m.symbol2Drawings[symbol] = ds
+	}
+
+	return true, nil
+}
+
+// Adjustment returns how the symbol's chart prices are adjusted.
+func (m *Model) Adjustment(symbol string) (Adjustment, error) {
+	if err := ValidateSymbol(symbol); err != nil {
+		return AdjustmentUnspecified, err
+	}
+
+	if a, ok := m.symbol2Adjustment[symbol]; ok {
+		return a, nil
+	}
+	return SplitAdjusted, nil
+}
+
+// AdjustmentSymbols returns the sorted symbols that aren't split-adjusted.
+func (m *Model) AdjustmentSymbols() []string {
+	var symbols []string
+	for s := range m.symbol2Adjustment {
+		symbols = append(symbols, s)
+	}
+	sort.Strings(symbols)
+	return symbols
+}
+
+// SetAdjustment sets how the symbol's chart prices are adjusted and returns true if it changed.
+// Adjustments are kept even if the stock is no longer in the model.
+func (m *Model) SetAdjustment(symbol string, adjustment Adjustment) (changed bool, err error) {
+	if err := ValidateSymbol(symbol); err != nil {
+		return false, err
+	}
+
+	if err := ValidateAdjustment(adjustment); err != nil {
+		return false, err
+	}
+
+	old, err := m.Adjustment(symbol)
+	if err != nil {
+		return false, err
+	}
+
+	if adjustment == old {
+		return false, nil
+	}
+
+	if adjustment == SplitAdjusted {
+		delete(m.symbol2Adjustment, symbol)
+	} else {
+		m.symbol2Adjustment[symbol] = adjustment
+	}
+
+	return true, nil
+}
+
+// Alerts returns copies of the alerts for the symbol in the order they were added.
+func (m *Model) Alerts(symbol string) ([]*Alert, error) {
+	if err := ValidateSymbol(symbol); err != nil {
+		return nil, err
+	}
+
+	var as []*Alert
+	for _, a := range m.symbol2Alerts[symbol] {
+		as = append(as, a.DeepCopy())
+	}
+	return as, nil
+}
+
+// AlertSymbols returns the sorted symbols that have alerts.
+func (m *Model) AlertSymbols() []string {
+	var symbols []string
+	for s, as := range m.symbol2Alerts {
+		if len(as) != 0 {
+			symbols = append(symbols, s)
+		}
+	}
+	sort.Strings(symbols)
+	return symbols
+}
+
+// MovingAverageAlertSymbols returns the sorted symbols in the model with moving average cross alerts
+// that haven't triggered. The alerts are checked against daily charts, which need refreshing
+// even while other intervals are shown.
+func (m *Model) MovingAverageAlertSymbols() []string {
+	var symbols []string
+	for s, as := range m.symbol2Alerts {
+		if m.symbol2Stock[s] == nil {
+			continue
+		}
+		for _, a := range as {
+			if a.Type == MovingAverageCross && !a.Triggered {
+				symbols = append(symbols, s)
+				break
+			}
+		}
+	}
+	sort.Strings(symbols)
+	return symbols
+}
+
+// AddAlert adds an alert for the symbol.
+func (m *Model) AddAlert(symbol string, a *Alert) error {
+	if err := ValidateSymbol(symbol); err != nil {
+		return err
+	}
+
+	if err := ValidateAlert(a); err != nil {
+		return err
+	}
+
+	a = a.DeepCopy()
+
+	// Start from the current price, so the alert doesn't trigger if its condition is already met.
+	if st := m.symbol2Stock[symbol]; st != nil && a.LastPrice == 0 {
+		a.LastPrice = latestAlertPrice(a, st.Quote, dailyChart(st))
+	}
+
+	m.symbol2Alerts[symbol] = append(m.symbol2Alerts[symbol], a)
+
+	return nil
+}
+
+// RemoveAlert removes the alert at the index of the symbol's alerts and returns true if removed.
+func (m *Model) RemoveAlert(symbol string, index int) (removed bool, err error) {
+	if err := ValidateSymbol(symbol); err != nil {
+		return false, err
+	}
+
+	as := m.symbol2Alerts[symbol]
+	if index < 0 || index >= len(as) {
+		return false, nil
+	}
+
+	as = append(as[:index], as[index+1:]...)
+	if len(as) == 0 {
+		delete(m.symbol2Alerts, symbol)
+	} else {
+		m.symbol2Alerts[symbol] = as
+	}
+
+	return true, nil
+}
+
+// CheckAlerts checks the symbol's armed alerts against its quote and daily chart
+// and returns copies of the alerts that triggered because the price crossed their
+// conditions since the last check.
+func (m *Model) CheckAlerts(symbol string) (triggered []*Alert, err error) {
+	if err := ValidateSymbol(symbol); err != nil {
+		return nil, err
+	}
+
+	st := m.symbol2Stock[symbol]
+	if st == nil {
+		return nil, nil
+	}
+
+	daily := dailyChart(st)
+
+	for _, a := range m.symbol2Alerts[symbol] {
+		if a.Triggered {
+			continue
+		}
+
+		price, ok := checkAlert(a, st.Quote, daily)
+		if !ok {
+			continue
+		}
+
+		a.Triggered = true
+		a.TriggerTime = now()
+		a.TriggerPrice = price
+		triggered = append(triggered, a.DeepCopy())
+	}
+
+	return triggered, nil
+}
+
+// checkAlert returns the price that crossed the alert's condition and true
+// or false if the condition was not crossed. It updates the alert's last price.
+func checkAlert(a *Alert, q *Quote, daily *Chart) (price float32, ok bool) {
+	curr := latestAlertPrice(a, q, daily)
+	if curr <= 0 {
+		return 0, false
+	}
+
+	last := a.LastPrice
+	a.LastPrice = curr
+
+	// Wait for a price to compare with, so alerts don't trigger on the side they start on.
+	if last == 0 {
+		return 0, false
+	}
+
+	var crossed bool
+	switch a.Type {
+	case PriceAbove:
+		crossed = last < a.Value && curr >= a.Value
+
+	case PriceBelow:
+		crossed = last > a.Value && curr <= a.Value
+
+	case PercentChange:
+		// Compare both prices against the previous close. The last price of the
+		// previous day is about the previous close, so the check resets each day.
+		prevClose := q.LatestPrice - q.Change
+		if prevClose <= 0 {
+			return 0, false
+		}
+		lastPercent, currPercent := absFloat32(last/prevClose-1), absFloat32(curr/prevClose-1)
+		crossed = lastPercent < a.Value && currPercent >= a.Value
+
+	case MovingAverageCross:
+		ma := latestMovingAverage(a, daily)
+		if ma <= 0 {
+			return 0, false
+		}
+		crossed = last < ma && curr >= ma || last > ma && curr <= ma
+	}
+
+	if !crossed {
+		return 0, false
+	}
+	return curr, true
+}
+
+// latestAlertPrice returns the latest price to check the alert against or zero if unknown.
+// Moving average alerts use the latest daily close, since they watch daily moving averages.
+func latestAlertPrice(a *Alert, q *Quote, daily *Chart) float32 {
+	if a.Type != MovingAverageCross {
+		if q == nil {
+			return 0
+		}
+		return q.LatestPrice
+	}
+
+	if daily == nil || daily.TradingSessionSeries == nil {
+		return 0
+	}
+
+	ts := daily.TradingSessionSeries.TradingSessions
+	if len(ts) == 0 {
+		return 0
+	}
+	return ts[len(ts)-1].Close
+}
+
+// latestMovingAverage returns the latest value of the moving average that the alert watches or zero if unknown.
+func latestMovingAverage(a *Alert, daily *Chart) float32 {
+	if daily == nil {
+		return 0
+	}
+
+	for _, ms := range daily.MovingAverageSeriesSet {
+		if ms.Type != a.MovingAverageType || ms.Intervals != a.MovingAverageIntervals {
+			continue
+		}
+		if n := len(ms.Values); n != 0 {
+			return ms.Values[n-1].Value
+		}
+	}
+	return 0
+}
+
+// dailyChart returns the stock's daily chart or nil if it has none.
+func dailyChart(st *Stock) *Chart {
+	for _, ch := range st.Charts {
+		if ch.Interval == Daily {
+			return ch
+		}
+	}
+	return nil
+}
+
+func absFloat32(v float32) float32 {
+	if v < 0 {
+		return -v
+	}
+	return v
+}
+
 // containsSymbol return true if the symbol is either the current symbol or in the sidebar.
 func (m *Model) containsSymbol(symbol string) bool {
 	if m.currentSymbol == symbol {
 		return true
 	}
 
-	for _, s := range m.sidebarSymbols {
+	for _, s := range m.currentWatchlist.Symbols {
 		if s == symbol {
 			return true
 		}
@@ -429,10 +1328,85 @@ func (m *Model) containsSymbol(symbol string) bool {
 }
 
 // ValidateSymbol validates a symbol and returns an error if it's invalid.
+// Symbols must be in the form returned by stock.NormalizeSymbol like SPY, BRK.B, or SHOP.TO.
 func ValidateSymbol(symbol string) error {
-	if !validSymbolRegexp.MatchString(symbol) {
-		return errs.Errorf("bad symbol: got %s, want: %v", symbol, validSymbolRegexp)
+	return stock.ValidateSymbol(symbol)
+}
+
+// ValidateWatchlistName validates a watchlist name and returns an error if it's invalid.
+func ValidateWatchlistName(name string) error {
+	if strings.TrimSpace(name) != name {
+		return errs.Errorf("bad watchlist name: %q has leading or trailing spaces", name)
 	}
+
+	if n := len([]rune(name)); n == 0 || n > maxWatchlistNameLength {
+		return errs.Errorf("bad watchlist name: %q should have 1 to %d characters", name, maxWatchlistNameLength)
+	}
+
+	return nil
+}
+
+// ValidateAdjustment validates an Adjustment and returns an error if it's invalid.
+func ValidateAdjustment(a Adjustment) error {
+	switch a {
+	case SplitAdjusted, TotalReturn:
+		return nil
+	default:
+		return errs.Errorf("bad adjustment: %v", a)
+	}
+}
+
+// ValidateDrawing validates a Drawing and returns an error if it's invalid.
+func ValidateDrawing(d *Drawing) error {
+	if d == nil {
+		return errs.Errorf("missing drawing")
+	}
+
+	switch d.Type {
+	case TrendLine, Rectangle:
+		if d.Start.Date.IsZero() || d.End.Date.IsZero() {
+			return errs.Errorf("missing drawing date")
+		}
+		if d.Start.Price <= 0 || d.End.Price <= 0 {
+			return errs.Errorf("bad drawing price")
+		}
+
+	case HorizontalLine:
+		if d.Start.Price <= 0 {
+			return errs.Errorf("bad drawing price")
+		}
+
+	default:
+		return errs.Errorf("bad drawing type: %v", d.Type)
+	}
+
+	return nil
+}
+
+// ValidateAlert validates an Alert and returns an error if it's invalid.
+func ValidateAlert(a *Alert) error {
+	if a == nil {
+		return errs.Errorf("missing alert")
+	}
+
+	switch a.Type {
+	case PriceAbove, PriceBelow, PercentChange:
+		if a.Value <= 0 {
+			return errs.Errorf("bad alert value: %v", a.Value)
+		}
+
+	case MovingAverageCross:
+		if a.MovingAverageType == MovingAverageTypeUnspecified {
+			return errs.Errorf("unspecified alert moving average type")
+		}
+		if a.MovingAverageIntervals <= 0 {
+			return errs.Errorf("bad alert moving average intervals: %d", a.MovingAverageIntervals)
+		}
+
+	default:
+		return errs.Errorf("bad alert type: %v", a.Type)
+	}
+
 	return nil
 }
 
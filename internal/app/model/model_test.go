@@ -167,6 +167,476 @@ func TestSetSidebarSymbols(t *testing.T) {
 	}
 }
 
+func TestDrawings(t *testing.T) {
+	m := New()
+
+	trendLine := &Drawing{
+		Type:  TrendLine,
+		Start: DrawingPoint{Date: time.Date(2019, time.March, 1, 0, 0, 0, 0, time.UTC), Price: 10},
+		End:   DrawingPoint{Date: time.Date(2019, time.April, 1, 0, 0, 0, 0, time.UTC), Price: 20},
+	}
+	horizLine := &Drawing{
+		Type:  HorizontalLine,
+		Start: DrawingPoint{Price: 15},
+	}
+
+	if err := m.AddDrawing("SPY", trendLine); err != nil {
+		t.Errorf("AddDrawing should not return an error if given a valid drawing: %v", err)
+	}
+	if err := m.AddDrawing("SPY", horizLine); err != nil {
+		t.Errorf("AddDrawing should not return an error if given a valid drawing: %v", err)
+	}
+	if err := m.AddDrawing("SPY", &Drawing{Type: Rectangle}); err == nil {
+		t.Errorf("AddDrawing should return an error if given a drawing without dates.")
+	}
+
+	got, err := m.Drawings("SPY")
+	if err != nil {
+		t.Errorf("Drawings should not return an error if given a valid symbol: %v", err)
+	}
+	if diff := cmp.Diff([]*Drawing{trendLine, horizLine}, got); diff != "" {
+		t.Errorf("diff (-want, +got)\n%s", diff)
+	}
+
+	// Drawings are kept even though SPY was never added to the model.
+	if diff := cmp.Diff([]string{"SPY"}, m.DrawingSymbols()); diff != "" {
+		t.Errorf("diff (-want, +got)\n%s", diff)
+	}
+
+	removed, err := m.RemoveDrawing("SPY", 0)
+	if !removed {
+		t.Errorf("RemoveDrawing should return true if the index is valid.")
+	}
+	if err != nil {
+		t.Errorf("RemoveDrawing should not return an error if given a valid symbol: %v", err)
+	}
+
+	removed, _ = m.RemoveDrawing("SPY", 1)
+	if removed {
+		t.Errorf("RemoveDrawing should return false if the index is out of range.")
+	}
+
+	got, _ = m.Drawings("SPY")
+	if diff := cmp.Diff([]*Drawing{horizLine}, got); diff != "" {
+		t.Errorf("diff (-want, +got)\n%s", diff)
+	}
+
+	m.RemoveDrawing("SPY", 0)
+
+	if diff := cmp.Diff([]string(nil), m.DrawingSymbols()); diff != "" {
+		t.Errorf("diff (-want, +got)\n%s", diff)
+	}
+}
+
+func TestAdjustments(t *testing.T) {
+	m := New()
+
+	got, err := m.Adjustment("SPY")
+	if err != nil {
+		t.Errorf("Adjustment should not return an error if given a valid symbol: %v", err)
+	}
+	if diff := cmp.Diff(SplitAdjusted, got); diff != "" {
+		t.Errorf("diff (-want, +got)\n%s", diff)
+	}
+
+	changed, err := m.SetAdjustment("SPY", TotalReturn)
+	if !changed {
+		t.Errorf("SetAdjustment should return true if the adjustment changed.")
+	}
+	if err != nil {
+		t.Errorf("SetAdjustment should not return an error if given a valid adjustment: %v", err)
+	}
+
+	if changed, _ := m.SetAdjustment("SPY", TotalReturn); changed {
+		t.Errorf("SetAdjustment should return false if the adjustment didn't change.")
+	}
+
+	if _, err := m.SetAdjustment("SPY", AdjustmentUnspecified); err == nil {
+		t.Errorf("SetAdjustment should return an error if given an unspecified adjustment.")
+	}
+
+	got, _ = m.Adjustment("SPY")
+	if diff := cmp.Diff(TotalReturn, got); diff != "" {
+		t.Errorf("diff (-want, +got)\n%s", diff)
+	}
+
+	// Adjustments are kept even though SPY was never added to the model.
+	if diff := cmp.Diff([]string{"SPY"}, m.AdjustmentSymbols()); diff != "" {
+		t.Errorf("diff (-want, +got)\n%s", diff)
+	}
+
+	m.SetAdjustment("SPY", SplitAdjusted)
+
+	if diff := cmp.Diff([]string(nil), m.AdjustmentSymbols()); diff != "" {
+		t.Errorf("diff (-want, +got)\n%s", diff)
+	}
+}
+
+func TestCheckAlerts(t *testing.T) {
+	old := now
+	defer func() { now = old }()
+	now = func() time.Time { return time.Date(2019, time.March, 10, 30, 0, 0, 0, time.UTC) }
+
+	dailyChart := func(close, ma float32) *Chart {
+		return &Chart{
+			Interval: Daily,
+			TradingSessionSeries: &TradingSessionSeries{
+				TradingSessions: []*TradingSession{{Close: close}},
+			},
+			MovingAverageSeriesSet: []*MovingAverageSeries{
+				{
+					Type:      Simple,
+					Intervals: 50,
+					Values:    []*MovingAverageValue{{Value: ma}},
+				},
+			},
+		}
+	}
+
+	maAlert := &Alert{
+		Type:                   MovingAverageCross,
+		MovingAverageType:      Simple,
+		MovingAverageIntervals: 50,
+	}
+
+	for _, tt := range []struct {
+		desc string
+
+		// quote and chart are the stock's data when the alert is added.
+		quote *Quote
+		chart *Chart
+
+		// nextQuote and nextChart are the stock's data when the alert is checked.
+		nextQuote *Quote
+		nextChart *Chart
+
+		alert         *Alert
+		wantTriggered bool
+	}{
+		{
+			desc:          "price above triggers when crossing above the value",
+			quote:         &Quote{LatestPrice: 99},
+			nextQuote:     &Quote{LatestPrice: 100},
+			alert:         &Alert{Type: PriceAbove, Value: 100},
+			wantTriggered: true,
+		},
+		{
+			desc:      "price above does not trigger below the value",
+			quote:     &Quote{LatestPrice: 98},
+			nextQuote: &Quote{LatestPrice: 99},
+			alert:     &Alert{Type: PriceAbove, Value: 100},
+		},
+		{
+			desc:      "price above does not trigger when already above the value",
+			quote:     &Quote{LatestPrice: 101},
+			nextQuote: &Quote{LatestPrice: 102},
+			alert:     &Alert{Type: PriceAbove, Value: 100},
+		},
+		{
+			desc:      "price above does not trigger without a price to compare with",
+			nextQuote: &Quote{LatestPrice: 101},
+			alert:     &Alert{Type: PriceAbove, Value: 100},
+		},
+		{
+			desc:          "price above triggers when crossing since the restored last price",
+			nextQuote:     &Quote{LatestPrice: 101},
+			alert:         &Alert{Type: PriceAbove, Value: 100, LastPrice: 99},
+			wantTriggered: true,
+		},
+		{
+			desc:          "price below triggers when crossing below the value",
+			quote:         &Quote{LatestPrice: 101},
+			nextQuote:     &Quote{LatestPrice: 99},
+			alert:         &Alert{Type: PriceBelow, Value: 100},
+			wantTriggered: true,
+		},
+		{
+			desc:      "price below does not trigger above the value",
+			quote:     &Quote{LatestPrice: 102},
+			nextQuote: &Quote{LatestPrice: 101},
+			alert:     &Alert{Type: PriceBelow, Value: 100},
+		},
+		{
+			desc:      "price below does not trigger when already below the value",
+			quote:     &Quote{LatestPrice: 99},
+			nextQuote: &Quote{LatestPrice: 98},
+			alert:     &Alert{Type: PriceBelow, Value: 100},
+		},
+		{
+			desc:          "percent change triggers on big drops",
+			quote:         &Quote{LatestPrice: 99, Change: -1, ChangePercent: -0.01},
+			nextQuote:     &Quote{LatestPrice: 90, Change: -10, ChangePercent: -0.1},
+			alert:         &Alert{Type: PercentChange, Value: 0.05},
+			wantTriggered: true,
+		},
+		{
+			desc:      "percent change does not trigger on small moves",
+			quote:     &Quote{LatestPrice: 100, Change: 0, ChangePercent: 0},
+			nextQuote: &Quote{LatestPrice: 99, Change: -1, ChangePercent: -0.01},
+			alert:     &Alert{Type: PercentChange, Value: 0.05},
+		},
+		{
+			desc:      "percent change does not trigger when already beyond the value",
+			quote:     &Quote{LatestPrice: 90, Change: -10, ChangePercent: -0.1},
+			nextQuote: &Quote{LatestPrice: 89, Change: -11, ChangePercent: -0.11},
+			alert:     &Alert{Type: PercentChange, Value: 0.05},
+		},
+		{
+			desc:          "moving average cross triggers when crossing above",
+			chart:         dailyChart(9, 10),
+			nextChart:     dailyChart(11, 10),
+			alert:         maAlert,
+			wantTriggered: true,
+		},
+		{
+			desc:          "moving average cross triggers when crossing below",
+			chart:         dailyChart(11, 10),
+			nextChart:     dailyChart(9, 10),
+			alert:         maAlert,
+			wantTriggered: true,
+		},
+		{
+			desc:      "moving average cross does not trigger when staying above",
+			chart:     dailyChart(11, 10),
+			nextChart: dailyChart(12, 10),
+			alert:     maAlert,
+		},
+		{
+			desc:      "moving average cross does not trigger without the moving average",
+			chart:     dailyChart(9, 10),
+			nextChart: dailyChart(11, 10),
+			alert:     &Alert{Type: MovingAverageCross, MovingAverageType: Exponential, MovingAverageIntervals: 50},
+		},
+		{
+			desc:      "triggered alerts do not trigger again",
+			quote:     &Quote{LatestPrice: 99},
+			nextQuote: &Quote{LatestPrice: 100},
+			alert:     &Alert{Type: PriceAbove, Value: 100, Triggered: true},
+		},
+	} {
+		t.Run(tt.desc, func(t *testing.T) {
+			m := New()
+			m.SetCurrentSymbol("SPY")
+
+			update := func(q *Quote, ch *Chart) {
+				t.Helper()
+				if q != nil {
+					if err := m.UpdateStockQuote("SPY", q); err != nil {
+						t.Fatalf("UpdateStockQuote should not return an error: %v", err)
+					}
+				}
+				if ch != nil {
+					if err := m.UpdateStockChart("SPY", ch); err != nil {
+						t.Fatalf("UpdateStockChart should not return an error: %v", err)
+					}
+				}
+			}
+
+			update(tt.quote, tt.chart)
+
+			if err := m.AddAlert("SPY", tt.alert); err != nil {
+				t.Fatalf("AddAlert should not return an error: %v", err)
+			}
+
+			update(tt.nextQuote, tt.nextChart)
+
+			triggered, err := m.CheckAlerts("SPY")
+			if err != nil {
+				t.Fatalf("CheckAlerts should not return an error: %v", err)
+			}
+
+			if got := len(triggered) != 0; got != tt.wantTriggered {
+				t.Errorf("got triggered %t, want %t", got, tt.wantTriggered)
+			}
+
+			if !tt.wantTriggered {
+				return
+			}
+
+			// Alerts only trigger once.
+			triggered, _ = m.CheckAlerts("SPY")
+			if len(triggered) != 0 {
+				t.Errorf("CheckAlerts should not trigger an alert twice.")
+			}
+
+			got, _ := m.Alerts("SPY")
+			if !got[0].Triggered || !got[0].TriggerTime.Equal(now()) {
+				t.Errorf("Alerts should return the alert as triggered at the current time.")
+			}
+		})
+	}
+}
+
+func TestRemoveAlert(t *testing.T) {
+	m := New()
+
+	if err := m.AddAlert("SPY", &Alert{Type: PriceAbove}); err == nil {
+		t.Errorf("AddAlert should return an error if given an alert without a value.")
+	}
+
+	a := &Alert{Type: PriceBelow, Value: 100}
+	if err := m.AddAlert("SPY", a); err != nil {
+		t.Errorf("AddAlert should not return an error if given a valid alert: %v", err)
+	}
+
+	// Alerts are kept even though SPY was never added to the model.
+	if diff := cmp.Diff([]string{"SPY"}, m.AlertSymbols()); diff != "" {
+		t.Errorf("diff (-want, +got)\n%s", diff)
+	}
+
+	if removed, _ := m.RemoveAlert("SPY", 1); removed {
+		t.Errorf("RemoveAlert should return false if the index is out of range.")
+	}
+
+	if removed, err := m.RemoveAlert("SPY", 0); !removed || err != nil {
+		t.Errorf("RemoveAlert should return true and no error if the index is valid: %v", err)
+	}
+
+	if diff := cmp.Diff([]string(nil), m.AlertSymbols()); diff != "" {
+		t.Errorf("diff (-want, +got)\n%s", diff)
+	}
+}
+
+func TestMovingAverageAlertSymbols(t *testing.T) {
+	m := New()
+
+	for _, s := range []string{"AAPL", "MSFT", "SPY"} {
+		if _, err := m.AddSidebarSymbol(s); err != nil {
+			t.Fatalf("AddSidebarSymbol should not return an error: %v", err)
+		}
+	}
+
+	for _, tt := range []struct {
+		symbol string
+		alert  *Alert
+	}{
+		{"SPY", &Alert{Type: MovingAverageCross, MovingAverageType: Simple, MovingAverageIntervals: 50}},
+		{"AAPL", &Alert{Type: PriceAbove, Value: 100}},
+		{"MSFT", &Alert{Type: MovingAverageCross, MovingAverageType: Exponential, MovingAverageIntervals: 21, Triggered: true}},
+		{"QQQ", &Alert{Type: MovingAverageCross, MovingAverageType: Simple, MovingAverageIntervals: 200}},
+	} {
+		if err := m.AddAlert(tt.symbol, tt.alert); err != nil {
+			t.Fatalf("AddAlert should not return an error: %v", err)
+		}
+	}
+
+	// AAPL has no moving average alerts, MSFT's alert triggered, and QQQ isn't in the model.
+	if diff := cmp.Diff([]string{"SPY"}, m.MovingAverageAlertSymbols()); diff != "" {
+		t.Errorf("diff (-want, +got)\n%s", diff)
+	}
+}
+
+func TestWatchlists(t *testing.T) {
+	m := New()
+
+	if diff := cmp.Diff([]string{DefaultWatchlistName}, m.WatchlistNames()); diff != "" {
+		t.Errorf("diff (-want, +got)\n%s", diff)
+	}
+
+	m.AddSidebarSymbol("SPY")
+
+	if err := m.AddWatchlist("Breakouts"); err != nil {
+		t.Errorf("AddWatchlist should not return an error if given a new name: %v", err)
+	}
+
+	if err := m.AddWatchlist("Breakouts"); err == nil {
+		t.Errorf("AddWatchlist should return an error if given an existing name.")
+	}
+
+	if err := m.AddWatchlist(" Breakouts"); err == nil {
+		t.Errorf("AddWatchlist should return an error if given a name with leading spaces.")
+	}
+
+	if changed, err := m.SetCurrentWatchlist("Breakouts"); !changed || err != nil {
+		t.Errorf("SetCurrentWatchlist should return true and no error if given another watchlist: %v", err)
+	}
+
+	if _, err := m.SetCurrentWatchlist("Holdings"); err == nil {
+		t.Errorf("SetCurrentWatchlist should return an error if given an unknown watchlist.")
+	}
+
+	// Stocks of the other watchlist are removed from the model.
+	if st, _ := m.Stock("SPY"); st != nil {
+		t.Errorf("SetCurrentWatchlist should remove the stocks of the old watchlist.")
+	}
+
+	m.AddSidebarSymbol("QQQ")
+
+	if err := m.RenameWatchlist("Breakouts", DefaultWatchlistName); err == nil {
+		t.Errorf("RenameWatchlist should return an error if given an existing name.")
+	}
+
+	if err := m.RenameWatchlist("Breakouts", "Holdings"); err != nil {
+		t.Errorf("RenameWatchlist should not return an error if given a new name: %v", err)
+	}
+
+	want := []*Watchlist{
+		{Name: DefaultWatchlistName, Symbols: []string{"SPY"}},
+		{Name: "Holdings", Symbols: []string{"QQQ"}},
+	}
+	if diff := cmp.Diff(want, m.Watchlists()); diff != "" {
+		t.Errorf("diff (-want, +got)\n%s", diff)
+	}
+
+	if removed, err := m.RemoveWatchlist("Holdings"); !removed || err != nil {
+		t.Errorf("RemoveWatchlist should return true and no error if given an existing watchlist: %v", err)
+	}
+
+	// The watchlist before the removed one becomes the current one.
+	if got := m.CurrentWatchlist(); got != DefaultWatchlistName {
+		t.Errorf("CurrentWatchlist got %q, want %q", got, DefaultWatchlistName)
+	}
+
+	if diff := cmp.Diff([]string{"SPY"}, m.SidebarSymbols()); diff != "" {
+		t.Errorf("diff (-want, +got)\n%s", diff)
+	}
+
+	spy, _ := m.Stock("SPY")
+	qqq, _ := m.Stock("QQQ")
+	if spy == nil || qqq != nil {
+		t.Errorf("RemoveWatchlist should swap the stocks of the removed and new current watchlist.")
+	}
+
+	if _, err := m.RemoveWatchlist(DefaultWatchlistName); err == nil {
+		t.Errorf("RemoveWatchlist should return an error if given the last watchlist.")
+	}
+}
+
+func TestValidateWatchlistName(t *testing.T) {
+	for _, tt := range []struct {
+		desc    string
+		input   string
+		wantErr bool
+	}{
+		{
+			desc:  "valid name",
+			input: "Earnings this week",
+		},
+		{
+			desc:    "empty name",
+			input:   "",
+			wantErr: true,
+		},
+		{
+			desc:    "trailing spaces",
+			input:   "Holdings ",
+			wantErr: true,
+		},
+		{
+			desc:    "too long",
+			input:   "0123456789012345678901234567890",
+			wantErr: true,
+		},
+	} {
+		t.Run(tt.desc, func(t *testing.T) {
+			gotErr := ValidateWatchlistName(tt.input)
+			if (gotErr != nil) != tt.wantErr {
+				t.Errorf("got error: %v, wanted err: %t", gotErr, tt.wantErr)
+			}
+		})
+	}
+}
+
 func TestUpdateStockQuote(t *testing.T) {
 	old := now
 	defer func() { now = old }()
@@ -364,6 +834,19 @@ func TestValidateSymbol(t *testing.T) {
 			desc:  "valid four letter symbol",
 			input: "QQQQ",
 		},
+		{
+			desc:  "valid class share symbol",
+			input: "BRK.B",
+		},
+		{
+			desc:  "valid foreign listing",
+			input: "SHOP.TO",
+		},
+		{
+			desc:    "dash must be normalized to a dot",
+			input:   "RDS-A",
+			wantErr: true,
+		},
 		{
 			desc:    "lowercase not allowed",
 			input:   "spy",
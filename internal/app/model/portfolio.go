@@ -0,0 +1,219 @@
+package model
+
+import (
+	"sort"
+	"time"
+
+	"github.com/btmura/ponzi2/internal/errs"
+)
+
+// Lot is a purchase of shares of a stock that the user holds.
+type Lot struct {
+	// Shares is the positive number of shares bought.
+	Shares float32
+
+	// Price is the positive cost per share.
+	Price float32
+
+	// Date is the date of the purchase. Zero if unknown.
+	Date time.Time
+}
+
+// DeepCopy returns a deep copy of the lot.
+func (l *Lot) DeepCopy() *Lot {
+	if l == nil {
+		return nil
+	}
+	deep := *l
+	return &deep
+}
+
+// Position sums up the lots of a stock and values them using the stock's quote.
+type Position struct {
+	// Symbol is the stock's symbol.
+	Symbol string
+
+	// Lots are the lots that make up the position.
+	Lots []*Lot
+
+	// Shares is the total number of shares.
+	Shares float32
+
+	// CostBasis is the total cost of the shares.
+	CostBasis float32
+
+	// AveragePrice is the average cost per share.
+	AveragePrice float32
+
+	// Priced is true if the fields below were computed from a quote.
+	Priced bool
+
+	// MarketValue is the value of the shares at the latest price.
+	MarketValue float32
+
+	// GainLoss is the unrealized gain or loss.
+	GainLoss float32
+
+	// GainLossPercent is the unrealized gain or loss like 0.05 for 5%.
+	GainLossPercent float32
+
+	// DayChange is the change in market value since the previous close.
+	// Lots bought on the quote's day count from their price instead.
+	DayChange float32
+
+	// DayChangePercent is the day change relative to the value at the start of the day.
+	DayChangePercent float32
+}
+
+// NewPosition returns the position for the lots valued using the quote.
+// Returns nil if there are no lots. The quote may be nil.
+func NewPosition(symbol string, lots []*Lot, q *Quote) *Position {
+	if len(lots) == 0 {
+		return nil
+	}
+
+	p := &Position{Symbol: symbol}
+	for _, l := range lots {
+		p.Lots = append(p.Lots, l.DeepCopy())
+		p.Shares += l.Shares
+		p.CostBasis += l.Shares * l.Price
+	}
+
+	if p.Shares != 0 {
+		p.AveragePrice = p.CostBasis / p.Shares
+	}
+
+	if q == nil || q.LatestPrice == 0 {
+		return p
+	}
+
+	p.Priced = true
+	p.MarketValue = p.Shares * q.LatestPrice
+	p.GainLoss = p.MarketValue - p.CostBasis
+	if p.CostBasis != 0 {
+		p.GainLossPercent = p.GainLoss / p.CostBasis
+	}
+
+	// startValue is the value of the position at the start of the day.
+	var startValue float32
+	for _, l := range lots {
+		if boughtOnQuoteDay(l, q) {
+			p.DayChange += l.Shares * (q.LatestPrice - l.Price)
+			startValue += l.Shares * l.Price
+			continue
+		}
+		p.DayChange += l.Shares * q.Change
+		startValue += l.Shares * (q.LatestPrice - q.Change)
+	}
+
+	if startValue != 0 {
+		p.DayChangePercent = p.DayChange / startValue
+	}
+
+	return p
+}
+
+// boughtOnQuoteDay returns true if the lot was bought on or after the day of the quote.
+func boughtOnQuoteDay(l *Lot, q *Quote) bool {
+	if l.Date.IsZero() || q.LatestTime.IsZero() {
+		return false
+	}
+	ly, lm, ld := l.Date.Date()
+	qy, qm, qd := q.LatestTime.Date()
+	lday := time.Date(ly, lm, ld, 0, 0, 0, 0, time.UTC)
+	qday := time.Date(qy, qm, qd, 0, 0, 0, 0, time.UTC)
+	return !lday.Before(qday)
+}
+
+// Lots returns copies of the symbol's lots.
+func (m *Model) Lots(symbol string) ([]*Lot, error) {
+	if err := ValidateSymbol(symbol); err != nil {
+		return nil, err
+	}
+
+	var ls []*Lot
+	for _, l := range m.symbol2Lots[symbol] {
+		ls = append(ls, l.DeepCopy())
+	}
+	return ls, nil
+}
+
+// LotSymbols returns the sorted symbols that have lots.
+func (m *Model) LotSymbols() []string {
+	var symbols []string
+	for s, ls := range m.symbol2Lots {
+		if len(ls) != 0 {
+			symbols = append(symbols, s)
+		}
+	}
+	sort.Strings(symbols)
+	return symbols
+}
+
+// AddLot adds a lot for the symbol.
+func (m *Model) AddLot(symbol string, l *Lot) error {
+	if err := ValidateSymbol(symbol); err != nil {
+		return err
+	}
+
+	if err := ValidateLot(l); err != nil {
+		return err
+	}
+
+	m.symbol2Lots[symbol] = append(m.symbol2Lots[symbol], l.DeepCopy())
+
+	return nil
+}
+
+// RemoveLot removes the lot at the index of the symbol's lots and returns true if removed.
+func (m *Model) RemoveLot(symbol string, index int) (removed bool, err error) {
+	if err := ValidateSymbol(symbol); err != nil {
+		return false, err
+	}
+
+	ls := m.symbol2Lots[symbol]
+	if index < 0 || index >= len(ls) {
+		return false, nil
+	}
+
+	ls = append(ls[:index], ls[index+1:]...)
+	if len(ls) == 0 {
+		delete(m.symbol2Lots, symbol)
+	} else {
+		m.symbol2Lots[symbol] = ls
+	}
+
+	return true, nil
+}
+
+// Position returns the symbol's position valued using its stock's quote if the stock is in the model.
+// Returns nil if the symbol has no lots.
+func (m *Model) Position(symbol string) (*Position, error) {
+	if err := ValidateSymbol(symbol); err != nil {
+		return nil, err
+	}
+
+	var q *Quote
+	if st := m.symbol2Stock[symbol]; st != nil {
+		q = st.Quote
+	}
+
+	return NewPosition(symbol, m.symbol2Lots[symbol], q), nil
+}
+
+// ValidateLot validates a Lot and returns an error if it's invalid.
+func ValidateLot(l *Lot) error {
+	if l == nil {
+		return errs.Errorf("missing lot")
+	}
+
+	if l.Shares <= 0 {
+		return errs.Errorf("bad lot shares: %v", l.Shares)
+	}
+
+	if l.Price <= 0 {
+		return errs.Errorf("bad lot price: %v", l.Price)
+	}
+
+	return nil
+}
@@ -0,0 +1,148 @@
+package model
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestNewPosition(t *testing.T) {
+	day := func(d int) time.Time {
+		return time.Date(2026, time.October, d, 0, 0, 0, 0, time.UTC)
+	}
+
+	for _, tt := range []struct {
+		desc  string
+		lots  []*Lot
+		quote *Quote
+		want  *Position
+	}{
+		{
+			desc: "no lots",
+		},
+		{
+			desc: "no quote",
+			lots: []*Lot{
+				{Shares: 10, Price: 100},
+				{Shares: 30, Price: 200},
+			},
+			want: &Position{
+				Symbol: "SPY",
+				Lots: []*Lot{
+					{Shares: 10, Price: 100},
+					{Shares: 30, Price: 200},
+				},
+				Shares:       40,
+				CostBasis:    7000,
+				AveragePrice: 175,
+			},
+		},
+		{
+			desc: "lots bought before the quote day",
+			lots: []*Lot{
+				{Shares: 10, Price: 100, Date: day(1)},
+			},
+			quote: &Quote{LatestPrice: 120, LatestTime: day(16).Add(10 * time.Hour), Change: 10},
+			want: &Position{
+				Symbol: "SPY",
+				Lots: []*Lot{
+					{Shares: 10, Price: 100, Date: day(1)},
+				},
+				Shares:           10,
+				CostBasis:        1000,
+				AveragePrice:     100,
+				Priced:           true,
+				MarketValue:      1200,
+				GainLoss:         200,
+				GainLossPercent:  0.2,
+				DayChange:        100,
+				DayChangePercent: float32(100) / 1100,
+			},
+		},
+		{
+			desc: "lot bought on the quote day",
+			lots: []*Lot{
+				{Shares: 10, Price: 100, Date: day(1)},
+				{Shares: 10, Price: 115, Date: day(16)},
+			},
+			quote: &Quote{LatestPrice: 120, LatestTime: day(16).Add(10 * time.Hour), Change: 10},
+			want: &Position{
+				Symbol: "SPY",
+				Lots: []*Lot{
+					{Shares: 10, Price: 100, Date: day(1)},
+					{Shares: 10, Price: 115, Date: day(16)},
+				},
+				Shares:           20,
+				CostBasis:        2150,
+				AveragePrice:     107.5,
+				Priced:           true,
+				MarketValue:      2400,
+				GainLoss:         250,
+				GainLossPercent:  float32(250) / 2150,
+				DayChange:        150,
+				DayChangePercent: float32(150) / 2250,
+			},
+		},
+	} {
+		t.Run(tt.desc, func(t *testing.T) {
+			got := NewPosition("SPY", tt.lots, tt.quote)
+			if diff := cmp.Diff(tt.want, got); diff != "" {
+				t.Errorf("diff (-want, +got)\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestLots(t *testing.T) {
+	m := New()
+
+	if err := m.AddLot("SPY", &Lot{Shares: 10}); err == nil {
+		t.Errorf("AddLot should return an error if given a lot without a price.")
+	}
+
+	if err := m.AddLot("SPY", &Lot{Shares: 10, Price: 100}); err != nil {
+		t.Errorf("AddLot should not return an error if given a valid lot: %v", err)
+	}
+
+	// Lots are kept even though SPY was never added to the model.
+	if diff := cmp.Diff([]string{"SPY"}, m.LotSymbols()); diff != "" {
+		t.Errorf("diff (-want, +got)\n%s", diff)
+	}
+
+	// Position is unpriced without a stock in the model.
+	p, err := m.Position("SPY")
+	if err != nil {
+		t.Fatalf("Position should not return an error: %v", err)
+	}
+	if p == nil || p.Priced {
+		t.Errorf("Position should return an unpriced position: %+v", p)
+	}
+
+	if _, err := m.AddSidebarSymbol("SPY"); err != nil {
+		t.Fatalf("AddSidebarSymbol should not return an error: %v", err)
+	}
+	if err := m.UpdateStockQuote("SPY", &Quote{LatestPrice: 110}); err != nil {
+		t.Fatalf("UpdateStockQuote should not return an error: %v", err)
+	}
+
+	if p, _ := m.Position("SPY"); p == nil || p.GainLoss != 100 {
+		t.Errorf("Position should use the stock's quote: %+v", p)
+	}
+
+	if removed, _ := m.RemoveLot("SPY", 1); removed {
+		t.Errorf("RemoveLot should return false if the index is out of range.")
+	}
+
+	if removed, err := m.RemoveLot("SPY", 0); !removed || err != nil {
+		t.Errorf("RemoveLot should return true and no error if the index is valid: %v", err)
+	}
+
+	if diff := cmp.Diff([]string(nil), m.LotSymbols()); diff != "" {
+		t.Errorf("diff (-want, +got)\n%s", diff)
+	}
+
+	if p, _ := m.Position("SPY"); p != nil {
+		t.Errorf("Position should return nil without lots: %+v", p)
+	}
+}
@@ -0,0 +1,102 @@
+package model
+
+import "github.com/btmura/ponzi2/internal/errs"
+
+// The enums below implement encoding.TextMarshaler and encoding.TextUnmarshaler,
+// so that formats like JSON show their names instead of numbers.
+
+// MarshalText implements encoding.TextMarshaler.
+func (i Interval) MarshalText() ([]byte, error) {
+	return []byte(i.String()), nil
+}
+
+// UnmarshalText implements encoding.TextUnmarshaler.
+func (i *Interval) UnmarshalText(text []byte) error {
+	for v := IntervalUnspecified; v <= Monthly; v++ {
+		if v.String() == string(text) {
+			*i = v
+			return nil
+		}
+	}
+	return errs.Errorf("bad interval: %q", text)
+}
+
+// MarshalText implements encoding.TextMarshaler.
+func (a Adjustment) MarshalText() ([]byte, error) {
+	return []byte(a.String()), nil
+}
+
+// UnmarshalText implements encoding.TextUnmarshaler.
+func (a *Adjustment) UnmarshalText(text []byte) error {
+	for v := AdjustmentUnspecified; v <= TotalReturn; v++ {
+		if v.String() == string(text) {
+			*a = v
+			return nil
+		}
+	}
+	return errs.Errorf("bad adjustment: %q", text)
+}
+
+// MarshalText implements encoding.TextMarshaler.
+func (m MovingAverageType) MarshalText() ([]byte, error) {
+	return []byte(m.String()), nil
+}
+
+// UnmarshalText implements encoding.TextUnmarshaler.
+func (m *MovingAverageType) UnmarshalText(text []byte) error {
+	for v := MovingAverageTypeUnspecified; v <= Exponential; v++ {
+		if v.String() == string(text) {
+			*m = v
+			return nil
+		}
+	}
+	return errs.Errorf("bad moving average type: %q", text)
+}
+
+// MarshalText implements encoding.TextMarshaler.
+func (b BandType) MarshalText() ([]byte, error) {
+	return []byte(b.String()), nil
+}
+
+// UnmarshalText implements encoding.TextUnmarshaler.
+func (b *BandType) UnmarshalText(text []byte) error {
+	for v := BandTypeUnspecified; v <= KeltnerChannels; v++ {
+		if v.String() == string(text) {
+			*b = v
+			return nil
+		}
+	}
+	return errs.Errorf("bad band type: %q", text)
+}
+
+// MarshalText implements encoding.TextMarshaler.
+func (d DrawingType) MarshalText() ([]byte, error) {
+	return []byte(d.String()), nil
+}
+
+// UnmarshalText implements encoding.TextUnmarshaler.
+func (d *DrawingType) UnmarshalText(text []byte) error {
+	for v := DrawingTypeUnspecified; v <= Rectangle; v++ {
+		if v.String() == string(text) {
+			*d = v
+			return nil
+		}
+	}
+	return errs.Errorf("bad drawing type: %q", text)
+}
+
+// MarshalText implements encoding.TextMarshaler.
+func (a AlertType) MarshalText() ([]byte, error) {
+	return []byte(a.String()), nil
+}
+
+// UnmarshalText implements encoding.TextUnmarshaler.
+func (a *AlertType) UnmarshalText(text []byte) error {
+	for v := AlertTypeUnspecified; v <= MovingAverageCross; v++ {
+		if v.String() == string(text) {
+			*a = v
+			return nil
+		}
+	}
+	return errs.Errorf("bad alert type: %q", text)
+}
@@ -0,0 +1,86 @@
+package model
+
+import (
+	"encoding"
+	"testing"
+)
+
+func TestText(t *testing.T) {
+	for _, tt := range []struct {
+		desc    string
+		value   encoding.TextMarshaler
+		out     encoding.TextUnmarshaler
+		want    string
+		wantErr bool
+	}{
+		{
+			desc:  "interval",
+			value: Weekly,
+			out:   new(Interval),
+			want:  "Weekly",
+		},
+		{
+			desc:  "moving average type",
+			value: Exponential,
+			out:   new(MovingAverageType),
+			want:  "Exponential",
+		},
+		{
+			desc:  "band type",
+			value: KeltnerChannels,
+			out:   new(BandType),
+			want:  "KeltnerChannels",
+		},
+		{
+			desc:  "drawing type",
+			value: HorizontalLine,
+			out:   new(DrawingType),
+			want:  "HorizontalLine",
+		},
+		{
+			desc:  "alert type",
+			value: MovingAverageCross,
+			out:   new(AlertType),
+			want:  "MovingAverageCross",
+		},
+		{
+			desc:  "adjustment",
+			value: TotalReturn,
+			out:   new(Adjustment),
+			want:  "TotalReturn",
+		},
+		{
+			desc:  "unspecified values round trip too",
+			value: IntervalUnspecified,
+			out:   new(Interval),
+			want:  "IntervalUnspecified",
+		},
+	} {
+		t.Run(tt.desc, func(t *testing.T) {
+			text, err := tt.value.MarshalText()
+			if err != nil {
+				t.Fatalf("MarshalText should not return an error: %v", err)
+			}
+
+			if got := string(text); got != tt.want {
+				t.Errorf("got %q, want %q", got, tt.want)
+			}
+
+			if err := tt.out.UnmarshalText(text); err != nil {
+				t.Fatalf("UnmarshalText should not return an error: %v", err)
+			}
+
+			if got, _ := tt.out.(encoding.TextMarshaler).MarshalText(); string(got) != tt.want {
+				t.Errorf("got %q after round trip, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestUnmarshalText_BadText(t *testing.T) {
+	for _, text := range []string{"", "daily", "Interval(9)"} {
+		if err := new(Interval).UnmarshalText([]byte(text)); err == nil {
+			t.Errorf("UnmarshalText(%q) should return an error.", text)
+		}
+	}
+}
@@ -0,0 +1,23 @@
+// Package notify shows desktop notifications using the tools that come with each platform.
+package notify
+
+import "github.com/btmura/ponzi2/internal/errs"
+
+// Send shows a desktop notification with the title and message.
+// It returns after starting the notification without waiting for it to be dismissed.
+func Send(title, message string) error {
+	// command is implemented per platform and returns nil if the platform is not supported.
+	cmd := command(title, message)
+	if cmd == nil {
+		return errs.Errorf("desktop notifications are not supported on this platform")
+	}
+
+	if err := cmd.Start(); err != nil {
+		return errs.Errorf("starting %s failed: %v", cmd.Path, err)
+	}
+
+	// Reap the process in the background, since nothing cares about the result.
+	go cmd.Wait()
+
+	return nil
+}
@@ -0,0 +1,12 @@
+package notify
+
+import (
+	"fmt"
+	"os/exec"
+	"strconv"
+)
+
+func command(title, message string) *exec.Cmd {
+	script := fmt.Sprintf("display notification %s with title %s", strconv.Quote(message), strconv.Quote(title))
+	return exec.Command("osascript", "-e", script)
+}
@@ -0,0 +1,7 @@
+package notify
+
+import "os/exec"
+
+func command(title, message string) *exec.Cmd {
+	return exec.Command("notify-send", "--app-name=ponzi2", title, message)
+}
@@ -0,0 +1,10 @@
+//go:build !linux && !darwin && !windows
+// +build !linux,!darwin,!windows
+
+package notify
+
+import "os/exec"
+
+func command(title, message string) *exec.Cmd {
+	return nil
+}
@@ -0,0 +1,27 @@
+package notify
+
+import (
+	"fmt"
+	"os/exec"
+	"strings"
+)
+
+// script shows a balloon tip from the notification area, since it works without extra modules.
+const script = `
+Add-Type -AssemblyName System.Windows.Forms
+$n = New-Object System.Windows.Forms.NotifyIcon
+$n.Icon = [System.Drawing.SystemIcons]::Information
+$n.Visible = $true
+$n.ShowBalloonTip(10000, %s, %s, 'Info')
+Start-Sleep -Seconds 10
+$n.Dispose()
+`
+
+func command(title, message string) *exec.Cmd {
+	return exec.Command("powershell", "-NoProfile", "-NonInteractive", "-Command", fmt.Sprintf(script, quote(title), quote(message)))
+}
+
+// quote returns a single quoted PowerShell string literal.
+func quote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", "''") + "'"
+}
@@ -25,6 +25,11 @@ func New(icon *gfx.VAO) *Button {
 	}
 }
 
+// SetIcon changes the icon, which is useful to show whether a toggle button is on.
+func (b *Button) SetIcon(icon *gfx.VAO) {
+	b.icon = icon
+}
+
 func (b *Button) StartSpinning() {
 	b.spinning.Start()
 }
@@ -0,0 +1,303 @@
+package chart
+
+import (
+	"image"
+	"math"
+
+	"github.com/btmura/ponzi2/internal/app/gfx"
+	"github.com/btmura/ponzi2/internal/app/model"
+	"github.com/btmura/ponzi2/internal/app/view"
+	"github.com/btmura/ponzi2/internal/app/view/status"
+	"github.com/btmura/ponzi2/internal/app/view/vao"
+)
+
+// Alert lines are purple when armed, gray after triggering, and yellow under the mouse.
+var (
+	alertLineVAO          = vao.HorizLine(view.Purple, view.Purple)
+	triggeredAlertLineVAO = vao.HorizLine(view.LightGray, view.LightGray)
+	selectedAlertLineVAO  = vao.HorizLine(view.Yellow, view.Yellow)
+)
+
+// alertLayer renders lines at the prices of price alerts and lets the user add and
+// remove alerts by clicking on the prices while alert mode is active.
+//
+// Clicking on an empty spot adds a price alert. Shift-clicking adds a percent change
+// alert for the percent between the price and the previous close. Clicking on a moving
+// average of a daily chart toggles an alert for when the price crosses it.
+// Clicking on an alert line removes the alert. Percent change alerts have lines
+// above and below the previous close.
+type alertLayer struct {
+	// renderable is true if this should be rendered.
+	renderable bool
+
+	// interval is the interval of the chart. Moving average alerts use daily charts.
+	interval model.Interval
+
+	// tradingSessions are used to find the moving average values under the mouse.
+	tradingSessions []*model.TradingSession
+
+	// movingAverageSeriesSet are the moving averages that can be clicked for alerts.
+	movingAverageSeriesSet []*model.MovingAverageSeries
+
+	// priceRange is used to convert between prices and y coordinates.
+	priceRange [2]float32
+
+	// prevClose is the previous close that percent change alerts are relative to. Zero if unknown.
+	prevClose float32
+
+	// alerts are all the alerts for the symbol. Indices match the data passed to SetData.
+	alerts []*model.Alert
+
+	// active is whether clicks add and remove alerts.
+	active bool
+
+	// selectedIndex is the index of the alert line under the mouse. -1 if none.
+	selectedIndex int
+
+	// bounds is the rectangle where the prices are drawn.
+	bounds image.Rectangle
+
+	// addCallback is fired when the user adds an alert.
+	addCallback func(a *model.Alert)
+
+	// removeCallback is fired with the index of the alert the user wants removed.
+	removeCallback func(index int)
+}
+
+func newAlertLayer() *alertLayer {
+	return &alertLayer{selectedIndex: -1}
+}
+
+type alertLayerData struct {
+	Interval               model.Interval
+	Quote                  *model.Quote
+	TradingSessionSeries   *model.TradingSessionSeries
+	MovingAverageSeriesSet []*model.MovingAverageSeries
+	BandSeriesSet          []*model.BandSeries
+	Alerts                 []*model.Alert
+}
+
+func (a *alertLayer) SetData(data alertLayerData) {
+	// Reset everything.
+	a.Close()
+
+	// Bail out if there is no data yet.
+	ts := data.TradingSessionSeries
+	if ts == nil || len(ts.TradingSessions) == 0 {
+		return
+	}
+
+	a.interval = data.Interval
+	a.tradingSessions = ts.TradingSessions
+	a.movingAverageSeriesSet = data.MovingAverageSeriesSet
+	a.priceRange = priceRange(ts.TradingSessions, data.BandSeriesSet)
+	a.alerts = data.Alerts
+
+	if q := data.Quote; q != nil && q.LatestPrice > 0 {
+		a.prevClose = q.LatestPrice - q.Change
+	}
+
+	a.renderable = true
+}
+
+// SetActive sets whether clicks add and remove alerts.
+func (a *alertLayer) SetActive(active bool) {
+	a.active = active
+}
+
+func (a *alertLayer) SetBounds(bounds image.Rectangle) {
+	a.bounds = bounds
+}
+
+func (a *alertLayer) ProcessInput(input *view.Input) {
+	a.selectedIndex = -1
+
+	if !a.renderable || !a.active || !input.MousePos.In(a.bounds) {
+		return
+	}
+
+	mouseY := input.MousePos.Y
+	a.selectedIndex = a.alertAt(mouseY)
+
+	if !input.MouseLeftButtonClicked.In(a.bounds) {
+		return
+	}
+
+	if a.selectedIndex >= 0 {
+		a.fireRemove(input, a.selectedIndex)
+		return
+	}
+
+	yPercent := float32(mouseY-a.bounds.Min.Y) / float32(a.bounds.Dy())
+	price := priceValue(a.priceRange, yPercent)
+
+	if input.MouseLeftButtonClicked.Shift {
+		if a.prevClose <= 0 || price <= 0 {
+			return
+		}
+
+		// Round to the hundredths of a percent that alerts are shown with.
+		v := math.Abs(float64(price/a.prevClose - 1))
+		v = math.Round(v*10000) / 10000
+		if v == 0 {
+			return
+		}
+
+		a.fireAdd(input, &model.Alert{Type: model.PercentChange, Value: float32(v)})
+		return
+	}
+
+	if ma := a.movingAverageAt(input.MousePos.Point); ma != nil {
+		for i, al := range a.alerts {
+			if al.Type == model.MovingAverageCross && al.MovingAverageType == ma.Type && al.MovingAverageIntervals == ma.Intervals {
+				a.fireRemove(input, i)
+				return
+			}
+		}
+
+		a.fireAdd(input, &model.Alert{
+			Type:                   model.MovingAverageCross,
+			MovingAverageType:      ma.Type,
+			MovingAverageIntervals: ma.Intervals,
+		})
+		return
+	}
+
+	alertType := model.PriceBelow
+	if last := a.tradingSessions[len(a.tradingSessions)-1]; price > last.Close {
+		alertType = model.PriceAbove
+	}
+
+	a.fireAdd(input, &model.Alert{Type: alertType, Value: price})
+}
+
+func (a *alertLayer) fireAdd(input *view.Input, al *model.Alert) {
+	input.AddFiredCallback(func() {
+		if a.addCallback != nil {
+			a.addCallback(al)
+		}
+	})
+}
+
+func (a *alertLayer) fireRemove(input *view.Input, index int) {
+	input.AddFiredCallback(func() {
+		if a.removeCallback != nil {
+			a.removeCallback(index)
+		}
+	})
+}
+
+// movingAverageAt returns the moving average drawn near the point on a daily chart or nil if none.
+func (a *alertLayer) movingAverageAt(pt image.Point) *model.MovingAverageSeries {
+	if a.interval != model.Daily {
+		return nil
+	}
+
+	i, _ := tradingSessionAtX(a.tradingSessions, a.bounds, pt.X)
+	for _, ma := range a.movingAverageSeriesSet {
+		if i < 0 || i >= len(ma.Values) || ma.Values[i].Value <= 0 {
+			continue
+		}
+
+		y := a.bounds.Min.Y + int(float32(a.bounds.Dy())*pricePercent(a.priceRange, ma.Values[i].Value))
+		if abs(y-pt.Y) <= drawingHitDistance {
+			return ma
+		}
+	}
+	return nil
+}
+
+// alertAt returns the index of the alert with a line near the y coordinate or -1 if none.
+func (a *alertLayer) alertAt(y int) int {
+	for i, al := range a.alerts {
+		for _, ay := range a.alertYs(al) {
+			if abs(ay-y) <= drawingHitDistance {
+				return i
+			}
+		}
+	}
+	return -1
+}
+
+// alertYs returns the y coordinates of an alert's lines that are within the bounds.
+func (a *alertLayer) alertYs(al *model.Alert) []int {
+	var prices []float32
+	switch al.Type {
+	case model.PriceAbove, model.PriceBelow:
+		prices = append(prices, al.Value)
+
+	case model.PercentChange:
+		if a.prevClose > 0 {
+			prices = append(prices, a.prevClose*(1+al.Value), a.prevClose*(1-al.Value))
+		}
+	}
+
+	var ys []int
+	for _, price := range prices {
+		p := pricePercent(a.priceRange, price)
+		if p < 0 || p > 1 {
+			continue
+		}
+		ys = append(ys, a.bounds.Min.Y+int(float32(a.bounds.Dy())*p))
+	}
+	return ys
+}
+
+func (a *alertLayer) Render(float32) {
+	if !a.renderable {
+		return
+	}
+
+	r := a.bounds
+	for i, al := range a.alerts {
+		line, color := alertLineVAO, view.Purple
+		switch {
+		case i == a.selectedIndex:
+			line, color = selectedAlertLineVAO, view.Yellow
+		case al.Triggered:
+			line, color = triggeredAlertLineVAO, view.LightGray
+		}
+
+		for _, y := range a.alertYs(al) {
+			gfx.SetModelMatrixRect(image.Rect(r.Min.X, y, r.Max.X, y))
+			line.Render()
+
+			pt := image.Pt(r.Min.X+axisLabelPadding, y+axisLabelPadding)
+			axisLabelTextRenderer.Render(status.Alert(al), pt, gfx.TextColor(color))
+		}
+	}
+}
+
+func (a *alertLayer) Close() {
+	a.renderable = false
+	a.tradingSessions = nil
+	a.movingAverageSeriesSet = nil
+	a.alerts = nil
+	a.prevClose = 0
+	a.selectedIndex = -1
+}
+
+// alertIconVAO returns an exclamation mark icon for the header's alert button.
+func alertIconVAO(color view.Color) *gfx.VAO {
+	data := &gfx.VAOVertexData{
+		Mode: gfx.Lines,
+		Vertices: []float32{
+			0, 0.4, 0, // 0
+			0, -0.15, 0, // 1
+			0, -0.3, 0, // 2
+			0, -0.4, 0, // 3
+		},
+		Indices: []uint16{0, 1, 2, 3},
+	}
+	for i := 0; i < 4; i++ {
+		data.Colors = append(data.Colors, color[0], color[1], color[2], color[3])
+	}
+	return gfx.NewVAO(data)
+}
+
+func abs(v int) int {
+	if v < 0 {
+		return -v
+	}
+	return v
+}
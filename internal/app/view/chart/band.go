@@ -0,0 +1,150 @@
+package chart
+
+import (
+	"image"
+
+	"github.com/btmura/ponzi2/internal/app/gfx"
+	"github.com/btmura/ponzi2/internal/app/model"
+	"github.com/btmura/ponzi2/internal/app/view"
+	"github.com/btmura/ponzi2/internal/app/view/vao"
+)
+
+// bandFillAlpha is the alpha of the fill between the upper and lower bands.
+const bandFillAlpha = 0.1
+
+// band renders a price band's upper and lower lines with a translucent fill between them.
+type band struct {
+	renderable bool
+	color      view.Color
+	upperLine  *gfx.VAO
+	lowerLine  *gfx.VAO
+	fill       *gfx.VAO
+	bounds     image.Rectangle
+}
+
+func newBand(color view.Color) *band {
+	return &band{color: color}
+}
+
+type bandData struct {
+	TradingSessionSeries *model.TradingSessionSeries
+	BandSeriesSet        []*model.BandSeries
+	BandSeries           *model.BandSeries
+}
+
+func (b *band) SetData(data bandData) {
+	// Reset everything.
+	b.Close()
+
+	// Bail out if there is not enough data yet.
+	ts := data.TradingSessionSeries
+	if ts == nil {
+		return
+	}
+
+	bs := data.BandSeries
+	if bs == nil {
+		return
+	}
+
+	yRange := priceRange(ts.TradingSessions, data.BandSeriesSet)
+
+	var uppers, lowers []float32
+	for _, v := range bs.Values {
+		uppers = append(uppers, bandPercent(yRange, v.Upper))
+		lowers = append(lowers, bandPercent(yRange, v.Lower))
+	}
+
+	b.upperLine = vao.DataLine(uppers, b.color)
+	b.lowerLine = vao.DataLine(lowers, b.color)
+	b.fill = bandFillVAO(uppers, lowers, b.color)
+
+	b.renderable = true
+}
+
+func (b *band) SetBounds(bounds image.Rectangle) {
+	b.bounds = bounds
+}
+
+func (b *band) Render(float32) {
+	if !b.renderable {
+		return
+	}
+	gfx.SetModelMatrixRect(b.bounds)
+	b.fill.Render()
+	b.upperLine.Render()
+	b.lowerLine.Render()
+}
+
+func (b *band) Close() {
+	b.renderable = false
+	if b.upperLine != nil {
+		b.upperLine.Delete()
+		b.upperLine = nil
+	}
+	if b.lowerLine != nil {
+		b.lowerLine.Delete()
+		b.lowerLine = nil
+	}
+	if b.fill != nil {
+		b.fill.Delete()
+		b.fill = nil
+	}
+}
+
+// bandPercent is like pricePercent but returns zero for missing values.
+func bandPercent(yRange [2]float32, value float32) float32 {
+	if value <= 0 {
+		return 0
+	}
+	return pricePercent(yRange, value)
+}
+
+// bandFillVAO returns a VAO of quads between adjacent points where both bands have values.
+func bandFillVAO(uppers, lowers []float32, color view.Color) *gfx.VAO {
+	if len(uppers) < 2 {
+		return gfx.EmptyVAO()
+	}
+
+	dx := 2.0 / float32(len(uppers)) // (-1 to 1) on X-axis
+	xc := func(i int) float32 {
+		return -1.0 + dx*float32(i) + dx*0.5
+	}
+	yc := func(v float32) float32 {
+		return 2.0*v - 1.0
+	}
+	valid := func(i int) bool {
+		return uppers[i] > 0 && lowers[i] > 0
+	}
+
+	c := color
+	c[3] = bandFillAlpha
+
+	data := &gfx.VAOVertexData{Mode: gfx.Triangles}
+	for i := 1; i < len(uppers); i++ {
+		if !valid(i-1) || !valid(i) {
+			continue
+		}
+
+		idx := uint16(len(data.Vertices) / 3)
+		data.Vertices = append(data.Vertices,
+			xc(i-1), yc(uppers[i-1]), 0, // 0
+			xc(i-1), yc(lowers[i-1]), 0, // 1
+			xc(i), yc(uppers[i]), 0, // 2
+			xc(i), yc(lowers[i]), 0, // 3
+		)
+		for j := 0; j < 4; j++ {
+			data.Colors = append(data.Colors, c[0], c[1], c[2], c[3])
+		}
+		data.Indices = append(data.Indices,
+			idx, idx+1, idx+2,
+			idx+2, idx+1, idx+3,
+		)
+	}
+
+	if len(data.Vertices) == 0 {
+		return gfx.EmptyVAO()
+	}
+
+	return gfx.NewVAO(data)
+}
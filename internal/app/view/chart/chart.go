@@ -3,6 +3,7 @@ package chart
 import (
 	"image"
 	"math"
+	"time"
 
 	"golang.org/x/image/font/gofont/goregular"
 
@@ -25,6 +26,12 @@ const (
 	chartSectionPadding = 5
 	chartTextPadding    = 20
 	chartVolumePercent  = 0.25
+
+	// chartIndicatorVolumePercent is the volume percent when indicators are shown.
+	chartIndicatorVolumePercent = 0.15
+
+	// chartIndicatorPercent is the percent of each indicator panel.
+	chartIndicatorPercent = 0.12
 )
 
 var (
@@ -44,19 +51,93 @@ var (
 	cursorVertLine  = vao.VertLine(view.LightGray, view.LightGray)
 )
 
-var movingAverageColors = map[model.Interval]map[int]view.Color{
+// defaultMovingAverageSettings are the moving averages to show per interval
+// when the user has not configured any.
+var defaultMovingAverageSettings = map[model.Interval][]*MovingAverageSetting{
 	model.Daily: {
-		8:   view.Purple,
-		21:  view.Green,
-		50:  view.Red,
-		200: view.White,
+		{MovingAverageSetting: model.MovingAverageSetting{Type: model.Exponential, Intervals: 8}, Color: view.Purple},
+		{MovingAverageSetting: model.MovingAverageSetting{Type: model.Exponential, Intervals: 21}, Color: view.Green},
+		{MovingAverageSetting: model.MovingAverageSetting{Type: model.Simple, Intervals: 50}, Color: view.Red},
+		{MovingAverageSetting: model.MovingAverageSetting{Type: model.Simple, Intervals: 200}, Color: view.White},
 	},
 	model.Weekly: {
-		10: view.Red,
-		40: view.White,
+		{MovingAverageSetting: model.MovingAverageSetting{Type: model.Simple, Intervals: 10}, Color: view.Red},
+		{MovingAverageSetting: model.MovingAverageSetting{Type: model.Simple, Intervals: 40}, Color: view.White},
+	},
+	model.Monthly: {
+		{MovingAverageSetting: model.MovingAverageSetting{Type: model.Simple, Intervals: 10}, Color: view.Red},
+		{MovingAverageSetting: model.MovingAverageSetting{Type: model.Simple, Intervals: 20}, Color: view.White},
+	},
+}
+
+// MovingAverageSetting specifies a moving average to show and its color.
+type MovingAverageSetting struct {
+	model.MovingAverageSetting
+	Color view.Color
+}
+
+// DefaultMovingAverageSettings returns the moving averages to show for an interval
+// when the user has not configured any.
+func DefaultMovingAverageSettings(interval model.Interval) []*MovingAverageSetting {
+	var ss []*MovingAverageSetting
+	for _, s := range defaultMovingAverageSettings[interval] {
+		sCopy := *s
+		ss = append(ss, &sCopy)
+	}
+	return ss
+}
+
+// movingAverageColor returns the color of a moving average using the given settings
+// or the interval's default settings if the given settings do not have it.
+func movingAverageColor(settings []*MovingAverageSetting, interval model.Interval, ma *model.MovingAverageSeries) view.Color {
+	for _, ss := range [][]*MovingAverageSetting{settings, defaultMovingAverageSettings[interval]} {
+		for _, s := range ss {
+			if s.Type == ma.Type && s.Intervals == ma.Intervals {
+				return s.Color
+			}
+		}
+	}
+	return view.White
+}
+
+// defaultBandSettings are the price bands to show per interval
+// when the user has not configured any.
+var defaultBandSettings = map[model.Interval][]*BandSetting{
+	model.Daily: {
+		{BandSetting: model.BandSetting{Type: model.BollingerBands, Intervals: 20, Width: 2}, Color: view.Blue},
 	},
 }
 
+// BandSetting specifies a price band to show and its color.
+type BandSetting struct {
+	model.BandSetting
+	Color view.Color
+}
+
+// DefaultBandSettings returns the price bands to show for an interval
+// when the user has not configured any.
+func DefaultBandSettings(interval model.Interval) []*BandSetting {
+	var ss []*BandSetting
+	for _, s := range defaultBandSettings[interval] {
+		sCopy := *s
+		ss = append(ss, &sCopy)
+	}
+	return ss
+}
+
+// bandColor returns the color of a price band using the given settings
+// or the interval's default settings if the given settings do not have it.
+func bandColor(settings []*BandSetting, interval model.Interval, b *model.BandSeries) view.Color {
+	for _, ss := range [][]*BandSetting{settings, defaultBandSettings[interval]} {
+		for _, s := range ss {
+			if s.Type == b.Type && s.Intervals == b.Intervals && s.Width == b.Width {
+				return s.Color
+			}
+		}
+	}
+	return view.Blue
+}
+
 // PriceStyle is visual style of the chart's prices.
 type PriceStyle int
 
@@ -94,11 +175,25 @@ type Chart struct {
 
 	movingAverages []*movingAverage
 
+	bands []*band
+
+	// drawingLayer renders the user's drawings over the prices.
+	drawingLayer *drawingLayer
+
+	// alertLayer renders the price alerts and lets the user add and remove alerts.
+	alertLayer *alertLayer
+
+	// positionLayer renders the cost basis of the user's position and lets the user add and remove lots.
+	positionLayer *positionLayer
+
 	volume         *volume
 	volumeLevel    *volumeLevel
 	volumeCursor   *volumeCursor
 	volumeTimeline *timeline
 
+	// indicatorPanels are the panels below the volume ordered from top to bottom.
+	indicatorPanels []*indicatorPanel
+
 	timelineAxis   *timelineAxis
 	timelineCursor *timelineCursor
 
@@ -125,6 +220,19 @@ type Chart struct {
 	// showMovingAverages is whether to render the moving averages.
 	showMovingAverages bool
 
+	// showDrawings is whether to render the drawings. Drawings are anchored to days,
+	// so they are not shown on intraday charts.
+	showDrawings bool
+
+	// drawingType is the type of drawing the user is adding. Unspecified if not drawing.
+	drawingType model.DrawingType
+
+	// alertMode is whether clicks on the prices add and remove alerts.
+	alertMode bool
+
+	// lotMode is whether clicks on the prices add and remove lots.
+	lotMode bool
+
 	// bounds is the rect with global coords that should be drawn within.
 	bounds image.Rectangle
 
@@ -145,15 +253,20 @@ func NewChart(priceStyle PriceStyle) *Chart {
 		return nil
 	}
 
-	return &Chart{
+	ch := &Chart{
 		frameBubble: rect.NewBubble(chartRounding),
 		header: newHeader(&headerArgs{
 			SymbolQuoteTextRenderer: chartSymbolQuoteTextRenderer,
 			QuotePrinter:            chartQuotePrinter,
+			PositionPrinter:         status.Position,
 			ShowBarButton:           true,
 			ShowCandlestickButton:   true,
 			ShowRefreshButton:       true,
 			ShowAddButton:           true,
+			ShowDrawingButtons:      true,
+			ShowAlertButton:         true,
+			ShowLotButton:           true,
+			ShowAdjustmentButton:    true,
 			Rounding:                chartRounding,
 			Padding:                 chartSectionPadding,
 		}),
@@ -171,6 +284,10 @@ func NewChart(priceStyle PriceStyle) *Chart {
 		timelineAxis:   new(timelineAxis),
 		timelineCursor: new(timelineCursor),
 
+		drawingLayer:  newDrawingLayer(),
+		alertLayer:    newAlertLayer(),
+		positionLayer: newPositionLayer(),
+
 		legend: newLegend(),
 
 		loadingTextBox: text.NewBox(chartSymbolQuoteTextRenderer, "LOADING...", text.Padding(chartTextPadding)),
@@ -178,6 +295,68 @@ func NewChart(priceStyle PriceStyle) *Chart {
 		loading:        true,
 		fadeIn:         animation.New(1 * view.FPS),
 	}
+
+	ch.header.SetTrendLineButtonClickCallback(func() {
+		ch.toggleDrawingType(model.TrendLine)
+	})
+	ch.header.SetHorizontalLineButtonClickCallback(func() {
+		ch.toggleDrawingType(model.HorizontalLine)
+	})
+	ch.header.SetRectangleButtonClickCallback(func() {
+		ch.toggleDrawingType(model.Rectangle)
+	})
+	ch.header.SetAlertButtonClickCallback(func() {
+		ch.setAlertMode(!ch.alertMode)
+	})
+	ch.header.SetLotButtonClickCallback(func() {
+		ch.setLotMode(!ch.lotMode)
+	})
+
+	return ch
+}
+
+// toggleDrawingType starts drawing the given type or stops drawing if already drawing it.
+func (ch *Chart) toggleDrawingType(drawingType model.DrawingType) {
+	if ch.drawingType == drawingType {
+		drawingType = model.DrawingTypeUnspecified
+	}
+	ch.setDrawingType(drawingType)
+}
+
+func (ch *Chart) setDrawingType(drawingType model.DrawingType) {
+	ch.drawingType = drawingType
+	ch.header.SetDrawingType(drawingType)
+	ch.drawingLayer.SetDrawingType(drawingType)
+
+	// Clicks can only draw, add alerts, or add lots at a time.
+	if drawingType != model.DrawingTypeUnspecified {
+		ch.setAlertMode(false)
+		ch.setLotMode(false)
+	}
+}
+
+func (ch *Chart) setAlertMode(alertMode bool) {
+	ch.alertMode = alertMode
+	ch.header.SetAlertMode(alertMode)
+	ch.alertLayer.SetActive(alertMode)
+
+	// Clicks can only draw, add alerts, or add lots at a time.
+	if alertMode {
+		ch.setDrawingType(model.DrawingTypeUnspecified)
+		ch.setLotMode(false)
+	}
+}
+
+func (ch *Chart) setLotMode(lotMode bool) {
+	ch.lotMode = lotMode
+	ch.header.SetLotMode(lotMode)
+	ch.positionLayer.SetActive(lotMode)
+
+	// Clicks can only draw, add alerts, or add lots at a time.
+	if lotMode {
+		ch.setDrawingType(model.DrawingTypeUnspecified)
+		ch.setAlertMode(false)
+	}
 }
 
 // SetPriceStyle sets the chart's price style.
@@ -216,6 +395,29 @@ type Data struct {
 
 	// Chart is optional chart data. Nil when data hasn't been received yet.
 	Chart *model.Chart
+
+	// MovingAverageSettings are optional colors for the chart's moving averages.
+	// Default colors are used for moving averages without settings.
+	MovingAverageSettings []*MovingAverageSetting
+
+	// BandSettings are optional colors for the chart's price bands.
+	// Default colors are used for price bands without settings.
+	BandSettings []*BandSetting
+
+	// Indicators are the optional indicators to show below the volume from top to bottom.
+	Indicators []Indicator
+
+	// Drawings are the optional user drawings to show over the prices in the order they were added.
+	Drawings []*model.Drawing
+
+	// Alerts are the optional user alerts in the order they were added.
+	Alerts []*model.Alert
+
+	// Position is the optional position of the user's lots. Nil if the user has no lots.
+	Position *model.Position
+
+	// Adjustment is how the chart's prices are adjusted. Unspecified means split-adjusted.
+	Adjustment model.Adjustment
 }
 
 // SetData sets the data to be shown on the chart.
@@ -235,8 +437,11 @@ func (ch *Chart) SetData(data Data) {
 	switch dc.Interval {
 	case model.Intraday:
 		ch.showMovingAverages = false
-	case model.Daily, model.Weekly:
+		ch.showDrawings = false
+		ch.setDrawingType(model.DrawingTypeUnspecified)
+	case model.Daily, model.Weekly, model.Monthly:
 		ch.showMovingAverages = true
+		ch.showDrawings = true
 	default:
 		logger.Errorf("bad interval: %v", dc.Interval)
 		return
@@ -244,9 +449,11 @@ func (ch *Chart) SetData(data Data) {
 
 	ts := dc.TradingSessionSeries
 
-	ch.price.SetData(priceData{ts})
-	ch.priceLevel.SetData(priceLevelData{ts})
-	ch.priceCursor.SetData(priceCursorData{ts})
+	bs := dc.BandSeriesSet
+
+	ch.price.SetData(priceData{ts, bs})
+	ch.priceLevel.SetData(priceLevelData{ts, bs})
+	ch.priceCursor.SetData(priceCursorData{ts, bs})
 	ch.priceTimeline.SetData(timelineData{dc.Interval, ts})
 
 	if ch.showMovingAverages {
@@ -256,21 +463,58 @@ func (ch *Chart) SetData(data Data) {
 
 		ch.movingAverages = nil
 		for _, ma := range dc.MovingAverageSeriesSet {
-			m := newMovingAverage(movingAverageColors[dc.Interval][ma.Intervals])
-			m.SetData(movingAverageData{ts, ma})
+			m := newMovingAverage(movingAverageColor(data.MovingAverageSettings, dc.Interval, ma))
+			m.SetData(movingAverageData{ts, bs, ma})
 			ch.movingAverages = append(ch.movingAverages, m)
 		}
+
+		for _, b := range ch.bands {
+			b.Close()
+		}
+
+		ch.bands = nil
+		for _, bd := range bs {
+			b := newBand(bandColor(data.BandSettings, dc.Interval, bd))
+			b.SetData(bandData{ts, bs, bd})
+			ch.bands = append(ch.bands, b)
+		}
 	}
 
+	ch.drawingLayer.SetData(drawingLayerData{ts, bs, data.Drawings})
+	ch.alertLayer.SetData(alertLayerData{dc.Interval, data.Quote, ts, dc.MovingAverageSeriesSet, bs, data.Alerts})
+	ch.positionLayer.SetData(positionLayerData{dc.Interval, ts, bs, data.Position})
+
 	ch.volume.SetData(volumeData{ts, dc.AverageVolumeSeries})
 	ch.volumeLevel.SetData(volumeLevelData{ts})
 	ch.volumeCursor.SetData(volumeCursorData{ts})
 	ch.volumeTimeline.SetData(timelineData{dc.Interval, ts})
 
+	for _, p := range ch.indicatorPanels {
+		p.Close()
+	}
+
+	ch.indicatorPanels = nil
+	for _, ind := range data.Indicators {
+		d, ok := makeIndicatorData(ind, dc)
+		if !ok {
+			continue
+		}
+		p := newIndicatorPanel()
+		p.SetData(indicatorPanelData{dc.Interval, ts, d})
+		ch.indicatorPanels = append(ch.indicatorPanels, p)
+	}
+
 	ch.timelineAxis.SetData(timelineAxisData{dc.Interval, ts})
 	ch.timelineCursor.SetData(timelineCursorData{dc.Interval, ts})
 
-	ch.legend.SetData(legendData{dc.Interval, ts, dc.MovingAverageSeriesSet})
+	ch.legend.SetData(legendData{
+		Interval:               dc.Interval,
+		TradingSessionSeries:   ts,
+		MovingAverageSeriesSet: dc.MovingAverageSeriesSet,
+		MovingAverageSettings:  data.MovingAverageSettings,
+		BandSeriesSet:          bs,
+		BandSettings:           data.BandSettings,
+	})
 }
 
 func (ch *Chart) SetBounds(bounds image.Rectangle) {
@@ -290,17 +534,35 @@ func (ch *Chart) ProcessInput(input *view.Input) {
 	// Calculate percentage needed for each section.
 	timeLabelsPercent := float32(ch.timelineAxis.MaxLabelSize.Y+chartSectionPadding*2) / float32(r.Dy())
 
-	// Divide up the rectangle into sections.
-	rects := rect.Slice(r, timeLabelsPercent, chartVolumePercent)
+	volumePercent := float32(chartVolumePercent)
+	if len(ch.indicatorPanels) != 0 {
+		volumePercent = chartIndicatorVolumePercent
+	}
 
-	pr, vr, tr := rects[2], rects[1], rects[0]
+	// Divide up the rectangle into sections from the bottom up.
+	percents := []float32{timeLabelsPercent}
+	for range ch.indicatorPanels {
+		percents = append(percents, chartIndicatorPercent)
+	}
+	percents = append(percents, volumePercent)
+	rects := rect.Slice(r, percents...)
+
+	n := len(rects)
+	pr, vr, tr := rects[n-1], rects[n-2], rects[0]
+
+	// Indicator rects are ordered from bottom to top like the slices.
+	irs := rects[1 : n-2]
 
 	ch.sectionDividers = []image.Rectangle{vr, tr}
+	ch.sectionDividers = append(ch.sectionDividers, irs...)
 
 	// Pad all the rects.
 	pr = pr.Inset(chartSectionPadding)
 	vr = vr.Inset(chartSectionPadding)
 	tr = tr.Inset(chartSectionPadding)
+	for i := range irs {
+		irs[i] = irs[i].Inset(chartSectionPadding)
+	}
 
 	// Create separate rects for each section's labels shown on the right.
 	plr, vlr := pr, vr
@@ -310,6 +572,11 @@ func (ch *Chart) ProcessInput(input *view.Input) {
 	if w := ch.volumeLevel.MaxLabelSize.X; w > maxWidth {
 		maxWidth = w
 	}
+	for _, p := range ch.indicatorPanels {
+		if w := p.MaxLabelSize().X; w > maxWidth {
+			maxWidth = w
+		}
+	}
 
 	// Set left side of label rects.
 	plr.Min.X = pr.Max.X - maxWidth
@@ -319,6 +586,14 @@ func (ch *Chart) ProcessInput(input *view.Input) {
 	pr.Max.X = plr.Min.X - chartSectionPadding
 	vr.Max.X = vlr.Min.X - chartSectionPadding
 
+	for i, p := range ch.indicatorPanels {
+		ir := irs[len(irs)-1-i]
+		ilr := ir
+		ilr.Min.X = ir.Max.X - maxWidth
+		ir.Max.X = ilr.Min.X - chartSectionPadding
+		p.SetBounds(ir, ilr)
+	}
+
 	// Time labels and its cursors labels overlap and use the same rect.
 	tr.Max.X = plr.Min.X
 	tlr := tr
@@ -332,6 +607,14 @@ func (ch *Chart) ProcessInput(input *view.Input) {
 		ma.SetBounds(pr)
 	}
 
+	for _, b := range ch.bands {
+		b.SetBounds(pr)
+	}
+
+	ch.drawingLayer.SetBounds(pr)
+	ch.alertLayer.SetBounds(pr)
+	ch.positionLayer.SetBounds(pr)
+
 	ch.volume.SetBounds(vr)
 	ch.volumeLevel.SetBounds(vr, vlr)
 	ch.volumeCursor.SetBounds(vr, vlr)
@@ -342,8 +625,16 @@ func (ch *Chart) ProcessInput(input *view.Input) {
 
 	ch.legend.SetBounds(pr)
 
+	if ch.showDrawings {
+		ch.drawingLayer.ProcessInput(input)
+	}
+	ch.alertLayer.ProcessInput(input)
+	ch.positionLayer.ProcessInput(input)
 	ch.priceCursor.ProcessInput(input)
 	ch.volumeCursor.ProcessInput(input)
+	for _, p := range ch.indicatorPanels {
+		p.ProcessInput(input)
+	}
 	ch.timelineCursor.ProcessInput(input)
 	ch.legend.ProcessInput(input)
 
@@ -423,12 +714,22 @@ func (ch *Chart) Render(fudge float32) {
 
 	ch.priceTimeline.Render(fudge)
 	ch.priceLevel.Render(fudge)
+	if ch.showMovingAverages {
+		for _, b := range ch.bands {
+			b.Render(fudge)
+		}
+	}
 	ch.price.Render(fudge)
 	if ch.showMovingAverages {
 		for _, ma := range ch.movingAverages {
 			ma.Render(fudge)
 		}
 	}
+	if ch.showDrawings {
+		ch.drawingLayer.Render(fudge)
+	}
+	ch.positionLayer.Render(fudge)
+	ch.alertLayer.Render(fudge)
 	ch.priceCursor.Render(fudge)
 
 	ch.volumeTimeline.Render(fudge)
@@ -436,6 +737,10 @@ func (ch *Chart) Render(fudge float32) {
 	ch.volume.Render(fudge)
 	ch.volumeCursor.Render(fudge)
 
+	for _, p := range ch.indicatorPanels {
+		p.Render(fudge)
+	}
+
 	ch.timelineAxis.Render(fudge)
 	ch.timelineCursor.Render(fudge)
 
@@ -462,6 +767,51 @@ func (ch *Chart) SetAddButtonClickCallback(cb func()) {
 	ch.header.SetAddButtonClickCallback(cb)
 }
 
+// SetAdjustmentButtonClickCallback sets the callback for adjustment button clicks.
+func (ch *Chart) SetAdjustmentButtonClickCallback(cb func()) {
+	ch.header.SetAdjustmentButtonClickCallback(cb)
+}
+
+// SetDrawingAddCallback sets the callback for when the user finishes a drawing.
+func (ch *Chart) SetDrawingAddCallback(cb func(d *model.Drawing)) {
+	ch.drawingLayer.addCallback = func(d *model.Drawing) {
+		// Stop drawing after each drawing, so clicks go back to normal.
+		ch.setDrawingType(model.DrawingTypeUnspecified)
+		if cb != nil {
+			cb(d)
+		}
+	}
+}
+
+// SetDrawingRemoveCallback sets the callback for when the user removes a drawing.
+// The index is the position of the drawing in the Drawings passed to SetData.
+func (ch *Chart) SetDrawingRemoveCallback(cb func(index int)) {
+	ch.drawingLayer.removeCallback = cb
+}
+
+// SetAlertAddCallback sets the callback for when the user adds an alert.
+func (ch *Chart) SetAlertAddCallback(cb func(a *model.Alert)) {
+	ch.alertLayer.addCallback = cb
+}
+
+// SetAlertRemoveCallback sets the callback for when the user removes an alert.
+// The index is the position of the alert in the Alerts passed to SetData.
+func (ch *Chart) SetAlertRemoveCallback(cb func(index int)) {
+	ch.alertLayer.removeCallback = cb
+}
+
+// SetLotAddCallback sets the callback for when the user clicks on the price and date to add a lot at.
+// The number of shares is up to the caller to ask for.
+func (ch *Chart) SetLotAddCallback(cb func(price float32, date time.Time)) {
+	ch.positionLayer.addCallback = cb
+}
+
+// SetLotRemoveCallback sets the callback for when the user removes a lot.
+// The index is the position of the lot in the Position passed to SetData.
+func (ch *Chart) SetLotRemoveCallback(cb func(index int)) {
+	ch.positionLayer.removeCallback = cb
+}
+
 // SetZoomChangeCallback sets the callback for zoom changes.
 func (ch *Chart) SetZoomChangeCallback(cb func(zoomChange ZoomChange)) {
 	ch.zoomChangeCallback = cb
@@ -478,10 +828,27 @@ func (ch *Chart) Close() {
 		ma.Close()
 	}
 	ch.movingAverages = nil
+	for _, b := range ch.bands {
+		b.Close()
+	}
+	ch.bands = nil
+	ch.drawingLayer.Close()
+	ch.drawingLayer.addCallback = nil
+	ch.drawingLayer.removeCallback = nil
+	ch.alertLayer.Close()
+	ch.alertLayer.addCallback = nil
+	ch.alertLayer.removeCallback = nil
+	ch.positionLayer.Close()
+	ch.positionLayer.addCallback = nil
+	ch.positionLayer.removeCallback = nil
 	ch.volume.Close()
 	ch.volumeLevel.Close()
 	ch.volumeCursor.Close()
 	ch.volumeTimeline.Close()
+	for _, p := range ch.indicatorPanels {
+		p.Close()
+	}
+	ch.indicatorPanels = nil
 	ch.timelineAxis.Close()
 	ch.timelineCursor.Close()
 	ch.legend.Close()
@@ -0,0 +1,504 @@
+package chart
+
+import (
+	"image"
+	"math"
+	"sort"
+	"time"
+
+	"github.com/btmura/ponzi2/internal/app/gfx"
+	"github.com/btmura/ponzi2/internal/app/model"
+	"github.com/btmura/ponzi2/internal/app/view"
+)
+
+const (
+	// drawingHitDistance is how many pixels away the mouse can be to select a drawing.
+	drawingHitDistance = 5
+
+	// drawingFillAlpha is the alpha of the fill inside rectangles.
+	drawingFillAlpha = 0.1
+)
+
+var (
+	drawingColor         = view.Orange
+	selectedDrawingColor = view.Yellow
+)
+
+// drawingLayer renders the user's drawings over the prices and lets the user
+// add new ones by dragging the mouse or remove the one under the mouse with backspace.
+type drawingLayer struct {
+	// renderable is true if this should be rendered.
+	renderable bool
+
+	// tradingSessions are used to convert between dates and x coordinates.
+	tradingSessions []*model.TradingSession
+
+	// priceRange is used to convert between prices and y coordinates.
+	priceRange [2]float32
+
+	// drawings are the drawings that have been added in the order they were added.
+	drawings []*drawingShape
+
+	// drawingType is the type of drawing to add or unspecified if not drawing.
+	drawingType model.DrawingType
+
+	// preview is the drawing being dragged out by the user. Nil if not dragging.
+	preview *drawingShape
+
+	// selectedIndex is the index of the drawing under the mouse. -1 if none.
+	selectedIndex int
+
+	// bounds is the rectangle where the prices are drawn.
+	bounds image.Rectangle
+
+	// addCallback is fired when the user finishes a drawing.
+	addCallback func(d *model.Drawing)
+
+	// removeCallback is fired with the index of the drawing the user wants removed.
+	removeCallback func(index int)
+}
+
+func newDrawingLayer() *drawingLayer {
+	return &drawingLayer{selectedIndex: -1}
+}
+
+type drawingLayerData struct {
+	TradingSessionSeries *model.TradingSessionSeries
+	BandSeriesSet        []*model.BandSeries
+	Drawings             []*model.Drawing
+}
+
+func (d *drawingLayer) SetData(data drawingLayerData) {
+	// Reset everything.
+	d.Close()
+
+	// Bail out if there is no data yet.
+	ts := data.TradingSessionSeries
+	if ts == nil || len(ts.TradingSessions) == 0 {
+		return
+	}
+
+	d.tradingSessions = ts.TradingSessions
+	d.priceRange = priceRange(ts.TradingSessions, data.BandSeriesSet)
+
+	for _, dr := range data.Drawings {
+		d.drawings = append(d.drawings, d.newShape(dr))
+	}
+
+	d.renderable = true
+}
+
+// SetDrawingType sets the type of drawing the user adds by dragging.
+func (d *drawingLayer) SetDrawingType(drawingType model.DrawingType) {
+	d.drawingType = drawingType
+}
+
+func (d *drawingLayer) SetBounds(bounds image.Rectangle) {
+	d.bounds = bounds
+}
+
+func (d *drawingLayer) ProcessInput(input *view.Input) {
+	if !d.renderable {
+		return
+	}
+
+	d.selectedIndex = -1
+	if input.MousePos.In(d.bounds) {
+		for i := len(d.drawings) - 1; i >= 0; i-- {
+			if d.drawings[i].hit(d.bounds, input.MousePos.Point) {
+				d.selectedIndex = i
+				break
+			}
+		}
+	}
+
+	if d.selectedIndex >= 0 && input.KeyReleased.GetKey() == view.KeyBackspace {
+		index := d.selectedIndex
+		input.AddFiredCallback(func() {
+			if d.removeCallback != nil {
+				d.removeCallback(index)
+			}
+		})
+		input.ClearKeyboardInput()
+	}
+
+	if d.preview != nil {
+		d.preview.Close()
+		d.preview = nil
+	}
+
+	if d.drawingType == model.DrawingTypeUnspecified {
+		return
+	}
+
+	var start, end image.Point
+	var done bool
+	switch {
+	case input.MouseLeftButtonDragging.PressedIn(d.bounds):
+		drag := input.MouseLeftButtonDragging
+		start, end = drag.PressedPos.Point, drag.CurrentPos.Point
+		done = drag.ReleasedPos != nil
+
+	case d.drawingType == model.HorizontalLine && input.MouseLeftButtonClicked.In(d.bounds):
+		start = input.MouseLeftButtonClicked.ReleasedPos.Point
+		end = start
+		done = true
+
+	default:
+		return
+	}
+
+	dr := d.drawingAt(start, end)
+
+	if !done {
+		d.preview = d.newShape(dr)
+		return
+	}
+
+	input.AddFiredCallback(func() {
+		if d.addCallback != nil {
+			d.addCallback(dr)
+		}
+	})
+}
+
+// drawingAt returns a drawing of the current type between two points in the bounds.
+func (d *drawingLayer) drawingAt(start, end image.Point) *model.Drawing {
+	point := func(pt image.Point) model.DrawingPoint {
+		pt.X = clampInt(pt.X, d.bounds.Min.X, d.bounds.Max.X-1)
+		pt.Y = clampInt(pt.Y, d.bounds.Min.Y, d.bounds.Max.Y)
+		_, s := tradingSessionAtX(d.tradingSessions, d.bounds, pt.X)
+		yPercent := float32(pt.Y-d.bounds.Min.Y) / float32(d.bounds.Dy())
+		return model.DrawingPoint{
+			Date:  s.Date,
+			Price: priceValue(d.priceRange, yPercent),
+		}
+	}
+
+	dr := &model.Drawing{
+		Type:  d.drawingType,
+		Start: point(start),
+		End:   point(end),
+	}
+	if dr.Type == model.HorizontalLine {
+		dr.End = dr.Start
+	}
+	return dr
+}
+
+func (d *drawingLayer) Render(float32) {
+	if !d.renderable {
+		return
+	}
+
+	gfx.SetModelMatrixRect(d.bounds)
+	for i, s := range d.drawings {
+		if i == d.selectedIndex {
+			renderVAOs(s.selected)
+			continue
+		}
+		renderVAOs(s.normal)
+	}
+
+	if d.preview != nil {
+		renderVAOs(d.preview.selected)
+	}
+}
+
+func (d *drawingLayer) Close() {
+	d.renderable = false
+	for _, s := range d.drawings {
+		s.Close()
+	}
+	d.drawings = nil
+	if d.preview != nil {
+		d.preview.Close()
+		d.preview = nil
+	}
+	d.selectedIndex = -1
+}
+
+// drawingShape is a drawing projected onto the current chart.
+type drawingShape struct {
+	// drawingType is the type of drawing.
+	drawingType model.DrawingType
+
+	// start and end are the corners in normalized coordinates from -1 to 1.
+	start, end [2]float32
+
+	// hidden is true if the shape is outside of the chart's sessions.
+	hidden bool
+
+	// normal are the VAOs to render when the drawing is not selected.
+	normal []*gfx.VAO
+
+	// selected are the VAOs to render when the drawing is under the mouse.
+	selected []*gfx.VAO
+}
+
+// newShape projects a drawing onto the current trading sessions and price range.
+// Dates are matched to the sessions containing them, so drawings follow the
+// chart when switching between intervals like daily and weekly.
+func (d *drawingLayer) newShape(dr *model.Drawing) *drawingShape {
+	x := func(p model.DrawingPoint) float32 {
+		return 2*drawingXPercent(d.tradingSessions, p.Date) - 1
+	}
+	y := func(p model.DrawingPoint) float32 {
+		return 2*clampFloat32(pricePercent(d.priceRange, p.Price), 0, 1) - 1
+	}
+
+	s := &drawingShape{
+		drawingType: dr.Type,
+		start:       [2]float32{x(dr.Start), y(dr.Start)},
+		end:         [2]float32{x(dr.End), y(dr.End)},
+	}
+
+	// Clip shapes to the chart rather than moving their ends, so trend lines keep their slopes.
+	switch dr.Type {
+	case model.HorizontalLine:
+		s.start[0], s.end[0] = -1, 1
+		s.end[1] = s.start[1]
+
+	case model.Rectangle:
+		if s.start[0] < -1 && s.end[0] < -1 || s.start[0] > 1 && s.end[0] > 1 {
+			s.hidden = true
+		}
+		s.start[0] = clampFloat32(s.start[0], -1, 1)
+		s.end[0] = clampFloat32(s.end[0], -1, 1)
+
+	default:
+		s.start, s.end, s.hidden = clipSegment(s.start, s.end)
+	}
+
+	s.normal = drawingShapeVAOs(s, drawingColor)
+	s.selected = drawingShapeVAOs(s, selectedDrawingColor)
+
+	return s
+}
+
+// hit returns true if the point in global coordinates is near the shape drawn in the bounds.
+func (s *drawingShape) hit(bounds image.Rectangle, pt image.Point) bool {
+	if s.hidden {
+		return false
+	}
+
+	toPixels := func(v [2]float32) [2]float32 {
+		return [2]float32{
+			float32(bounds.Min.X) + (v[0]+1)/2*float32(bounds.Dx()),
+			float32(bounds.Min.Y) + (v[1]+1)/2*float32(bounds.Dy()),
+		}
+	}
+	p := [2]float32{float32(pt.X), float32(pt.Y)}
+	a, b := toPixels(s.start), toPixels(s.end)
+
+	switch s.drawingType {
+	case model.Rectangle:
+		c, e := [2]float32{a[0], b[1]}, [2]float32{b[0], a[1]}
+		for _, edge := range [][2][2]float32{{a, c}, {c, b}, {b, e}, {e, a}} {
+			if segmentDistance(p, edge[0], edge[1]) <= drawingHitDistance {
+				return true
+			}
+		}
+		return false
+
+	default:
+		return segmentDistance(p, a, b) <= drawingHitDistance
+	}
+}
+
+func (s *drawingShape) Close() {
+	for _, v := range append(s.normal, s.selected...) {
+		v.Delete()
+	}
+}
+
+// drawingShapeVAOs returns the VAOs of a shape's lines and, for rectangles, its fill.
+func drawingShapeVAOs(s *drawingShape, color view.Color) []*gfx.VAO {
+	if s.hidden {
+		return nil
+	}
+
+	x1, y1, x2, y2 := s.start[0], s.start[1], s.end[0], s.end[1]
+
+	lines := &gfx.VAOVertexData{Mode: gfx.Lines}
+	addLine := func(ax, ay, bx, by float32) {
+		idx := uint16(len(lines.Vertices) / 3)
+		lines.Vertices = append(lines.Vertices, ax, ay, 0, bx, by, 0)
+		lines.Colors = append(lines.Colors,
+			color[0], color[1], color[2], color[3],
+			color[0], color[1], color[2], color[3],
+		)
+		lines.Indices = append(lines.Indices, idx, idx+1)
+	}
+
+	if s.drawingType != model.Rectangle {
+		addLine(x1, y1, x2, y2)
+		return []*gfx.VAO{gfx.NewVAO(lines)}
+	}
+
+	addLine(x1, y1, x2, y1)
+	addLine(x2, y1, x2, y2)
+	addLine(x2, y2, x1, y2)
+	addLine(x1, y2, x1, y1)
+
+	c := color
+	c[3] = drawingFillAlpha
+
+	fill := &gfx.VAOVertexData{
+		Mode: gfx.Triangles,
+		Vertices: []float32{
+			x1, y1, 0, // 0
+			x1, y2, 0, // 1
+			x2, y1, 0, // 2
+			x2, y2, 0, // 3
+		},
+		Indices: []uint16{0, 1, 2, 2, 1, 3},
+	}
+	for i := 0; i < 4; i++ {
+		fill.Colors = append(fill.Colors, c[0], c[1], c[2], c[3])
+	}
+
+	return []*gfx.VAO{gfx.NewVAO(fill), gfx.NewVAO(lines)}
+}
+
+func renderVAOs(vs []*gfx.VAO) {
+	for _, v := range vs {
+		v.Render()
+	}
+}
+
+// drawingXPercent returns the x percent of the center of the session containing the date.
+// Dates outside of the sessions are placed on sessions extrapolated by the average
+// spacing between sessions, so the percent can be outside of 0 to 1.
+func drawingXPercent(ts []*model.TradingSession, date time.Time) float32 {
+	n := len(ts)
+	if n == 0 {
+		return 0
+	}
+
+	first, last := ts[0].Date, ts[n-1].Date
+
+	var i float64
+	switch {
+	case n > 1 && date.Before(first):
+		spacing := last.Sub(first) / time.Duration(n-1)
+		i = -math.Ceil(float64(first.Sub(date)) / float64(spacing))
+
+	case n > 1 && date.After(last):
+		spacing := last.Sub(first) / time.Duration(n-1)
+		i = float64(n-1) + math.Floor(float64(date.Sub(last))/float64(spacing))
+
+	default:
+		// Find the last session that starts on or before the date.
+		i = float64(sort.Search(n, func(i int) bool {
+			return ts[i].Date.After(date)
+		}) - 1)
+		if i < 0 {
+			i = 0
+		}
+	}
+
+	return float32((i + 0.5) / float64(n))
+}
+
+// clipSegment clips the segment from a to b to the normalized coordinates from -1 to 1
+// using the Liang-Barsky algorithm. It returns true if the segment is entirely outside.
+func clipSegment(a, b [2]float32) (ca, cb [2]float32, outside bool) {
+	d := [2]float32{b[0] - a[0], b[1] - a[1]}
+	t0, t1 := float32(0), float32(1)
+
+	for i := 0; i < 2; i++ {
+		for _, e := range [][2]float32{
+			{-d[i], a[i] + 1}, // Distance from the min edge.
+			{d[i], 1 - a[i]},  // Distance from the max edge.
+		} {
+			p, q := e[0], e[1]
+			if p == 0 {
+				if q < 0 {
+					return a, b, true
+				}
+				continue
+			}
+
+			t := q / p
+			if p < 0 {
+				if t > t1 {
+					return a, b, true
+				}
+				if t > t0 {
+					t0 = t
+				}
+			} else {
+				if t < t0 {
+					return a, b, true
+				}
+				if t < t1 {
+					t1 = t
+				}
+			}
+		}
+	}
+
+	ca = [2]float32{a[0] + t0*d[0], a[1] + t0*d[1]}
+	cb = [2]float32{a[0] + t1*d[0], a[1] + t1*d[1]}
+	return ca, cb, false
+}
+
+// segmentDistance returns the distance from the point p to the segment from a to b.
+func segmentDistance(p, a, b [2]float32) float32 {
+	dx, dy := b[0]-a[0], b[1]-a[1]
+
+	var t float32
+	if l := dx*dx + dy*dy; l != 0 {
+		t = clampFloat32(((p[0]-a[0])*dx+(p[1]-a[1])*dy)/l, 0, 1)
+	}
+
+	cx, cy := a[0]+t*dx-p[0], a[1]+t*dy-p[1]
+	return float32(math.Sqrt(float64(cx*cx + cy*cy)))
+}
+
+// drawingIconVAO returns an icon of a drawing type for the header buttons.
+func drawingIconVAO(drawingType model.DrawingType, color view.Color) *gfx.VAO {
+	var points [][2]float32
+	switch drawingType {
+	case model.TrendLine:
+		points = [][2]float32{{-0.4, -0.4}, {0.4, 0.4}}
+	case model.HorizontalLine:
+		points = [][2]float32{{-0.5, 0}, {0.5, 0}}
+	case model.Rectangle:
+		points = [][2]float32{
+			{-0.4, -0.3}, {0.4, -0.3},
+			{0.4, -0.3}, {0.4, 0.3},
+			{0.4, 0.3}, {-0.4, 0.3},
+			{-0.4, 0.3}, {-0.4, -0.3},
+		}
+	}
+
+	data := &gfx.VAOVertexData{Mode: gfx.Lines}
+	for i, p := range points {
+		data.Vertices = append(data.Vertices, p[0], p[1], 0)
+		data.Colors = append(data.Colors, color[0], color[1], color[2], color[3])
+		data.Indices = append(data.Indices, uint16(i))
+	}
+	return gfx.NewVAO(data)
+}
+
+func clampInt(v, min, max int) int {
+	switch {
+	case v < min:
+		return min
+	case v > max:
+		return max
+	}
+	return v
+}
+
+func clampFloat32(v, min, max float32) float32 {
+	switch {
+	case v < min:
+		return min
+	case v > max:
+		return max
+	}
+	return v
+}
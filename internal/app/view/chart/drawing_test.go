@@ -0,0 +1,117 @@
+package chart
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+
+	"github.com/btmura/ponzi2/internal/app/model"
+)
+
+func TestDrawingXPercent(t *testing.T) {
+	date := func(day int) time.Time {
+		return time.Date(2020, time.January, day, 0, 0, 0, 0, time.UTC)
+	}
+
+	// Weekly sessions starting on Mondays.
+	ts := []*model.TradingSession{
+		{Date: date(6)},
+		{Date: date(13)},
+		{Date: date(20)},
+		{Date: date(27)},
+	}
+
+	for _, tt := range []struct {
+		desc string
+		date time.Time
+		want float32
+	}{
+		{
+			desc: "first session",
+			date: date(6),
+			want: 0.125,
+		},
+		{
+			desc: "middle of a session",
+			date: date(15),
+			want: 0.375,
+		},
+		{
+			desc: "last session",
+			date: date(29),
+			want: 0.875,
+		},
+		{
+			desc: "week before the first session",
+			date: date(1),
+			want: -0.125,
+		},
+		{
+			desc: "two weeks before the first session",
+			date: date(6).AddDate(0, 0, -14),
+			want: -0.375,
+		},
+		{
+			desc: "week after the last session",
+			date: date(27).AddDate(0, 0, 8),
+			want: 1.125,
+		},
+	} {
+		t.Run(tt.desc, func(t *testing.T) {
+			got := drawingXPercent(ts, tt.date)
+			if diff := cmp.Diff(tt.want, got, cmp.Comparer(approxEqual)); diff != "" {
+				t.Errorf("diff (-want, +got)\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestClipSegment(t *testing.T) {
+	for _, tt := range []struct {
+		desc        string
+		a, b        [2]float32
+		wantA       [2]float32
+		wantB       [2]float32
+		wantOutside bool
+	}{
+		{
+			desc:  "inside",
+			a:     [2]float32{-0.5, -0.5},
+			b:     [2]float32{0.5, 0.5},
+			wantA: [2]float32{-0.5, -0.5},
+			wantB: [2]float32{0.5, 0.5},
+		},
+		{
+			desc:  "start before the left edge keeps the slope",
+			a:     [2]float32{-3, -1},
+			b:     [2]float32{1, 1},
+			wantA: [2]float32{-1, 0},
+			wantB: [2]float32{1, 1},
+		},
+		{
+			desc:        "left of the chart",
+			a:           [2]float32{-3, 0},
+			b:           [2]float32{-2, 0.5},
+			wantA:       [2]float32{-3, 0},
+			wantB:       [2]float32{-2, 0.5},
+			wantOutside: true,
+		},
+	} {
+		t.Run(tt.desc, func(t *testing.T) {
+			gotA, gotB, gotOutside := clipSegment(tt.a, tt.b)
+
+			if diff := cmp.Diff(tt.wantA, gotA, cmp.Comparer(approxEqual)); diff != "" {
+				t.Errorf("diff (-want, +got)\n%s", diff)
+			}
+
+			if diff := cmp.Diff(tt.wantB, gotB, cmp.Comparer(approxEqual)); diff != "" {
+				t.Errorf("diff (-want, +got)\n%s", diff)
+			}
+
+			if gotOutside != tt.wantOutside {
+				t.Errorf("got outside %t, want %t", gotOutside, tt.wantOutside)
+			}
+		})
+	}
+}
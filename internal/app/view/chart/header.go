@@ -3,6 +3,7 @@ package chart
 import (
 	"bytes"
 	"image"
+	"strings"
 
 	"github.com/btmura/ponzi2/internal/app/gfx"
 	"github.com/btmura/ponzi2/internal/app/model"
@@ -10,6 +11,7 @@ import (
 	"github.com/btmura/ponzi2/internal/app/view/animation"
 	"github.com/btmura/ponzi2/internal/app/view/button"
 	"github.com/btmura/ponzi2/internal/app/view/rect"
+	"github.com/btmura/ponzi2/internal/app/view/status"
 	"github.com/btmura/ponzi2/internal/app/view/vao"
 )
 
@@ -22,6 +24,26 @@ var (
 	removeButtonVAO      = vao.TexturedSquare(bytes.NewReader(_escFSMustByte(false, "/data/removebutton.png")))
 )
 
+// Drawing button icons are drawn with lines and are yellow when their drawing tool is active.
+var (
+	trendLineButtonVAO            = drawingIconVAO(model.TrendLine, view.White)
+	activeTrendLineButtonVAO      = drawingIconVAO(model.TrendLine, view.Yellow)
+	horizontalLineButtonVAO       = drawingIconVAO(model.HorizontalLine, view.White)
+	activeHorizontalLineButtonVAO = drawingIconVAO(model.HorizontalLine, view.Yellow)
+	rectangleButtonVAO            = drawingIconVAO(model.Rectangle, view.White)
+	activeRectangleButtonVAO      = drawingIconVAO(model.Rectangle, view.Yellow)
+	alertButtonVAO                = alertIconVAO(view.White)
+	activeAlertButtonVAO          = alertIconVAO(view.Yellow)
+	lotButtonVAO                  = lotIconVAO(view.White)
+	activeLotButtonVAO            = lotIconVAO(view.Yellow)
+)
+
+// Adjustment button icons are yellow when charts show total returns.
+var (
+	adjustmentButtonVAO       = adjustmentIconVAO(view.White)
+	activeAdjustmentButtonVAO = adjustmentIconVAO(view.Yellow)
+)
+
 // header shows a header for charts and thumbnails with a clickable button.
 type header struct {
 	// symbol is the symbol to render.
@@ -33,12 +55,24 @@ type header struct {
 	// quoteColor is the color to render the quote text.
 	quoteColor view.Color
 
+	// positionText is the text with the user's position. Empty if the user has no lots.
+	positionText string
+
+	// positionColor is the color to render the position text.
+	positionColor view.Color
+
+	// alertText describes the triggered alerts. Empty if none have triggered.
+	alertText string
+
 	// symbolQuoteTextRenderer renders the symbol and quote text.
 	symbolQuoteTextRenderer *gfx.TextRenderer
 
 	// quotePrinter is the function used to generate the quote text.
 	quotePrinter func(*model.Quote) string
 
+	// positionPrinter is the function used to generate the position text. Nil to not show positions.
+	positionPrinter func(*model.Position) string
+
 	// barButton is the button to show price bars.
 	barButton *headerButton
 
@@ -54,6 +88,24 @@ type header struct {
 	// removeButton is the button to remove the symbol.
 	removeButton *headerButton
 
+	// trendLineButton is the button to draw trend lines.
+	trendLineButton *headerButton
+
+	// horizontalLineButton is the button to draw horizontal price levels.
+	horizontalLineButton *headerButton
+
+	// rectangleButton is the button to draw rectangles.
+	rectangleButton *headerButton
+
+	// alertButton is the button to add and remove alerts.
+	alertButton *headerButton
+
+	// lotButton is the button to add and remove portfolio lots.
+	lotButton *headerButton
+
+	// adjustmentButton is the button to switch between split-adjusted and total return prices.
+	adjustmentButton *headerButton
+
 	// rounding is only used to layout the symbol and quote text.
 	rounding int
 
@@ -89,11 +141,16 @@ type headerButton struct {
 type headerArgs struct {
 	SymbolQuoteTextRenderer *gfx.TextRenderer
 	QuotePrinter            func(*model.Quote) string
+	PositionPrinter         func(*model.Position) string
 	ShowBarButton           bool
 	ShowCandlestickButton   bool
 	ShowRefreshButton       bool
 	ShowAddButton           bool
 	ShowRemoveButton        bool
+	ShowDrawingButtons      bool
+	ShowAlertButton         bool
+	ShowLotButton           bool
+	ShowAdjustmentButton    bool
 	Rounding                int
 	Padding                 int
 }
@@ -102,6 +159,7 @@ func newHeader(args *headerArgs) *header {
 	return &header{
 		symbolQuoteTextRenderer: args.SymbolQuoteTextRenderer,
 		quotePrinter:            args.QuotePrinter,
+		positionPrinter:         args.PositionPrinter,
 		barButton: &headerButton{
 			Button:  button.New(barButtonVAO),
 			enabled: args.ShowBarButton,
@@ -122,6 +180,30 @@ func newHeader(args *headerArgs) *header {
 			Button:  button.New(removeButtonVAO),
 			enabled: args.ShowRemoveButton,
 		},
+		trendLineButton: &headerButton{
+			Button:  button.New(trendLineButtonVAO),
+			enabled: args.ShowDrawingButtons,
+		},
+		horizontalLineButton: &headerButton{
+			Button:  button.New(horizontalLineButtonVAO),
+			enabled: args.ShowDrawingButtons,
+		},
+		rectangleButton: &headerButton{
+			Button:  button.New(rectangleButtonVAO),
+			enabled: args.ShowDrawingButtons,
+		},
+		alertButton: &headerButton{
+			Button:  button.New(alertButtonVAO),
+			enabled: args.ShowAlertButton,
+		},
+		lotButton: &headerButton{
+			Button:  button.New(lotButtonVAO),
+			enabled: args.ShowLotButton,
+		},
+		adjustmentButton: &headerButton{
+			Button:  button.New(adjustmentButtonVAO),
+			enabled: args.ShowAdjustmentButton,
+		},
 		rounding: args.Rounding,
 		padding:  args.Padding,
 		fadeIn:   animation.New(1 * view.FPS),
@@ -163,17 +245,82 @@ func (h *header) SetData(data Data) {
 	if q := data.Quote; q != nil {
 		c = q.ChangePercent
 	}
+	h.quoteColor = changeColor(c)
+
+	h.positionText = ""
+	if h.positionPrinter != nil {
+		h.positionText = h.positionPrinter(data.Position)
+	}
+
+	var g float32
+	if p := data.Position; p != nil {
+		g = p.GainLoss
+	}
+	h.positionColor = changeColor(g)
+
+	if data.Adjustment == model.TotalReturn {
+		h.adjustmentButton.SetIcon(activeAdjustmentButtonVAO)
+	} else {
+		h.adjustmentButton.SetIcon(adjustmentButtonVAO)
+	}
+
+	var triggered []string
+	for _, a := range data.Alerts {
+		if a.Triggered {
+			triggered = append(triggered, status.Alert(a))
+		}
+	}
+
+	h.alertText = ""
+	if len(triggered) != 0 {
+		h.alertText = "ALERT " + strings.Join(triggered, ", ")
+	}
+}
 
+// changeColor returns green for gains, red for losses, and white otherwise.
+func changeColor(change float32) view.Color {
 	switch {
-	case c > 0:
-		h.quoteColor = view.Green
+	case change > 0:
+		return view.Green
 
-	case c < 0:
-		h.quoteColor = view.Red
+	case change < 0:
+		return view.Red
 
 	default:
-		h.quoteColor = view.White
+		return view.White
+	}
+}
+
+// SetDrawingType highlights the button of the active drawing tool.
+// DrawingTypeUnspecified means no tool is active.
+func (h *header) SetDrawingType(drawingType model.DrawingType) {
+	icon := func(t model.DrawingType, normal, active *gfx.VAO) *gfx.VAO {
+		if t == drawingType {
+			return active
+		}
+		return normal
+	}
+	h.trendLineButton.SetIcon(icon(model.TrendLine, trendLineButtonVAO, activeTrendLineButtonVAO))
+	h.horizontalLineButton.SetIcon(icon(model.HorizontalLine, horizontalLineButtonVAO, activeHorizontalLineButtonVAO))
+	h.rectangleButton.SetIcon(icon(model.Rectangle, rectangleButtonVAO, activeRectangleButtonVAO))
+}
+
+// SetAlertMode highlights the alert button when clicks add and remove alerts.
+func (h *header) SetAlertMode(active bool) {
+	if active {
+		h.alertButton.SetIcon(activeAlertButtonVAO)
+		return
 	}
+	h.alertButton.SetIcon(alertButtonVAO)
+}
+
+// SetLotMode highlights the lot button when clicks add and remove lots.
+func (h *header) SetLotMode(active bool) {
+	if active {
+		h.lotButton.SetIcon(activeLotButtonVAO)
+		return
+	}
+	h.lotButton.SetIcon(lotButtonVAO)
 }
 
 // headerClicks reports what buttons were clicked.
@@ -192,6 +339,18 @@ type headerClicks struct {
 
 	// RemoveButtonClicked is true if the remove button was clicked.
 	RemoveButtonClicked bool
+
+	// DrawingButtonClicked is true if any of the drawing buttons were clicked.
+	DrawingButtonClicked bool
+
+	// AlertButtonClicked is true if the alert button was clicked.
+	AlertButtonClicked bool
+
+	// LotButtonClicked is true if the lot button was clicked.
+	LotButtonClicked bool
+
+	// AdjustmentButtonClicked is true if the adjustment button was clicked.
+	AdjustmentButtonClicked bool
 }
 
 // HasClicks returns true if a clickable part of the header was clicked.
@@ -200,7 +359,11 @@ func (c headerClicks) HasClicks() bool {
 		c.CandlestickButtonClicked ||
 		c.AddButtonClicked ||
 		c.RefreshButtonClicked ||
-		c.RemoveButtonClicked
+		c.RemoveButtonClicked ||
+		c.DrawingButtonClicked ||
+		c.AlertButtonClicked ||
+		c.LotButtonClicked ||
+		c.AdjustmentButtonClicked
 }
 
 func (h *header) SetBounds(bounds image.Rectangle) {
@@ -242,6 +405,35 @@ func (h *header) ProcessInput(input *view.Input) (body image.Rectangle, clicks h
 	if h.barButton.enabled {
 		h.barButton.SetBounds(bounds)
 		clicks.BarButtonClicked = h.barButton.ProcessInput(input)
+		bounds = rect.Translate(bounds, -buttonSize.X, 0)
+	}
+
+	for _, b := range []*headerButton{h.rectangleButton, h.horizontalLineButton, h.trendLineButton} {
+		if b.enabled {
+			b.SetBounds(bounds)
+			if b.ProcessInput(input) {
+				clicks.DrawingButtonClicked = true
+			}
+			bounds = rect.Translate(bounds, -buttonSize.X, 0)
+		}
+	}
+
+	if h.alertButton.enabled {
+		h.alertButton.SetBounds(bounds)
+		clicks.AlertButtonClicked = h.alertButton.ProcessInput(input)
+		bounds = rect.Translate(bounds, -buttonSize.X, 0)
+	}
+
+	if h.lotButton.enabled {
+		h.lotButton.SetBounds(bounds)
+		clicks.LotButtonClicked = h.lotButton.ProcessInput(input)
+		bounds = rect.Translate(bounds, -buttonSize.X, 0)
+	}
+
+	if h.adjustmentButton.enabled {
+		h.adjustmentButton.SetBounds(bounds)
+		clicks.AdjustmentButtonClicked = h.adjustmentButton.ProcessInput(input)
+		bounds = rect.Translate(bounds, -buttonSize.X, 0)
 	}
 
 	// Don't report clicks when the refresh button is just an indicator.
@@ -270,6 +462,24 @@ func (h *header) Update() (dirty bool) {
 	if h.removeButton.Update() {
 		dirty = true
 	}
+	if h.trendLineButton.Update() {
+		dirty = true
+	}
+	if h.horizontalLineButton.Update() {
+		dirty = true
+	}
+	if h.rectangleButton.Update() {
+		dirty = true
+	}
+	if h.alertButton.Update() {
+		dirty = true
+	}
+	if h.lotButton.Update() {
+		dirty = true
+	}
+	if h.adjustmentButton.Update() {
+		dirty = true
+	}
 	if h.fadeIn.Update() {
 		dirty = true
 	}
@@ -310,6 +520,28 @@ func (h *header) Render(fudge float32) {
 		h.bounds = rect.Translate(h.bounds, -buttonSize.X, 0)
 	}
 
+	for _, b := range []*headerButton{h.rectangleButton, h.horizontalLineButton, h.trendLineButton} {
+		if b.enabled {
+			b.Render(fudge)
+			h.bounds = rect.Translate(h.bounds, -buttonSize.X, 0)
+		}
+	}
+
+	if h.alertButton.enabled {
+		h.alertButton.Render(fudge)
+		h.bounds = rect.Translate(h.bounds, -buttonSize.X, 0)
+	}
+
+	if h.lotButton.enabled {
+		h.lotButton.Render(fudge)
+		h.bounds = rect.Translate(h.bounds, -buttonSize.X, 0)
+	}
+
+	if h.adjustmentButton.enabled {
+		h.adjustmentButton.Render(fudge)
+		h.bounds = rect.Translate(h.bounds, -buttonSize.X, 0)
+	}
+
 	if h.hasError {
 		gfx.SetModelMatrixRect(h.bounds)
 		errorIconVAO.Render()
@@ -333,6 +565,17 @@ func (h *header) Render(fudge float32) {
 			pt.X += h.symbolQuoteTextRenderer.Render(h.quoteText, pt, gfx.TextColor(h.quoteColor), gfx.TextRenderMaxWidth(w))
 			gfx.SetAlpha(old)
 		}
+
+		pt.X += h.padding
+
+		if w := buttonEdge - pt.X; w > 0 && h.positionText != "" {
+			pt.X += h.symbolQuoteTextRenderer.Render(h.positionText, pt, gfx.TextColor(h.positionColor), gfx.TextRenderMaxWidth(w))
+			pt.X += h.padding
+		}
+
+		if w := buttonEdge - pt.X; w > 0 && h.alertText != "" {
+			h.symbolQuoteTextRenderer.Render(h.alertText, pt, gfx.TextColor(view.Yellow), gfx.TextRenderMaxWidth(w))
+		}
 	}
 }
 
@@ -361,6 +604,36 @@ func (h *header) SetRemoveButtonClickCallback(cb func()) {
 	h.removeButton.SetClickCallback(cb)
 }
 
+// SetTrendLineButtonClickCallback sets the callback for trend line button clicks.
+func (h *header) SetTrendLineButtonClickCallback(cb func()) {
+	h.trendLineButton.SetClickCallback(cb)
+}
+
+// SetHorizontalLineButtonClickCallback sets the callback for horizontal line button clicks.
+func (h *header) SetHorizontalLineButtonClickCallback(cb func()) {
+	h.horizontalLineButton.SetClickCallback(cb)
+}
+
+// SetRectangleButtonClickCallback sets the callback for rectangle button clicks.
+func (h *header) SetRectangleButtonClickCallback(cb func()) {
+	h.rectangleButton.SetClickCallback(cb)
+}
+
+// SetAlertButtonClickCallback sets the callback for alert button clicks.
+func (h *header) SetAlertButtonClickCallback(cb func()) {
+	h.alertButton.SetClickCallback(cb)
+}
+
+// SetLotButtonClickCallback sets the callback for lot button clicks.
+func (h *header) SetLotButtonClickCallback(cb func()) {
+	h.lotButton.SetClickCallback(cb)
+}
+
+// SetAdjustmentButtonClickCallback sets the callback for adjustment button clicks.
+func (h *header) SetAdjustmentButtonClickCallback(cb func()) {
+	h.adjustmentButton.SetClickCallback(cb)
+}
+
 // Close frees the resources backing the ChartHeader.
 func (h *header) Close() {
 	h.barButton.Close()
@@ -368,4 +641,30 @@ func (h *header) Close() {
 	h.refreshButton.Close()
 	h.addButton.Close()
 	h.removeButton.Close()
+	h.trendLineButton.Close()
+	h.horizontalLineButton.Close()
+	h.rectangleButton.Close()
+	h.alertButton.Close()
+	h.lotButton.Close()
+	h.adjustmentButton.Close()
+}
+
+// adjustmentIconVAO returns a rising line with a plus sign for reinvested dividends.
+func adjustmentIconVAO(color view.Color) *gfx.VAO {
+	data := &gfx.VAOVertexData{
+		Mode: gfx.Lines,
+		Vertices: []float32{
+			-0.4, -0.4, 0, // 0
+			0.1, 0.1, 0, // 1
+			0.3, 0.4, 0, // 2
+			0.3, 0, 0, // 3
+			0.1, 0.2, 0, // 4
+			0.5, 0.2, 0, // 5
+		},
+		Indices: []uint16{0, 1, 2, 3, 4, 5},
+	}
+	for i := 0; i < 6; i++ {
+		data.Colors = append(data.Colors, color[0], color[1], color[2], color[3])
+	}
+	return gfx.NewVAO(data)
 }
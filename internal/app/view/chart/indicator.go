@@ -0,0 +1,293 @@
+package chart
+
+import (
+	"fmt"
+	"image"
+	"math"
+
+	"github.com/btmura/ponzi2/internal/app/gfx"
+	"github.com/btmura/ponzi2/internal/app/model"
+	"github.com/btmura/ponzi2/internal/app/view"
+	"github.com/btmura/ponzi2/internal/app/view/vao"
+	"github.com/btmura/ponzi2/internal/logger"
+)
+
+// Indicator is a technical indicator shown in a panel below the volume.
+type Indicator int
+
+// Indicator values.
+//go:generate stringer -type=Indicator
+const (
+	IndicatorUnspecified Indicator = iota
+	RelativeStrengthIndex
+	MACD
+	SlowStochastic
+)
+
+// defaultIndicators are the indicators to show when the user has not configured any.
+var defaultIndicators = []Indicator{
+	RelativeStrengthIndex,
+	MACD,
+}
+
+// DefaultIndicators returns the indicators to show when the user has not configured any.
+func DefaultIndicators() []Indicator {
+	return append([]Indicator(nil), defaultIndicators...)
+}
+
+const (
+	// indicatorMinPercent and indicatorMaxPercent keep values at the edges of
+	// an indicator's range from being dropped by vao.DataLine.
+	indicatorMinPercent = 0.001
+	indicatorMaxPercent = 0.999
+)
+
+// indicator renders the lines and histogram of a technical indicator.
+type indicator struct {
+	// renderable is whether the indicator can be rendered.
+	renderable bool
+
+	// lines are the VAOs of the indicator lines.
+	lines []*gfx.VAO
+
+	// histogram is the VAO of the histogram bars. Nil if the indicator has no histogram.
+	histogram *gfx.VAO
+
+	// bounds is the rectangle with global coords that should be drawn within.
+	bounds image.Rectangle
+}
+
+func newIndicator() *indicator {
+	return new(indicator)
+}
+
+// indicatorData is the data to render an indicator panel.
+type indicatorData struct {
+	// Title is the name of the indicator and its parameters.
+	Title string
+
+	// Range is the inclusive range of values shown in the panel.
+	Range [2]float32
+
+	// Levels are values that get a horizontal line and label.
+	Levels []float32
+
+	// Lines are the values of each line with one value per trading session.
+	Lines []indicatorLine
+
+	// Histogram are the values of bars drawn from zero. Nil for no histogram.
+	Histogram []float32
+}
+
+// indicatorLine is a line of indicator values and its color.
+type indicatorLine struct {
+	Values []float32
+	Color  view.Color
+}
+
+// makeIndicatorData returns the data to render an indicator from the chart
+// or false if the chart does not have the indicator's series.
+func makeIndicatorData(ind Indicator, ch *model.Chart) (indicatorData, bool) {
+	switch ind {
+	case RelativeStrengthIndex:
+		rs := ch.RelativeStrengthIndexSeries
+		if rs == nil {
+			return indicatorData{}, false
+		}
+
+		var vs []float32
+		for _, v := range rs.Values {
+			vs = append(vs, v.Value)
+		}
+
+		return indicatorData{
+			Title:  fmt.Sprintf("RSI(%d)", rs.Intervals),
+			Range:  [2]float32{0, 100},
+			Levels: []float32{30, 70},
+			Lines:  []indicatorLine{{vs, view.Purple}},
+		}, true
+
+	case MACD:
+		ms := ch.MACDSeries
+		if ms == nil {
+			return indicatorData{}, false
+		}
+
+		var macds, sigs, hists []float32
+		var max float32
+		for _, v := range ms.Values {
+			macds = append(macds, v.MACD)
+			sigs = append(sigs, v.Signal)
+			hists = append(hists, v.Histogram)
+			for _, x := range []float32{v.MACD, v.Signal, v.Histogram} {
+				if a := float32(math.Abs(float64(x))); a > max {
+					max = a
+				}
+			}
+		}
+
+		if max == 0 {
+			return indicatorData{}, false
+		}
+
+		return indicatorData{
+			Title:     fmt.Sprintf("MACD(%d,%d,%d)", ms.FastIntervals, ms.SlowIntervals, ms.SignalIntervals),
+			Range:     [2]float32{-max, max},
+			Levels:    []float32{0},
+			Lines:     []indicatorLine{{macds, view.White}, {sigs, view.Red}},
+			Histogram: hists,
+		}, true
+
+	case SlowStochastic:
+		ss := ch.StochasticSeries
+		if ss == nil {
+			return indicatorData{}, false
+		}
+
+		var ks, ds []float32
+		for _, v := range ss.Values {
+			ks = append(ks, v.K)
+			ds = append(ds, v.D)
+		}
+
+		return indicatorData{
+			Title:  fmt.Sprintf("STO(%d,%d,%d)", ss.Intervals, ss.KIntervals, ss.DIntervals),
+			Range:  [2]float32{0, 100},
+			Levels: []float32{20, 80},
+			Lines:  []indicatorLine{{ks, view.White}, {ds, view.Red}},
+		}, true
+
+	default:
+		logger.Errorf("bad indicator: %v", ind)
+		return indicatorData{}, false
+	}
+}
+
+func (i *indicator) SetData(data indicatorData) {
+	// Reset everything.
+	i.Close()
+
+	for _, l := range data.Lines {
+		i.lines = append(i.lines, vao.DataLine(indicatorPercents(data.Range, l.Values), l.Color))
+	}
+
+	if data.Histogram != nil {
+		i.histogram = indicatorHistogramVAO(data.Range, data.Histogram)
+	}
+
+	i.renderable = true
+}
+
+func (i *indicator) SetBounds(bounds image.Rectangle) {
+	i.bounds = bounds
+}
+
+func (i *indicator) Render(fudge float32) {
+	if !i.renderable {
+		return
+	}
+
+	gfx.SetModelMatrixRect(i.bounds)
+
+	if i.histogram != nil {
+		i.histogram.Render()
+	}
+
+	for _, l := range i.lines {
+		l.Render()
+	}
+}
+
+func (i *indicator) Close() {
+	i.renderable = false
+	for _, l := range i.lines {
+		l.Delete()
+	}
+	i.lines = nil
+	if i.histogram != nil {
+		i.histogram.Delete()
+		i.histogram = nil
+	}
+}
+
+// indicatorPercents converts indicator values into percents of the range.
+// Leading zero values mean there was not enough data and are left at zero
+// so that vao.DataLine skips them.
+func indicatorPercents(valueRange [2]float32, values []float32) []float32 {
+	ps := make([]float32, len(values))
+	started := false
+	for i, v := range values {
+		if v != 0 {
+			started = true
+		}
+		if !started {
+			continue
+		}
+
+		p := indicatorPercent(valueRange, v)
+		switch {
+		case p < indicatorMinPercent:
+			p = indicatorMinPercent
+		case p > indicatorMaxPercent:
+			p = indicatorMaxPercent
+		}
+		ps[i] = p
+	}
+	return ps
+}
+
+func indicatorPercent(valueRange [2]float32, value float32) float32 {
+	return (value - valueRange[0]) / (valueRange[1] - valueRange[0])
+}
+
+func indicatorValue(valueRange [2]float32, percent float32) float32 {
+	return valueRange[0] + percent*(valueRange[1]-valueRange[0])
+}
+
+func indicatorHistogramVAO(valueRange [2]float32, values []float32) *gfx.VAO {
+	var vertices []float32
+	var colors []float32
+	var lineIndices []uint16
+
+	dx := 2.0 / float32(len(values)) // (-1 to 1) on X-axis
+	zeroY := 2*indicatorPercent(valueRange, 0) - 1
+
+	for i, v := range values {
+		if v == 0 {
+			continue
+		}
+
+		centerX := -1.0 + dx*float32(i) + dx*.5
+		y := 2*indicatorPercent(valueRange, v) - 1
+
+		idxOffset := uint16(len(vertices) / 3)
+		vertices = append(vertices,
+			centerX, y, 0, // 0
+			centerX, zeroY, 0, // 1
+		)
+
+		c := view.Blue
+		if v < 0 {
+			c = view.Red
+		}
+		colors = append(colors,
+			c[0], c[1], c[2], c[3], // 0
+			c[0], c[1], c[2], c[3], // 1
+		)
+
+		lineIndices = append(lineIndices, idxOffset, idxOffset+1)
+	}
+
+	if len(vertices) == 0 {
+		return gfx.EmptyVAO()
+	}
+
+	return gfx.NewVAO(
+		&gfx.VAOVertexData{
+			Mode:     gfx.Lines,
+			Vertices: vertices,
+			Colors:   colors,
+			Indices:  lineIndices,
+		},
+	)
+}
@@ -0,0 +1,64 @@
+package chart
+
+import (
+	"image"
+
+	"github.com/btmura/ponzi2/internal/app/view"
+)
+
+// indicatorCursor renders crosshairs at the mouse pointer
+// with the corresponding indicator value on the y-axis.
+type indicatorCursor struct {
+	// renderable is true if this should be rendered.
+	renderable bool
+
+	// valueRange represents the inclusive range from min to max value.
+	valueRange [2]float32
+
+	// indicatorRect is the rectangle where the indicator lines are drawn.
+	indicatorRect image.Rectangle
+
+	// labelRect is the rectangle where the axis labels are drawn.
+	labelRect image.Rectangle
+
+	// mousePos is the current mouse position. Nil for no mouse input.
+	mousePos *view.MousePosition
+}
+
+func (i *indicatorCursor) SetData(data indicatorData) {
+	// Reset everything.
+	i.Close()
+
+	i.valueRange = data.Range
+
+	i.renderable = true
+}
+
+func (i *indicatorCursor) SetBounds(indicatorRect, labelRect image.Rectangle) {
+	i.indicatorRect = indicatorRect
+	i.labelRect = labelRect
+}
+
+func (i *indicatorCursor) ProcessInput(input *view.Input) {
+	i.mousePos = input.MousePos
+}
+
+func (i *indicatorCursor) Render(fudge float32) {
+	if !i.renderable {
+		return
+	}
+
+	if i.mousePos == nil {
+		return
+	}
+
+	renderCursorLines(i.indicatorRect, i.mousePos)
+
+	if i.mousePos.In(i.indicatorRect) {
+		renderIndicatorLabel(fudge, i.valueRange, i.labelRect, i.mousePos.Point, true)
+	}
+}
+
+func (i *indicatorCursor) Close() {
+	i.renderable = false
+}
@@ -0,0 +1,148 @@
+package chart
+
+import (
+	"fmt"
+	"image"
+
+	"github.com/btmura/ponzi2/internal/app/gfx"
+	"github.com/btmura/ponzi2/internal/app/view"
+	"github.com/btmura/ponzi2/internal/app/view/vao"
+)
+
+// indicatorHorizLine is the horizontal lines rendered behind the indicator lines.
+var indicatorHorizLine = vao.HorizLine(view.TransparentGray, view.Gray)
+
+// indicatorLevel renders the horizontal level lines, their labels, and the indicator title.
+type indicatorLevel struct {
+	// renderable is true if this should be rendered.
+	renderable bool
+
+	// title is the name of the indicator and its parameters.
+	title string
+
+	// valueRange represents the inclusive range from min to max value.
+	valueRange [2]float32
+
+	// levels are the values to draw lines and labels at.
+	levels []float32
+
+	// MaxLabelSize is the maximum label size useful for rendering measurements.
+	MaxLabelSize image.Point
+
+	// lineBounds is the rectangle where the horizontal lines should be drawn within.
+	lineBounds image.Rectangle
+
+	// labelBounds is the rectangle where the labels for the lines should be drawn within.
+	labelBounds image.Rectangle
+}
+
+func newIndicatorLevel() *indicatorLevel {
+	return new(indicatorLevel)
+}
+
+func (i *indicatorLevel) SetData(data indicatorData) {
+	// Reset everything.
+	i.Close()
+
+	i.title = data.Title
+	i.valueRange = data.Range
+	i.levels = data.Levels
+
+	// Measure the max label size by creating labels with the extreme values.
+	for _, v := range data.Range {
+		if s := makeIndicatorLabel(i.valueRange, v).size; s.X > i.MaxLabelSize.X {
+			i.MaxLabelSize = s
+		}
+	}
+
+	i.renderable = true
+}
+
+func (i *indicatorLevel) SetBounds(lineBounds, labelBounds image.Rectangle) {
+	i.lineBounds = lineBounds
+	i.labelBounds = labelBounds
+}
+
+func (i *indicatorLevel) Render(fudge float32) {
+	if !i.renderable {
+		return
+	}
+
+	r := i.lineBounds
+	for _, y := range i.levelYPositions(r) {
+		gfx.SetModelMatrixRect(image.Rect(r.Min.X, y, r.Max.X, y))
+		indicatorHorizLine.Render()
+	}
+
+	titlePt := image.Pt(r.Min.X+axisLabelPadding, r.Max.Y-axisLabelTextRenderer.LineHeight())
+	axisLabelTextRenderer.Render(i.title, titlePt, gfx.TextColor(view.LightGray))
+
+	r = i.labelBounds
+	for _, y := range i.levelYPositions(r) {
+		renderIndicatorLabel(fudge, i.valueRange, r, image.Pt(0, y), false)
+	}
+}
+
+func (i *indicatorLevel) levelYPositions(r image.Rectangle) []int {
+	var yPositions []int
+	for _, v := range i.levels {
+		yPositions = append(yPositions, r.Min.Y+int(float32(r.Dy())*indicatorPercent(i.valueRange, v)))
+	}
+	return yPositions
+}
+
+func (i *indicatorLevel) Close() {
+	i.renderable = false
+}
+
+// indicatorLabel is a right-justified Y-axis label with the indicator value.
+type indicatorLabel struct {
+	text string
+	size image.Point
+}
+
+func makeIndicatorLabel(valueRange [2]float32, value float32) indicatorLabel {
+	t := indicatorText(valueRange, value)
+	return indicatorLabel{
+		text: t,
+		size: axisLabelTextRenderer.Measure(t),
+	}
+}
+
+// indicatorText formats the value with decimals only when the range is small.
+func indicatorText(valueRange [2]float32, value float32) string {
+	if valueRange[1]-valueRange[0] >= 10 {
+		return fmt.Sprintf("%.0f", value)
+	}
+	return fmt.Sprintf("%.2f", value)
+}
+
+func renderIndicatorLabel(fudge float32, valueRange [2]float32, r image.Rectangle, pt image.Point, includeBubble bool) {
+	yPercent := float32(pt.Y-r.Min.Y) / float32(r.Dy())
+	value := indicatorValue(valueRange, yPercent)
+	label := makeIndicatorLabel(valueRange, value)
+
+	textPt := image.Point{
+		X: r.Max.X - label.size.X,
+		Y: r.Min.Y + int(float32(r.Dy())*yPercent) - label.size.Y/2,
+	}
+	bubbleRect := image.Rectangle{
+		Min: textPt,
+		Max: textPt.Add(label.size),
+	}.Inset(-axisLabelPadding)
+
+	// Move the label to the left if the mouse is overlapping.
+	if pt.In(bubbleRect) {
+		textPt.X = r.Min.X
+		bubbleRect = image.Rectangle{
+			Min: textPt,
+			Max: textPt.Add(label.size),
+		}.Inset(-axisLabelPadding)
+	}
+
+	if includeBubble {
+		axisLabelBubble.SetBounds(bubbleRect)
+		axisLabelBubble.Render(fudge)
+	}
+	axisLabelTextRenderer.Render(label.text, textPt, gfx.TextColor(view.White))
+}
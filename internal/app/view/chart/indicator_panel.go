@@ -0,0 +1,69 @@
+package chart
+
+import (
+	"image"
+
+	"github.com/btmura/ponzi2/internal/app/model"
+	"github.com/btmura/ponzi2/internal/app/view"
+)
+
+// indicatorPanel is a section below the volume that shows a technical indicator
+// with its own level labels, cursor, and timeline.
+type indicatorPanel struct {
+	indicator         *indicator
+	indicatorLevel    *indicatorLevel
+	indicatorCursor   *indicatorCursor
+	indicatorTimeline *timeline
+}
+
+func newIndicatorPanel() *indicatorPanel {
+	return &indicatorPanel{
+		indicator:         newIndicator(),
+		indicatorLevel:    newIndicatorLevel(),
+		indicatorCursor:   new(indicatorCursor),
+		indicatorTimeline: newTimeline(view.LightGray, view.TransparentLightGray, view.Gray, view.TransparentGray),
+	}
+}
+
+type indicatorPanelData struct {
+	Interval             model.Interval
+	TradingSessionSeries *model.TradingSessionSeries
+	Indicator            indicatorData
+}
+
+func (p *indicatorPanel) SetData(data indicatorPanelData) {
+	p.indicator.SetData(data.Indicator)
+	p.indicatorLevel.SetData(data.Indicator)
+	p.indicatorCursor.SetData(data.Indicator)
+	p.indicatorTimeline.SetData(timelineData{data.Interval, data.TradingSessionSeries})
+}
+
+// MaxLabelSize returns the maximum label size useful for rendering measurements.
+func (p *indicatorPanel) MaxLabelSize() image.Point {
+	return p.indicatorLevel.MaxLabelSize
+}
+
+func (p *indicatorPanel) SetBounds(indicatorRect, labelRect image.Rectangle) {
+	p.indicator.SetBounds(indicatorRect)
+	p.indicatorLevel.SetBounds(indicatorRect, labelRect)
+	p.indicatorCursor.SetBounds(indicatorRect, labelRect)
+	p.indicatorTimeline.SetBounds(indicatorRect)
+}
+
+func (p *indicatorPanel) ProcessInput(input *view.Input) {
+	p.indicatorCursor.ProcessInput(input)
+}
+
+func (p *indicatorPanel) Render(fudge float32) {
+	p.indicatorTimeline.Render(fudge)
+	p.indicatorLevel.Render(fudge)
+	p.indicator.Render(fudge)
+	p.indicatorCursor.Render(fudge)
+}
+
+func (p *indicatorPanel) Close() {
+	p.indicator.Close()
+	p.indicatorLevel.Close()
+	p.indicatorCursor.Close()
+	p.indicatorTimeline.Close()
+}
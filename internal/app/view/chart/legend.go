@@ -99,6 +99,9 @@ type legendData struct {
 	Interval               model.Interval
 	TradingSessionSeries   *model.TradingSessionSeries
 	MovingAverageSeriesSet []*model.MovingAverageSeries
+	MovingAverageSettings  []*MovingAverageSetting
+	BandSeriesSet          []*model.BandSeries
+	BandSettings           []*BandSetting
 }
 
 func (l *legend) SetData(data legendData) {
@@ -206,7 +209,7 @@ func (l *legend) Update() (dirty bool) {
 	}
 
 	rows := [][3]legendCell{
-		{empty, text(curr.Date.Format("1/2/06")), empty},
+		{empty, text(curr.Date.Format(legendDateLayout(l.data.Interval))), empty},
 		{empty, empty, empty},
 		{
 			whiteArrow(curr.Open - prev.Open),
@@ -251,12 +254,40 @@ func (l *legend) Update() (dirty bool) {
 		}
 
 		rows = append(rows, [3]legendCell{
-			symbol("◼", movingAverageColors[l.data.Interval][ma.Intervals]),
+			symbol("◼", movingAverageColor(l.data.MovingAverageSettings, l.data.Interval, ma)),
 			text(fmt.Sprintf("%s %d", typeLabel, ma.Intervals)),
 			text(formatFloat(ma.Values[i].Value)),
 		})
 	}
 
+	if len(l.data.BandSeriesSet) != 0 {
+		rows = append(rows, [3]legendCell{empty, empty, empty})
+	}
+
+	for _, b := range l.data.BandSeriesSet {
+		typeLabel := "?"
+		switch b.Type {
+		case model.BollingerBands:
+			typeLabel = "BB"
+		case model.KeltnerChannels:
+			typeLabel = "KC"
+		}
+
+		v := b.Values[i]
+		rows = append(rows,
+			[3]legendCell{
+				symbol("◼", bandColor(l.data.BandSettings, l.data.Interval, b)),
+				text(fmt.Sprintf("%s %d, %g", typeLabel, b.Intervals, b.Width)),
+				text(formatFloat(v.Upper)),
+			},
+			[3]legendCell{
+				empty,
+				empty,
+				text(formatFloat(v.Lower)),
+			},
+		)
+	}
+
 	if curr.Volume != 0 {
 		dv := curr.Volume - prev.Volume
 		rows = append(rows,
@@ -328,3 +359,15 @@ func (l *legend) Render(fudge float32) {
 func (l *legend) Close() {
 	l.renderable = false
 }
+
+// legendDateLayout returns the layout to format trading session dates with.
+func legendDateLayout(interval model.Interval) string {
+	switch interval {
+	case model.Intraday:
+		return "3:04 PM"
+	case model.Monthly:
+		return "Jan 2006"
+	default:
+		return "1/2/06"
+	}
+}
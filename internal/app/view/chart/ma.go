@@ -22,6 +22,7 @@ func newMovingAverage(color view.Color) *movingAverage {
 
 type movingAverageData struct {
 	TradingSessionSeries *model.TradingSessionSeries
+	BandSeriesSet        []*model.BandSeries
 	MovingAverageSeries  *model.MovingAverageSeries
 }
 
@@ -40,7 +41,7 @@ func (m *movingAverage) SetData(data movingAverageData) {
 		return
 	}
 
-	yRange := priceRange(ts.TradingSessions)
+	yRange := priceRange(ts.TradingSessions, data.BandSeriesSet)
 
 	m.line = movingAverageDataLine(ms.Values, yRange, m.color)
 
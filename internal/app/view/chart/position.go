@@ -0,0 +1,274 @@
+package chart
+
+import (
+	"fmt"
+	"image"
+	"sort"
+	"time"
+
+	"github.com/btmura/ponzi2/internal/app/gfx"
+	"github.com/btmura/ponzi2/internal/app/model"
+	"github.com/btmura/ponzi2/internal/app/view"
+	"github.com/btmura/ponzi2/internal/app/view/vao"
+)
+
+// lotMarkerSize is the width and height in pixels of the markers at each lot's date and price.
+const lotMarkerSize = 8
+
+// The cost basis line and lot markers are blue to stand apart from drawings and alerts.
+// Lot markers are yellow under the mouse.
+var (
+	costBasisColor       = view.Blue
+	costBasisLineVAO     = vao.HorizLine(costBasisColor, costBasisColor)
+	lotMarkerVAO         = lotMarkerIconVAO(costBasisColor)
+	selectedLotMarkerVAO = lotMarkerIconVAO(view.Yellow)
+)
+
+// positionLayer renders a line at the average cost of the user's position
+// and markers at the date and price of each lot that has a date.
+// Intraday charts only show the average cost line.
+//
+// It also lets the user add and remove lots by clicking on the prices while lot mode is active.
+// Clicking on an empty spot asks to add a lot at the price and date under the mouse.
+// Clicking on a lot marker removes the lot. Lots without dates and lots on intraday
+// charts are marked at the latest session in lot mode, so that they can be removed too.
+type positionLayer struct {
+	// renderable is true if this should be rendered.
+	renderable bool
+
+	// showLots is true if the lot markers should be rendered.
+	showLots bool
+
+	// tradingSessions are used to convert dates to x coordinates.
+	tradingSessions []*model.TradingSession
+
+	// priceRange is used to convert prices to y coordinates.
+	priceRange [2]float32
+
+	// position is the user's position. Nil if the user has no lots.
+	position *model.Position
+
+	// active is whether clicks add and remove lots.
+	active bool
+
+	// selectedIndex is the index of the lot marker under the mouse. -1 if none.
+	selectedIndex int
+
+	// bounds is the rectangle where the prices are drawn.
+	bounds image.Rectangle
+
+	// addCallback is fired with the price and date under the mouse when the user wants to add a lot.
+	addCallback func(price float32, date time.Time)
+
+	// removeCallback is fired with the index of the lot the user wants removed.
+	removeCallback func(index int)
+}
+
+func newPositionLayer() *positionLayer {
+	return &positionLayer{selectedIndex: -1}
+}
+
+type positionLayerData struct {
+	Interval             model.Interval
+	TradingSessionSeries *model.TradingSessionSeries
+	BandSeriesSet        []*model.BandSeries
+	Position             *model.Position
+}
+
+func (p *positionLayer) SetData(data positionLayerData) {
+	// Reset everything.
+	p.Close()
+
+	// Bail out if there is no data yet.
+	ts := data.TradingSessionSeries
+	if ts == nil || len(ts.TradingSessions) == 0 {
+		return
+	}
+
+	p.tradingSessions = ts.TradingSessions
+	p.priceRange = priceRange(ts.TradingSessions, data.BandSeriesSet)
+	p.position = data.Position
+	p.showLots = data.Interval != model.Intraday
+
+	p.renderable = true
+}
+
+// SetActive sets whether clicks add and remove lots.
+func (p *positionLayer) SetActive(active bool) {
+	p.active = active
+}
+
+func (p *positionLayer) SetBounds(bounds image.Rectangle) {
+	p.bounds = bounds
+}
+
+func (p *positionLayer) ProcessInput(input *view.Input) {
+	p.selectedIndex = -1
+
+	if !p.renderable || !p.active || !input.MousePos.In(p.bounds) {
+		return
+	}
+
+	if p.position != nil {
+		for i, l := range p.position.Lots {
+			if pt, ok := p.lotPoint(l); ok && abs(pt.X-input.MousePos.X) <= drawingHitDistance && abs(pt.Y-input.MousePos.Y) <= drawingHitDistance {
+				p.selectedIndex = i
+				break
+			}
+		}
+	}
+
+	if !input.MouseLeftButtonClicked.In(p.bounds) {
+		return
+	}
+
+	if i := p.selectedIndex; i >= 0 {
+		input.AddFiredCallback(func() {
+			if p.removeCallback != nil {
+				p.removeCallback(i)
+			}
+		})
+		return
+	}
+
+	_, session := tradingSessionAtX(p.tradingSessions, p.bounds, input.MousePos.X)
+
+	yPercent := float32(input.MousePos.Y-p.bounds.Min.Y) / float32(p.bounds.Dy())
+	price := priceValue(p.priceRange, yPercent)
+
+	// Lot dates are calendar days like the dates that -add_lot parses.
+	y, m, d := session.Date.Date()
+	date := time.Date(y, m, d, 0, 0, 0, 0, time.UTC)
+
+	input.AddFiredCallback(func() {
+		if p.addCallback != nil {
+			p.addCallback(price, date)
+		}
+	})
+}
+
+// priceY returns the y coordinate of the price and true if it is within the bounds.
+func (p *positionLayer) priceY(price float32) (int, bool) {
+	yp := pricePercent(p.priceRange, price)
+	if yp < 0 || yp > 1 {
+		return 0, false
+	}
+	return p.bounds.Min.Y + int(float32(p.bounds.Dy())*yp), true
+}
+
+// lotPoint returns the center of the lot's marker and true if it should be rendered.
+func (p *positionLayer) lotPoint(l *model.Lot) (image.Point, bool) {
+	// Lots that can't be placed by date are marked at the latest session only in lot mode.
+	atDate := p.showLots && !l.Date.IsZero()
+	if !atDate && !p.active {
+		return image.Point{}, false
+	}
+
+	y, ok := p.priceY(l.Price)
+	if !ok {
+		return image.Point{}, false
+	}
+
+	n := float32(len(p.tradingSessions))
+	xPercent := (n - 0.5) / n
+	if atDate {
+		xPercent = lotXPercent(p.tradingSessions, l.Date)
+	}
+
+	return image.Pt(p.bounds.Min.X+int(float32(p.bounds.Dx())*xPercent), y), true
+}
+
+func (p *positionLayer) Render(float32) {
+	if !p.renderable || p.position == nil {
+		return
+	}
+
+	r := p.bounds
+
+	if y, ok := p.priceY(p.position.AveragePrice); ok {
+		gfx.SetModelMatrixRect(image.Rect(r.Min.X, y, r.Max.X, y))
+		costBasisLineVAO.Render()
+
+		txt := fmt.Sprintf("Cost %.2f", p.position.AveragePrice)
+		sz := axisLabelTextRenderer.Measure(txt)
+		pt := image.Pt(r.Max.X-sz.X-axisLabelPadding, y+axisLabelPadding)
+		axisLabelTextRenderer.Render(txt, pt, gfx.TextColor(costBasisColor))
+	}
+
+	for i, l := range p.position.Lots {
+		pt, ok := p.lotPoint(l)
+		if !ok {
+			continue
+		}
+
+		marker := lotMarkerVAO
+		if i == p.selectedIndex {
+			marker = selectedLotMarkerVAO
+		}
+
+		gfx.SetModelMatrixRect(image.Rect(pt.X-lotMarkerSize/2, pt.Y-lotMarkerSize/2, pt.X+lotMarkerSize/2, pt.Y+lotMarkerSize/2))
+		marker.Render()
+	}
+}
+
+func (p *positionLayer) Close() {
+	p.renderable = false
+	p.tradingSessions = nil
+	p.position = nil
+	p.selectedIndex = -1
+}
+
+// lotXPercent returns the x percent of the center of the session containing the lot's date.
+// Dates are compared by calendar day, since lot dates are not in the sessions' time zone.
+func lotXPercent(ts []*model.TradingSession, date time.Time) float32 {
+	day := func(t time.Time) time.Time {
+		y, m, d := t.Date()
+		return time.Date(y, m, d, 0, 0, 0, 0, time.UTC)
+	}
+	lotDay := day(date)
+
+	// Find the last session that starts on or before the date.
+	i := sort.Search(len(ts), func(i int) bool {
+		return day(ts[i].Date).After(lotDay)
+	}) - 1
+	if i < 0 {
+		i = 0
+	}
+	return (float32(i) + 0.5) / float32(len(ts))
+}
+
+// lotMarkerIconVAO returns a diamond outline to mark where lots were bought.
+func lotMarkerIconVAO(color view.Color) *gfx.VAO {
+	data := &gfx.VAOVertexData{
+		Mode: gfx.Lines,
+		Vertices: []float32{
+			0, 1, 0, // 0
+			1, 0, 0, // 1
+			0, -1, 0, // 2
+			-1, 0, 0, // 3
+		},
+		Indices: []uint16{0, 1, 1, 2, 2, 3, 3, 0},
+	}
+	for i := 0; i < 4; i++ {
+		data.Colors = append(data.Colors, color[0], color[1], color[2], color[3])
+	}
+	return gfx.NewVAO(data)
+}
+
+// lotIconVAO returns a small diamond like the lot markers for the header's lot button.
+func lotIconVAO(color view.Color) *gfx.VAO {
+	data := &gfx.VAOVertexData{
+		Mode: gfx.Lines,
+		Vertices: []float32{
+			0, 0.35, 0, // 0
+			0.35, 0, 0, // 1
+			0, -0.35, 0, // 2
+			-0.35, 0, 0, // 3
+		},
+		Indices: []uint16{0, 1, 1, 2, 2, 3, 3, 0},
+	}
+	for i := 0; i < 4; i++ {
+		data.Colors = append(data.Colors, color[0], color[1], color[2], color[3])
+	}
+	return gfx.NewVAO(data)
+}
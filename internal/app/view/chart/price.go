@@ -68,6 +68,7 @@ func (p *price) SetStyle(newStyle PriceStyle) {
 
 type priceData struct {
 	TradingSessionSeries *model.TradingSessionSeries
+	BandSeriesSet        []*model.BandSeries
 }
 
 func (p *price) SetData(data priceData) {
@@ -80,7 +81,7 @@ func (p *price) SetData(data priceData) {
 		return
 	}
 
-	p.priceRange = priceRange(ts.TradingSessions)
+	p.priceRange = priceRange(ts.TradingSessions, data.BandSeriesSet)
 
 	p.barLines = priceBarVAO(ts.TradingSessions, p.priceRange)
 
@@ -144,7 +145,8 @@ func (p *price) Close() {
 	}
 }
 
-func priceRange(ts []*model.TradingSession) [2]float32 {
+// priceRange returns the range of prices including any price bands, so the bands are not clipped.
+func priceRange(ts []*model.TradingSession, bs []*model.BandSeries) [2]float32 {
 	if len(ts) == 0 {
 		return [2]float32{0, 0}
 	}
@@ -160,13 +162,29 @@ func priceRange(ts []*model.TradingSession) [2]float32 {
 		}
 	}
 
+	for _, b := range bs {
+		for _, v := range b.Values {
+			if v.Lower > 0 && v.Lower < low {
+				low = v.Lower
+			}
+			if v.Upper != 0 && v.Upper > high {
+				high = v.Upper
+			}
+		}
+	}
+
 	if low > high {
 		return [2]float32{0, 0}
 	}
 
 	// Pad the high and low, so the candlesticks have space around them.
+	// Prices are drawn on a log scale, so keep the low positive when the bands go near zero.
 	padding := (high - low) * .05
-	low -= padding
+	if low-padding > low/2 {
+		low -= padding
+	} else {
+		low /= 2
+	}
 	high += padding
 
 	return [2]float32{low, high}
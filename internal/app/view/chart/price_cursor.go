@@ -31,6 +31,7 @@ type priceCursor struct {
 
 type priceCursorData struct {
 	TradingSessionSeries *model.TradingSessionSeries
+	BandSeriesSet        []*model.BandSeries
 }
 
 func (p *priceCursor) SetData(data priceCursorData) {
@@ -45,7 +46,7 @@ func (p *priceCursor) SetData(data priceCursorData) {
 		return
 	}
 
-	p.priceRange = priceRange(ts.TradingSessions)
+	p.priceRange = priceRange(ts.TradingSessions, data.BandSeriesSet)
 
 	p.renderable = true
 }
@@ -37,6 +37,7 @@ func newPriceLevel() *priceLevel {
 
 type priceLevelData struct {
 	TradingSessionSeries *model.TradingSessionSeries
+	BandSeriesSet        []*model.BandSeries
 }
 
 func (p *priceLevel) SetData(data priceLevelData) {
@@ -49,7 +50,7 @@ func (p *priceLevel) SetData(data priceLevelData) {
 		return
 	}
 
-	p.priceRange = priceRange(ts.TradingSessions)
+	p.priceRange = priceRange(ts.TradingSessions, data.BandSeriesSet)
 
 	// Measure the max label size by creating a label with the max value.
 	p.MaxLabelSize = makePriceLabel(p.priceRange[1]).size
@@ -0,0 +1,72 @@
+package chart
+
+import (
+	"math"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+
+	"github.com/btmura/ponzi2/internal/app/model"
+)
+
+func TestPriceRange(t *testing.T) {
+	for _, tt := range []struct {
+		desc string
+		ts   []*model.TradingSession
+		bs   []*model.BandSeries
+		want [2]float32
+	}{
+		{
+			desc: "no sessions",
+			want: [2]float32{0, 0},
+		},
+		{
+			desc: "sessions",
+			ts: []*model.TradingSession{
+				{Low: 10, High: 20},
+				{Low: 15, High: 30},
+			},
+			want: [2]float32{9, 31},
+		},
+		{
+			desc: "bands widen the range",
+			ts: []*model.TradingSession{
+				{Low: 10, High: 20},
+			},
+			bs: []*model.BandSeries{
+				{Values: []*model.BandValue{{Lower: 5, Upper: 25}}},
+			},
+			want: [2]float32{4, 26},
+		},
+		{
+			desc: "band lower value below the padding",
+			ts: []*model.TradingSession{
+				{Low: 1, High: 20},
+			},
+			bs: []*model.BandSeries{
+				{Values: []*model.BandValue{{Lower: 0.5, Upper: 20}}},
+			},
+			want: [2]float32{0.25, 20.975},
+		},
+	} {
+		t.Run(tt.desc, func(t *testing.T) {
+			got := priceRange(tt.ts, tt.bs)
+			if diff := cmp.Diff(tt.want, got, cmp.Comparer(approxEqual)); diff != "" {
+				t.Errorf("diff (-want, +got)\n%s", diff)
+			}
+
+			if len(tt.ts) != 0 {
+				if got[0] <= 0 {
+					t.Errorf("got low %v, want it positive for the log scale", got[0])
+				}
+				if p := pricePercent(got, tt.ts[0].Low); math.IsNaN(float64(p)) || math.IsInf(float64(p), 0) {
+					t.Errorf("pricePercent(%v, %v) = %v, want a number", got, tt.ts[0].Low, p)
+				}
+			}
+		})
+	}
+}
+
+func approxEqual(a, b float32) bool {
+	return math.Abs(float64(a-b)) < 1e-4
+}
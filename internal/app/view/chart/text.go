@@ -0,0 +1,38 @@
+package chart
+
+import "github.com/btmura/ponzi2/internal/errs"
+
+// The enums below implement encoding.TextMarshaler and encoding.TextUnmarshaler,
+// so that formats like JSON show their names instead of numbers.
+
+// MarshalText implements encoding.TextMarshaler.
+func (p PriceStyle) MarshalText() ([]byte, error) {
+	return []byte(p.String()), nil
+}
+
+// UnmarshalText implements encoding.TextUnmarshaler.
+func (p *PriceStyle) UnmarshalText(text []byte) error {
+	for v := PriceStyleUnspecified; v <= Candlestick; v++ {
+		if v.String() == string(text) {
+			*p = v
+			return nil
+		}
+	}
+	return errs.Errorf("bad price style: %q", text)
+}
+
+// MarshalText implements encoding.TextMarshaler.
+func (i Indicator) MarshalText() ([]byte, error) {
+	return []byte(i.String()), nil
+}
+
+// UnmarshalText implements encoding.TextUnmarshaler.
+func (i *Indicator) UnmarshalText(text []byte) error {
+	for v := IndicatorUnspecified; v <= SlowStochastic; v++ {
+		if v.String() == string(text) {
+			*i = v
+			return nil
+		}
+	}
+	return errs.Errorf("bad indicator: %q", text)
+}
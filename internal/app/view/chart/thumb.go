@@ -2,6 +2,7 @@ package chart
 
 import (
 	"image"
+	"math"
 
 	"golang.org/x/image/font/gofont/goregular"
 
@@ -20,11 +21,15 @@ const (
 	thumbSectionPadding = 2
 	thumbTextPadding    = 10
 	thumbVolumePercent  = 0.4
+
+	// thumbAlertFlashes is how many times the thumb flashes when an alert triggers.
+	thumbAlertFlashes = 3
 )
 
 var (
 	thumbSymbolQuoteTextRenderer = gfx.NewTextRenderer(goregular.TTF, 12)
 	thumbQuotePrinter            = func(q *model.Quote) string { return status.PriceChange(q) }
+	thumbAlertBorderVAO          = thumbBorderVAO(view.Yellow)
 )
 
 // Thumb shows a thumbnail for a stock.
@@ -66,9 +71,25 @@ type Thumb struct {
 	// fadeIn fades in the data after it loads.
 	fadeIn *animation.Animation
 
+	// triggeredAlertCount is how many of the stock's alerts have triggered.
+	// The thumb has a border while it's positive.
+	triggeredAlertCount int
+
+	// alertFlash flashes the border when more alerts trigger.
+	alertFlash *animation.Animation
+
+	// positionText is the short text with the user's position gain. Empty if the user has no lots.
+	positionText string
+
+	// positionColor is the color to render the position text.
+	positionColor view.Color
+
 	// bounds is the rect with global coords that should be drawn within.
 	bounds image.Rectangle
 
+	// priceBounds is a sub-rect of the body where the prices are drawn.
+	priceBounds image.Rectangle
+
 	// bodyBounds is a sub-rect of bounds without the header.
 	bodyBounds image.Rectangle
 
@@ -106,6 +127,7 @@ func NewThumb(priceStyle PriceStyle) *Thumb {
 		errorTextBox:   text.NewBox(thumbSymbolQuoteTextRenderer, "ERROR", text.Color(view.Orange), text.Padding(thumbTextPadding)),
 		loading:        true,
 		fadeIn:         animation.New(1 * view.FPS),
+		alertFlash:     animation.New(thumbAlertFlashes * view.FPS),
 	}
 }
 
@@ -138,6 +160,9 @@ func (t *Thumb) SetErrorMessage(errorMessage string) {
 
 // SetData sets the data to be shown on the chart.
 func (t *Thumb) SetData(data Data) {
+	// Only flash for alerts triggered by updates and not alerts restored on startup.
+	flashAlerts := t.hasStockUpdated
+
 	if !t.hasStockUpdated && data.Chart != nil {
 		t.fadeIn.Start()
 	}
@@ -145,6 +170,24 @@ func (t *Thumb) SetData(data Data) {
 
 	t.header.SetData(data)
 
+	var triggered int
+	for _, a := range data.Alerts {
+		if a.Triggered {
+			triggered++
+		}
+	}
+	if flashAlerts && triggered > t.triggeredAlertCount {
+		t.alertFlash = animation.New(thumbAlertFlashes*view.FPS, animation.Started())
+	}
+	t.triggeredAlertCount = triggered
+
+	t.positionText = status.PositionGain(data.Position)
+	var g float32
+	if p := data.Position; p != nil {
+		g = p.GainLoss
+	}
+	t.positionColor = changeColor(g)
+
 	dc := data.Chart
 	if dc == nil {
 		return
@@ -159,14 +202,16 @@ func (t *Thumb) SetData(data Data) {
 
 	vs := dc.AverageVolumeSeries
 
-	if ts == nil || vs == nil {
+	if ts == nil {
 		return
 	}
 
 	tl := len(ts.TradingSessions)
-	if vl := len(vs.Values); tl != vl {
-		logger.Errorf("volume has different lengths: %d vs %d", tl, vl)
-		return
+	if vs != nil {
+		if vl := len(vs.Values); tl != vl {
+			logger.Errorf("volume has different lengths: %d vs %d", tl, vl)
+			return
+		}
 	}
 
 	for _, ma := range mas {
@@ -188,13 +233,15 @@ func (t *Thumb) SetData(data Data) {
 			mas[i] = m
 		}
 	}
-	if l := len(vs.Values); l > days {
-		vs = vs.DeepCopy()
-		vs.Values = vs.Values[l-days:]
+	if vs != nil {
+		if l := len(vs.Values); l > days {
+			vs = vs.DeepCopy()
+			vs.Values = vs.Values[l-days:]
+		}
 	}
 
-	t.price.SetData(priceData{ts})
-	t.priceCursor.SetData(priceCursorData{ts})
+	t.price.SetData(priceData{TradingSessionSeries: ts})
+	t.priceCursor.SetData(priceCursorData{TradingSessionSeries: ts})
 	t.priceTimeline.SetData(timelineData{dc.Interval, ts})
 
 	for _, ma := range t.movingAverages {
@@ -203,8 +250,8 @@ func (t *Thumb) SetData(data Data) {
 
 	t.movingAverages = nil
 	for _, ma := range mas {
-		m := newMovingAverage(movingAverageColors[dc.Interval][ma.Intervals])
-		m.SetData(movingAverageData{ts, ma})
+		m := newMovingAverage(movingAverageColor(data.MovingAverageSettings, dc.Interval, ma))
+		m.SetData(movingAverageData{TradingSessionSeries: ts, MovingAverageSeries: ma})
 		t.movingAverages = append(t.movingAverages, m)
 	}
 
@@ -244,6 +291,8 @@ func (t *Thumb) ProcessInput(input *view.Input) {
 	pr = pr.Inset(thumbSectionPadding)
 	vr = vr.Inset(thumbSectionPadding)
 
+	t.priceBounds = pr
+
 	t.price.SetBounds(pr)
 	t.priceCursor.SetBounds(pr, pr)
 	t.priceTimeline.SetBounds(pr)
@@ -280,6 +329,9 @@ func (t *Thumb) Update() (dirty bool) {
 	if t.fadeIn.Update() {
 		dirty = true
 	}
+	if t.alertFlash.Update() {
+		dirty = true
+	}
 	return dirty
 }
 
@@ -288,6 +340,7 @@ func (t *Thumb) Render(fudge float32) {
 	t.frameBubble.Render(fudge)
 	t.header.Render(fudge)
 	rect.RenderLineAtTop(t.bodyBounds)
+	t.renderAlertBorder(fudge)
 
 	// Only show messages if no prior data to show.
 	if !t.hasStockUpdated {
@@ -318,11 +371,60 @@ func (t *Thumb) Render(fudge float32) {
 	}
 	t.priceCursor.Render(fudge)
 
+	if t.positionText != "" {
+		r := t.priceBounds
+		pt := image.Pt(r.Min.X+thumbSectionPadding, r.Max.Y-thumbSymbolQuoteTextRenderer.LineHeight())
+		thumbSymbolQuoteTextRenderer.Render(t.positionText, pt, gfx.TextColor(t.positionColor), gfx.TextRenderMaxWidth(r.Dx()))
+	}
+
 	t.volumeTimeline.Render(fudge)
 	t.volume.Render(fudge)
 	t.volumeCursor.Render(fudge)
 }
 
+// renderAlertBorder renders a border while alerts have triggered that flashes when new ones trigger.
+func (t *Thumb) renderAlertBorder(fudge float32) {
+	if t.triggeredAlertCount == 0 {
+		return
+	}
+
+	alpha := float32(1)
+	if t.alertFlash.Animating() {
+		// Go from bright to dark and back once per flash.
+		alpha = float32(math.Abs(math.Cos(math.Pi * thumbAlertFlashes * float64(t.alertFlash.Value(fudge)))))
+	}
+
+	old := gfx.Alpha()
+	gfx.SetAlpha(old * alpha)
+	defer gfx.SetAlpha(old)
+
+	gfx.SetModelMatrixRect(t.bounds.Inset(thumbRounding / 2))
+	thumbAlertBorderVAO.Render()
+}
+
+// thumbBorderVAO returns a VAO of a square outline from (-1, -1) to (1, 1).
+func thumbBorderVAO(color view.Color) *gfx.VAO {
+	data := &gfx.VAOVertexData{
+		Mode: gfx.Lines,
+		Vertices: []float32{
+			-1, -1, 0, // 0
+			+1, -1, 0, // 1
+			+1, +1, 0, // 2
+			-1, +1, 0, // 3
+		},
+		Indices: []uint16{
+			0, 1,
+			1, 2,
+			2, 3,
+			3, 0,
+		},
+	}
+	for i := 0; i < 4; i++ {
+		data.Colors = append(data.Colors, color[0], color[1], color[2], color[3])
+	}
+	return gfx.NewVAO(data)
+}
+
 // SetRemoveButtonClickCallback sets the callback for remove button clicks.
 func (t *Thumb) SetRemoveButtonClickCallback(cb func()) {
 	t.header.SetRemoveButtonClickCallback(cb)
@@ -93,6 +93,8 @@ func weekLineValues(interval model.Interval, ts []*model.TradingSession) (majorV
 		cm := curr.Month()
 		monthChanged := pm != cm
 
+		yearChanged := prev.Year() != curr.Year()
+
 		if prev.Month() != curr.Month() {
 			pendingMonthChanged = true
 		}
@@ -107,7 +109,7 @@ func weekLineValues(interval model.Interval, ts []*model.TradingSession) (majorV
 
 		switch interval {
 		case model.Intraday:
-			if hourChanged {
+			if !hourChanged {
 				continue
 			}
 
@@ -138,6 +140,19 @@ func weekLineValues(interval model.Interval, ts []*model.TradingSession) (majorV
 				addMinor()
 			}
 
+		case model.Monthly:
+			if !monthChanged {
+				continue
+			}
+
+			switch {
+			case yearChanged:
+				addMajor()
+
+			case cm == time.April, cm == time.July, cm == time.October:
+				addMinor()
+			}
+
 		default:
 			logger.Errorf("bad interval: %v", interval)
 		}
@@ -93,6 +93,8 @@ func timelineLabelText(interval model.Interval, t time.Time) string {
 		return t.Format("Jan")
 	case model.Weekly:
 		return t.Format("Jan")[:1]
+	case model.Monthly:
+		return t.Format("2006")
 	default:
 		logger.Errorf("bad interval: %v", interval)
 		return ""
@@ -124,6 +126,13 @@ func makeTimelineLabels(interval model.Interval, ts []*model.TradingSession) []t
 				continue
 			}
 
+		case model.Monthly:
+			py := ts[i-1].Date.Year()
+			y := ts[i].Date.Year()
+			if py == y {
+				continue
+			}
+
 		default:
 			logger.Errorf("bad interval: %v", interval)
 			return nil
@@ -50,9 +50,11 @@ func (t *timelineCursor) SetData(data timelineCursorData) {
 
 	switch data.Interval {
 	case model.Intraday:
-		t.layout = "03:04"
+		t.layout = "3:04 PM"
 	case model.Daily, model.Weekly:
 		t.layout = "1/2/06"
+	case model.Monthly:
+		t.layout = "Jan 2006"
 	default:
 		logger.Errorf("bad interval: %v", data.Interval)
 		return
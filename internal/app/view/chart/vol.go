@@ -75,8 +75,7 @@ func (v *volume) SetData(data volumeData) {
 
 	// Bail out if there is no data yet.
 	ts := data.TradingSessionSeries
-	vs := data.AverageVolumeSeries
-	if ts == nil || vs == nil {
+	if ts == nil {
 		return
 	}
 
@@ -84,7 +83,11 @@ func (v *volume) SetData(data volumeData) {
 
 	v.barLines = volumeLineVAO(ts.TradingSessions, yRange, Bar)
 	v.stickLines = volumeLineVAO(ts.TradingSessions, yRange, Candlestick)
-	v.avgLine = volumeDataLine(vs.Values, yRange)
+
+	// Intraday charts have no average volume series.
+	if vs := data.AverageVolumeSeries; vs != nil {
+		v.avgLine = volumeDataLine(vs.Values, yRange)
+	}
 
 	v.renderable = true
 }
@@ -129,7 +132,9 @@ func (v *volume) Render(fudge float32) {
 		})
 	}
 
-	v.avgLine.Render()
+	if v.avgLine != nil {
+		v.avgLine.Render()
+	}
 }
 
 func (v *volume) Close() {
@@ -142,6 +147,7 @@ func (v *volume) Close() {
 	}
 	if v.avgLine != nil {
 		v.avgLine.Delete()
+		v.avgLine = nil
 	}
 }
 
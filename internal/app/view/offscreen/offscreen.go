@@ -0,0 +1,107 @@
+// Package offscreen renders charts into images without showing a window.
+//
+// It is separate from the ui package, so that only commands that render images
+// need the libraries to create OpenGL contexts without a window system.
+package offscreen
+
+import (
+	"image"
+	"runtime"
+
+	"github.com/go-gl/gl/v4.5-core/gl"
+
+	"github.com/btmura/ponzi2/internal/app/gfx"
+	"github.com/btmura/ponzi2/internal/app/view"
+	"github.com/btmura/ponzi2/internal/app/view/chart"
+	"github.com/btmura/ponzi2/internal/matrix"
+)
+
+// windowTitle is the title of the hidden window on platforms where the context needs one.
+const windowTitle = "ponzichart"
+
+// maxUpdates is the most updates to finish animations before rendering.
+const maxUpdates = 1000
+
+func init() {
+	// OpenGL contexts are current on a thread, so keep main() on the main thread.
+	runtime.LockOSThread()
+}
+
+// Offscreen renders charts into images without showing a window.
+//
+// On Linux, the OpenGL context comes from EGL, which doesn't need a window system.
+// Elsewhere, GLFW only creates contexts along with windows, so it creates one that is never shown.
+// Either way, charts are rendered to a framebuffer.
+type Offscreen struct {
+	// ctx is the OpenGL context that isn't attached to a visible window.
+	ctx *offscreenContext
+
+	// framebuffer is where charts are rendered.
+	framebuffer *gfx.Framebuffer
+
+	// size is the size of the rendered images.
+	size image.Point
+}
+
+// New initializes OpenGL and returns an Offscreen that renders images of the given size.
+// It must be called from the main thread.
+func New(size image.Point) (*Offscreen, error) {
+	ctx, err := newOffscreenContext(size)
+	if err != nil {
+		return nil, err
+	}
+
+	o := &Offscreen{ctx: ctx, size: size}
+
+	if err := ctx.initGL(); err != nil {
+		o.Close()
+		return nil, err
+	}
+
+	gl.Enable(gl.BLEND)
+	gl.BlendFunc(gl.SRC_ALPHA, gl.ONE_MINUS_SRC_ALPHA)
+	gl.ClearColor(0, 0, 0, 1)
+
+	if err := gfx.InitProgram(); err != nil {
+		o.Close()
+		return nil, err
+	}
+
+	gfx.SetAlpha(1.0)
+
+	fb, err := gfx.NewFramebuffer(size)
+	if err != nil {
+		o.Close()
+		return nil, err
+	}
+	o.framebuffer = fb
+
+	gl.Viewport(0, 0, int32(size.X), int32(size.Y))
+	fw, fh := float32(size.X), float32(size.Y)
+	gfx.SetProjectionViewMatrix(matrix.Ortho(fw, fh, fw /* use width as depth */))
+
+	return o, nil
+}
+
+// RenderChart renders the chart with its data already set and returns the image.
+func (o *Offscreen) RenderChart(ch *chart.Chart) *image.RGBA {
+	ch.SetBounds(image.Rectangle{Max: o.size})
+	ch.ProcessInput(new(view.Input))
+
+	// Finish animations like the fade in after data loads.
+	for i := 0; i < maxUpdates && ch.Update(); i++ {
+	}
+
+	gl.Clear(gl.COLOR_BUFFER_BIT | gl.DEPTH_BUFFER_BIT)
+	ch.Render(0)
+
+	return o.framebuffer.Image()
+}
+
+// Close releases the framebuffer and the OpenGL context.
+func (o *Offscreen) Close() {
+	if o.framebuffer != nil {
+		o.framebuffer.Delete()
+	}
+	o.ctx.destroy()
+}
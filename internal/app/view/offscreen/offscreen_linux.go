@@ -0,0 +1,146 @@
+package offscreen
+
+// #cgo LDFLAGS: -lEGL
+// #include <stdlib.h>
+// #include <EGL/egl.h>
+// #include <EGL/eglext.h>
+//
+// #ifndef EGL_PLATFORM_SURFACELESS_MESA
+// #define EGL_PLATFORM_SURFACELESS_MESA 0x31DD
+// #endif
+//
+// // getDisplay returns Mesa's surfaceless display, which needs no window system,
+// // or the default display if the surfaceless platform isn't available.
+// static EGLDisplay getDisplay() {
+// 	PFNEGLGETPLATFORMDISPLAYEXTPROC getPlatformDisplay =
+// 		(PFNEGLGETPLATFORMDISPLAYEXTPROC) eglGetProcAddress("eglGetPlatformDisplayEXT");
+// 	if (getPlatformDisplay) {
+// 		EGLDisplay d = getPlatformDisplay(EGL_PLATFORM_SURFACELESS_MESA, EGL_DEFAULT_DISPLAY, NULL);
+// 		if (d != EGL_NO_DISPLAY) {
+// 			return d;
+// 		}
+// 	}
+// 	return eglGetDisplay(EGL_DEFAULT_DISPLAY);
+// }
+//
+// static void* getProcAddress(const char* name) {
+// 	return (void*) eglGetProcAddress(name);
+// }
+import "C"
+
+import (
+	"image"
+	"unsafe"
+
+	"github.com/go-gl/gl/v4.5-core/gl"
+
+	"github.com/btmura/ponzi2/internal/errs"
+)
+
+// offscreenContext is an OpenGL context created with EGL on a pbuffer surface,
+// so it doesn't need X11 or Wayland.
+type offscreenContext struct {
+	// display is the EGL display, preferably Mesa's surfaceless one.
+	display C.EGLDisplay
+
+	// surface is the pbuffer surface the context is current on.
+	surface C.EGLSurface
+
+	// context is the OpenGL context.
+	context C.EGLContext
+}
+
+func newOffscreenContext(size image.Point) (*offscreenContext, error) {
+	d := C.getDisplay()
+	if d == C.EGLDisplay(C.EGL_NO_DISPLAY) {
+		return nil, errs.Errorf("eglGetDisplay failed: no EGL display")
+	}
+
+	if C.eglInitialize(d, nil, nil) != C.EGL_TRUE {
+		return nil, eglErrorf("eglInitialize")
+	}
+
+	c := &offscreenContext{display: d}
+
+	if C.eglBindAPI(C.EGL_OPENGL_API) != C.EGL_TRUE {
+		c.destroy()
+		return nil, eglErrorf("eglBindAPI")
+	}
+
+	configAttribs := []C.EGLint{
+		C.EGL_SURFACE_TYPE, C.EGL_PBUFFER_BIT,
+		C.EGL_RENDERABLE_TYPE, C.EGL_OPENGL_BIT,
+		C.EGL_RED_SIZE, 8,
+		C.EGL_GREEN_SIZE, 8,
+		C.EGL_BLUE_SIZE, 8,
+		C.EGL_ALPHA_SIZE, 8,
+		C.EGL_NONE,
+	}
+	var config C.EGLConfig
+	var numConfigs C.EGLint
+	if C.eglChooseConfig(d, &configAttribs[0], &config, 1, &numConfigs) != C.EGL_TRUE {
+		c.destroy()
+		return nil, eglErrorf("eglChooseConfig")
+	}
+	if numConfigs == 0 {
+		c.destroy()
+		return nil, errs.Errorf("eglChooseConfig failed: no config with OpenGL and pbuffers")
+	}
+
+	surfaceAttribs := []C.EGLint{
+		C.EGL_WIDTH, C.EGLint(size.X),
+		C.EGL_HEIGHT, C.EGLint(size.Y),
+		C.EGL_NONE,
+	}
+	c.surface = C.eglCreatePbufferSurface(d, config, &surfaceAttribs[0])
+	if c.surface == C.EGLSurface(C.EGL_NO_SURFACE) {
+		c.destroy()
+		return nil, eglErrorf("eglCreatePbufferSurface")
+	}
+
+	contextAttribs := []C.EGLint{
+		C.EGL_CONTEXT_MAJOR_VERSION, 4,
+		C.EGL_CONTEXT_MINOR_VERSION, 5,
+		C.EGL_CONTEXT_OPENGL_PROFILE_MASK, C.EGL_CONTEXT_OPENGL_CORE_PROFILE_BIT,
+		C.EGL_CONTEXT_OPENGL_FORWARD_COMPATIBLE, C.EGL_TRUE,
+		C.EGL_NONE,
+	}
+	c.context = C.eglCreateContext(d, config, C.EGLContext(C.EGL_NO_CONTEXT), &contextAttribs[0])
+	if c.context == C.EGLContext(C.EGL_NO_CONTEXT) {
+		c.destroy()
+		return nil, eglErrorf("eglCreateContext")
+	}
+
+	if C.eglMakeCurrent(d, c.surface, c.surface, c.context) != C.EGL_TRUE {
+		c.destroy()
+		return nil, eglErrorf("eglMakeCurrent")
+	}
+
+	return c, nil
+}
+
+// initGL loads the OpenGL functions through EGL rather than GLX, which needs an X server.
+func (c *offscreenContext) initGL() error {
+	return gl.InitWithProcAddrFunc(func(name string) unsafe.Pointer {
+		cname := C.CString(name)
+		defer C.free(unsafe.Pointer(cname))
+		return C.getProcAddress(cname)
+	})
+}
+
+func (c *offscreenContext) destroy() {
+	d := c.display
+	C.eglMakeCurrent(d, C.EGLSurface(C.EGL_NO_SURFACE), C.EGLSurface(C.EGL_NO_SURFACE), C.EGLContext(C.EGL_NO_CONTEXT))
+	if c.context != C.EGLContext(C.EGL_NO_CONTEXT) {
+		C.eglDestroyContext(d, c.context)
+	}
+	if c.surface != C.EGLSurface(C.EGL_NO_SURFACE) {
+		C.eglDestroySurface(d, c.surface)
+	}
+	C.eglTerminate(d)
+}
+
+// eglErrorf returns an error for the EGL function that failed with the current EGL error code.
+func eglErrorf(funcName string) error {
+	return errs.Errorf("%s failed: EGL error %#x", funcName, int(C.eglGetError()))
+}
@@ -0,0 +1,48 @@
+//go:build !linux
+// +build !linux
+
+package offscreen
+
+import (
+	"image"
+
+	"github.com/go-gl/gl/v4.5-core/gl"
+	"github.com/go-gl/glfw/v3.3/glfw"
+)
+
+// offscreenContext is an OpenGL context owned by a window that is never shown,
+// since GLFW only creates contexts along with windows.
+type offscreenContext struct {
+	// win is the hidden window that owns the OpenGL context.
+	win *glfw.Window
+}
+
+func newOffscreenContext(size image.Point) (*offscreenContext, error) {
+	if err := glfw.Init(); err != nil {
+		return nil, err
+	}
+
+	glfw.WindowHint(glfw.ContextVersionMajor, 4)
+	glfw.WindowHint(glfw.ContextVersionMinor, 5)
+	glfw.WindowHint(glfw.OpenGLProfile, glfw.OpenGLCoreProfile)
+	glfw.WindowHint(glfw.OpenGLForwardCompatible, glfw.True)
+	glfw.WindowHint(glfw.Visible, glfw.False)
+
+	win, err := glfw.CreateWindow(size.X, size.Y, windowTitle, nil, nil)
+	if err != nil {
+		glfw.Terminate()
+		return nil, err
+	}
+	win.MakeContextCurrent()
+
+	return &offscreenContext{win: win}, nil
+}
+
+func (c *offscreenContext) initGL() error {
+	return gl.Init()
+}
+
+func (c *offscreenContext) destroy() {
+	c.win.Destroy()
+	glfw.Terminate()
+}
@@ -62,3 +62,56 @@ func SourceUpdate(q *model.Quote) string {
 
 	return fmt.Sprintf("%s %s", ds, q.LatestUpdate.Format(l))
 }
+
+// Alert returns a status line describing the alert's condition.
+func Alert(a *model.Alert) string {
+	if a == nil {
+		return ""
+	}
+
+	switch a.Type {
+	case model.PriceAbove:
+		return fmt.Sprintf("Above %.2f", a.Value)
+
+	case model.PriceBelow:
+		return fmt.Sprintf("Below %.2f", a.Value)
+
+	case model.PercentChange:
+		return fmt.Sprintf("Change %.2f%%", a.Value*100)
+
+	case model.MovingAverageCross:
+		t := "MA"
+		switch a.MovingAverageType {
+		case model.Simple:
+			t = "SMA"
+		case model.Exponential:
+			t = "EMA"
+		}
+		return fmt.Sprintf("Cross %s(%d)", t, a.MovingAverageIntervals)
+
+	default:
+		return ""
+	}
+}
+
+// Position returns a status line with the position's shares, unrealized gain or loss, and day change.
+func Position(p *model.Position) string {
+	if p == nil {
+		return ""
+	}
+
+	if !p.Priced {
+		return fmt.Sprintf("%g sh @ %.2f", p.Shares, p.AveragePrice)
+	}
+
+	return fmt.Sprintf("%g sh P&L %+.2f (%+.2f%%) Day %+.2f (%+.2f%%)",
+		p.Shares, p.GainLoss, p.GainLossPercent*100, p.DayChange, p.DayChangePercent*100)
+}
+
+// PositionGain returns a short status line with the position's unrealized gain or loss percent.
+func PositionGain(p *model.Position) string {
+	if p == nil || !p.Priced {
+		return ""
+	}
+	return fmt.Sprintf("P&L %+.2f%%", p.GainLossPercent*100)
+}
@@ -0,0 +1,150 @@
+package ui
+
+import (
+	"fmt"
+	"image"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/btmura/ponzi2/internal/app/model"
+	"github.com/btmura/ponzi2/internal/app/view"
+	"github.com/btmura/ponzi2/internal/app/view/rect"
+	"github.com/btmura/ponzi2/internal/app/view/text"
+)
+
+// lotPrompt asks the user for the number of shares of a lot over the chart.
+//
+// Clicking on the prices in lot mode shows the prompt with the price and date under the mouse.
+// ENTER adds the lot and ESCAPE cancels it.
+type lotPrompt struct {
+	// visible is true if the prompt is shown.
+	visible bool
+
+	// symbol is the symbol of the lot.
+	symbol string
+
+	// price is the price per share of the lot rounded to cents.
+	price float32
+
+	// date is the date of the lot.
+	date time.Time
+
+	// shares is the number of shares typed so far.
+	shares string
+
+	// textBox renders the prompt and the shares.
+	textBox *text.Box
+
+	// submitCallback is called with the lot when the user presses ENTER.
+	submitCallback func(symbol string, l *model.Lot)
+}
+
+func newLotPrompt() *lotPrompt {
+	return &lotPrompt{
+		textBox: text.NewBox(tokenPromptTextRenderer, "",
+			text.Bubble(rect.NewBubble(inputSymbolBubbleRounding)),
+			text.Padding(viewPadding)),
+	}
+}
+
+// Show shows the prompt for a lot of the symbol at the price and date with no shares typed.
+func (p *lotPrompt) Show(symbol string, price float32, date time.Time) {
+	p.visible = true
+	p.symbol = symbol
+	p.price = float32(math.Round(float64(price)*100) / 100)
+	p.date = date
+	p.shares = ""
+	p.updateText()
+}
+
+// Visible returns true if the prompt is shown and takes keyboard input.
+func (p *lotPrompt) Visible() bool {
+	return p.visible
+}
+
+// Paste adds text from the clipboard to the shares.
+func (p *lotPrompt) Paste(txt string) {
+	p.shares += strings.TrimSpace(txt)
+	p.updateText()
+}
+
+func (p *lotPrompt) SetBounds(bounds image.Rectangle) {
+	p.textBox.SetBounds(bounds)
+}
+
+func (p *lotPrompt) ProcessInput(input *view.Input) {
+	if !p.visible {
+		return
+	}
+
+	if char := input.KeyReleased.GetChar(); char != 0 {
+		if char >= '0' && char <= '9' || char == '.' {
+			p.shares += string(char)
+			p.updateText()
+		}
+		input.ClearKeyboardInput()
+		return
+	}
+
+	switch input.KeyReleased.GetKey() {
+	case view.KeyEscape:
+		p.visible = false
+		p.shares = ""
+		input.ClearKeyboardInput()
+
+	case view.KeyBackspace:
+		if n := len(p.shares); n > 0 {
+			p.shares = p.shares[:n-1]
+			p.updateText()
+		}
+		input.ClearKeyboardInput()
+
+	case view.KeyEnter:
+		// Keep the prompt open until the user types a valid number of shares or cancels.
+		l := &model.Lot{Price: p.price, Date: p.date}
+		if v, err := strconv.ParseFloat(p.shares, 32); err == nil {
+			l.Shares = float32(v)
+		}
+		if model.ValidateLot(l) != nil {
+			input.ClearKeyboardInput()
+			return
+		}
+
+		symbol := p.symbol
+		input.AddFiredCallback(func() {
+			if p.submitCallback != nil {
+				p.submitCallback(symbol, l)
+			}
+		})
+		p.visible = false
+		p.shares = ""
+		input.ClearKeyboardInput()
+	}
+}
+
+func (p *lotPrompt) updateText() {
+	lot := fmt.Sprintf("%s bought at %.2f on %s", p.symbol, p.price, p.date.Format("Jan 2, 2006"))
+	if p.shares == "" {
+		p.textBox.SetText("Type the shares of " + lot + " and press ENTER...")
+		return
+	}
+	p.textBox.SetText("Shares of " + lot + ": " + p.shares)
+}
+
+func (p *lotPrompt) Update() (dirty bool) {
+	return p.textBox.Update()
+}
+
+func (p *lotPrompt) Render(fudge float32) {
+	if !p.visible {
+		return
+	}
+	p.textBox.Render(fudge)
+}
+
+// SetSubmitCallback sets the callback for when the user enters the shares of a lot.
+func (p *lotPrompt) SetSubmitCallback(cb func(symbol string, l *model.Lot)) {
+	p.submitCallback = cb
+}
@@ -39,6 +39,9 @@ type sidebar struct {
 	// priceStyle is the style to create thumbnails with.
 	priceStyle chart.PriceStyle
 
+	// header shows the current watchlist above the slots.
+	header *watchlistHeader
+
 	// slots are slots which can have thumbnails or be a drop site.
 	slots []*sidebarSlot
 
@@ -98,7 +101,12 @@ type draggedSidebarSlot struct {
 }
 
 func newSidebar() *sidebar {
-	return new(sidebar)
+	return &sidebar{header: newWatchlistHeader()}
+}
+
+// SetWatchlists sets the names of the watchlists and the one whose thumbnails are shown.
+func (s *sidebar) SetWatchlists(names []string, current string) {
+	s.header.SetWatchlists(names, current)
 }
 
 func (s *sidebar) SetPriceStyle(newPriceStyle chart.PriceStyle) {
@@ -136,6 +144,18 @@ func (s *sidebar) AddChartThumb(symbol string) (changed bool) {
 	return true
 }
 
+// ClearChartThumbs removes all the thumbnails without fading them out.
+func (s *sidebar) ClearChartThumbs() (changed bool) {
+	for _, slot := range s.slots {
+		changed = true
+		slot.Close()
+	}
+	s.slots = nil
+	s.draggedSlot = nil
+	s.scrollOffset = 0
+	return changed
+}
+
 func (s *sidebar) RemoveChartThumb(symbol string) (changed bool) {
 	if err := model.ValidateSymbol(symbol); err != nil {
 		logger.Errorf("invalid symbol: %v", err)
@@ -197,10 +217,13 @@ func (s *sidebar) SetErrorMessage(symbol string, errorMessage string) (changed b
 func (s *sidebar) ContentSize() image.Point {
 	num := len(s.slots)
 	if num == 0 {
+		if s.header.Visible() {
+			return image.Pt(thumbSize.X, s.header.Height())
+		}
 		return image.Pt(0, 0)
 	}
 
-	height := num * thumbSize.Y
+	height := s.header.Height() + num*thumbSize.Y
 	if num > 1 {
 		// Add padding between thumbnails.
 		height += (num - 1) * viewPadding
@@ -227,6 +250,8 @@ func (s *sidebar) ProcessInput(input *view.Input) {
 	// Set each slot's bounds on the screen.
 	s.setSlotBounds()
 
+	s.header.ProcessInput(input)
+
 	// Find the slot being dragged and update its position.
 	wasDragging := s.draggedSlot != nil
 	s.setDraggedSlot(input)
@@ -320,11 +345,17 @@ func (s *sidebar) scrollDown(scrollAmount int) {
 	s.scrollOffset -= scroll
 }
 
-// setSlotBounds goes through the sidebar and assign bounds to each slot.
+// setSlotBounds goes through the sidebar and assign bounds to the header and each slot.
 func (s *sidebar) setSlotBounds() {
+	headerBounds := image.Rect(
+		s.bounds.Min.X, s.bounds.Max.Y-s.header.Height(),
+		s.bounds.Max.X, s.bounds.Max.Y,
+	)
+	s.header.SetBounds(headerBounds.Sub(image.Pt(0, s.scrollOffset)))
+
 	slotBounds := image.Rect(
-		s.bounds.Min.X, s.bounds.Max.Y-viewPadding-thumbSize.Y,
-		s.bounds.Max.X, s.bounds.Max.Y-viewPadding,
+		s.bounds.Min.X, headerBounds.Min.Y-viewPadding-thumbSize.Y,
+		s.bounds.Max.X, headerBounds.Min.Y-viewPadding,
 	)
 	slotBounds = slotBounds.Sub(image.Pt(0, s.scrollOffset))
 
@@ -464,6 +495,9 @@ func (s *sidebar) fireSidebarChangeCallback(input *view.Input) {
 
 // Update moves the animation one step forward.
 func (s *sidebar) Update() (dirty bool) {
+	if s.header.Update() {
+		dirty = true
+	}
 	for i := 0; i < len(s.slots); i++ {
 		slot := s.slots[i]
 		if slot.Update() {
@@ -480,6 +514,10 @@ func (s *sidebar) Update() (dirty bool) {
 
 // Render renders a frame.
 func (s *sidebar) Render(fudge float32) {
+	if s.ContentSize().Y != 0 {
+		s.header.Render(fudge)
+	}
+
 	// Draw the non-dragged thumbnails first, so they appear under the dragged thumbnail.
 	for _, slot := range s.slots {
 		if s.draggedSlot != nil && s.draggedSlot.sidebarSlot == slot {
@@ -505,10 +543,27 @@ func (s *sidebar) SetThumbClickCallback(cb func(symbol string)) {
 	s.thumbClickCallback = cb
 }
 
+func (s *sidebar) SetWatchlistSwitchCallback(cb func(name string)) {
+	s.header.SetSwitchCallback(cb)
+}
+
+func (s *sidebar) SetWatchlistAddCallback(cb func(name string)) {
+	s.header.SetAddCallback(cb)
+}
+
+func (s *sidebar) SetWatchlistRenameCallback(cb func(oldName, newName string)) {
+	s.header.SetRenameCallback(cb)
+}
+
+func (s *sidebar) SetWatchlistRemoveCallback(cb func(name string)) {
+	s.header.SetRemoveCallback(cb)
+}
+
 func (s *sidebar) Close() {
 	s.changeCallback = nil
 	s.thumbRemoveButtonClickCallback = nil
 	s.thumbClickCallback = nil
+	s.header.Close()
 }
 
 func newSidebarSlot(symbol string, thumb *chart.Thumb) *sidebarSlot {
@@ -0,0 +1,133 @@
+package ui
+
+import (
+	"image"
+	"strings"
+	"unicode"
+
+	"golang.org/x/image/font/gofont/goregular"
+
+	"github.com/btmura/ponzi2/internal/app/gfx"
+	"github.com/btmura/ponzi2/internal/app/view"
+	"github.com/btmura/ponzi2/internal/app/view/rect"
+	"github.com/btmura/ponzi2/internal/app/view/text"
+)
+
+var tokenPromptTextRenderer = gfx.NewTextRenderer(goregular.TTF, 24)
+
+// tokenPromptMaskChar is shown instead of each typed character, so the token isn't visible on screen.
+const tokenPromptMaskChar = "*"
+
+// tokenPrompt asks the user for an API token over the chart.
+//
+// CTRL+K shows the prompt. Typed and pasted characters are masked.
+// ENTER submits the token and ESCAPE cancels it.
+type tokenPrompt struct {
+	// visible is true if the prompt is shown.
+	visible bool
+
+	// token is the token typed so far.
+	token string
+
+	// textBox renders the prompt and the masked token.
+	textBox *text.Box
+
+	// submitCallback is called with the token when the user presses ENTER.
+	submitCallback func(token string)
+}
+
+func newTokenPrompt() *tokenPrompt {
+	return &tokenPrompt{
+		textBox: text.NewBox(tokenPromptTextRenderer, "",
+			text.Bubble(rect.NewBubble(inputSymbolBubbleRounding)),
+			text.Padding(viewPadding)),
+	}
+}
+
+// Show shows the prompt with no token typed.
+func (p *tokenPrompt) Show() {
+	p.visible = true
+	p.token = ""
+	p.updateText()
+}
+
+// Visible returns true if the prompt is shown and takes keyboard input.
+func (p *tokenPrompt) Visible() bool {
+	return p.visible
+}
+
+// Paste adds text from the clipboard to the token.
+func (p *tokenPrompt) Paste(txt string) {
+	p.token += strings.TrimSpace(txt)
+	p.updateText()
+}
+
+func (p *tokenPrompt) SetBounds(bounds image.Rectangle) {
+	p.textBox.SetBounds(bounds)
+}
+
+func (p *tokenPrompt) ProcessInput(input *view.Input) {
+	if !p.visible {
+		return
+	}
+
+	if char := input.KeyReleased.GetChar(); char != 0 {
+		if unicode.IsPrint(char) && !unicode.IsSpace(char) {
+			p.token += string(char)
+			p.updateText()
+		}
+		input.ClearKeyboardInput()
+		return
+	}
+
+	switch input.KeyReleased.GetKey() {
+	case view.KeyEscape:
+		p.visible = false
+		p.token = ""
+		input.ClearKeyboardInput()
+
+	case view.KeyBackspace:
+		if r := []rune(p.token); len(r) > 0 {
+			p.token = string(r[:len(r)-1])
+			p.updateText()
+		}
+		input.ClearKeyboardInput()
+
+	case view.KeyEnter:
+		token := p.token
+		if token != "" {
+			input.AddFiredCallback(func() {
+				if p.submitCallback != nil {
+					p.submitCallback(token)
+				}
+			})
+		}
+		p.visible = false
+		p.token = ""
+		input.ClearKeyboardInput()
+	}
+}
+
+func (p *tokenPrompt) updateText() {
+	if p.token == "" {
+		p.textBox.SetText("Paste or type your IEX API token and press ENTER...")
+		return
+	}
+	p.textBox.SetText("IEX API token: " + strings.Repeat(tokenPromptMaskChar, len([]rune(p.token))))
+}
+
+func (p *tokenPrompt) Update() (dirty bool) {
+	return p.textBox.Update()
+}
+
+func (p *tokenPrompt) Render(fudge float32) {
+	if !p.visible {
+		return
+	}
+	p.textBox.Render(fudge)
+}
+
+// SetSubmitCallback sets the callback for when the user enters a token.
+func (p *tokenPrompt) SetSubmitCallback(cb func(token string)) {
+	p.submitCallback = cb
+}
@@ -7,6 +7,7 @@ import (
 	"image"
 	"image/png"
 	"runtime"
+	"time"
 	"unicode"
 
 	"github.com/go-gl/gl/v4.5-core/gl"
@@ -44,6 +45,13 @@ var acceptedChars = map[rune]bool{
 	'S': true, 'T': true, 'U': true,
 	'V': true, 'W': true, 'X': true,
 	'Y': true, 'Z': true,
+	'0': true, '1': true, '2': true,
+	'3': true, '4': true, '5': true,
+	'6': true, '7': true, '8': true,
+	'9': true,
+
+	// Separators for share classes and exchanges like BRK.B, RDS-A, and SHOP.TO.
+	'.': true, '-': true, '/': true,
 }
 
 // Constants used by Run for the "game loop".
@@ -92,6 +100,12 @@ type UI struct {
 	// inputSymbolTextBox stores and renders the symbol being entered by the user.
 	inputSymbolTextBox *text.Box
 
+	// tokenPrompt asks the user for an API token.
+	tokenPrompt *tokenPrompt
+
+	// lotPrompt asks the user for the shares of a lot to add.
+	lotPrompt *lotPrompt
+
 	// inputSymbolSubmittedCallback is called when a new symbol is entered.
 	inputSymbolSubmittedCallback func(symbol string)
 
@@ -110,12 +124,48 @@ type UI struct {
 	// chartAddButtonClickCallback is called when the main chart's add button is clicked.
 	chartAddButtonClickCallback func(symbol string)
 
+	// chartAdjustmentButtonClickCallback is called when the main chart's adjustment button is clicked.
+	chartAdjustmentButtonClickCallback func(symbol string)
+
+	// chartDrawingAddCallback is called when the user finishes a drawing on the main chart.
+	chartDrawingAddCallback func(symbol string, d *model.Drawing)
+
+	// chartDrawingRemoveCallback is called when the user removes a drawing from the main chart.
+	chartDrawingRemoveCallback func(symbol string, index int)
+
+	// chartAlertAddCallback is called when the user adds an alert on the main chart.
+	chartAlertAddCallback func(symbol string, a *model.Alert)
+
+	// chartAlertRemoveCallback is called when the user removes an alert on the main chart.
+	chartAlertRemoveCallback func(symbol string, index int)
+
+	// chartLotRemoveCallback is called when the user removes a lot from the main chart.
+	chartLotRemoveCallback func(symbol string, index int)
+
 	// thumbRemoveButtonClickCallback is called when a thumb's remove button is clicked.
 	thumbRemoveButtonClickCallback func(symbol string)
 
 	// thumbClickCallback is called when a thumb is clicked.
 	thumbClickCallback func(symbol string)
 
+	// watchlistSwitchCallback is called when the user switches to another watchlist.
+	watchlistSwitchCallback func(name string)
+
+	// watchlistAddCallback is called when the user names a new watchlist.
+	watchlistAddCallback func(name string)
+
+	// watchlistRenameCallback is called when the user renames a watchlist.
+	watchlistRenameCallback func(oldName, newName string)
+
+	// watchlistRemoveCallback is called when the user removes a watchlist.
+	watchlistRemoveCallback func(name string)
+
+	// watchlistPasteCallback is called with the clipboard text when the user pastes symbols.
+	watchlistPasteCallback func(text string)
+
+	// watchlistCopyCallback is called when the user copies the current watchlist.
+	watchlistCopyCallback func()
+
 	// win is the handle to the GLFW window.
 	win *glfw.Window
 
@@ -141,11 +191,23 @@ type UI struct {
 	// mouseLeftButtonReleased is whether the left mouse button was released.
 	mouseLeftButtonReleased bool
 
+	// mouseLeftButtonShift is whether the shift key was held down when the left mouse button was released.
+	mouseLeftButtonShift bool
+
 	// mouseScrollDirection is the next mouse scroll event to report. Nil if no scroll has happened.
 	mouseScrollDirection view.ScrollDirection
 
 	// keyReleased is the key released. Unspecified if no key was released.
 	keyReleased *view.KeyReleaseEvent
+
+	// pasteRequested is whether the user pressed CTRL+V to paste symbols.
+	pasteRequested bool
+
+	// copyRequested is whether the user pressed CTRL+C to copy symbols.
+	copyRequested bool
+
+	// tokenPromptRequested is whether the user pressed CTRL+K to enter an API token.
+	tokenPromptRequested bool
 }
 
 type uiChart struct {
@@ -185,6 +247,8 @@ func New() *UI {
 		inputSymbolTextBox: text.NewBox(inputSymbolTextRenderer, "",
 			text.Bubble(rect.NewBubble(inputSymbolBubbleRounding)),
 			text.Padding(viewPadding)),
+		tokenPrompt: newTokenPrompt(),
+		lotPrompt:   newLotPrompt(),
 	}
 }
 
@@ -243,7 +307,7 @@ func (u *UI) Init(_ context.Context) (cleanup func(), err error) {
 	})
 
 	win.SetKeyCallback(func(win *glfw.Window, key glfw.Key, scancode int, action glfw.Action, mods glfw.ModifierKey) {
-		u.handleKeyEvent(key, action)
+		u.handleKeyEvent(key, action, mods)
 	})
 
 	win.SetCursorPosCallback(func(win *glfw.Window, xpos, ypos float64) {
@@ -251,7 +315,7 @@ func (u *UI) Init(_ context.Context) (cleanup func(), err error) {
 	})
 
 	win.SetMouseButtonCallback(func(win *glfw.Window, button glfw.MouseButton, action glfw.Action, mods glfw.ModifierKey) {
-		u.handleMouseButtonEvent(button, action)
+		u.handleMouseButtonEvent(button, action, mods)
 	})
 
 	win.SetScrollCallback(func(win *glfw.Window, xoff, yoff float64) {
@@ -274,6 +338,30 @@ func (u *UI) Init(_ context.Context) (cleanup func(), err error) {
 		}
 	})
 
+	u.sidebar.SetWatchlistSwitchCallback(func(name string) {
+		if u.watchlistSwitchCallback != nil {
+			u.watchlistSwitchCallback(name)
+		}
+	})
+
+	u.sidebar.SetWatchlistAddCallback(func(name string) {
+		if u.watchlistAddCallback != nil {
+			u.watchlistAddCallback(name)
+		}
+	})
+
+	u.sidebar.SetWatchlistRenameCallback(func(oldName, newName string) {
+		if u.watchlistRenameCallback != nil {
+			u.watchlistRenameCallback(oldName, newName)
+		}
+	})
+
+	u.sidebar.SetWatchlistRemoveCallback(func(name string) {
+		if u.watchlistRemoveCallback != nil {
+			u.watchlistRemoveCallback(name)
+		}
+	})
+
 	return func() { glfw.Terminate() }, nil
 }
 
@@ -299,7 +387,25 @@ func (u *UI) handleCharEvent(char rune) {
 	u.WakeLoop()
 }
 
-func (u *UI) handleKeyEvent(key glfw.Key, action glfw.Action) {
+func (u *UI) handleKeyEvent(key glfw.Key, action glfw.Action, mods glfw.ModifierKey) {
+	// Handle shortcuts on press, since users often let go of CTRL before the other key.
+	if action == glfw.Press && mods&(glfw.ModControl|glfw.ModSuper) != 0 {
+		switch key {
+		case glfw.KeyV:
+			u.pasteRequested = true
+			u.WakeLoop()
+
+		case glfw.KeyC:
+			u.copyRequested = true
+			u.WakeLoop()
+
+		case glfw.KeyK:
+			u.tokenPromptRequested = true
+			u.WakeLoop()
+		}
+		return
+	}
+
 	if action != glfw.Release {
 		return
 	}
@@ -331,7 +437,7 @@ func (u *UI) handleCursorPosEvent(x, y float64) {
 	u.WakeLoop()
 }
 
-func (u *UI) handleMouseButtonEvent(button glfw.MouseButton, action glfw.Action) {
+func (u *UI) handleMouseButtonEvent(button glfw.MouseButton, action glfw.Action, mods glfw.ModifierKey) {
 	if button != glfw.MouseButtonLeft {
 		return
 	}
@@ -347,6 +453,7 @@ func (u *UI) handleMouseButtonEvent(button glfw.MouseButton, action glfw.Action)
 
 	u.mouseLeftButtonPressed = action == glfw.Press
 	u.mouseLeftButtonReleased = action == glfw.Release
+	u.mouseLeftButtonShift = action == glfw.Release && mods&glfw.ModShift != 0
 }
 
 func (u *UI) handleScrollEvent(yoff float64) {
@@ -490,6 +597,7 @@ func (u *UI) prepareInput() *view.Input {
 			input.MouseLeftButtonClicked = &view.MouseClickEvent{
 				PressedPos:  *u.mouseLeftButtonPressedPos,
 				ReleasedPos: mousePos,
+				Shift:       u.mouseLeftButtonShift,
 			}
 		}
 	}
@@ -525,12 +633,26 @@ func (u *UI) processInput(input *view.Input) (dirty bool) {
 
 	u.instructionsTextBox.SetBounds(m.chartBounds)
 	u.inputSymbolTextBox.SetBounds(m.winBounds)
+	u.tokenPrompt.SetBounds(m.winBounds)
+	u.lotPrompt.SetBounds(m.winBounds)
 
-	u.updateInputSymbolTextBox(input)
+	if u.tokenPromptRequested {
+		u.tokenPrompt.Show()
+		u.tokenPromptRequested = false
+	}
+
+	u.processClipboardInput(input)
 
+	// Let the token prompt handle keys first, so the token isn't typed anywhere else.
+	u.tokenPrompt.ProcessInput(input)
+	u.lotPrompt.ProcessInput(input)
+
+	// Let the sidebar handle keys first in case the user is typing a watchlist name.
 	u.sidebar.SetBounds(m.sidebarBounds)
 	u.sidebar.ProcessInput(input)
 
+	u.updateInputSymbolTextBox(input)
+
 	for i := 0; i < len(u.charts); i++ {
 		c := u.charts[i]
 		c.SetBounds(m.chartBounds)
@@ -544,6 +666,41 @@ func (u *UI) processInput(input *view.Input) (dirty bool) {
 	return len(input.FiredCallbacks()) != 0
 }
 
+// processClipboardInput fires the paste and copy callbacks for the watchlist shortcuts.
+func (u *UI) processClipboardInput(input *view.Input) {
+	// Paste into the token prompt instead of the watchlist if the user is entering a token.
+	if u.pasteRequested && u.tokenPrompt.Visible() {
+		u.tokenPrompt.Paste(u.win.GetClipboardString())
+		u.pasteRequested = false
+	}
+
+	// Paste into the lot prompt instead of the watchlist if the user is entering shares.
+	if u.pasteRequested && u.lotPrompt.Visible() {
+		u.lotPrompt.Paste(u.win.GetClipboardString())
+		u.pasteRequested = false
+	}
+
+	if u.pasteRequested {
+		txt := u.win.GetClipboardString()
+		input.AddFiredCallback(func() {
+			if u.watchlistPasteCallback != nil {
+				u.watchlistPasteCallback(txt)
+			}
+		})
+	}
+
+	if u.copyRequested {
+		input.AddFiredCallback(func() {
+			if u.watchlistCopyCallback != nil {
+				u.watchlistCopyCallback()
+			}
+		})
+	}
+
+	u.pasteRequested = false
+	u.copyRequested = false
+}
+
 func (u *UI) updateInputSymbolTextBox(input *view.Input) {
 	b := u.inputSymbolTextBox
 
@@ -605,6 +762,14 @@ func (u *UI) update() (dirty bool) {
 		dirty = true
 	}
 
+	if u.tokenPrompt.Update() {
+		dirty = true
+	}
+
+	if u.lotPrompt.Update() {
+		dirty = true
+	}
+
 	return dirty
 }
 
@@ -628,6 +793,10 @@ func (u *UI) render(fudge float32) {
 
 	// Render the sidebar thumbnails.
 	u.sidebar.Render(fudge)
+
+	// Render the prompts over everything else.
+	u.lotPrompt.Render(fudge)
+	u.tokenPrompt.Render(fudge)
 }
 
 // viewMetrics has dynamic metrics used to render the view.
@@ -719,6 +888,41 @@ func (u *UI) SetChartAddButtonClickCallback(cb func(symbol string)) {
 	u.chartAddButtonClickCallback = cb
 }
 
+// SetChartAdjustmentButtonClickCallback sets the callback for when the main chart's adjustment button is clicked.
+func (u *UI) SetChartAdjustmentButtonClickCallback(cb func(symbol string)) {
+	u.chartAdjustmentButtonClickCallback = cb
+}
+
+// SetChartDrawingAddCallback sets the callback for when the user finishes a drawing on the main chart.
+func (u *UI) SetChartDrawingAddCallback(cb func(symbol string, d *model.Drawing)) {
+	u.chartDrawingAddCallback = cb
+}
+
+// SetChartDrawingRemoveCallback sets the callback for when the user removes a drawing from the main chart.
+func (u *UI) SetChartDrawingRemoveCallback(cb func(symbol string, index int)) {
+	u.chartDrawingRemoveCallback = cb
+}
+
+// SetChartAlertAddCallback sets the callback for when the user adds an alert on the main chart.
+func (u *UI) SetChartAlertAddCallback(cb func(symbol string, a *model.Alert)) {
+	u.chartAlertAddCallback = cb
+}
+
+// SetChartAlertRemoveCallback sets the callback for when the user removes an alert on the main chart.
+func (u *UI) SetChartAlertRemoveCallback(cb func(symbol string, index int)) {
+	u.chartAlertRemoveCallback = cb
+}
+
+// SetChartLotAddCallback sets the callback for when the user adds a lot on the main chart.
+func (u *UI) SetChartLotAddCallback(cb func(symbol string, l *model.Lot)) {
+	u.lotPrompt.SetSubmitCallback(cb)
+}
+
+// SetChartLotRemoveCallback sets the callback for when the user removes a lot from the main chart.
+func (u *UI) SetChartLotRemoveCallback(cb func(symbol string, index int)) {
+	u.chartLotRemoveCallback = cb
+}
+
 // SetThumbRemoveButtonClickCallback sets the callback for when a thumb's remove button is clicked.
 func (u *UI) SetThumbRemoveButtonClickCallback(cb func(symbol string)) {
 	u.thumbRemoveButtonClickCallback = cb
@@ -729,6 +933,58 @@ func (u *UI) SetThumbClickCallback(cb func(symbol string)) {
 	u.thumbClickCallback = cb
 }
 
+// SetWatchlistSwitchCallback sets the callback for when the user switches to another watchlist.
+func (u *UI) SetWatchlistSwitchCallback(cb func(name string)) {
+	u.watchlistSwitchCallback = cb
+}
+
+// SetWatchlistAddCallback sets the callback for when the user names a new watchlist.
+func (u *UI) SetWatchlistAddCallback(cb func(name string)) {
+	u.watchlistAddCallback = cb
+}
+
+// SetWatchlistRenameCallback sets the callback for when the user renames a watchlist.
+func (u *UI) SetWatchlistRenameCallback(cb func(oldName, newName string)) {
+	u.watchlistRenameCallback = cb
+}
+
+// SetWatchlistRemoveCallback sets the callback for when the user removes a watchlist.
+func (u *UI) SetWatchlistRemoveCallback(cb func(name string)) {
+	u.watchlistRemoveCallback = cb
+}
+
+// SetWatchlistPasteCallback sets the callback for when the user pastes symbols with CTRL+V.
+func (u *UI) SetWatchlistPasteCallback(cb func(text string)) {
+	u.watchlistPasteCallback = cb
+}
+
+// SetWatchlistCopyCallback sets the callback for when the user copies the current watchlist with CTRL+C.
+func (u *UI) SetWatchlistCopyCallback(cb func()) {
+	u.watchlistCopyCallback = cb
+}
+
+// SetAPITokenSubmittedCallback sets the callback for when the user enters an API token after pressing CTRL+K.
+func (u *UI) SetAPITokenSubmittedCallback(cb func(token string)) {
+	u.tokenPrompt.SetSubmitCallback(cb)
+}
+
+// ShowAPITokenPrompt asks the user for an API token like when they press CTRL+K.
+func (u *UI) ShowAPITokenPrompt() {
+	defer u.WakeLoop()
+	u.tokenPromptRequested = true
+}
+
+// SetClipboardText puts the text on the system clipboard.
+func (u *UI) SetClipboardText(text string) {
+	u.win.SetClipboardString(text)
+}
+
+// SetWatchlists sets the names of the watchlists and the one shown in the sidebar.
+func (u *UI) SetWatchlists(names []string, current string) {
+	defer u.WakeLoop()
+	u.sidebar.SetWatchlists(names, current)
+}
+
 // SetChart sets the main chart to the given symbol and data.
 func (u *UI) SetChart(symbol string, data chart.Data, priceStyle chart.PriceStyle) bool {
 	if err := model.ValidateSymbol(symbol); err != nil {
@@ -771,6 +1027,47 @@ func (u *UI) SetChart(symbol string, data chart.Data, priceStyle chart.PriceStyl
 		}
 	})
 
+	c.SetAdjustmentButtonClickCallback(func() {
+		if u.chartAdjustmentButtonClickCallback != nil {
+			u.chartAdjustmentButtonClickCallback(symbol)
+		}
+	})
+
+	c.SetDrawingAddCallback(func(d *model.Drawing) {
+		if u.chartDrawingAddCallback != nil {
+			u.chartDrawingAddCallback(symbol, d)
+		}
+	})
+
+	c.SetDrawingRemoveCallback(func(index int) {
+		if u.chartDrawingRemoveCallback != nil {
+			u.chartDrawingRemoveCallback(symbol, index)
+		}
+	})
+
+	c.SetAlertAddCallback(func(a *model.Alert) {
+		if u.chartAlertAddCallback != nil {
+			u.chartAlertAddCallback(symbol, a)
+		}
+	})
+
+	c.SetAlertRemoveCallback(func(index int) {
+		if u.chartAlertRemoveCallback != nil {
+			u.chartAlertRemoveCallback(symbol, index)
+		}
+	})
+
+	// Ask for the shares of the lot before adding it.
+	c.SetLotAddCallback(func(price float32, date time.Time) {
+		u.lotPrompt.Show(symbol, price, date)
+	})
+
+	c.SetLotRemoveCallback(func(index int) {
+		if u.chartLotRemoveCallback != nil {
+			u.chartLotRemoveCallback(symbol, index)
+		}
+	})
+
 	c.SetZoomChangeCallback(func(zoomChange chart.ZoomChange) {
 		u.handleChartZoomChangeEvent(zoomChange)
 	})
@@ -814,6 +1111,15 @@ func (u *UI) AddChartThumb(symbol string, data chart.Data) (changed bool) {
 	return false
 }
 
+// ClearChartThumbs removes all the thumbnails at once, like when switching watchlists.
+func (u *UI) ClearChartThumbs() (changed bool) {
+	if u.sidebar.ClearChartThumbs() {
+		defer u.WakeLoop()
+		return true
+	}
+	return false
+}
+
 // RemoveChartThumb removes the thumbnail with given symbol.
 func (u *UI) RemoveChartThumb(symbol string) (changed bool) {
 	if err := model.ValidateSymbol(symbol); err != nil {
@@ -0,0 +1,284 @@
+package ui
+
+import (
+	"image"
+	"unicode"
+
+	"golang.org/x/image/font/gofont/goregular"
+
+	"github.com/btmura/ponzi2/internal/app/gfx"
+	"github.com/btmura/ponzi2/internal/app/view"
+	"github.com/btmura/ponzi2/internal/app/view/button"
+)
+
+var watchlistTextRenderer = gfx.NewTextRenderer(goregular.TTF, 12)
+
+// Watchlist buttons are drawn with lines, since they are smaller than the chart buttons.
+var (
+	previousWatchlistButtonVAO = watchlistIconVAO([]float32{0.2, 0.4, -0.2, 0, 0.2, -0.4}, []uint16{0, 1, 1, 2})
+	nextWatchlistButtonVAO     = watchlistIconVAO([]float32{-0.2, 0.4, 0.2, 0, -0.2, -0.4}, []uint16{0, 1, 1, 2})
+	addWatchlistButtonVAO      = watchlistIconVAO([]float32{-0.4, 0, 0.4, 0, 0, 0.4, 0, -0.4}, []uint16{0, 1, 2, 3})
+	removeWatchlistButtonVAO   = watchlistIconVAO([]float32{-0.4, 0, 0.4, 0}, []uint16{0, 1})
+)
+
+// watchlistEdit is what the user is typing a watchlist name for.
+type watchlistEdit int
+
+const (
+	watchlistEditNone watchlistEdit = iota
+	watchlistEditAdd
+	watchlistEditRename
+)
+
+// watchlistHeader is the row at the top of the sidebar that shows the current
+// watchlist and lets the user switch, add, rename, and remove watchlists.
+//
+// Clicking on the name starts renaming the watchlist. Clicking on the add button
+// starts naming a new watchlist. ENTER finishes the name and ESCAPE cancels it.
+type watchlistHeader struct {
+	// names are the names of all the watchlists in order.
+	names []string
+
+	// current is the name of the watchlist shown in the sidebar.
+	current string
+
+	// edit is what the user is typing a name for if anything.
+	edit watchlistEdit
+
+	// editText is the name typed so far.
+	editText string
+
+	previousButton *button.Button
+	nextButton     *button.Button
+	addButton      *button.Button
+	removeButton   *button.Button
+
+	// bounds is the rectangle to draw within.
+	bounds image.Rectangle
+
+	// nameBounds is the rectangle where the name is drawn.
+	nameBounds image.Rectangle
+
+	// switchCallback is called with the name of the watchlist to show.
+	switchCallback func(name string)
+
+	// addCallback is called with the name of a new watchlist.
+	addCallback func(name string)
+
+	// renameCallback is called with the old and new name of a watchlist.
+	renameCallback func(oldName, newName string)
+
+	// removeCallback is called with the name of the watchlist to remove.
+	removeCallback func(name string)
+}
+
+func newWatchlistHeader() *watchlistHeader {
+	h := &watchlistHeader{
+		previousButton: button.New(previousWatchlistButtonVAO),
+		nextButton:     button.New(nextWatchlistButtonVAO),
+		addButton:      button.New(addWatchlistButtonVAO),
+		removeButton:   button.New(removeWatchlistButtonVAO),
+	}
+
+	h.previousButton.SetClickCallback(func() { h.fireSwitch(-1) })
+	h.nextButton.SetClickCallback(func() { h.fireSwitch(+1) })
+
+	h.addButton.SetClickCallback(func() {
+		h.edit = watchlistEditAdd
+		h.editText = ""
+	})
+
+	h.removeButton.SetClickCallback(func() {
+		if h.removeCallback != nil {
+			h.removeCallback(h.current)
+		}
+	})
+
+	return h
+}
+
+// SetWatchlists sets the names of the watchlists and the current one.
+func (h *watchlistHeader) SetWatchlists(names []string, current string) {
+	h.names = names
+	h.current = current
+}
+
+// Height returns the height of the header.
+func (h *watchlistHeader) Height() int {
+	return viewPadding + watchlistTextRenderer.LineHeight() + viewPadding
+}
+
+// Editing returns true if the user is typing a watchlist name.
+func (h *watchlistHeader) Editing() bool {
+	return h.edit != watchlistEditNone
+}
+
+// Visible returns true if the header has something worth showing by itself.
+func (h *watchlistHeader) Visible() bool {
+	return len(h.names) > 1 || h.Editing()
+}
+
+func (h *watchlistHeader) SetBounds(bounds image.Rectangle) {
+	h.bounds = bounds
+
+	size := watchlistTextRenderer.LineHeight()
+	r := image.Rect(0, 0, size, size).Add(image.Pt(bounds.Min.X, bounds.Min.Y+viewPadding))
+
+	h.previousButton.SetBounds(r)
+
+	r = r.Add(image.Pt(bounds.Dx()-size, 0))
+	h.removeButton.SetBounds(r)
+
+	r = r.Sub(image.Pt(size, 0))
+	h.addButton.SetBounds(r)
+
+	r = r.Sub(image.Pt(size, 0))
+	h.nextButton.SetBounds(r)
+
+	h.nameBounds = image.Rect(bounds.Min.X+size+viewPadding/2, bounds.Min.Y, r.Min.X-viewPadding/2, bounds.Max.Y)
+}
+
+func (h *watchlistHeader) ProcessInput(input *view.Input) {
+	h.processKeyInput(input)
+
+	if input.MouseLeftButtonClicked == nil {
+		return
+	}
+
+	// Clicking anywhere else cancels typing the name.
+	if h.Editing() && !input.MouseLeftButtonClicked.In(h.nameBounds) {
+		h.edit = watchlistEditNone
+	}
+
+	if input.MouseLeftButtonClicked.In(h.nameBounds) && !h.Editing() {
+		h.edit = watchlistEditRename
+		h.editText = h.current
+		input.ClearMouseInput()
+		return
+	}
+
+	if len(h.names) > 1 {
+		h.previousButton.ProcessInput(input)
+		h.nextButton.ProcessInput(input)
+		h.removeButton.ProcessInput(input)
+	}
+	h.addButton.ProcessInput(input)
+}
+
+func (h *watchlistHeader) processKeyInput(input *view.Input) {
+	if !h.Editing() {
+		return
+	}
+
+	if char := input.KeyReleased.GetChar(); char != 0 {
+		if unicode.IsPrint(char) {
+			h.editText += string(char)
+		}
+		input.ClearKeyboardInput()
+		return
+	}
+
+	switch input.KeyReleased.GetKey() {
+	case view.KeyEscape:
+		h.edit = watchlistEditNone
+		input.ClearKeyboardInput()
+
+	case view.KeyBackspace:
+		if r := []rune(h.editText); len(r) > 0 {
+			h.editText = string(r[:len(r)-1])
+		}
+		input.ClearKeyboardInput()
+
+	case view.KeyEnter:
+		edit, oldName, newName := h.edit, h.current, h.editText
+		input.AddFiredCallback(func() {
+			switch edit {
+			case watchlistEditAdd:
+				if h.addCallback != nil {
+					h.addCallback(newName)
+				}
+			case watchlistEditRename:
+				if h.renameCallback != nil && newName != oldName {
+					h.renameCallback(oldName, newName)
+				}
+			}
+		})
+		h.edit = watchlistEditNone
+		input.ClearKeyboardInput()
+	}
+}
+
+// fireSwitch calls the switch callback with the watchlist before or after the current one.
+func (h *watchlistHeader) fireSwitch(delta int) {
+	if h.switchCallback == nil || len(h.names) == 0 {
+		return
+	}
+
+	i := 0
+	for j, n := range h.names {
+		if n == h.current {
+			i = j
+			break
+		}
+	}
+
+	i = (i + delta + len(h.names)) % len(h.names)
+	h.switchCallback(h.names[i])
+}
+
+func (h *watchlistHeader) Update() (dirty bool) {
+	return false
+}
+
+func (h *watchlistHeader) Render(fudge float32) {
+	if len(h.names) > 1 {
+		h.previousButton.Render(fudge)
+		h.nextButton.Render(fudge)
+		h.removeButton.Render(fudge)
+	}
+	h.addButton.Render(fudge)
+
+	txt, color := h.current, view.White
+	if h.Editing() {
+		txt, color = h.editText+"_", view.Yellow
+	}
+
+	pt := image.Pt(h.nameBounds.Min.X, h.bounds.Min.Y+viewPadding)
+	watchlistTextRenderer.Render(txt, pt, gfx.TextColor(color), gfx.TextRenderMaxWidth(h.nameBounds.Dx()))
+}
+
+func (h *watchlistHeader) SetSwitchCallback(cb func(name string)) {
+	h.switchCallback = cb
+}
+
+func (h *watchlistHeader) SetAddCallback(cb func(name string)) {
+	h.addCallback = cb
+}
+
+func (h *watchlistHeader) SetRenameCallback(cb func(oldName, newName string)) {
+	h.renameCallback = cb
+}
+
+func (h *watchlistHeader) SetRemoveCallback(cb func(name string)) {
+	h.removeCallback = cb
+}
+
+func (h *watchlistHeader) Close() {
+	h.switchCallback = nil
+	h.addCallback = nil
+	h.renameCallback = nil
+	h.removeCallback = nil
+}
+
+// watchlistIconVAO returns a white line icon from x, y pairs and line indices.
+func watchlistIconVAO(points []float32, indices []uint16) *gfx.VAO {
+	data := &gfx.VAOVertexData{
+		Mode:    gfx.Lines,
+		Indices: indices,
+	}
+	for i := 0; i+1 < len(points); i += 2 {
+		data.Vertices = append(data.Vertices, points[i], points[i+1], 0)
+		data.Colors = append(data.Colors, view.White[0], view.White[1], view.White[2], view.White[3])
+	}
+	return gfx.NewVAO(data)
+}
@@ -99,6 +99,9 @@ type MouseClickEvent struct {
 
 	// ReleasedPos is where the mouse was released.
 	ReleasedPos MousePosition
+
+	// Shift is true if the shift key was held down when the mouse was released.
+	Shift bool
 }
 
 // In returns true if the left mouse button was clicked within
@@ -0,0 +1,273 @@
+// Package watchlist reads and writes lists of symbols in formats used by other programs.
+package watchlist
+
+import (
+	"bufio"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/btmura/ponzi2/internal/errs"
+	"github.com/btmura/ponzi2/internal/stock"
+)
+
+// Format is a format of a list of symbols.
+type Format int
+
+// Format values.
+//go:generate stringer -type=Format
+const (
+	FormatUnspecified Format = iota
+
+	// PlainText has symbols separated by commas, spaces, or new lines.
+	// Lines starting with # are comments.
+	PlainText
+
+	// CSV has a header row with a Symbol column or symbols in the first column.
+	CSV
+
+	// TradingView has comma separated symbols with optional exchange prefixes
+	// like NASDAQ:AAPL and ###Section markers.
+	TradingView
+
+	// ThinkOrSwim is the CSV exported by thinkorswim with a few title lines
+	// before a header row with a Symbol column. It is written like CSV,
+	// which thinkorswim can import.
+	ThinkOrSwim
+)
+
+// formatNames are the names of the formats accepted by ParseFormat.
+var formatNames = map[string]Format{
+	"text":        PlainText,
+	"csv":         CSV,
+	"tradingview": TradingView,
+	"thinkorswim": ThinkOrSwim,
+}
+
+// exchangeSymbolRegexp matches TradingView symbols with exchange prefixes like NASDAQ:AAPL.
+var exchangeSymbolRegexp = regexp.MustCompile(`^[A-Za-z][A-Za-z0-9_]*:[A-Za-z0-9.\-/]+$`)
+
+// ParseFormat returns the format with a name like "csv" or "tradingview".
+func ParseFormat(name string) (Format, error) {
+	if f, ok := formatNames[strings.ToLower(name)]; ok {
+		return f, nil
+	}
+	return FormatUnspecified, errs.Errorf("unknown watchlist format: %q", name)
+}
+
+// FormatFromPath returns the format to write a file with based on its extension.
+func FormatFromPath(path string) Format {
+	if strings.EqualFold(filepath.Ext(path), ".csv") {
+		return CSV
+	}
+	return PlainText
+}
+
+// Detect guesses the format of the data. It never returns ThinkOrSwim,
+// since CSV reads thinkorswim exports just as well.
+func Detect(data string) Format {
+	var lines []string
+	for _, l := range strings.Split(data, "\n") {
+		if l = strings.TrimSpace(l); l != "" && !strings.HasPrefix(l, "#") {
+			lines = append(lines, l)
+		}
+	}
+
+	if len(lines) == 0 {
+		return PlainText
+	}
+
+	if strings.Contains(data, "###") {
+		return TradingView
+	}
+
+	// Check for a header row first, since thinkorswim title lines have times like 10:32:15 AM.
+	for _, l := range lines {
+		if symbolColumn(strings.Split(l, ",")) >= 0 {
+			return CSV
+		}
+	}
+
+	for _, s := range strings.FieldsFunc(lines[0], isSeparator) {
+		if exchangeSymbolRegexp.MatchString(s) {
+			return TradingView
+		}
+	}
+
+	if len(lines) > 1 && strings.Contains(lines[0], ",") {
+		return CSV
+	}
+
+	return PlainText
+}
+
+// Read reads the symbols in the given format. Symbols are normalized like BRK-B to BRK.B
+// and duplicates are removed while keeping the order of the first ones.
+func Read(r io.Reader, f Format) ([]string, error) {
+	switch f {
+	case PlainText:
+		return readPlainText(r)
+	case CSV, ThinkOrSwim:
+		return readCSV(r)
+	case TradingView:
+		return readTradingView(r)
+	default:
+		return nil, errs.Errorf("unsupported watchlist format: %v", f)
+	}
+}
+
+func readPlainText(r io.Reader) ([]string, error) {
+	l := new(symbolList)
+
+	sc := bufio.NewScanner(r)
+	for line := 1; sc.Scan(); line++ {
+		txt := strings.TrimSpace(sc.Text())
+		if strings.HasPrefix(txt, "#") {
+			continue
+		}
+
+		for _, s := range strings.FieldsFunc(txt, isSeparator) {
+			if err := l.add(s); err != nil {
+				return nil, errs.Errorf("line %d: %v", line, err)
+			}
+		}
+	}
+	if err := sc.Err(); err != nil {
+		return nil, err
+	}
+
+	return l.symbols, nil
+}
+
+func readTradingView(r io.Reader) ([]string, error) {
+	data, err := ioutil.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+
+	l := new(symbolList)
+	for _, s := range strings.FieldsFunc(string(data), isSeparator) {
+		if strings.HasPrefix(s, "###") {
+			continue
+		}
+
+		// Drop the exchange prefix like NASDAQ:AAPL.
+		if i := strings.LastIndex(s, ":"); i >= 0 {
+			s = s[i+1:]
+		}
+
+		if err := l.add(s); err != nil {
+			return nil, err
+		}
+	}
+	return l.symbols, nil
+}
+
+// readCSV reads the Symbol column of the first header row that has one,
+// ignoring any title lines before it, or the first column if there is no header.
+func readCSV(r io.Reader) ([]string, error) {
+	cr := csv.NewReader(r)
+	cr.FieldsPerRecord = -1
+	cr.LazyQuotes = true
+
+	records, err := cr.ReadAll()
+	if err != nil {
+		return nil, err
+	}
+
+	start, col := 0, 0
+	for i, rec := range records {
+		if j := symbolColumn(rec); j >= 0 {
+			start, col = i+1, j
+			break
+		}
+	}
+
+	l := new(symbolList)
+	for i := start; i < len(records); i++ {
+		rec := records[i]
+		if col >= len(rec) || strings.TrimSpace(rec[col]) == "" {
+			continue
+		}
+
+		if err := l.add(rec[col]); err != nil {
+			return nil, errs.Errorf("row %d: %v", i+1, err)
+		}
+	}
+	return l.symbols, nil
+}
+
+// symbolColumn returns the index of the Symbol column in a header row or -1 if it's not a header row.
+func symbolColumn(rec []string) int {
+	for i, f := range rec {
+		if strings.EqualFold(strings.Trim(strings.TrimSpace(f), `"`), "Symbol") {
+			return i
+		}
+	}
+	return -1
+}
+
+func isSeparator(r rune) bool {
+	return r == ',' || r == ' ' || r == '\t' || r == '\n' || r == '\r'
+}
+
+// symbolList is an ordered list of symbols without duplicates.
+type symbolList struct {
+	symbols []string
+	seen    map[string]bool
+}
+
+func (l *symbolList) add(s string) error {
+	s, err := stock.NormalizeSymbol(s)
+	if err != nil {
+		return err
+	}
+
+	if l.seen == nil {
+		l.seen = map[string]bool{}
+	}
+
+	if !l.seen[s] {
+		l.seen[s] = true
+		l.symbols = append(l.symbols, s)
+	}
+
+	return nil
+}
+
+// Write writes the symbols in the given format.
+func Write(w io.Writer, f Format, symbols []string) error {
+	switch f {
+	case PlainText:
+		for _, s := range symbols {
+			if _, err := fmt.Fprintln(w, s); err != nil {
+				return err
+			}
+		}
+		return nil
+
+	case CSV, ThinkOrSwim:
+		cw := csv.NewWriter(w)
+		if err := cw.Write([]string{"Symbol"}); err != nil {
+			return err
+		}
+		for _, s := range symbols {
+			if err := cw.Write([]string{s}); err != nil {
+				return err
+			}
+		}
+		cw.Flush()
+		return cw.Error()
+
+	case TradingView:
+		_, err := io.WriteString(w, strings.Join(symbols, ","))
+		return err
+
+	default:
+		return errs.Errorf("unsupported watchlist format: %v", f)
+	}
+}
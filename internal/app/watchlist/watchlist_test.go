@@ -0,0 +1,176 @@
+package watchlist
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestRead(t *testing.T) {
+	for _, tt := range []struct {
+		desc    string
+		format  Format
+		data    string
+		want    []string
+		wantErr bool
+	}{
+		{
+			desc:   "plain text with comments, commas, and duplicates",
+			format: PlainText,
+			data:   "# Holdings\naapl\nMSFT, spy\n\nAAPL\n",
+			want:   []string{"AAPL", "MSFT", "SPY"},
+		},
+		{
+			desc:   "plain text with class shares and exchanges",
+			format: PlainText,
+			data:   "BRK.B\nrds-a\nSHOP.TO\n",
+			want:   []string{"BRK.B", "RDS.A", "SHOP.TO"},
+		},
+		{
+			desc:    "plain text with bad symbol",
+			format:  PlainText,
+			data:    "AAPL\nSPYSPY\n",
+			wantErr: true,
+		},
+		{
+			desc:   "csv with header",
+			format: CSV,
+			data:   "Name,Symbol\nApple,AAPL\nMicrosoft,MSFT\n",
+			want:   []string{"AAPL", "MSFT"},
+		},
+		{
+			desc:   "csv without header",
+			format: CSV,
+			data:   "AAPL,100\nMSFT,200\n",
+			want:   []string{"AAPL", "MSFT"},
+		},
+		{
+			desc:   "tradingview with sections and exchanges",
+			format: TradingView,
+			data:   "###Tech,NASDAQ:AAPL,NASDAQ:MSFT,###Index,AMEX:SPY",
+			want:   []string{"AAPL", "MSFT", "SPY"},
+		},
+		{
+			desc:   "thinkorswim with title lines",
+			format: ThinkOrSwim,
+			data:   "Watchlist 'Tech' as of 10/18/2026\n\n\"Symbol\",\"Last\",\"Net Chng\"\n\"AAPL\",\"150.00\",\"+1.00\"\n\"MSFT\",\"300.00\",\"-2.00\"\n",
+			want:   []string{"AAPL", "MSFT"},
+		},
+		{
+			desc:    "unspecified format",
+			data:    "AAPL",
+			wantErr: true,
+		},
+	} {
+		t.Run(tt.desc, func(t *testing.T) {
+			got, gotErr := Read(strings.NewReader(tt.data), tt.format)
+
+			if diff := cmp.Diff(tt.want, got); diff != "" {
+				t.Errorf("diff (-want, +got)\n%s", diff)
+			}
+
+			if (gotErr != nil) != tt.wantErr {
+				t.Errorf("got error: %v, wanted err: %t", gotErr, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestWrite(t *testing.T) {
+	symbols := []string{"AAPL", "MSFT"}
+
+	for _, tt := range []struct {
+		desc   string
+		format Format
+		want   string
+	}{
+		{
+			desc:   "plain text",
+			format: PlainText,
+			want:   "AAPL\nMSFT\n",
+		},
+		{
+			desc:   "csv",
+			format: CSV,
+			want:   "Symbol\nAAPL\nMSFT\n",
+		},
+		{
+			desc:   "tradingview",
+			format: TradingView,
+			want:   "AAPL,MSFT",
+		},
+	} {
+		t.Run(tt.desc, func(t *testing.T) {
+			var b bytes.Buffer
+			if err := Write(&b, tt.format, symbols); err != nil {
+				t.Fatalf("Write should not return an error: %v", err)
+			}
+
+			if diff := cmp.Diff(tt.want, b.String()); diff != "" {
+				t.Errorf("diff (-want, +got)\n%s", diff)
+			}
+
+			// Reading back what was written should give the same symbols.
+			got, err := Read(&b, tt.format)
+			if err != nil {
+				t.Fatalf("Read should not return an error: %v", err)
+			}
+
+			if diff := cmp.Diff(symbols, got); diff != "" {
+				t.Errorf("diff (-want, +got)\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestDetect(t *testing.T) {
+	for _, tt := range []struct {
+		desc string
+		data string
+		want Format
+	}{
+		{
+			desc: "one symbol per line",
+			data: "AAPL\nMSFT\n",
+			want: PlainText,
+		},
+		{
+			desc: "comma separated line",
+			data: "AAPL,MSFT,SPY",
+			want: PlainText,
+		},
+		{
+			desc: "tradingview",
+			data: "NASDAQ:AAPL,NASDAQ:MSFT",
+			want: TradingView,
+		},
+		{
+			desc: "csv",
+			data: "Symbol,Shares\nAAPL,10\n",
+			want: CSV,
+		},
+		{
+			desc: "thinkorswim",
+			data: "Watchlist 'Tech'\n\nSymbol,Last\nAAPL,150.00\n",
+			want: CSV,
+		},
+		{
+			desc: "thinkorswim with timestamped title",
+			data: "Watchlist 'Tech' as of 10/18/26 10:32:15 AM\n\n\"Symbol\",\"Last\",\"Net Chng\"\n\"AAPL\",\"150.00\",\"+1.00\"\n",
+			want: CSV,
+		},
+		{
+			desc: "tradingview with some exchanges",
+			data: "SPY,NASDAQ:AAPL",
+			want: TradingView,
+		},
+	} {
+		t.Run(tt.desc, func(t *testing.T) {
+			if got := Detect(tt.data); got != tt.want {
+				t.Errorf("got %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
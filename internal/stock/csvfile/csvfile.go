@@ -0,0 +1,255 @@
+// Package csvfile provides a stock data provider that reads daily bars from local CSV files.
+package csvfile
+
+import (
+	"context"
+	"encoding/csv"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/btmura/ponzi2/internal/errs"
+	"github.com/btmura/ponzi2/internal/logger"
+	"github.com/btmura/ponzi2/internal/stock"
+)
+
+// loc is the timezone to use when parsing dates.
+var loc = mustLoadLocation("America/New_York")
+
+// dateLayouts are the accepted layouts of the Date column.
+var dateLayouts = []string{
+	"2006-01-02",
+	"1/2/2006",
+	"20060102",
+}
+
+// Provider reads daily bars from CSV files with one file per symbol
+// named like AAPL.csv in a directory.
+//
+// Each file must have a header row with at least the columns
+// Date, Open, High, Low, Close, and Volume in any order.
+type Provider struct {
+	// dir is the directory with the CSV files.
+	dir string
+}
+
+// NewProvider returns a new Provider that reads CSV files from the given directory.
+func NewProvider(dir string) *Provider {
+	return &Provider{dir: dir}
+}
+
+// GetQuotes implements the stock.Provider interface.
+// Quotes are derived from the last bar of each symbol's file.
+func (p *Provider) GetQuotes(ctx context.Context, req *stock.GetQuotesRequest) ([]*stock.Quote, error) {
+	var quotes []*stock.Quote
+	for _, sym := range req.Symbols {
+		ch, err := p.readChart(sym)
+		if err != nil {
+			return nil, err
+		}
+		if ch == nil || len(ch.Bars) == 0 {
+			continue
+		}
+		quotes = append(quotes, quoteFromChart(ch))
+	}
+	return quotes, nil
+}
+
+// GetDailyCharts implements the stock.Provider interface.
+// All the bars in the files are returned regardless of the requested range.
+// Symbols without a CSV file are omitted from the result.
+func (p *Provider) GetDailyCharts(ctx context.Context, req *stock.GetChartsRequest) ([]*stock.Chart, error) {
+	var charts []*stock.Chart
+	for _, sym := range req.Symbols {
+		ch, err := p.readChart(sym)
+		if err != nil {
+			return nil, err
+		}
+		if ch == nil {
+			continue
+		}
+		charts = append(charts, ch)
+	}
+	return charts, nil
+}
+
+// GetIntradayCharts implements the stock.Provider interface.
+// CSV files only have daily bars, so it always returns an error.
+func (p *Provider) GetIntradayCharts(ctx context.Context, req *stock.GetChartsRequest) ([]*stock.Chart, error) {
+	return nil, errs.Errorf("intraday charts not supported by csv files")
+}
+
+// readChart reads the chart for a symbol. Returns nil if there is no file for the symbol.
+func (p *Provider) readChart(symbol string) (*stock.Chart, error) {
+	for _, name := range fileNames(symbol) {
+		file, err := os.Open(filepath.Join(p.dir, name))
+		if os.IsNotExist(err) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		bars, err := decodeBars(file)
+		if cerr := file.Close(); cerr != nil {
+			logger.Error(cerr)
+		}
+		if err != nil {
+			return nil, errs.Errorf("%s: %v", name, err)
+		}
+
+		return &stock.Chart{
+			Symbol: symbol,
+			Bars:   bars,
+		}, nil
+	}
+
+	logger.Infof("no csv file for %s in %s", symbol, p.dir)
+	return nil, nil
+}
+
+// fileNames returns the file names to try for a symbol like BRK.B.csv, brk.b.csv,
+// and the BRK-B.csv spelling used by Yahoo Finance for share classes.
+func fileNames(symbol string) []string {
+	names := []string{symbol + ".csv", strings.ToLower(symbol) + ".csv"}
+
+	if sym, err := stock.ParseSymbol(symbol); err == nil && sym.Class != "" {
+		yahoo := sym.Base + "-" + sym.Class
+		if sym.Exchange != "" {
+			yahoo += "." + sym.Exchange
+		}
+		names = append(names, yahoo+".csv", strings.ToLower(yahoo)+".csv")
+	}
+
+	return names
+}
+
+// decodeBars decodes bars sorted by date from CSV data with a header row.
+func decodeBars(r io.Reader) ([]*stock.Bar, error) {
+	cr := csv.NewReader(r)
+	cr.FieldsPerRecord = -1
+	cr.TrimLeadingSpace = true
+
+	header, err := cr.Read()
+	if err == io.EOF {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	col := map[string]int{}
+	for i, h := range header {
+		col[strings.ToLower(strings.TrimSpace(h))] = i
+	}
+
+	for _, h := range []string{"date", "open", "high", "low", "close", "volume"} {
+		if _, ok := col[h]; !ok {
+			return nil, errs.Errorf("missing %s column", h)
+		}
+	}
+
+	var bars []*stock.Bar
+	for line := 2; ; line++ {
+		record, err := cr.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		field := func(name string) string {
+			if i := col[name]; i < len(record) {
+				return strings.TrimSpace(record[i])
+			}
+			return ""
+		}
+
+		date, err := parseDate(field("date"))
+		if err != nil {
+			return nil, errs.Errorf("line %d: %v", line, err)
+		}
+
+		var prices [4]float32
+		for i, name := range []string{"open", "high", "low", "close"} {
+			v, err := strconv.ParseFloat(field(name), 32)
+			if err != nil {
+				return nil, errs.Errorf("line %d: bad %s: %v", line, name, err)
+			}
+			prices[i] = float32(v)
+		}
+
+		vol, err := strconv.ParseFloat(field("volume"), 64)
+		if err != nil {
+			return nil, errs.Errorf("line %d: bad volume: %v", line, err)
+		}
+
+		bars = append(bars, &stock.Bar{
+			Date:   date,
+			Open:   prices[0],
+			High:   prices[1],
+			Low:    prices[2],
+			Close:  prices[3],
+			Volume: int(vol),
+		})
+	}
+
+	sort.Slice(bars, func(i, j int) bool {
+		return bars[i].Date.Before(bars[j].Date)
+	})
+
+	// Fill in the change fields, since CSV exports rarely include them.
+	for i, b := range bars {
+		if i == 0 {
+			continue
+		}
+		prev := bars[i-1].Close
+		b.Change = b.Close - prev
+		if prev != 0 {
+			b.ChangePercent = b.Change / prev
+		}
+	}
+
+	return bars, nil
+}
+
+func parseDate(value string) (time.Time, error) {
+	for _, layout := range dateLayouts {
+		if t, err := time.ParseInLocation(layout, value, loc); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, errs.Errorf("bad date: %q", value)
+}
+
+// quoteFromChart returns a quote based on the last bar of a non-empty chart.
+func quoteFromChart(ch *stock.Chart) *stock.Quote {
+	last := ch.Bars[len(ch.Bars)-1]
+	return &stock.Quote{
+		Symbol:        ch.Symbol,
+		LatestPrice:   last.Close,
+		LatestSource:  stock.Close,
+		LatestTime:    last.Date,
+		LatestUpdate:  last.Date,
+		LatestVolume:  last.Volume,
+		Open:          last.Open,
+		High:          last.High,
+		Low:           last.Low,
+		Close:         last.Close,
+		Change:        last.Change,
+		ChangePercent: last.ChangePercent,
+	}
+}
+
+func mustLoadLocation(name string) *time.Location {
+	loc, err := time.LoadLocation(name)
+	if err != nil {
+		logger.Fatalf("time.LoadLocation(%s) failed: %v", name, err)
+	}
+	return loc
+}
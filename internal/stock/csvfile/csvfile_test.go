@@ -0,0 +1,163 @@
+package csvfile
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+
+	"github.com/btmura/ponzi2/internal/stock"
+)
+
+func TestDecodeBars(t *testing.T) {
+	for _, tt := range []struct {
+		desc    string
+		data    string
+		want    []*stock.Bar
+		wantErr bool
+	}{
+		{
+			desc: "empty file",
+		},
+		{
+			desc: "header only",
+			data: "Date,Open,High,Low,Close,Volume\n",
+		},
+		{
+			desc: "unsorted with extra column",
+			data: "Date,Open,High,Low,Close,Adj Close,Volume\n" +
+				"2019-10-02,20,25,15,22,21.5,2000\n" +
+				"2019-10-01,10,12,8,11,10.5,1000\n",
+			want: []*stock.Bar{
+				{
+					Date:   time.Date(2019, time.October, 1, 0, 0, 0, 0, loc),
+					Open:   10,
+					High:   12,
+					Low:    8,
+					Close:  11,
+					Volume: 1000,
+				},
+				{
+					Date:          time.Date(2019, time.October, 2, 0, 0, 0, 0, loc),
+					Open:          20,
+					High:          25,
+					Low:           15,
+					Close:         22,
+					Volume:        2000,
+					Change:        11,
+					ChangePercent: 1,
+				},
+			},
+		},
+		{
+			desc: "reordered columns with slash dates and float volume",
+			data: "volume, close, low, high, open, date\n" +
+				"1500.0, 4, 2, 5, 3, 10/1/2019\n",
+			want: []*stock.Bar{
+				{
+					Date:   time.Date(2019, time.October, 1, 0, 0, 0, 0, loc),
+					Open:   3,
+					High:   5,
+					Low:    2,
+					Close:  4,
+					Volume: 1500,
+				},
+			},
+		},
+		{
+			desc:    "missing volume column",
+			data:    "Date,Open,High,Low,Close\n2019-10-01,1,2,3,4\n",
+			wantErr: true,
+		},
+		{
+			desc:    "bad date",
+			data:    "Date,Open,High,Low,Close,Volume\nOct 1,1,2,3,4,5\n",
+			wantErr: true,
+		},
+		{
+			desc:    "bad price",
+			data:    "Date,Open,High,Low,Close,Volume\n2019-10-01,1,2,x,4,5\n",
+			wantErr: true,
+		},
+	} {
+		t.Run(tt.desc, func(t *testing.T) {
+			got, gotErr := decodeBars(strings.NewReader(tt.data))
+
+			if diff := cmp.Diff(tt.want, got); diff != "" {
+				t.Errorf("diff (-want, +got)\n%s", diff)
+			}
+
+			if (gotErr != nil) != tt.wantErr {
+				t.Errorf("got error: %v, wanted err: %t", gotErr, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestQuoteFromChart(t *testing.T) {
+	input := &stock.Chart{
+		Symbol: "SPY",
+		Bars: []*stock.Bar{
+			{
+				Date:  time.Date(2019, time.October, 1, 0, 0, 0, 0, loc),
+				Close: 100,
+			},
+			{
+				Date:          time.Date(2019, time.October, 2, 0, 0, 0, 0, loc),
+				Open:          101,
+				High:          110,
+				Low:           99,
+				Close:         105,
+				Volume:        1000,
+				Change:        5,
+				ChangePercent: 0.05,
+			},
+		},
+	}
+
+	want := &stock.Quote{
+		Symbol:        "SPY",
+		LatestPrice:   105,
+		LatestSource:  stock.Close,
+		LatestTime:    time.Date(2019, time.October, 2, 0, 0, 0, 0, loc),
+		LatestUpdate:  time.Date(2019, time.October, 2, 0, 0, 0, 0, loc),
+		LatestVolume:  1000,
+		Open:          101,
+		High:          110,
+		Low:           99,
+		Close:         105,
+		Change:        5,
+		ChangePercent: 0.05,
+	}
+
+	if diff := cmp.Diff(want, quoteFromChart(input)); diff != "" {
+		t.Errorf("diff (-want, +got)\n%s", diff)
+	}
+}
+
+func TestFileNames(t *testing.T) {
+	for _, tt := range []struct {
+		input string
+		want  []string
+	}{
+		{
+			input: "SPY",
+			want:  []string{"SPY.csv", "spy.csv"},
+		},
+		{
+			input: "BRK.B",
+			want:  []string{"BRK.B.csv", "brk.b.csv", "BRK-B.csv", "brk-b.csv"},
+		},
+		{
+			input: "RCI.B.TO",
+			want:  []string{"RCI.B.TO.csv", "rci.b.to.csv", "RCI-B.TO.csv", "rci-b.to.csv"},
+		},
+	} {
+		t.Run(tt.input, func(t *testing.T) {
+			if diff := cmp.Diff(tt.want, fileNames(tt.input)); diff != "" {
+				t.Errorf("diff (-want, +got)\n%s", diff)
+			}
+		})
+	}
+}
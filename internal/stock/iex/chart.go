@@ -4,10 +4,9 @@ import (
 	"bytes"
 	"context"
 	"encoding/json"
-	"fmt"
 	"io"
 	"io/ioutil"
-	"net/http"
+	"math"
 	"net/url"
 	"sort"
 	"strconv"
@@ -18,6 +17,7 @@ import (
 
 	"github.com/btmura/ponzi2/internal/errs"
 	"github.com/btmura/ponzi2/internal/logger"
+	"github.com/btmura/ponzi2/internal/stock/market"
 )
 
 // Chart has points for a stock chart.
@@ -51,6 +51,13 @@ type ChartPoint struct {
 	Volume        int
 	Change        float32
 	ChangePercent float32
+
+	// TotalReturnOpen, TotalReturnHigh, TotalReturnLow, and TotalReturnClose are adjusted
+	// for dividends as well as splits. Zero if the response doesn't have them like minute points.
+	TotalReturnOpen  float32
+	TotalReturnHigh  float32
+	TotalReturnLow   float32
+	TotalReturnClose float32
 }
 
 // DeepCopy returns a deep copy of the chart point.
@@ -70,6 +77,14 @@ type GetChartsRequest struct {
 	ChartLast int
 }
 
+// adjustmentCheckPoints is how many cached daily points to ask for again to detect
+// splits and dividends that adjusted the prices of the cached points.
+const adjustmentCheckPoints = 5
+
+// adjustmentTolerance is the smallest relative change of a cached price that is
+// considered an adjustment for a split or dividend.
+const adjustmentTolerance = 0.001
+
 // Range is the range to specify in the request.
 type Range int
 
@@ -79,13 +94,15 @@ const (
 	RangeUnspecified Range = iota
 	OneDay
 	TwoYears
+	FiveYears
+	Max
 )
 
 // GetCharts gets charts for stock symbols.
 func (c *Client) GetCharts(ctx context.Context, req *GetChartsRequest) ([]*Chart, error) {
 	cacheClientVar.Add("get-charts-requests", 1)
 
-	if req.Token == "" {
+	if req.Token == "" && !c.replaying {
 		return nil, ErrMissingAPIToken
 	}
 
@@ -93,17 +110,26 @@ func (c *Client) GetCharts(ctx context.Context, req *GetChartsRequest) ([]*Chart
 		return nil, nil
 	}
 
-	if req.Range != TwoYears {
-		return nil, errs.Errorf("only the two years range is supported")
+	// Cache minute and daily points separately, since they have different resolutions.
+	var interval ChartInterval
+	switch req.Range {
+	case OneDay:
+		interval = MinuteInterval
+	case TwoYears, FiveYears, Max:
+		interval = DailyInterval
+	default:
+		return nil, errs.Errorf("unsupported range: %v", req.Range)
 	}
 
 	fixedNow := now()
-	today := midnight(fixedNow)
 
 	type data struct {
 		// cacheChart is the chart found in the cache. Nil if not in cache.
 		cacheChart *Chart
 
+		// cacheRange is the range of the chart found in the cache.
+		cacheRange Range
+
 		// minChartLast is the minimum chartLast value to complete the data set.
 		// 0 means make a request for the range's default data.
 		// -1 means don't make any request at all.
@@ -119,7 +145,7 @@ func (c *Client) GetCharts(ctx context.Context, req *GetChartsRequest) ([]*Chart
 	symbol2Data := map[string]*data{}
 
 	for _, sym := range req.Symbols {
-		k := ChartCacheKey{req.Token, sym, DailyInterval}
+		k := ChartCacheKey{sym, interval}
 		v, err := c.chartCache.Get(ctx, k)
 		if err != nil {
 			return nil, err
@@ -131,8 +157,9 @@ func (c *Client) GetCharts(ctx context.Context, req *GetChartsRequest) ([]*Chart
 
 		ps := v.Chart.ChartPoints
 
-		// If cached value has no data, then consider this missing.
-		if len(ps) == 0 {
+		// If cached value has no data or not enough history, then consider this missing.
+		// Daily charts cached before total return prices were requested are missing them.
+		if len(ps) == 0 || cachedRange(v, interval) < req.Range || interval == DailyInterval && !v.HasTotalReturnPrices {
 			symbol2Data[sym] = &data{
 				cacheChart:   v.Chart.DeepCopy(),
 				minChartLast: 0,
@@ -140,37 +167,31 @@ func (c *Client) GetCharts(ctx context.Context, req *GetChartsRequest) ([]*Chart
 			continue
 		}
 
-		// Compute the number of points required to be combined with the cached value
-		// by counting business days between the latest point's date and today's date.
-		minChartLast := -1
-
-		latest := midnight(ps[len(ps)-1].Date)
-
-		for {
-			latest = latest.AddDate(0, 0, 1 /* day */)
-
-			// Don't ask for data in the future. :)
-			if !latest.Before(today) {
-				break
-			}
-
-			// Don't ask for data for weekends, since the market is closed.
-			// Keep iterating though.
-			if latest.Weekday() != time.Saturday && latest.Weekday() != time.Sunday {
-				if minChartLast == -1 {
-					minChartLast = 0
-				}
-				minChartLast++
+		var minChartLast int
+		switch interval {
+		case MinuteInterval:
+			minChartLast = minuteChartLast(ps, fixedNow)
+		default:
+			minChartLast = dailyChartLast(ps, fixedNow)
+
+			// Ask for some cached points again at least once a day to detect
+			// splits and dividends that changed the prices of the cached points.
+			switch {
+			case minChartLast > 0:
+				minChartLast += adjustmentCheckPoints
+			case v.LastUpdateTime.Before(midnight(fixedNow.In(loc))):
+				minChartLast = adjustmentCheckPoints
 			}
 		}
 
 		symbol2Data[sym] = &data{
 			cacheChart:   v.Chart.DeepCopy(),
+			cacheRange:   cachedRange(v, interval),
 			minChartLast: minChartLast,
 		}
 	}
 
-	token := req.Token
+	token, rng := req.Token, req.Range
 	chartLast2Request := map[int]*GetChartsRequest{}
 	for sym, data := range symbol2Data {
 		if data.minChartLast == -1 {
@@ -180,7 +201,7 @@ func (c *Client) GetCharts(ctx context.Context, req *GetChartsRequest) ([]*Chart
 		if req == nil {
 			req = &GetChartsRequest{
 				Token:     token,
-				Range:     TwoYears,
+				Range:     rng,
 				ChartLast: data.minChartLast,
 			}
 			chartLast2Request[data.minChartLast] = req
@@ -228,8 +249,19 @@ func (c *Client) GetCharts(ctx context.Context, req *GetChartsRequest) ([]*Chart
 			data.finalChart = data.responseChart
 
 		default:
+			// Keep the cached chart if the response is missing the symbol.
+			if data.responseChart == nil {
+				data.finalChart = data.cacheChart
+				break
+			}
+
+			cachePoints := data.cacheChart.ChartPoints
+			if interval == DailyInterval {
+				cachePoints = adjustCachedPoints(sym, cachePoints, data.responseChart.ChartPoints)
+			}
+
 			date2Point := map[time.Time]*ChartPoint{}
-			for _, pt := range data.cacheChart.ChartPoints {
+			for _, pt := range cachePoints {
 				date2Point[timeKey(pt.Date)] = pt
 			}
 			for _, pt := range data.responseChart.ChartPoints {
@@ -252,10 +284,24 @@ func (c *Client) GetCharts(ctx context.Context, req *GetChartsRequest) ([]*Chart
 	}
 
 	for sym, data := range symbol2Data {
-		k := ChartCacheKey{req.Token, sym, DailyInterval}
+		// Don't cache anything if the response is missing the symbol.
+		if data.finalChart == nil {
+			data.finalChart = &Chart{Symbol: sym}
+			continue
+		}
+
+		// Record the widest range, so requests for narrower ranges can use it.
+		r := req.Range
+		if data.minChartLast != 0 {
+			r = data.cacheRange
+		}
+
+		k := ChartCacheKey{sym, interval}
 		v := &ChartCacheValue{
-			Chart:          data.finalChart,
-			LastUpdateTime: fixedNow,
+			Chart:                data.finalChart,
+			LastUpdateTime:       fixedNow,
+			Range:                r,
+			HasTotalReturnPrices: interval == DailyInterval,
 		}
 		if err := c.chartCache.Put(ctx, k, v); err != nil {
 			return nil, err
@@ -270,8 +316,143 @@ func (c *Client) GetCharts(ctx context.Context, req *GetChartsRequest) ([]*Chart
 	return charts, nil
 }
 
+// adjustCachedPoints adjusts the cached points for splits and dividends that changed
+// the prices in the response since the points were cached. It compares the earliest
+// cached point that the response also has and scales all the cached points by the
+// same ratio, since adjustments apply to all the points before the ex-date.
+// The cached points must be copies, since they are changed in place.
+func adjustCachedPoints(symbol string, cached, fresh []*ChartPoint) []*ChartPoint {
+	date2Fresh := map[time.Time]*ChartPoint{}
+	for _, pt := range fresh {
+		date2Fresh[timeKey(pt.Date)] = pt
+	}
+
+	var cp, fp *ChartPoint
+	for _, pt := range cached {
+		if f := date2Fresh[timeKey(pt.Date)]; f != nil {
+			cp, fp = pt, f
+			break
+		}
+	}
+
+	// Adjustments can't be detected without any points in common.
+	if cp == nil {
+		return cached
+	}
+
+	splitRatio := adjustmentRatio(cp.Close, fp.Close)
+	totalReturnRatio := adjustmentRatio(cp.TotalReturnClose, fp.TotalReturnClose)
+	if splitRatio == 1 && totalReturnRatio == 1 {
+		return cached
+	}
+
+	logger.Infof("adjusting cached %s points by %g for splits and %g for total return", symbol, splitRatio, totalReturnRatio)
+	cacheClientVar.Add("chart-cache-adjustments", 1)
+
+	for _, pt := range cached {
+		pt.Open *= splitRatio
+		pt.High *= splitRatio
+		pt.Low *= splitRatio
+		pt.Close *= splitRatio
+		pt.Change *= splitRatio
+		pt.Volume = int(math.Round(float64(pt.Volume) / float64(splitRatio)))
+		pt.TotalReturnOpen *= totalReturnRatio
+		pt.TotalReturnHigh *= totalReturnRatio
+		pt.TotalReturnLow *= totalReturnRatio
+		pt.TotalReturnClose *= totalReturnRatio
+	}
+
+	return cached
+}
+
+// adjustmentRatio returns the ratio of the fresh price to the cached price
+// or 1 if the prices are missing or within the tolerance of each other.
+func adjustmentRatio(cached, fresh float32) float32 {
+	if cached <= 0 || fresh <= 0 {
+		return 1
+	}
+
+	r := fresh / cached
+	if math.Abs(float64(r-1)) < adjustmentTolerance {
+		return 1
+	}
+	return r
+}
+
+// cachedRange returns the range of the cached value's chart.
+func cachedRange(v *ChartCacheValue, interval ChartInterval) Range {
+	if v.Range != RangeUnspecified {
+		return v.Range
+	}
+
+	// Entries without ranges were cached when only these ranges were supported.
+	if interval == MinuteInterval {
+		return OneDay
+	}
+	return TwoYears
+}
+
+// dailyChartLast returns the minimum chartLast value to complete the cached daily points
+// by counting trading days between the latest point's date and today's date.
+// Returns -1 if the cached points are already complete.
+func dailyChartLast(ps []*ChartPoint, now time.Time) int {
+	today := midnight(now.In(loc))
+
+	minChartLast := -1
+
+	latest := midnight(ps[len(ps)-1].Date.In(loc))
+
+	for {
+		latest = latest.AddDate(0, 0, 1 /* day */)
+
+		// Don't ask for data in the future. :)
+		if !latest.Before(today) {
+			break
+		}
+
+		// Don't ask for data for weekends and holidays, since the market is closed.
+		// Keep iterating though.
+		if market.NYSE.IsTradingDay(latest) {
+			if minChartLast == -1 {
+				minChartLast = 0
+			}
+			minChartLast++
+		}
+	}
+
+	return minChartLast
+}
+
+// minuteChartLast returns the minimum chartLast value to complete the cached minute points
+// by counting minutes between the latest point and now or the market close, which may be early.
+// Returns 0 if the cached points are not from the latest trading day
+// and -1 if the cached points are already complete.
+func minuteChartLast(ps []*ChartPoint, now time.Time) int {
+	n := now.In(loc)
+
+	// Find the latest trading day that has opened.
+	open, end := market.NYSE.LatestSession(n)
+
+	latest := ps[len(ps)-1].Date.In(loc)
+	if !midnight(latest).Equal(midnight(open)) {
+		return 0
+	}
+
+	// The last minute point of the day starts one minute before the close.
+	if !latest.Before(end.Add(-time.Minute)) {
+		return -1
+	}
+
+	if n.Before(end) {
+		end = n
+	}
+
+	// Ask for the latest cached minute again, since it may have been incomplete.
+	return int(end.Sub(latest)/time.Minute) + 1
+}
+
 func (c *Client) noCacheGetCharts(ctx context.Context, req *GetChartsRequest) ([]*Chart, error) {
-	if req.Token == "" {
+	if req.Token == "" && !c.replaying {
 		return nil, ErrMissingAPIToken
 	}
 
@@ -289,6 +470,10 @@ func (c *Client) noCacheGetCharts(ctx context.Context, req *GetChartsRequest) ([
 		rangeStr = "1d"
 	case TwoYears:
 		rangeStr = "2y"
+	case FiveYears:
+		rangeStr = "5y"
+	case Max:
+		rangeStr = "max"
 	default:
 		return nil, errs.Errorf("iex: unsupported range for chart req: %s", req.Range)
 	}
@@ -297,7 +482,7 @@ func (c *Client) noCacheGetCharts(ctx context.Context, req *GetChartsRequest) ([
 		return nil, errs.Errorf("iex: chart last must be greater than or equal to zero")
 	}
 
-	u, err := url.Parse("https://cloud.iexapis.com/stable/stock/market/batch")
+	u, err := c.batchURL()
 	if err != nil {
 		return nil, err
 	}
@@ -317,41 +502,22 @@ func (c *Client) noCacheGetCharts(ctx context.Context, req *GetChartsRequest) ([
 		"volume",
 		"change",
 		"changePercent",
+		"fOpen",
+		"fHigh",
+		"fLow",
+		"fClose",
 	}, ","))
 	if req.ChartLast > 0 {
 		v.Set("chartLast", strconv.Itoa(req.ChartLast))
 	}
 	u.RawQuery = v.Encode()
 
-	httpReq, err := http.NewRequest(http.MethodGet, u.String(), nil)
+	body, err := c.get(ctx, u)
 	if err != nil {
 		return nil, err
 	}
 
-	httpResp, err := http.DefaultClient.Do(httpReq.WithContext(ctx))
-	if err != nil {
-		return nil, err
-	}
-	defer func() {
-		if err := httpResp.Body.Close(); err != nil {
-			logger.Error(err)
-		}
-	}()
-
-	r := httpResp.Body
-	if c.dumpAPIResponses {
-		ss := make([]string, len(req.Symbols))
-		copy(ss, req.Symbols)
-		sort.Strings(ss)
-
-		rr, err := dumpResponse(fmt.Sprintf("iex-chart-%s-%v.txt", strings.Join(ss, "-"), rangeStr), r)
-		if err != nil {
-			return nil, errs.Errorf("iex: failed to dump chart resp: %v", err)
-		}
-		r = rr
-	}
-
-	charts, err := decodeCharts(r)
+	charts, err := decodeCharts(bytes.NewReader(body))
 	if err != nil {
 		return nil, errs.Errorf("iex: failed to decode chart resp: %v", err)
 	}
@@ -369,6 +535,10 @@ func decodeCharts(r io.Reader) ([]*Chart, error) {
 		Volume        float64 `json:"volume"`
 		Change        float64 `json:"change"`
 		ChangePercent float64 `json:"changePercent"`
+		FOpen         float64 `json:"fOpen"`
+		FHigh         float64 `json:"fHigh"`
+		FLow          float64 `json:"fLow"`
+		FClose        float64 `json:"fClose"`
 	}
 
 	type stock struct {
@@ -399,14 +569,18 @@ func decodeCharts(r io.Reader) ([]*Chart, error) {
 			}
 
 			ch.ChartPoints = append(ch.ChartPoints, &ChartPoint{
-				Date:          date,
-				Open:          float32(pt.Open),
-				High:          float32(pt.High),
-				Low:           float32(pt.Low),
-				Close:         float32(pt.Close),
-				Volume:        int(pt.Volume),
-				Change:        float32(pt.Change),
-				ChangePercent: float32(pt.ChangePercent),
+				Date:             date,
+				Open:             float32(pt.Open),
+				High:             float32(pt.High),
+				Low:              float32(pt.Low),
+				Close:            float32(pt.Close),
+				Volume:           int(pt.Volume),
+				Change:           float32(pt.Change),
+				ChangePercent:    float32(pt.ChangePercent),
+				TotalReturnOpen:  float32(pt.FOpen),
+				TotalReturnHigh:  float32(pt.FHigh),
+				TotalReturnLow:   float32(pt.FLow),
+				TotalReturnClose: float32(pt.FClose),
 			})
 		}
 		sort.Slice(ch.ChartPoints, func(i, j int) bool {
@@ -1,6 +1,8 @@
 package iex
 
 import (
+	"context"
+	"strconv"
 	"strings"
 	"testing"
 	"time"
@@ -111,6 +113,35 @@ func TestDecodeCharts(t *testing.T) {
 				},
 			},
 		},
+		{
+			desc: "daily chart with total return prices",
+			data: `{
+				"KO": {
+					"chart": [
+						{"date":"2019-09-13","open":53.1,"high":53.5,"low":52.9,"close":53.2,"volume":100,"fOpen":52.7,"fHigh":53.1,"fLow":52.5,"fClose":52.8}
+					]
+				}
+			}`,
+			want: []*Chart{
+				{
+					Symbol: "KO",
+					ChartPoints: []*ChartPoint{
+						{
+							Date:             time.Date(2019, time.September, 13, 0, 0, 0, 0, loc),
+							Open:             53.1,
+							High:             53.5,
+							Low:              52.9,
+							Close:            53.2,
+							Volume:           100,
+							TotalReturnOpen:  52.7,
+							TotalReturnHigh:  53.1,
+							TotalReturnLow:   52.5,
+							TotalReturnClose: 52.8,
+						},
+					},
+				},
+			},
+		},
 	} {
 		t.Run(tt.desc, func(t *testing.T) {
 			got, gotErr := decodeCharts(strings.NewReader(tt.data))
@@ -159,3 +190,274 @@ func TestChartDate(t *testing.T) {
 		})
 	}
 }
+
+func TestAdjustCachedPoints(t *testing.T) {
+	day := func(d int) time.Time { return time.Date(2020, time.August, d, 0, 0, 0, 0, loc) }
+
+	for _, tt := range []struct {
+		desc   string
+		cached []*ChartPoint
+		fresh  []*ChartPoint
+		want   []*ChartPoint
+	}{
+		{
+			desc:   "no changes",
+			cached: []*ChartPoint{{Date: day(27), Close: 500, Volume: 10, TotalReturnClose: 490}, {Date: day(28), Close: 498, TotalReturnClose: 488}},
+			fresh:  []*ChartPoint{{Date: day(28), Close: 498, TotalReturnClose: 488}},
+			want:   []*ChartPoint{{Date: day(27), Close: 500, Volume: 10, TotalReturnClose: 490}, {Date: day(28), Close: 498, TotalReturnClose: 488}},
+		},
+		{
+			desc:   "split",
+			cached: []*ChartPoint{{Date: day(27), Open: 400, High: 520, Low: 400, Close: 500, Change: 20, Volume: 10, TotalReturnClose: 490}, {Date: day(28), Close: 498, TotalReturnClose: 488}},
+			fresh:  []*ChartPoint{{Date: day(28), Close: 124.5, TotalReturnClose: 122}, {Date: day(31), Close: 129, TotalReturnClose: 129}},
+			want:   []*ChartPoint{{Date: day(27), Open: 100, High: 130, Low: 100, Close: 125, Change: 5, Volume: 40, TotalReturnClose: 122.5}, {Date: day(28), Close: 124.5, Volume: 0, TotalReturnClose: 122}},
+		},
+		{
+			desc:   "dividend",
+			cached: []*ChartPoint{{Date: day(27), Close: 50, Volume: 10, TotalReturnClose: 50}, {Date: day(28), Close: 50, TotalReturnClose: 50}},
+			fresh:  []*ChartPoint{{Date: day(28), Close: 50, TotalReturnClose: 49.5}},
+			want:   []*ChartPoint{{Date: day(27), Close: 50, Volume: 10, TotalReturnClose: 49.5}, {Date: day(28), Close: 50, TotalReturnClose: 49.5}},
+		},
+		{
+			desc:   "rounding",
+			cached: []*ChartPoint{{Date: day(28), Close: 1000, TotalReturnClose: 1000}},
+			fresh:  []*ChartPoint{{Date: day(28), Close: 1000.01, TotalReturnClose: 999.99}},
+			want:   []*ChartPoint{{Date: day(28), Close: 1000, TotalReturnClose: 1000}},
+		},
+		{
+			desc:   "no points in common",
+			cached: []*ChartPoint{{Date: day(27), Close: 500}},
+			fresh:  []*ChartPoint{{Date: day(28), Close: 125}},
+			want:   []*ChartPoint{{Date: day(27), Close: 500}},
+		},
+		{
+			desc:   "missing total return prices",
+			cached: []*ChartPoint{{Date: day(28), Close: 50}},
+			fresh:  []*ChartPoint{{Date: day(28), Close: 50, TotalReturnClose: 49}},
+			want:   []*ChartPoint{{Date: day(28), Close: 50}},
+		},
+	} {
+		t.Run(tt.desc, func(t *testing.T) {
+			got := adjustCachedPoints("AAPL", tt.cached, tt.fresh)
+			if diff := cmp.Diff(tt.want, got); diff != "" {
+				t.Errorf("diff (-want, +got)\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestClientGetCharts_AdjustsCachedPoints(t *testing.T) {
+	old := now
+	defer func() { now = old }()
+	now = func() time.Time { return time.Date(2020, time.September, 1, 12, 0, 0, 0, loc) }
+
+	day := func(d int) time.Time { return time.Date(2020, time.August, d, 0, 0, 0, 0, loc) }
+
+	cache := mapChartCache{
+		ChartCacheKey{"AAPL", DailyInterval}: {
+			Chart: &Chart{
+				Symbol: "AAPL",
+				ChartPoints: []*ChartPoint{
+					{Date: day(27), Close: 500, Volume: 10, TotalReturnClose: 500},
+					{Date: day(28), Close: 498, Volume: 10, TotalReturnClose: 498},
+				},
+			},
+			LastUpdateTime:       day(29),
+			Range:                TwoYears,
+			HasTotalReturnPrices: true,
+		},
+	}
+
+	ft := &fakeTransport{
+		responses: []fakeResponse{
+			{status: 200, body: `{"AAPL": {"chart": [
+				{"date":"2020-08-28","close":124.5,"volume":40,"fClose":124.5},
+				{"date":"2020-08-31","close":129,"volume":50,"fClose":129}
+			]}}`},
+		},
+	}
+	c := NewClient(cache, HTTPTransport(ft), RateLimit(0, 0))
+
+	got, err := c.GetCharts(context.Background(), &GetChartsRequest{Token: "abc", Symbols: []string{"AAPL"}, Range: TwoYears})
+	if err != nil {
+		t.Fatalf("GetCharts should not return an error: %v", err)
+	}
+
+	want := []*Chart{
+		{
+			Symbol: "AAPL",
+			ChartPoints: []*ChartPoint{
+				{Date: day(27), Close: 125, Volume: 40, TotalReturnClose: 125},
+				{Date: day(28), Close: 124.5, Volume: 40, TotalReturnClose: 124.5},
+				{Date: time.Date(2020, time.August, 31, 0, 0, 0, 0, loc), Close: 129, Volume: 50, TotalReturnClose: 129},
+			},
+		},
+	}
+
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("diff (-want, +got)\n%s", diff)
+	}
+
+	// The cached chart is missing one day, so ask for it and some cached days.
+	if diff := cmp.Diff(strconv.Itoa(1+adjustmentCheckPoints), ft.requests[0].URL.Query().Get("chartLast")); diff != "" {
+		t.Errorf("chartLast diff (-want, +got)\n%s", diff)
+	}
+}
+
+func TestClientGetCharts_RefetchesWithoutTotalReturnPrices(t *testing.T) {
+	old := now
+	defer func() { now = old }()
+	now = func() time.Time { return time.Date(2020, time.September, 1, 12, 0, 0, 0, loc) }
+
+	cache := mapChartCache{
+		ChartCacheKey{"AAPL", DailyInterval}: {
+			Chart: &Chart{
+				Symbol:      "AAPL",
+				ChartPoints: []*ChartPoint{{Date: time.Date(2020, time.August, 31, 0, 0, 0, 0, loc), Close: 129}},
+			},
+			LastUpdateTime: time.Date(2020, time.September, 1, 9, 0, 0, 0, loc),
+			Range:          TwoYears,
+		},
+	}
+
+	ft := &fakeTransport{responses: []fakeResponse{{status: 200, body: `{"AAPL": {"chart": []}}`}}}
+	c := NewClient(cache, HTTPTransport(ft), RateLimit(0, 0))
+
+	if _, err := c.GetCharts(context.Background(), &GetChartsRequest{Token: "abc", Symbols: []string{"AAPL"}, Range: TwoYears}); err != nil {
+		t.Fatalf("GetCharts should not return an error: %v", err)
+	}
+
+	if diff := cmp.Diff("", ft.requests[0].URL.Query().Get("chartLast")); diff != "" {
+		t.Errorf("chartLast diff (-want, +got)\n%s", diff)
+	}
+
+	if !cache[ChartCacheKey{"AAPL", DailyInterval}].HasTotalReturnPrices {
+		t.Error("cached value should have total return prices")
+	}
+}
+
+// mapChartCache is a chart cache backed by a map.
+type mapChartCache map[ChartCacheKey]*ChartCacheValue
+
+func (m mapChartCache) Get(ctx context.Context, key ChartCacheKey) (*ChartCacheValue, error) {
+	return m[key], nil
+}
+
+func (m mapChartCache) Put(ctx context.Context, key ChartCacheKey, val *ChartCacheValue) error {
+	m[key] = val
+	return nil
+}
+
+func TestDailyChartLast(t *testing.T) {
+	for _, tt := range []struct {
+		desc      string
+		inputDate time.Time
+		inputNow  time.Time
+		want      int
+	}{
+		{
+			desc:      "up to date",
+			inputDate: time.Date(2019, 10, 16, 0, 0, 0, 0, loc),
+			inputNow:  time.Date(2019, 10, 17, 10, 0, 0, 0, loc),
+			want:      -1,
+		},
+		{
+			desc:      "missing two days",
+			inputDate: time.Date(2019, 10, 14, 0, 0, 0, 0, loc),
+			inputNow:  time.Date(2019, 10, 17, 10, 0, 0, 0, loc),
+			want:      2,
+		},
+		{
+			desc:      "skip weekend",
+			inputDate: time.Date(2019, 10, 11, 0, 0, 0, 0, loc),
+			inputNow:  time.Date(2019, 10, 15, 10, 0, 0, 0, loc),
+			want:      1,
+		},
+		{
+			desc:      "skip holiday",
+			inputDate: time.Date(2019, 12, 24, 0, 0, 0, 0, loc),
+			inputNow:  time.Date(2019, 12, 27, 10, 0, 0, 0, loc),
+			want:      1,
+		},
+		{
+			desc:      "up to date after holiday",
+			inputDate: time.Date(2019, 12, 24, 0, 0, 0, 0, loc),
+			inputNow:  time.Date(2019, 12, 26, 10, 0, 0, 0, loc),
+			want:      -1,
+		},
+	} {
+		t.Run(tt.desc, func(t *testing.T) {
+			got := dailyChartLast([]*ChartPoint{{Date: tt.inputDate}}, tt.inputNow)
+
+			if diff := cmp.Diff(tt.want, got); diff != "" {
+				t.Errorf("diff (-want, +got)\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestMinuteChartLast(t *testing.T) {
+	for _, tt := range []struct {
+		desc      string
+		inputDate time.Time
+		inputNow  time.Time
+		want      int
+	}{
+		{
+			desc:      "previous day",
+			inputDate: time.Date(2019, 10, 16, 15, 59, 0, 0, loc),
+			inputNow:  time.Date(2019, 10, 17, 10, 0, 0, 0, loc),
+			want:      0,
+		},
+		{
+			desc:      "same day during market hours",
+			inputDate: time.Date(2019, 10, 17, 9, 55, 0, 0, loc),
+			inputNow:  time.Date(2019, 10, 17, 10, 0, 30, 0, loc),
+			want:      6,
+		},
+		{
+			desc:      "same day after market close",
+			inputDate: time.Date(2019, 10, 17, 15, 50, 0, 0, loc),
+			inputNow:  time.Date(2019, 10, 17, 18, 0, 0, 0, loc),
+			want:      11,
+		},
+		{
+			desc:      "complete day",
+			inputDate: time.Date(2019, 10, 17, 15, 59, 0, 0, loc),
+			inputNow:  time.Date(2019, 10, 17, 18, 0, 0, 0, loc),
+			want:      -1,
+		},
+		{
+			desc:      "complete day before next open",
+			inputDate: time.Date(2019, 10, 17, 15, 59, 0, 0, loc),
+			inputNow:  time.Date(2019, 10, 18, 9, 0, 0, 0, loc),
+			want:      -1,
+		},
+		{
+			desc:      "complete friday on weekend",
+			inputDate: time.Date(2019, 10, 18, 15, 59, 0, 0, loc),
+			inputNow:  time.Date(2019, 10, 20, 12, 0, 0, 0, loc),
+			want:      -1,
+		},
+		{
+			desc:      "same day after early close",
+			inputDate: time.Date(2019, 11, 29, 12, 50, 0, 0, loc),
+			inputNow:  time.Date(2019, 11, 29, 14, 0, 0, 0, loc),
+			want:      11,
+		},
+		{
+			desc:      "complete early close day on holiday",
+			inputDate: time.Date(2019, 7, 3, 12, 59, 0, 0, loc),
+			inputNow:  time.Date(2019, 7, 4, 12, 0, 0, 0, loc),
+			want:      -1,
+		},
+	} {
+		t.Run(tt.desc, func(t *testing.T) {
+			got := minuteChartLast([]*ChartPoint{{Date: tt.inputDate}}, tt.inputNow)
+
+			if diff := cmp.Diff(tt.want, got); diff != "" {
+				t.Errorf("diff (-want, +got)\n%s", diff)
+			}
+		})
+	}
+}
@@ -3,17 +3,32 @@ package iex
 import (
 	"context"
 	"encoding/gob"
+	"expvar"
+	"fmt"
+	"io/ioutil"
 	"os"
 	"os/user"
 	"path/filepath"
+	"sort"
+	"strings"
 	"sync"
 	"time"
 
 	"github.com/btmura/ponzi2/internal/errs"
+	"github.com/btmura/ponzi2/internal/logger"
 )
 
 // ChartCacheKey is the key to look up chart cache entries.
+// Keys don't have API tokens, so that rotating tokens keeps the cached
+// data and tokens are never saved to disk. The cache only has IEX data,
+// so keys don't need a provider.
 type ChartCacheKey struct {
+	Symbol   string
+	Interval ChartInterval
+}
+
+// legacyChartCacheKey is the key that older versions saved with the API token.
+type legacyChartCacheKey struct {
 	Token    string
 	Symbol   string
 	Interval ChartInterval
@@ -23,6 +38,7 @@ type ChartCacheKey struct {
 type ChartInterval int
 
 // ChartInterval values.
+//go:generate stringer -type=ChartInterval
 const (
 	ChartIntervalUnspecified ChartInterval = iota
 	MinuteInterval
@@ -33,6 +49,14 @@ const (
 type ChartCacheValue struct {
 	Chart          *Chart
 	LastUpdateTime time.Time
+
+	// Range is the widest range of data that the chart has.
+	// Unspecified for entries cached before ranges were recorded.
+	Range Range
+
+	// HasTotalReturnPrices is whether daily points have total return prices.
+	// False for entries cached before they were requested, which are fetched again.
+	HasTotalReturnPrices bool
 }
 
 // DeepCopy returns a deep copy of the value.
@@ -55,40 +79,276 @@ func (n *NoOpChartCache) Put(ctx context.Context, key ChartCacheKey, val *ChartC
 	return nil
 }
 
-// GOBChartCache caches data from the chart endpoint.
-// Fields are exported for gob encoding and decoding.
+const (
+	// chartCacheDirName is the name of the directory with a file per chart cache entry.
+	chartCacheDirName = "iex-chart-cache"
+
+	// legacyChartCacheFileName is the name of the single file that older versions saved the chart cache to.
+	legacyChartCacheFileName = "iex-chart-cache.gob"
+
+	// migratedLegacyChartCacheFileName is the name older versions renamed the legacy file to after migration.
+	// It has API tokens, so it is removed.
+	migratedLegacyChartCacheFileName = "iex-chart-cache.gob.bak"
+
+	// chartCacheFileExt is the extension of entry files.
+	chartCacheFileExt = ".gob"
+
+	// chartCacheTempFilePrefix is the prefix of temporary files that entries are written to before renaming.
+	chartCacheTempFilePrefix = ".tmp-"
+
+	// chartCacheTempFileMaxAge is how old temporary files must be before they are removed as crash leftovers.
+	// Newer ones may belong to another process like iextool writing to the same cache.
+	chartCacheTempFileMaxAge = time.Hour
+
+	// chartCacheFlushDelay is how long to wait after a put before flushing,
+	// so that puts in quick succession are written together.
+	chartCacheFlushDelay = 2 * time.Second
+)
+
+// ChartCachePolicy limits what the chart cache keeps. Zero values mean no limit.
+type ChartCachePolicy struct {
+	// MaxEntries is the most entries to keep. Least recently used entries are evicted first.
+	MaxEntries int
+
+	// MaxBytes is the most bytes of entry files to keep. Least recently used entries are evicted first.
+	MaxBytes int64
+
+	// MaxAge is how long to keep entries after their LastUpdateTime.
+	// Entries for symbols that are no longer shown stop being updated,
+	// so they are evicted after this long.
+	MaxAge time.Duration
+}
+
+// DefaultChartCachePolicy is a policy that keeps a few years of daily charts for hundreds of symbols.
+var DefaultChartCachePolicy = ChartCachePolicy{
+	MaxEntries: 1000,
+	MaxBytes:   256 << 20,
+	MaxAge:     30 * 24 * time.Hour,
+}
+
+// ChartCacheStats describes how much the chart cache has.
+type ChartCacheStats struct {
+	// Entries is the number of entries.
+	Entries int
+
+	// Bytes is the size of the entry files. Entries not saved yet don't count.
+	Bytes int64
+}
+
+// GOBChartCache caches data from the chart endpoint in memory and saves each entry
+// to its own gob file in the background. Files are written to temporary files and
+// renamed, so a crash never leaves a partially written entry.
 type GOBChartCache struct {
-	Data map[ChartCacheKey]*ChartCacheValue
-	mu   sync.Mutex
+	// dirPath is the directory with the entry files.
+	dirPath string
+
+	// policy is the policy applied after every flush.
+	policy ChartCachePolicy
+
+	// mu guards data, dirty, sizes, and accessTimes.
+	mu sync.Mutex
+
+	// data has all the entries.
+	data map[ChartCacheKey]*ChartCacheValue
+
+	// dirty has the keys of the entries that need to be saved.
+	dirty map[ChartCacheKey]bool
+
+	// sizes has the sizes of the entry files.
+	sizes map[ChartCacheKey]int64
+
+	// accessTimes has when entries were last read or written to evict the least recently used.
+	accessTimes map[ChartCacheKey]time.Time
+
+	// ioMu serializes writing and removing files, so a flush can't
+	// write back an entry that Delete just removed.
+	ioMu sync.Mutex
+
+	// flushSignal wakes the flush loop after a put.
+	flushSignal chan struct{}
+
+	// done stops the flush loop.
+	done chan struct{}
+
+	// flushLoopDone is closed when the flush loop returns.
+	flushLoopDone chan struct{}
+}
+
+// chartCacheEntry is what each entry file has.
+type chartCacheEntry struct {
+	Key   ChartCacheKey
+	Value *ChartCacheValue
 }
 
-// OpenGOBChartCache opens the GOB-based chart cache from disk.
-func OpenGOBChartCache() (*GOBChartCache, error) {
+// OpenGOBChartCache opens the GOB-based chart cache from disk and evicts entries according to the policy.
+// Call Close to save entries that haven't been saved yet.
+func OpenGOBChartCache(policy ChartCachePolicy) (*GOBChartCache, error) {
+	dir, err := userCacheDir()
+	if err != nil {
+		return nil, err
+	}
+	return openGOBChartCache(dir, policy)
+}
+
+// openGOBChartCache opens the chart cache in the parent directory.
+// Entries that can't be read are logged and skipped.
+func openGOBChartCache(parentDirPath string, policy ChartCachePolicy) (*GOBChartCache, error) {
 	t := now()
 	defer func() {
 		cacheClientVar.Set("chart-cache-load-time", time.Since(t))
 	}()
 
-	path, err := chartCachePath()
+	dirPath := filepath.Join(parentDirPath, chartCacheDirName)
+	if err := os.MkdirAll(dirPath, 0755); err != nil {
+		return nil, err
+	}
+
+	g := &GOBChartCache{
+		dirPath:       dirPath,
+		policy:        policy,
+		data:          map[ChartCacheKey]*ChartCacheValue{},
+		dirty:         map[ChartCacheKey]bool{},
+		sizes:         map[ChartCacheKey]int64{},
+		accessTimes:   map[ChartCacheKey]time.Time{},
+		flushSignal:   make(chan struct{}, 1),
+		done:          make(chan struct{}),
+		flushLoopDone: make(chan struct{}),
+	}
+
+	fis, err := ioutil.ReadDir(dirPath)
 	if err != nil {
 		return nil, err
 	}
 
-	file, err := os.Open(path)
+	// obsolete has the paths of files to remove once their entries are saved under new names.
+	var obsolete []string
+
+	for _, fi := range fis {
+		p := filepath.Join(dirPath, fi.Name())
+
+		// Remove temporary files left behind by crashes. Skip newer ones that other processes may be writing.
+		if strings.HasPrefix(fi.Name(), chartCacheTempFilePrefix) {
+			if t.Sub(fi.ModTime()) > chartCacheTempFileMaxAge {
+				logger.Infof("removing leftover chart cache file: %s", p)
+				if err := os.Remove(p); err != nil && !os.IsNotExist(err) {
+					logger.Errorf("removing %s failed: %v", p, err)
+				}
+			}
+			continue
+		}
+
+		if filepath.Ext(fi.Name()) != chartCacheFileExt {
+			continue
+		}
+
+		e, err := readChartCacheEntry(p)
+		if err != nil {
+			logger.Errorf("skipping corrupt chart cache entry: %v", err)
+			cacheClientVar.Add("chart-cache-corrupt-entries", 1)
+			continue
+		}
+
+		// Older versions had API tokens in keys and file names. Decoding drops the
+		// tokens, so save the entries under their new names without them.
+		current := fi.Name() == filepath.Base(g.entryPath(e.Key))
+		if !current {
+			obsolete = append(obsolete, p)
+		}
+
+		if !g.addLoaded(e.Key, e.Value) {
+			continue
+		}
+
+		if current {
+			g.sizes[e.Key] = fi.Size()
+			delete(g.dirty, e.Key)
+		} else {
+			g.dirty[e.Key] = true
+		}
+	}
+
+	obsolete = append(obsolete, g.migrateLegacyFile(parentDirPath)...)
+
+	if _, err := g.Evict(context.Background(), policy); err != nil {
+		logger.Errorf("evicting chart cache entries failed: %v", err)
+	}
+
+	go g.flushLoop()
+
+	// Save any migrated entries and then remove the old files with API tokens.
+	if len(g.dirty) != 0 || len(obsolete) != 0 {
+		if err := g.Flush(context.Background()); err != nil {
+			logger.Errorf("saving migrated chart cache entries failed: %v", err)
+			return g, nil
+		}
+
+		for _, p := range obsolete {
+			if err := os.Remove(p); err != nil && !os.IsNotExist(err) {
+				logger.Errorf("removing %s failed: %v", p, err)
+			}
+		}
+	}
+
+	return g, nil
+}
+
+// addLoaded adds an entry read from disk unless the cache already has a newer one for the key.
+// Keys with different API tokens used to be separate entries but now are the same.
+func (g *GOBChartCache) addLoaded(key ChartCacheKey, val *ChartCacheValue) bool {
+	if v, ok := g.data[key]; ok && !val.LastUpdateTime.After(v.LastUpdateTime) {
+		return false
+	}
+	g.data[key] = val
+	g.accessTimes[key] = val.LastUpdateTime
+	return true
+}
+
+// migrateLegacyFile adds the entries from the single file saved by older versions.
+// It returns the paths of the legacy files to remove once the entries are saved,
+// since they have API tokens.
+func (g *GOBChartCache) migrateLegacyFile(parentDirPath string) []string {
+	var obsolete []string
+
+	// Remove the renamed legacy file that older versions kept after migration.
+	bak := filepath.Join(parentDirPath, migratedLegacyChartCacheFileName)
+	if _, err := os.Stat(bak); err == nil {
+		obsolete = append(obsolete, bak)
+	}
+
+	p := filepath.Join(parentDirPath, legacyChartCacheFileName)
+
+	file, err := os.Open(p)
 	if os.IsNotExist(err) {
-		return &GOBChartCache{}, nil
+		return obsolete
 	}
 	if err != nil {
-		return nil, err
+		logger.Errorf("opening legacy chart cache failed: %v", err)
+		return obsolete
 	}
-	defer file.Close()
 
-	c := &GOBChartCache{}
-	dec := gob.NewDecoder(file)
-	if err := dec.Decode(c); err != nil {
-		return nil, err
+	// Older versions encoded a struct with the entries in an exported Data field.
+	var legacy struct {
+		Data map[legacyChartCacheKey]*ChartCacheValue
 	}
-	return c, nil
+	err = gob.NewDecoder(file).Decode(&legacy)
+	file.Close()
+	if err != nil {
+		logger.Errorf("skipping corrupt legacy chart cache %s: %v", p, err)
+	}
+
+	logger.Infof("migrating %d entries from %s", len(legacy.Data), p)
+
+	for k, v := range legacy.Data {
+		if v == nil {
+			continue
+		}
+		key := ChartCacheKey{Symbol: k.Symbol, Interval: k.Interval}
+		if g.addLoaded(key, v) {
+			g.dirty[key] = true
+		}
+	}
+
+	return append(obsolete, p)
 }
 
 // Get implements the iexChartCacheInterface.
@@ -98,8 +358,9 @@ func (g *GOBChartCache) Get(ctx context.Context, key ChartCacheKey) (*ChartCache
 
 	cacheClientVar.Add("chart-cache-gets", 1)
 
-	v := g.Data[key]
+	v := g.data[key]
 	if v != nil {
+		g.accessTimes[key] = now()
 		cacheClientVar.Add("chart-cache-hits", 1)
 		return v.DeepCopy(), nil
 	}
@@ -108,57 +369,362 @@ func (g *GOBChartCache) Get(ctx context.Context, key ChartCacheKey) (*ChartCache
 }
 
 // Put implements the iexChartCacheInterface.
+// The entry is saved in the background shortly after.
 func (g *GOBChartCache) Put(ctx context.Context, key ChartCacheKey, val *ChartCacheValue) error {
-	g.mu.Lock()
-	defer g.mu.Unlock()
-
 	cacheClientVar.Add("chart-cache-puts", 1)
 
-	if !validTokenRegexp.MatchString(key.Token) {
-		return errs.Errorf("bad token: got %s, want: %v", key.Token, validTokenRegexp)
+	if err := validateChartCacheKey(key); err != nil {
+		return err
+	}
+
+	if val == nil {
+		return errs.Errorf("missing value")
 	}
 
-	if !validSymbolRegexp.MatchString(key.Symbol) {
-		return errs.Errorf("bad symbol: got %s, want: %v", key.Symbol, validSymbolRegexp)
+	g.mu.Lock()
+	v := val.DeepCopy()
+	v.LastUpdateTime = now()
+	g.data[key] = v
+	g.dirty[key] = true
+	g.accessTimes[key] = v.LastUpdateTime
+	g.updateStatsVars()
+	g.mu.Unlock()
+
+	select {
+	case g.flushSignal <- struct{}{}:
+	default:
 	}
 
-	if g.Data == nil {
-		g.Data = map[ChartCacheKey]*ChartCacheValue{}
+	return nil
+}
+
+// Keys returns the keys of all the entries sorted by symbol and interval.
+func (g *GOBChartCache) Keys(ctx context.Context) ([]ChartCacheKey, error) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	var keys []ChartCacheKey
+	for k := range g.data {
+		keys = append(keys, k)
+	}
+
+	sort.Slice(keys, func(i, j int) bool {
+		a, b := keys[i], keys[j]
+		if a.Symbol != b.Symbol {
+			return a.Symbol < b.Symbol
+		}
+		return a.Interval < b.Interval
+	})
+
+	return keys, nil
+}
+
+// Delete removes the entry with the key and its file if they exist.
+func (g *GOBChartCache) Delete(ctx context.Context, key ChartCacheKey) error {
+	cacheClientVar.Add("chart-cache-deletes", 1)
+
+	if err := validateChartCacheKey(key); err != nil {
+		return err
 	}
-	g.Data[key] = val.DeepCopy()
-	g.Data[key].LastUpdateTime = now()
 
-	saveChartCache(g)
+	g.ioMu.Lock()
+	defer g.ioMu.Unlock()
 
+	g.mu.Lock()
+	g.remove(key)
+	g.updateStatsVars()
+	g.mu.Unlock()
+
+	if err := os.Remove(g.entryPath(key)); err != nil && !os.IsNotExist(err) {
+		return err
+	}
 	return nil
 }
 
-func saveChartCache(g *GOBChartCache) error {
+// Flush saves the entries that haven't been saved yet.
+func (g *GOBChartCache) Flush(ctx context.Context) error {
 	t := now()
 	defer func() {
 		cacheClientVar.Set("chart-cache-save-time", time.Since(t))
 	}()
 
-	path, err := chartCachePath()
-	if err != nil {
+	g.ioMu.Lock()
+	defer g.ioMu.Unlock()
+
+	// Copy the dirty entries, so that puts aren't blocked while writing.
+	g.mu.Lock()
+	entries := make([]*chartCacheEntry, 0, len(g.dirty))
+	for k := range g.dirty {
+		entries = append(entries, &chartCacheEntry{Key: k, Value: g.data[k].DeepCopy()})
+	}
+	g.dirty = map[ChartCacheKey]bool{}
+	g.mu.Unlock()
+
+	var failed []*chartCacheEntry
+	sizes := map[ChartCacheKey]int64{}
+	var firstErr error
+	for _, e := range entries {
+		size, err := writeChartCacheEntry(g.entryPath(e.Key), e)
+		if err != nil {
+			cacheClientVar.Add("chart-cache-save-errors", 1)
+			failed = append(failed, e)
+			if firstErr == nil {
+				firstErr = err
+			}
+			continue
+		}
+		sizes[e.Key] = size
+	}
+
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	// Deletes wait for flushes to finish, so the entries can't have been removed meanwhile.
+	for k, size := range sizes {
+		g.sizes[k] = size
+	}
+
+	// Retry failed entries on the next flush.
+	for _, e := range failed {
+		g.dirty[e.Key] = true
+	}
+
+	g.updateStatsVars()
+
+	return firstErr
+}
+
+// Evict removes expired entries and then the least recently used entries until the cache is within the policy.
+// It returns the number of evicted entries.
+func (g *GOBChartCache) Evict(ctx context.Context, policy ChartCachePolicy) (int, error) {
+	g.ioMu.Lock()
+	defer g.ioMu.Unlock()
+
+	g.mu.Lock()
+	keys := g.evictionKeys(policy)
+	for _, k := range keys {
+		g.remove(k)
+	}
+	g.updateStatsVars()
+	g.mu.Unlock()
+
+	cacheClientVar.Add("chart-cache-evictions", int64(len(keys)))
+
+	var firstErr error
+	for _, k := range keys {
+		if err := os.Remove(g.entryPath(k)); err != nil && !os.IsNotExist(err) && firstErr == nil {
+			firstErr = err
+		}
+	}
+
+	return len(keys), firstErr
+}
+
+// evictionKeys returns the keys of the entries to evict to be within the policy.
+// Callers must hold mu.
+func (g *GOBChartCache) evictionKeys(policy ChartCachePolicy) []ChartCacheKey {
+	var keys []ChartCacheKey
+	for k := range g.data {
+		keys = append(keys, k)
+	}
+
+	// Sort from least to most recently used.
+	sort.Slice(keys, func(i, j int) bool {
+		a, b := keys[i], keys[j]
+		if !g.accessTimes[a].Equal(g.accessTimes[b]) {
+			return g.accessTimes[a].Before(g.accessTimes[b])
+		}
+		if a.Symbol != b.Symbol {
+			return a.Symbol < b.Symbol
+		}
+		return a.Interval < b.Interval
+	})
+
+	var evicted, kept []ChartCacheKey
+	var bytes int64
+	t := now()
+	for _, k := range keys {
+		if policy.MaxAge > 0 && t.Sub(g.data[k].LastUpdateTime) > policy.MaxAge {
+			evicted = append(evicted, k)
+			continue
+		}
+		kept = append(kept, k)
+		bytes += g.sizes[k]
+	}
+
+	tooMany := func() bool {
+		return policy.MaxEntries > 0 && len(kept) > policy.MaxEntries
+	}
+
+	tooBig := func() bool {
+		return policy.MaxBytes > 0 && bytes > policy.MaxBytes
+	}
+
+	for len(kept) != 0 && (tooMany() || tooBig()) {
+		k := kept[0]
+		kept = kept[1:]
+		bytes -= g.sizes[k]
+		evicted = append(evicted, k)
+	}
+
+	return evicted
+}
+
+// Stats returns how much the cache has.
+func (g *GOBChartCache) Stats(ctx context.Context) (*ChartCacheStats, error) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	return g.stats(), nil
+}
+
+// stats returns how much the cache has. Callers must hold mu.
+func (g *GOBChartCache) stats() *ChartCacheStats {
+	s := &ChartCacheStats{Entries: len(g.data)}
+	for _, size := range g.sizes {
+		s.Bytes += size
+	}
+	return s
+}
+
+// updateStatsVars publishes the stats. Callers must hold mu.
+func (g *GOBChartCache) updateStatsVars() {
+	s := g.stats()
+
+	entries := new(expvar.Int)
+	entries.Set(int64(s.Entries))
+	cacheClientVar.Set("chart-cache-entries", entries)
+
+	bytes := new(expvar.Int)
+	bytes.Set(s.Bytes)
+	cacheClientVar.Set("chart-cache-bytes", bytes)
+}
+
+// remove removes the entry from memory. Callers must hold mu.
+func (g *GOBChartCache) remove(key ChartCacheKey) {
+	delete(g.data, key)
+	delete(g.dirty, key)
+	delete(g.sizes, key)
+	delete(g.accessTimes, key)
+}
+
+// Close stops the background flushing and saves the entries that haven't been saved yet.
+func (g *GOBChartCache) Close() error {
+	close(g.done)
+	<-g.flushLoopDone
+	return g.flushAndEvict()
+}
+
+// flushAndEvict saves the entries that haven't been saved yet and then applies the cache's policy.
+func (g *GOBChartCache) flushAndEvict() error {
+	if err := g.Flush(context.Background()); err != nil {
 		return err
 	}
+	_, err := g.Evict(context.Background(), g.policy)
+	return err
+}
 
-	file, err := os.OpenFile(path, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0660)
-	if err != nil {
+// flushLoop flushes the cache shortly after puts until Close is called.
+func (g *GOBChartCache) flushLoop() {
+	defer close(g.flushLoopDone)
+	for {
+		select {
+		case <-g.flushSignal:
+		case <-g.done:
+			return
+		}
+
+		select {
+		case <-time.After(chartCacheFlushDelay):
+		case <-g.done:
+			return
+		}
+
+		if err := g.flushAndEvict(); err != nil {
+			logger.Errorf("saving chart cache failed: %v", err)
+		}
+	}
+}
+
+// entryPath returns the path of the key's entry file.
+func (g *GOBChartCache) entryPath(key ChartCacheKey) string {
+	name := fmt.Sprintf("%s-%v%s", key.Symbol, key.Interval, chartCacheFileExt)
+	return filepath.Join(g.dirPath, name)
+}
+
+// validateChartCacheKey returns an error if the key can't be used to name an entry file.
+func validateChartCacheKey(key ChartCacheKey) error {
+	if _, err := parseSymbol(key.Symbol); err != nil {
 		return err
 	}
+
+	if key.Interval != MinuteInterval && key.Interval != DailyInterval {
+		return errs.Errorf("bad interval: %v", key.Interval)
+	}
+
+	return nil
+}
+
+func readChartCacheEntry(path string) (*chartCacheEntry, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
 	defer file.Close()
 
-	return gob.NewEncoder(file).Encode(g)
+	e := &chartCacheEntry{}
+	if err := gob.NewDecoder(file).Decode(e); err != nil {
+		return nil, errs.Errorf("decoding %s failed: %v", path, err)
+	}
+
+	if e.Value == nil {
+		return nil, errs.Errorf("%s: missing value", path)
+	}
+
+	return e, nil
 }
 
-func chartCachePath() (string, error) {
-	dir, err := userCacheDir()
+// writeChartCacheEntry writes the entry to a temporary file and renames it to the path.
+// It returns the size of the file.
+func writeChartCacheEntry(path string, e *chartCacheEntry) (int64, error) {
+	tmp, err := ioutil.TempFile(filepath.Dir(path), chartCacheTempFilePrefix+filepath.Base(path)+"-")
 	if err != nil {
-		return "", err
+		return 0, err
 	}
-	return filepath.Join(dir, "iex-chart-cache.gob"), nil
+
+	// Remove the temporary file if anything fails before the rename.
+	renamed := false
+	defer func() {
+		if !renamed {
+			os.Remove(tmp.Name())
+		}
+	}()
+
+	if err := gob.NewEncoder(tmp).Encode(e); err != nil {
+		tmp.Close()
+		return 0, err
+	}
+
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return 0, err
+	}
+
+	fi, err := tmp.Stat()
+	if err != nil {
+		tmp.Close()
+		return 0, err
+	}
+
+	if err := tmp.Close(); err != nil {
+		return 0, err
+	}
+
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return 0, err
+	}
+	renamed = true
+
+	return fi.Size(), nil
 }
 
 func userCacheDir() (string, error) {
@@ -0,0 +1,440 @@
+package iex
+
+import (
+	"bytes"
+	"context"
+	"encoding/gob"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestGOBChartCache(t *testing.T) {
+	old := now
+	defer func() { now = old }()
+	now = func() time.Time { return time.Date(2018, time.October, 11, 0, 0, 0, 0, time.UTC) }
+
+	ctx := context.Background()
+	key := ChartCacheKey{Symbol: "AAPL", Interval: DailyInterval}
+	val := &ChartCacheValue{
+		Chart: &Chart{
+			Symbol: "AAPL",
+			ChartPoints: []*ChartPoint{
+				{Date: time.Date(2018, time.October, 10, 0, 0, 0, 0, time.UTC), Close: 10},
+			},
+		},
+		Range: TwoYears,
+	}
+	want := val.DeepCopy()
+	want.LastUpdateTime = now()
+
+	dir := tempDir(t)
+	defer os.RemoveAll(dir)
+
+	g, err := openGOBChartCache(dir, ChartCachePolicy{})
+	if err != nil {
+		t.Fatalf("openGOBChartCache: %v", err)
+	}
+
+	if err := g.Put(ctx, key, val); err != nil {
+		t.Fatalf("Put: %v", err)
+	}
+
+	if err := g.Close(); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+
+	g, err = openGOBChartCache(dir, ChartCachePolicy{})
+	if err != nil {
+		t.Fatalf("openGOBChartCache: %v", err)
+	}
+	defer g.Close()
+
+	got, err := g.Get(ctx, key)
+	if err != nil {
+		t.Fatalf("Get: %v", err)
+	}
+
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("diff (-want, +got)\n%s", diff)
+	}
+
+	if err := g.Delete(ctx, key); err != nil {
+		t.Fatalf("Delete: %v", err)
+	}
+
+	if diff := cmp.Diff([]string(nil), fileNames(t, filepath.Join(dir, chartCacheDirName))); diff != "" {
+		t.Errorf("diff (-want, +got)\n%s", diff)
+	}
+}
+
+func TestGOBChartCache_SkipCorruptEntries(t *testing.T) {
+	ctx := context.Background()
+	key := ChartCacheKey{Symbol: "AAPL", Interval: DailyInterval}
+
+	dir := tempDir(t)
+	defer os.RemoveAll(dir)
+
+	g, err := openGOBChartCache(dir, ChartCachePolicy{})
+	if err != nil {
+		t.Fatalf("openGOBChartCache: %v", err)
+	}
+
+	if err := g.Put(ctx, key, &ChartCacheValue{Chart: &Chart{Symbol: "AAPL"}}); err != nil {
+		t.Fatalf("Put: %v", err)
+	}
+
+	if err := g.Close(); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+
+	entryDir := filepath.Join(dir, chartCacheDirName)
+	writeFile(t, filepath.Join(entryDir, "MSFT-DailyInterval-deadbeef.gob"), "corrupt")
+	writeFile(t, filepath.Join(entryDir, "notes.txt"), "unrelated")
+
+	// Old temporary files are crash leftovers, but new ones may belong to other processes.
+	oldTemp := filepath.Join(entryDir, chartCacheTempFilePrefix+"AAPL-DailyInterval.gob-123")
+	writeFile(t, oldTemp, "partial")
+	old := time.Now().Add(-2 * chartCacheTempFileMaxAge)
+	if err := os.Chtimes(oldTemp, old, old); err != nil {
+		t.Fatalf("os.Chtimes: %v", err)
+	}
+	writeFile(t, filepath.Join(entryDir, chartCacheTempFilePrefix+"AAPL-DailyInterval.gob-456"), "partial")
+
+	g, err = openGOBChartCache(dir, ChartCachePolicy{})
+	if err != nil {
+		t.Fatalf("openGOBChartCache: %v", err)
+	}
+	defer g.Close()
+
+	got, err := g.Keys(ctx)
+	if err != nil {
+		t.Fatalf("Keys: %v", err)
+	}
+
+	if diff := cmp.Diff([]ChartCacheKey{key}, got); diff != "" {
+		t.Errorf("diff (-want, +got)\n%s", diff)
+	}
+
+	// Only the old temporary file should be removed.
+	want := []string{
+		chartCacheTempFilePrefix + "AAPL-DailyInterval.gob-456",
+		"AAPL-DailyInterval.gob",
+		"MSFT-DailyInterval-deadbeef.gob",
+		"notes.txt",
+	}
+	if diff := cmp.Diff(want, fileNames(t, entryDir)); diff != "" {
+		t.Errorf("diff (-want, +got)\n%s", diff)
+	}
+}
+
+func TestGOBChartCache_MigrateLegacyFile(t *testing.T) {
+	ctx := context.Background()
+	key := ChartCacheKey{Symbol: "AAPL", Interval: MinuteInterval}
+	oldVal := &ChartCacheValue{Chart: &Chart{Symbol: "AAPL"}, LastUpdateTime: time.Date(2018, time.October, 10, 0, 0, 0, 0, time.UTC)}
+	newVal := &ChartCacheValue{Chart: &Chart{Symbol: "AAPL"}, LastUpdateTime: time.Date(2018, time.October, 11, 0, 0, 0, 0, time.UTC)}
+
+	dir := tempDir(t)
+	defer os.RemoveAll(dir)
+
+	// Entries for the same symbol with different tokens should become one entry with the newest data.
+	legacy := struct {
+		Data map[legacyChartCacheKey]*ChartCacheValue
+	}{
+		Data: map[legacyChartCacheKey]*ChartCacheValue{
+			{Token: "old_token", Symbol: "AAPL", Interval: MinuteInterval}: oldVal,
+			{Token: "new_token", Symbol: "AAPL", Interval: MinuteInterval}: newVal,
+		},
+	}
+	writeGOBFile(t, filepath.Join(dir, legacyChartCacheFileName), legacy)
+	writeFile(t, filepath.Join(dir, migratedLegacyChartCacheFileName), "new_token")
+
+	g, err := openGOBChartCache(dir, ChartCachePolicy{})
+	if err != nil {
+		t.Fatalf("openGOBChartCache: %v", err)
+	}
+
+	if err := g.Close(); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+
+	if diff := cmp.Diff([]string{chartCacheDirName}, fileNames(t, dir)); diff != "" {
+		t.Errorf("diff (-want, +got)\n%s", diff)
+	}
+
+	checkNoTokens(t, filepath.Join(dir, chartCacheDirName), "old_token", "new_token")
+
+	// Reopen to check that the migrated entry was saved to its own file.
+	g, err = openGOBChartCache(dir, ChartCachePolicy{})
+	if err != nil {
+		t.Fatalf("openGOBChartCache: %v", err)
+	}
+	defer g.Close()
+
+	got, err := g.Get(ctx, key)
+	if err != nil {
+		t.Fatalf("Get: %v", err)
+	}
+
+	if diff := cmp.Diff(newVal, got); diff != "" {
+		t.Errorf("diff (-want, +got)\n%s", diff)
+	}
+}
+
+func TestGOBChartCache_MigrateTokenEntries(t *testing.T) {
+	ctx := context.Background()
+	key := ChartCacheKey{Symbol: "AAPL", Interval: DailyInterval}
+	oldVal := &ChartCacheValue{Chart: &Chart{Symbol: "AAPL"}, LastUpdateTime: time.Date(2018, time.October, 10, 0, 0, 0, 0, time.UTC)}
+	newVal := &ChartCacheValue{Chart: &Chart{Symbol: "AAPL"}, LastUpdateTime: time.Date(2018, time.October, 11, 0, 0, 0, 0, time.UTC)}
+
+	dir := tempDir(t)
+	defer os.RemoveAll(dir)
+
+	type legacyEntry struct {
+		Key   legacyChartCacheKey
+		Value *ChartCacheValue
+	}
+
+	// Older versions named entry files with a hash of the token.
+	entryDir := filepath.Join(dir, chartCacheDirName)
+	if err := os.MkdirAll(entryDir, 0755); err != nil {
+		t.Fatalf("os.MkdirAll: %v", err)
+	}
+	writeGOBFile(t, filepath.Join(entryDir, "AAPL-DailyInterval-11111111.gob"), &legacyEntry{
+		Key:   legacyChartCacheKey{Token: "old_token", Symbol: "AAPL", Interval: DailyInterval},
+		Value: oldVal,
+	})
+	writeGOBFile(t, filepath.Join(entryDir, "AAPL-DailyInterval-22222222.gob"), &legacyEntry{
+		Key:   legacyChartCacheKey{Token: "new_token", Symbol: "AAPL", Interval: DailyInterval},
+		Value: newVal,
+	})
+
+	g, err := openGOBChartCache(dir, ChartCachePolicy{})
+	if err != nil {
+		t.Fatalf("openGOBChartCache: %v", err)
+	}
+	defer g.Close()
+
+	got, err := g.Get(ctx, key)
+	if err != nil {
+		t.Fatalf("Get: %v", err)
+	}
+
+	if diff := cmp.Diff(newVal, got); diff != "" {
+		t.Errorf("diff (-want, +got)\n%s", diff)
+	}
+
+	if diff := cmp.Diff([]string{"AAPL-DailyInterval.gob"}, fileNames(t, entryDir)); diff != "" {
+		t.Errorf("diff (-want, +got)\n%s", diff)
+	}
+
+	checkNoTokens(t, entryDir, "old_token", "new_token")
+}
+
+func TestGOBChartCache_EvictionKeys(t *testing.T) {
+	old := now
+	defer func() { now = old }()
+	now = func() time.Time { return time.Date(2018, time.October, 11, 0, 0, 0, 0, time.UTC) }
+
+	day := func(d int) time.Time { return time.Date(2018, time.October, d, 0, 0, 0, 0, time.UTC) }
+
+	aapl := ChartCacheKey{Symbol: "AAPL", Interval: DailyInterval}
+	msft := ChartCacheKey{Symbol: "MSFT", Interval: DailyInterval}
+	goog := ChartCacheKey{Symbol: "GOOG", Interval: DailyInterval}
+
+	// AAPL was updated most recently, but MSFT was read most recently.
+	g := &GOBChartCache{
+		data: map[ChartCacheKey]*ChartCacheValue{
+			aapl: {LastUpdateTime: day(10)},
+			msft: {LastUpdateTime: day(9)},
+			goog: {LastUpdateTime: day(1)},
+		},
+		sizes: map[ChartCacheKey]int64{
+			aapl: 100,
+			msft: 200,
+			goog: 300,
+		},
+		accessTimes: map[ChartCacheKey]time.Time{
+			aapl: day(10),
+			msft: day(11),
+			goog: day(1),
+		},
+	}
+
+	for _, tt := range []struct {
+		desc   string
+		policy ChartCachePolicy
+		want   []ChartCacheKey
+	}{
+		{
+			desc:   "no limits",
+			policy: ChartCachePolicy{},
+		},
+		{
+			desc:   "max age",
+			policy: ChartCachePolicy{MaxAge: 5 * 24 * time.Hour},
+			want:   []ChartCacheKey{goog},
+		},
+		{
+			desc:   "max entries",
+			policy: ChartCachePolicy{MaxEntries: 1},
+			want:   []ChartCacheKey{goog, aapl},
+		},
+		{
+			desc:   "max bytes",
+			policy: ChartCachePolicy{MaxBytes: 300},
+			want:   []ChartCacheKey{goog},
+		},
+		{
+			desc:   "max bytes evicts least recently used",
+			policy: ChartCachePolicy{MaxBytes: 250},
+			want:   []ChartCacheKey{goog, aapl},
+		},
+		{
+			desc:   "max age and max entries",
+			policy: ChartCachePolicy{MaxAge: 5 * 24 * time.Hour, MaxEntries: 2},
+			want:   []ChartCacheKey{goog},
+		},
+	} {
+		t.Run(tt.desc, func(t *testing.T) {
+			got := g.evictionKeys(tt.policy)
+
+			if diff := cmp.Diff(tt.want, got); diff != "" {
+				t.Errorf("diff (-want, +got)\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestGOBChartCache_Evict(t *testing.T) {
+	// Advance the time on every call, so that access times are distinct.
+	old := now
+	defer func() { now = old }()
+	tick := time.Date(2018, time.October, 11, 0, 0, 0, 0, time.UTC)
+	now = func() time.Time {
+		tick = tick.Add(time.Second)
+		return tick
+	}
+
+	ctx := context.Background()
+	aapl := ChartCacheKey{Symbol: "AAPL", Interval: DailyInterval}
+	msft := ChartCacheKey{Symbol: "MSFT", Interval: DailyInterval}
+
+	dir := tempDir(t)
+	defer os.RemoveAll(dir)
+
+	g, err := openGOBChartCache(dir, ChartCachePolicy{})
+	if err != nil {
+		t.Fatalf("openGOBChartCache: %v", err)
+	}
+	defer g.Close()
+
+	for _, k := range []ChartCacheKey{aapl, msft} {
+		if err := g.Put(ctx, k, &ChartCacheValue{Chart: &Chart{Symbol: k.Symbol}}); err != nil {
+			t.Fatalf("Put: %v", err)
+		}
+	}
+
+	if err := g.Flush(ctx); err != nil {
+		t.Fatalf("Flush: %v", err)
+	}
+
+	// Read AAPL, so that MSFT is the least recently used.
+	if _, err := g.Get(ctx, aapl); err != nil {
+		t.Fatalf("Get: %v", err)
+	}
+
+	n, err := g.Evict(ctx, ChartCachePolicy{MaxEntries: 1})
+	if err != nil {
+		t.Fatalf("Evict: %v", err)
+	}
+
+	if diff := cmp.Diff(1, n); diff != "" {
+		t.Errorf("diff (-want, +got)\n%s", diff)
+	}
+
+	keys, err := g.Keys(ctx)
+	if err != nil {
+		t.Fatalf("Keys: %v", err)
+	}
+
+	if diff := cmp.Diff([]ChartCacheKey{aapl}, keys); diff != "" {
+		t.Errorf("diff (-want, +got)\n%s", diff)
+	}
+
+	st, err := g.Stats(ctx)
+	if err != nil {
+		t.Fatalf("Stats: %v", err)
+	}
+
+	if diff := cmp.Diff(1, st.Entries); diff != "" {
+		t.Errorf("diff (-want, +got)\n%s", diff)
+	}
+
+	if diff := cmp.Diff(1, len(fileNames(t, filepath.Join(dir, chartCacheDirName)))); diff != "" {
+		t.Errorf("diff (-want, +got)\n%s", diff)
+	}
+}
+
+func tempDir(t *testing.T) string {
+	t.Helper()
+	dir, err := ioutil.TempDir("", "chartcache")
+	if err != nil {
+		t.Fatalf("ioutil.TempDir: %v", err)
+	}
+	return dir
+}
+
+func writeFile(t *testing.T, path, data string) {
+	t.Helper()
+	if err := ioutil.WriteFile(path, []byte(data), 0644); err != nil {
+		t.Fatalf("ioutil.WriteFile: %v", err)
+	}
+}
+
+func fileNames(t *testing.T, dir string) []string {
+	t.Helper()
+	fis, err := ioutil.ReadDir(dir)
+	if err != nil {
+		t.Fatalf("ioutil.ReadDir: %v", err)
+	}
+	var names []string
+	for _, fi := range fis {
+		names = append(names, fi.Name())
+	}
+	return names
+}
+
+func writeGOBFile(t *testing.T, path string, v interface{}) {
+	t.Helper()
+	file, err := os.Create(path)
+	if err != nil {
+		t.Fatalf("os.Create: %v", err)
+	}
+	if err := gob.NewEncoder(file).Encode(v); err != nil {
+		t.Fatalf("Encode: %v", err)
+	}
+	if err := file.Close(); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+}
+
+// checkNoTokens checks that none of the files in the directory have the tokens.
+func checkNoTokens(t *testing.T, dir string, tokens ...string) {
+	t.Helper()
+	for _, name := range fileNames(t, dir) {
+		data, err := ioutil.ReadFile(filepath.Join(dir, name))
+		if err != nil {
+			t.Fatalf("ioutil.ReadFile: %v", err)
+		}
+		for _, token := range tokens {
+			if bytes.Contains(data, []byte(token)) {
+				t.Errorf("%s has token %q", name, token)
+			}
+		}
+	}
+}
@@ -0,0 +1,355 @@
+package iex
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"math/rand"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/btmura/ponzi2/internal/errs"
+	"github.com/btmura/ponzi2/internal/logger"
+	"github.com/btmura/ponzi2/internal/stock"
+)
+
+// Defaults of the options that control HTTP requests.
+const (
+	// defaultRequestTimeout is how long to wait for each attempt of a request.
+	defaultRequestTimeout = 30 * time.Second
+
+	// defaultMaxRetries is how many times to retry requests that failed temporarily.
+	defaultMaxRetries = 3
+
+	// defaultInitialBackoff is how long to wait before the first retry. It doubles after every retry.
+	defaultInitialBackoff = 500 * time.Millisecond
+
+	// defaultMaxBackoff is the longest to wait before retrying.
+	defaultMaxBackoff = 10 * time.Second
+
+	// defaultRequestsPerSecond is the request rate that IEX allows per IP address.
+	// IEX measures it in milliseconds, so it doesn't allow bursts.
+	defaultRequestsPerSecond = 100
+
+	// defaultBurst is how many requests can be made at once.
+	defaultBurst = 1
+)
+
+// maxErrorMessageLength is the most of an error response body to include in errors.
+const maxErrorMessageLength = 200
+
+// ErrThrottled is wrapped by errors returned when IEX rejects requests for
+// exceeding its rate limits or the account's message quota.
+var ErrThrottled = stock.ErrThrottled
+
+// ErrUnauthorized is wrapped by errors returned when IEX rejects the API token.
+var ErrUnauthorized = stock.ErrUnauthorized
+
+// StatusError is the error returned when IEX responds with an unsuccessful HTTP status.
+type StatusError struct {
+	// StatusCode is the HTTP status code like 429.
+	StatusCode int
+
+	// Message is the start of the response body, which IEX uses to explain the error.
+	Message string
+
+	// RetryAfter is how long IEX asked to wait before retrying. Zero if unspecified.
+	RetryAfter time.Duration
+}
+
+// Error implements the error interface.
+func (e *StatusError) Error() string {
+	msg := fmt.Sprintf("iex: %d %s", e.StatusCode, http.StatusText(e.StatusCode))
+	if e.Message != "" {
+		msg += ": " + e.Message
+	}
+	return msg
+}
+
+// Unwrap returns ErrThrottled or ErrUnauthorized, so that callers can check errors with errors.Is.
+func (e *StatusError) Unwrap() error {
+	switch e.StatusCode {
+	case http.StatusTooManyRequests, http.StatusPaymentRequired:
+		return ErrThrottled
+	case http.StatusUnauthorized, http.StatusForbidden:
+		return ErrUnauthorized
+	default:
+		return nil
+	}
+}
+
+// temporary returns true if the request may succeed when retried.
+// Exceeding the message quota is not temporary, since it only resets monthly.
+func (e *StatusError) temporary() bool {
+	return e.StatusCode == http.StatusTooManyRequests || e.StatusCode >= 500
+}
+
+// ClientOption is an option to pass to NewClient.
+type ClientOption func(c *Client)
+
+// BaseURL returns an option to make requests to another host like SandboxBaseURL
+// or a fake server instead of CloudBaseURL.
+func BaseURL(baseURL string) ClientOption {
+	return func(c *Client) {
+		c.baseURL = strings.TrimRight(baseURL, "/")
+	}
+}
+
+// HTTPTransport returns an option to make requests with the given transport instead of the default one.
+func HTTPTransport(t http.RoundTripper) ClientOption {
+	return func(c *Client) {
+		c.httpClient = &http.Client{Transport: t}
+	}
+}
+
+// RequestTimeout returns an option to set how long to wait for each attempt of a request.
+// Zero means no timeout.
+func RequestTimeout(d time.Duration) ClientOption {
+	return func(c *Client) {
+		c.requestTimeout = d
+	}
+}
+
+// MaxRetries returns an option to set how many times to retry requests that failed temporarily.
+func MaxRetries(n int) ClientOption {
+	return func(c *Client) {
+		c.maxRetries = n
+	}
+}
+
+// Backoff returns an option to set how long to wait before the first retry and the longest to wait before any retry.
+func Backoff(initial, max time.Duration) ClientOption {
+	return func(c *Client) {
+		c.initialBackoff = initial
+		c.maxBackoff = max
+	}
+}
+
+// RateLimit returns an option to limit the rate of requests. Zero requestsPerSecond means no limit.
+func RateLimit(requestsPerSecond float64, burst int) ClientOption {
+	return func(c *Client) {
+		if requestsPerSecond <= 0 {
+			c.limiter = nil
+			return
+		}
+		c.limiter = newRateLimiter(requestsPerSecond, burst)
+	}
+}
+
+// batchURL returns the URL of the batch endpoint that returns data for multiple symbols.
+func (c *Client) batchURL() (*url.URL, error) {
+	u, err := url.Parse(c.baseURL + "/stock/market/batch")
+	if err != nil {
+		return nil, errs.Errorf("iex: bad base url: %v", err)
+	}
+	return u, nil
+}
+
+// get makes a GET request and returns the response body. It retries connection errors,
+// timeouts, throttled requests, and server errors with exponential backoff and jitter.
+func (c *Client) get(ctx context.Context, u *url.URL) ([]byte, error) {
+	for attempt := 0; ; attempt++ {
+		body, err := c.getOnce(ctx, u)
+		if err == nil {
+			return body, nil
+		}
+
+		if !c.shouldRetry(ctx, err) || attempt >= c.maxRetries {
+			return nil, err
+		}
+
+		d := c.backoff(attempt)
+		if se, ok := err.(*StatusError); ok && se.RetryAfter > d {
+			d = se.RetryAfter
+		}
+
+		logger.Infof("retrying in %v: %v", d, err)
+		cacheClientVar.Add("http-retries", 1)
+
+		select {
+		case <-time.After(d):
+		case <-ctx.Done():
+			return nil, err
+		}
+	}
+}
+
+// getOnce makes a GET request without retrying.
+func (c *Client) getOnce(ctx context.Context, u *url.URL) ([]byte, error) {
+	if c.limiter != nil {
+		if err := c.limiter.wait(ctx); err != nil {
+			return nil, err
+		}
+	}
+
+	cacheClientVar.Add("http-requests", 1)
+
+	if c.requestTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, c.requestTimeout)
+		defer cancel()
+	}
+
+	httpReq, err := http.NewRequest(http.MethodGet, u.String(), nil)
+	if err != nil {
+		return nil, err
+	}
+
+	httpResp, err := c.httpClient.Do(httpReq.WithContext(ctx))
+	if err != nil {
+		return nil, redactError(err)
+	}
+	defer func() {
+		if err := httpResp.Body.Close(); err != nil {
+			logger.Error(err)
+		}
+	}()
+
+	// Read the whole body before the timeout cancels the request.
+	body, err := ioutil.ReadAll(httpResp.Body)
+	if err != nil {
+		return nil, redactError(err)
+	}
+
+	if httpResp.StatusCode >= 200 && httpResp.StatusCode < 300 {
+		return body, nil
+	}
+
+	cacheClientVar.Add(fmt.Sprintf("http-status-%d", httpResp.StatusCode), 1)
+
+	msg := strings.TrimSpace(string(body))
+	if len(msg) > maxErrorMessageLength {
+		msg = msg[:maxErrorMessageLength]
+	}
+
+	return nil, &StatusError{
+		StatusCode: httpResp.StatusCode,
+		Message:    msg,
+		RetryAfter: parseRetryAfter(httpResp.Header.Get("Retry-After")),
+	}
+}
+
+// shouldRetry returns true if the error is temporary and the caller hasn't given up.
+func (c *Client) shouldRetry(ctx context.Context, err error) bool {
+	if ctx.Err() != nil {
+		return false
+	}
+	if se, ok := err.(*StatusError); ok {
+		return se.temporary()
+	}
+	// Retry connection errors and timeouts of individual attempts.
+	return true
+}
+
+// backoff returns how long to wait before the retry after the given attempt.
+// It picks a random duration between half and all of the exponential backoff,
+// so that clients that failed together don't retry together.
+func (c *Client) backoff(attempt int) time.Duration {
+	d := c.initialBackoff
+	for i := 0; i < attempt && d < c.maxBackoff; i++ {
+		d *= 2
+	}
+	if d > c.maxBackoff {
+		d = c.maxBackoff
+	}
+	if d <= 0 {
+		return 0
+	}
+	return d/2 + time.Duration(rand.Int63n(int64(d/2)+1))
+}
+
+// parseRetryAfter parses a Retry-After header in seconds. It returns zero if the header is missing or a date.
+func parseRetryAfter(v string) time.Duration {
+	secs, err := strconv.Atoi(strings.TrimSpace(v))
+	if err != nil || secs < 0 {
+		return 0
+	}
+	return time.Duration(secs) * time.Second
+}
+
+// redactError removes the API token from the URL in errors from the HTTP client,
+// so that it doesn't appear in logs and error messages.
+func redactError(err error) error {
+	ue, ok := err.(*url.Error)
+	if !ok {
+		return err
+	}
+
+	u, perr := url.Parse(ue.URL)
+	if perr != nil {
+		return &url.Error{Op: ue.Op, URL: "", Err: ue.Err}
+	}
+
+	v := u.Query()
+	if v.Get("token") != "" {
+		v.Set("token", "REDACTED")
+		u.RawQuery = v.Encode()
+	}
+
+	return &url.Error{Op: ue.Op, URL: u.String(), Err: ue.Err}
+}
+
+// rateLimiter is a token bucket that limits the rate of requests.
+type rateLimiter struct {
+	// mu guards the fields below.
+	mu sync.Mutex
+
+	// rate is how many tokens are added per second.
+	rate float64
+
+	// burst is the most tokens that the bucket holds.
+	burst float64
+
+	// tokens is how many tokens the bucket has.
+	tokens float64
+
+	// last is when tokens were last added.
+	last time.Time
+}
+
+func newRateLimiter(requestsPerSecond float64, burst int) *rateLimiter {
+	if burst < 1 {
+		burst = 1
+	}
+	return &rateLimiter{
+		rate:   requestsPerSecond,
+		burst:  float64(burst),
+		tokens: float64(burst),
+		last:   time.Now(),
+	}
+}
+
+// wait blocks until a request can be made or the context is done.
+// It uses time.Now instead of now, since tests mock now to return a fixed time.
+func (r *rateLimiter) wait(ctx context.Context) error {
+	for {
+		r.mu.Lock()
+		t := time.Now()
+		r.tokens += t.Sub(r.last).Seconds() * r.rate
+		if r.tokens > r.burst {
+			r.tokens = r.burst
+		}
+		r.last = t
+
+		if r.tokens >= 1 {
+			r.tokens--
+			r.mu.Unlock()
+			return nil
+		}
+
+		d := time.Duration((1 - r.tokens) / r.rate * float64(time.Second))
+		r.mu.Unlock()
+
+		cacheClientVar.Add("http-rate-limited", 1)
+
+		select {
+		case <-time.After(d):
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
@@ -0,0 +1,339 @@
+package iex
+
+import (
+	"context"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+// fakeResponse is a response or error that fakeTransport returns.
+type fakeResponse struct {
+	status int
+	body   string
+	header http.Header
+	err    error
+}
+
+// fakeTransport returns the responses in order and repeats the last one.
+type fakeTransport struct {
+	mu        sync.Mutex
+	responses []fakeResponse
+	requests  []*http.Request
+}
+
+func (f *fakeTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	r := f.responses[len(f.responses)-1]
+	if n := len(f.requests); n < len(f.responses) {
+		r = f.responses[n]
+	}
+	f.requests = append(f.requests, req)
+
+	if r.err != nil {
+		return nil, r.err
+	}
+
+	header := r.header
+	if header == nil {
+		header = http.Header{}
+	}
+
+	return &http.Response{
+		StatusCode: r.status,
+		Header:     header,
+		Body:       ioutil.NopCloser(strings.NewReader(r.body)),
+		Request:    req,
+	}, nil
+}
+
+func (f *fakeTransport) requestCount() int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return len(f.requests)
+}
+
+func TestClientGetQuotes_Retries(t *testing.T) {
+	for _, tt := range []struct {
+		desc         string
+		responses    []fakeResponse
+		wantRequests int
+		wantErr      error
+		wantStatus   int
+	}{
+		{
+			desc:         "success",
+			responses:    []fakeResponse{{status: 200, body: `{}`}},
+			wantRequests: 1,
+		},
+		{
+			desc: "retries server errors",
+			responses: []fakeResponse{
+				{status: 503, body: "unavailable"},
+				{status: 500, body: "internal error"},
+				{status: 200, body: `{}`},
+			},
+			wantRequests: 3,
+		},
+		{
+			desc: "retries connection errors",
+			responses: []fakeResponse{
+				{err: errors.New("connection reset")},
+				{status: 200, body: `{}`},
+			},
+			wantRequests: 2,
+		},
+		{
+			desc: "retries throttled requests",
+			responses: []fakeResponse{
+				{status: 429, body: "Too many requests", header: http.Header{"Retry-After": []string{"0"}}},
+				{status: 200, body: `{}`},
+			},
+			wantRequests: 2,
+		},
+		{
+			desc:         "gives up after max retries",
+			responses:    []fakeResponse{{status: 429, body: "Too many requests"}},
+			wantRequests: 3,
+			wantErr:      ErrThrottled,
+			wantStatus:   429,
+		},
+		{
+			desc:         "doesn't retry exceeded quota",
+			responses:    []fakeResponse{{status: 402, body: "Payment required"}},
+			wantRequests: 1,
+			wantErr:      ErrThrottled,
+			wantStatus:   402,
+		},
+		{
+			desc:         "doesn't retry bad token",
+			responses:    []fakeResponse{{status: 401, body: "Unauthorized"}},
+			wantRequests: 1,
+			wantErr:      ErrUnauthorized,
+			wantStatus:   401,
+		},
+		{
+			desc:         "doesn't retry bad request",
+			responses:    []fakeResponse{{status: 400, body: "Bad request"}},
+			wantRequests: 1,
+			wantStatus:   400,
+		},
+	} {
+		t.Run(tt.desc, func(t *testing.T) {
+			ft := &fakeTransport{responses: tt.responses}
+			c := NewClient(new(NoOpChartCache),
+				HTTPTransport(ft),
+				MaxRetries(2),
+				Backoff(time.Millisecond, time.Millisecond),
+				RateLimit(0, 0))
+
+			_, err := c.GetQuotes(context.Background(), &GetQuotesRequest{Token: "abc", Symbols: []string{"AAPL"}})
+
+			if diff := cmp.Diff(tt.wantRequests, ft.requestCount()); diff != "" {
+				t.Errorf("request count diff (-want, +got)\n%s", diff)
+			}
+
+			if tt.wantStatus == 0 {
+				if err != nil {
+					t.Fatalf("GetQuotes should not return an error: %v", err)
+				}
+				return
+			}
+
+			var se *StatusError
+			if !errors.As(err, &se) {
+				t.Fatalf("GetQuotes should return a StatusError, got: %v", err)
+			}
+
+			if diff := cmp.Diff(tt.wantStatus, se.StatusCode); diff != "" {
+				t.Errorf("status diff (-want, +got)\n%s", diff)
+			}
+
+			if tt.wantErr != nil && !errors.Is(err, tt.wantErr) {
+				t.Errorf("got error: %v, want error wrapping: %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestClientGetCharts(t *testing.T) {
+	old := now
+	defer func() { now = old }()
+	now = func() time.Time { return time.Date(2018, time.October, 11, 0, 0, 0, 0, loc) }
+
+	ft := &fakeTransport{
+		responses: []fakeResponse{
+			{status: 503},
+			{status: 200, body: `{"AAPL": {"chart": [{"date":"2018-10-10","open":1,"high":2,"low":0.5,"close":1.5,"volume":100}]}}`},
+		},
+	}
+	c := NewClient(new(NoOpChartCache),
+		HTTPTransport(ft),
+		Backoff(time.Millisecond, time.Millisecond),
+		RateLimit(0, 0))
+
+	got, err := c.GetCharts(context.Background(), &GetChartsRequest{Token: "abc", Symbols: []string{"AAPL"}, Range: FiveYears})
+	if err != nil {
+		t.Fatalf("GetCharts should not return an error: %v", err)
+	}
+
+	want := []*Chart{
+		{
+			Symbol: "AAPL",
+			ChartPoints: []*ChartPoint{
+				{Date: time.Date(2018, time.October, 10, 0, 0, 0, 0, loc), Open: 1, High: 2, Low: 0.5, Close: 1.5, Volume: 100},
+			},
+		},
+	}
+
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("diff (-want, +got)\n%s", diff)
+	}
+
+	if diff := cmp.Diff("5y", ft.requests[len(ft.requests)-1].URL.Query().Get("range")); diff != "" {
+		t.Errorf("range diff (-want, +got)\n%s", diff)
+	}
+}
+
+func TestClientBaseURL(t *testing.T) {
+	for _, tt := range []struct {
+		opts []ClientOption
+		want string
+	}{
+		{want: "https://cloud.iexapis.com/stable/stock/market/batch"},
+		{opts: []ClientOption{BaseURL(SandboxBaseURL)}, want: "https://sandbox.iexapis.com/stable/stock/market/batch"},
+		{opts: []ClientOption{BaseURL("http://localhost:9000/")}, want: "http://localhost:9000/stock/market/batch"},
+	} {
+		ft := &fakeTransport{responses: []fakeResponse{{status: 200, body: `{}`}}}
+		opts := append([]ClientOption{HTTPTransport(ft), RateLimit(0, 0)}, tt.opts...)
+		c := NewClient(new(NoOpChartCache), opts...)
+
+		if _, err := c.GetQuotes(context.Background(), &GetQuotesRequest{Token: "abc", Symbols: []string{"AAPL"}}); err != nil {
+			t.Fatalf("GetQuotes should not return an error: %v", err)
+		}
+
+		u := *ft.requests[0].URL
+		u.RawQuery = ""
+		if diff := cmp.Diff(tt.want, u.String()); diff != "" {
+			t.Errorf("diff (-want, +got)\n%s", diff)
+		}
+	}
+}
+
+func TestClientGet_RedactsToken(t *testing.T) {
+	ft := &fakeTransport{responses: []fakeResponse{{err: errors.New("connection refused")}}}
+	c := NewClient(new(NoOpChartCache),
+		HTTPTransport(ft),
+		MaxRetries(0),
+		RateLimit(0, 0))
+
+	_, err := c.GetQuotes(context.Background(), &GetQuotesRequest{Token: "secret_token", Symbols: []string{"AAPL"}})
+	if err == nil {
+		t.Fatal("GetQuotes should return an error")
+	}
+
+	if strings.Contains(err.Error(), "secret_token") {
+		t.Errorf("error has the token: %v", err)
+	}
+}
+
+func TestClientGet_Timeout(t *testing.T) {
+	block := make(chan struct{})
+	defer close(block)
+
+	calls := 0
+	rt := roundTripperFunc(func(req *http.Request) (*http.Response, error) {
+		calls++
+		select {
+		case <-req.Context().Done():
+			return nil, req.Context().Err()
+		case <-block:
+			return nil, errors.New("unblocked")
+		}
+	})
+
+	c := NewClient(new(NoOpChartCache),
+		HTTPTransport(rt),
+		RequestTimeout(time.Millisecond),
+		MaxRetries(1),
+		Backoff(time.Millisecond, time.Millisecond),
+		RateLimit(0, 0))
+
+	if _, err := c.GetQuotes(context.Background(), &GetQuotesRequest{Token: "abc", Symbols: []string{"AAPL"}}); err == nil {
+		t.Fatal("GetQuotes should return an error")
+	}
+
+	if diff := cmp.Diff(2, calls); diff != "" {
+		t.Errorf("diff (-want, +got)\n%s", diff)
+	}
+}
+
+func TestClientBackoff(t *testing.T) {
+	c := NewClient(new(NoOpChartCache), Backoff(100*time.Millisecond, time.Second))
+
+	for _, tt := range []struct {
+		attempt int
+		min     time.Duration
+		max     time.Duration
+	}{
+		{attempt: 0, min: 50 * time.Millisecond, max: 100 * time.Millisecond},
+		{attempt: 1, min: 100 * time.Millisecond, max: 200 * time.Millisecond},
+		{attempt: 3, min: 400 * time.Millisecond, max: 800 * time.Millisecond},
+		{attempt: 10, min: 500 * time.Millisecond, max: time.Second},
+	} {
+		for i := 0; i < 10; i++ {
+			if got := c.backoff(tt.attempt); got < tt.min || got > tt.max {
+				t.Errorf("backoff(%d) = %v, want between %v and %v", tt.attempt, got, tt.min, tt.max)
+			}
+		}
+	}
+}
+
+func TestRateLimiter(t *testing.T) {
+	r := newRateLimiter(1, 2)
+
+	// The burst should be available right away.
+	for i := 0; i < 2; i++ {
+		if err := r.wait(context.Background()); err != nil {
+			t.Fatalf("wait should not return an error: %v", err)
+		}
+	}
+
+	// The next request has to wait about a second, so it should time out.
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+
+	if err := r.wait(ctx); err != context.DeadlineExceeded {
+		t.Errorf("got error: %v, want: %v", err, context.DeadlineExceeded)
+	}
+}
+
+func TestParseRetryAfter(t *testing.T) {
+	for _, tt := range []struct {
+		input string
+		want  time.Duration
+	}{
+		{input: "", want: 0},
+		{input: "5", want: 5 * time.Second},
+		{input: "-1", want: 0},
+		{input: "Wed, 21 Oct 2015 07:28:00 GMT", want: 0},
+	} {
+		if diff := cmp.Diff(tt.want, parseRetryAfter(tt.input)); diff != "" {
+			t.Errorf("parseRetryAfter(%q) diff (-want, +got)\n%s", tt.input, diff)
+		}
+	}
+}
+
+type roundTripperFunc func(req *http.Request) (*http.Response, error)
+
+func (f roundTripperFunc) RoundTrip(req *http.Request) (*http.Response, error) {
+	return f(req)
+}
@@ -2,18 +2,15 @@
 package iex
 
 import (
-	"bytes"
 	"context"
-	"errors"
 	"expvar"
-	"fmt"
-	"io"
-	"io/ioutil"
-	"os"
+	"net/http"
 	"regexp"
 	"time"
 
+	"github.com/btmura/ponzi2/internal/errs"
 	"github.com/btmura/ponzi2/internal/logger"
+	"github.com/btmura/ponzi2/internal/stock"
 )
 
 var (
@@ -24,26 +21,60 @@ var (
 	loc = mustLoadLocation("America/New_York")
 )
 
-var (
-	// validTokenRegexp is a regexp that accepts valid IEX API tokens.
-	validTokenRegexp = regexp.MustCompile("^[A-Za-z0-9_]{1,}$")
+// Base URLs of the IEX API to pass to BaseURL.
+const (
+	// CloudBaseURL is the base URL of the production API. Used by default.
+	CloudBaseURL = "https://cloud.iexapis.com/stable"
 
-	// validSymbolRegexp is a regexp that accepts valid stock symbols. Examples: X, FB, SPY, AAPL
-	validSymbolRegexp = regexp.MustCompile("^[A-Z]{1,5}$")
+	// SandboxBaseURL is the base URL of the sandbox API, which returns scrambled data for test tokens.
+	SandboxBaseURL = "https://sandbox.iexapis.com/stable"
 )
 
+// validTokenRegexp is a regexp that accepts valid IEX API tokens.
+var validTokenRegexp = regexp.MustCompile("^[A-Za-z0-9_]{1,}$")
+
 var cacheClientVar = expvar.NewMap("iex-client-stats")
 
+// ValidateToken returns an error if the token isn't a valid IEX API token,
+// so that bad tokens are rejected before making any requests.
+func ValidateToken(token string) error {
+	if !validTokenRegexp.MatchString(token) {
+		return errs.Errorf("bad IEX API token: should only have letters, numbers, and underscores")
+	}
+	return nil
+}
+
 // ErrMissingAPIToken is the error returned when a request does not have an API token.
-var ErrMissingAPIToken = errors.New("missing API token")
+var ErrMissingAPIToken = stock.ErrMissingAPIToken
 
 // Client is used to make IEX API requests.
 type Client struct {
 	// chartCache caches chart responses for GetCharts.
 	chartCache iexChartCacheInterface
 
-	// dumpAPIResponses dumps API responses into text files.
-	dumpAPIResponses bool
+	// baseURL is the base URL of the API without a trailing slash like CloudBaseURL.
+	baseURL string
+
+	// httpClient makes the HTTP requests.
+	httpClient *http.Client
+
+	// requestTimeout is how long to wait for each attempt of a request. Zero means no timeout.
+	requestTimeout time.Duration
+
+	// maxRetries is how many times to retry requests that failed temporarily.
+	maxRetries int
+
+	// initialBackoff is how long to wait before the first retry.
+	initialBackoff time.Duration
+
+	// maxBackoff is the longest to wait before retrying.
+	maxBackoff time.Duration
+
+	// limiter limits the rate of requests. Nil for no limit.
+	limiter *rateLimiter
+
+	// replaying is whether requests are served from recorded responses and don't need API tokens.
+	replaying bool
 }
 
 type iexChartCacheInterface interface {
@@ -51,34 +82,23 @@ type iexChartCacheInterface interface {
 	Put(ctx context.Context, key ChartCacheKey, val *ChartCacheValue) error
 }
 
-// NewClient returns a new Client.
-func NewClient(chartCache iexChartCacheInterface, dumpAPIResponses bool) *Client {
-	return &Client{
-		chartCache:       chartCache,
-		dumpAPIResponses: dumpAPIResponses,
-	}
-}
-
-func dumpResponse(fileName string, r io.Reader) (io.ReadCloser, error) {
-	file, err := os.OpenFile(fileName, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0660)
-	if err != nil {
-		return nil, err
+// NewClient returns a new Client. Requests time out, are retried, and are rate limited
+// within IEX's limits by default. Pass options to change these defaults.
+func NewClient(chartCache iexChartCacheInterface, opts ...ClientOption) *Client {
+	c := &Client{
+		chartCache:     chartCache,
+		baseURL:        CloudBaseURL,
+		httpClient:     http.DefaultClient,
+		requestTimeout: defaultRequestTimeout,
+		maxRetries:     defaultMaxRetries,
+		initialBackoff: defaultInitialBackoff,
+		maxBackoff:     defaultMaxBackoff,
+		limiter:        newRateLimiter(defaultRequestsPerSecond, defaultBurst),
 	}
-	defer func() {
-		if err := file.Close(); err != nil {
-			logger.Error(err)
-		}
-	}()
-
-	b, err := ioutil.ReadAll(r)
-	if err != nil {
-		return nil, err
+	for _, o := range opts {
+		o(c)
 	}
-	if _, err := fmt.Fprintf(file, "%s", b); err != nil {
-		return nil, err
-	}
-
-	return ioutil.NopCloser(bytes.NewBuffer(b)), nil
+	return c
 }
 
 func mustLoadLocation(name string) *time.Location {
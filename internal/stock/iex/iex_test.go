@@ -42,3 +42,40 @@ func TestValidTokenRegexp(t *testing.T) {
 		})
 	}
 }
+
+func TestValidateToken(t *testing.T) {
+	for _, tt := range []struct {
+		desc  string
+		input string
+		want  bool
+	}{
+		{
+			desc:  "empty",
+			input: "",
+			want:  false,
+		},
+		{
+			desc:  "whitespace",
+			input: " ",
+			want:  false,
+		},
+		{
+			desc:  "trailing whitespace",
+			input: " abc ",
+			want:  false,
+		},
+		{
+			desc:  "valid",
+			input: "abc_123",
+			want:  true,
+		},
+	} {
+		t.Run(tt.desc, func(t *testing.T) {
+			got := ValidateToken(tt.input) == nil
+
+			if diff := cmp.Diff(tt.want, got); diff != "" {
+				t.Errorf("diff (-want, +got)\n%s", diff)
+			}
+		})
+	}
+}
@@ -0,0 +1,450 @@
+// Package iextest provides a fake IEX API server that serves synthetic quotes and charts
+// for tests and local development without an API token or network connection.
+package iextest
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"hash/fnv"
+	"math"
+	"math/rand"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/btmura/ponzi2/internal/logger"
+	"github.com/btmura/ponzi2/internal/stock/market"
+)
+
+// Token is an API token to use with the server. The server accepts any non-empty token.
+const Token = "fake_token"
+
+// batchPath is the path of the batch endpoint relative to the base URL.
+const batchPath = "/stock/market/batch"
+
+// epoch is the first day of synthetic data. Prices are generated from it,
+// so that every range returns the same prices for the same days.
+var epoch = time.Date(2000, time.January, 3, 0, 0, 0, 0, loc)
+
+// Every symbol pays a dividend of dividendYield of its price every dividendInterval trading days.
+const (
+	dividendInterval = 63
+	dividendYield    = 0.005
+)
+
+// loc is the timezone of the synthetic dates.
+var loc = mustLoadLocation("America/New_York")
+
+// Server is a fake IEX API server that implements the batch endpoint for quotes and charts.
+//
+// Prices are a random walk seeded by the symbol, so the same symbol always has the same prices,
+// and any symbol is valid. Latency and failures can be injected to test how clients handle them.
+// Pass the URL of the server to iex.BaseURL.
+type Server struct {
+	// mu guards the fields below.
+	mu sync.Mutex
+
+	// latency is how long to wait before responding.
+	latency time.Duration
+
+	// sleep waits for the latency or until the request is canceled.
+	sleep func(ctx context.Context, d time.Duration) error
+
+	// failureRate is the fraction of requests from 0 to 1 to fail with failureStatus.
+	failureRate float64
+
+	// failureStatus is the HTTP status of failed requests.
+	failureStatus int
+
+	// rand decides which requests fail.
+	rand *rand.Rand
+
+	// now returns the current time to generate data up to.
+	now func() time.Time
+
+	// requestCount is how many requests the server received.
+	requestCount int
+}
+
+// Option is an option to pass to NewServer.
+type Option func(s *Server)
+
+// Latency returns an option to wait before responding to each request.
+func Latency(d time.Duration) Option {
+	return func(s *Server) {
+		s.latency = d
+	}
+}
+
+// Sleep returns an option to set the function that waits for the latency before responding.
+// It should return an error if the context is done first, so that the server doesn't respond.
+func Sleep(sleep func(ctx context.Context, d time.Duration) error) Option {
+	return func(s *Server) {
+		s.sleep = sleep
+	}
+}
+
+// FailureRate returns an option to fail the fraction of requests from 0 to 1 with the HTTP status.
+func FailureRate(rate float64, status int) Option {
+	return func(s *Server) {
+		s.failureRate = rate
+		s.failureStatus = status
+	}
+}
+
+// Seed returns an option to seed the randomness of which requests fail.
+func Seed(seed int64) Option {
+	return func(s *Server) {
+		s.rand = rand.New(rand.NewSource(seed))
+	}
+}
+
+// Now returns an option to set the function that returns the current time.
+// The server generates data up to the last weekday at or before that time, which must be after 2000.
+func Now(now func() time.Time) Option {
+	return func(s *Server) {
+		s.now = now
+	}
+}
+
+// NewServer returns a new Server that doesn't have latency or failures by default.
+func NewServer(opts ...Option) *Server {
+	s := &Server{
+		sleep:         sleepContext,
+		failureStatus: http.StatusServiceUnavailable,
+		rand:          rand.New(rand.NewSource(time.Now().UnixNano())),
+		now:           time.Now,
+	}
+	for _, o := range opts {
+		o(s)
+	}
+	return s
+}
+
+// SetLatency changes how long to wait before responding to each request.
+func (s *Server) SetLatency(d time.Duration) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.latency = d
+}
+
+// SetFailureRate changes the fraction of requests to fail with the HTTP status.
+func (s *Server) SetFailureRate(rate float64, status int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.failureRate = rate
+	s.failureStatus = status
+}
+
+// RequestCount returns how many requests the server received.
+func (s *Server) RequestCount() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.requestCount
+}
+
+// ServeHTTP implements the http.Handler interface.
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	s.requestCount++
+	latency := s.latency
+	sleep := s.sleep
+	fail := s.failureRate > 0 && s.rand.Float64() < s.failureRate
+	failureStatus := s.failureStatus
+	n := s.now().In(loc)
+	s.mu.Unlock()
+
+	if latency > 0 {
+		if err := sleep(r.Context(), latency); err != nil {
+			return
+		}
+	}
+
+	if !strings.HasSuffix(r.URL.Path, batchPath) {
+		http.NotFound(w, r)
+		return
+	}
+
+	v := r.URL.Query()
+
+	if v.Get("token") == "" {
+		http.Error(w, "An API key is required to access this data and no key was provided", http.StatusUnauthorized)
+		return
+	}
+
+	if fail {
+		http.Error(w, http.StatusText(failureStatus), failureStatus)
+		return
+	}
+
+	var symbols []string
+	for _, sym := range strings.Split(v.Get("symbols"), ",") {
+		if sym != "" {
+			symbols = append(symbols, sym)
+		}
+	}
+
+	symbol2Data := map[string]interface{}{}
+
+	switch types := v.Get("types"); types {
+	case "quote":
+		for _, sym := range symbols {
+			symbol2Data[sym] = map[string]interface{}{"quote": newQuote(sym, n)}
+		}
+
+	case "chart":
+		chartLast := 0
+		if cl := v.Get("chartLast"); cl != "" {
+			i, err := strconv.Atoi(cl)
+			if err != nil || i < 0 {
+				http.Error(w, fmt.Sprintf("bad chartLast: %q", cl), http.StatusBadRequest)
+				return
+			}
+			chartLast = i
+		}
+
+		rng := v.Get("range")
+		for _, sym := range symbols {
+			ps, err := newChartPoints(sym, rng, n)
+			if err != nil {
+				http.Error(w, err.Error(), http.StatusBadRequest)
+				return
+			}
+			if chartLast > 0 && len(ps) > chartLast {
+				ps = ps[len(ps)-chartLast:]
+			}
+			symbol2Data[sym] = map[string]interface{}{"chart": ps}
+		}
+
+	default:
+		http.Error(w, fmt.Sprintf("unsupported types: %q", types), http.StatusBadRequest)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(symbol2Data); err != nil {
+		logger.Errorf("writing response failed: %v", err)
+	}
+}
+
+// quote is a quote in the batch response.
+type quote struct {
+	Symbol        string  `json:"symbol"`
+	CompanyName   string  `json:"companyName"`
+	LatestPrice   float64 `json:"latestPrice"`
+	LatestSource  string  `json:"latestSource"`
+	LatestTime    string  `json:"latestTime"`
+	LatestUpdate  int64   `json:"latestUpdate"`
+	LatestVolume  int64   `json:"latestVolume"`
+	Open          float64 `json:"open"`
+	High          float64 `json:"high"`
+	Low           float64 `json:"low"`
+	Close         float64 `json:"close"`
+	Change        float64 `json:"change"`
+	ChangePercent float64 `json:"changePercent"`
+}
+
+// chartPoint is a chart point in the batch response. Minute is only set for the 1d range,
+// and the prices adjusted for dividends are only set for the other ranges.
+type chartPoint struct {
+	Date          string  `json:"date"`
+	Minute        string  `json:"minute,omitempty"`
+	Open          float64 `json:"open"`
+	High          float64 `json:"high"`
+	Low           float64 `json:"low"`
+	Close         float64 `json:"close"`
+	Volume        int64   `json:"volume"`
+	Change        float64 `json:"change"`
+	ChangePercent float64 `json:"changePercent"`
+	FOpen         float64 `json:"fOpen,omitempty"`
+	FHigh         float64 `json:"fHigh,omitempty"`
+	FLow          float64 `json:"fLow,omitempty"`
+	FClose        float64 `json:"fClose,omitempty"`
+}
+
+// newQuote returns the closing quote of the last trading day.
+func newQuote(symbol string, now time.Time) *quote {
+	ps := dailyPoints(symbol, lastTradingDay(now))
+	last := ps[len(ps)-1]
+	_, closeTime, _ := market.NYSE.Session(last.date)
+
+	return &quote{
+		Symbol:       symbol,
+		CompanyName:  symbol + " Inc.",
+		LatestPrice:  last.Close,
+		LatestSource: "Close",
+		LatestTime:   last.date.Format("January 2, 2006"),
+		LatestUpdate: closeTime.UnixNano() / int64(time.Millisecond),
+		LatestVolume: last.Volume,
+		Open:         last.Open,
+		High:         last.High,
+		Low:          last.Low,
+		Close:        last.Close,
+		Change:       last.Change,
+		// Unlike charts, quotes have fractions instead of percentages.
+		ChangePercent: math.Round(last.Change/(last.Close-last.Change)*1e4) / 1e4,
+	}
+}
+
+// newChartPoints returns the chart points of the range ending on the last trading day.
+func newChartPoints(symbol, rng string, now time.Time) ([]*chartPoint, error) {
+	end := lastTradingDay(now)
+
+	var start time.Time
+	switch rng {
+	case "1d":
+		return minutePoints(symbol, end, now), nil
+	case "2y":
+		start = end.AddDate(-2, 0, 0)
+	case "5y":
+		start = end.AddDate(-5, 0, 0)
+	case "max":
+		start = epoch.AddDate(0, 0, -1)
+	default:
+		return nil, fmt.Errorf("unsupported range: %q", rng)
+	}
+
+	var ps []*chartPoint
+	for _, p := range dailyPoints(symbol, end) {
+		if p.date.After(start) {
+			ps = append(ps, &p.chartPoint)
+		}
+	}
+	return ps, nil
+}
+
+// dailyPoint is a chart point with its parsed date.
+type dailyPoint struct {
+	chartPoint
+	date time.Time
+}
+
+// dailyPoints returns the symbol's daily points from the epoch to the end day.
+// Prices before each dividend are adjusted for it like IEX does, so the adjustments
+// of the same day change as the end day passes more dividends.
+func dailyPoints(symbol string, end time.Time) []*dailyPoint {
+	r := rand.New(rand.NewSource(symbolSeed(symbol)))
+	prevClose := 10 + float64(symbolSeed(symbol)%490)
+	baseVolume := 1e6 + float64(symbolSeed(symbol)%9e6)
+
+	var ps []*dailyPoint
+	for d := epoch; !d.After(end); d = d.AddDate(0, 0, 1) {
+		if !market.NYSE.IsTradingDay(d) {
+			continue
+		}
+
+		open := prevClose * (1 + r.NormFloat64()*0.005)
+		cls := open * (1 + r.NormFloat64()*0.015 + 0.0003)
+		high := math.Max(open, cls) * (1 + r.Float64()*0.01)
+		low := math.Min(open, cls) * (1 - r.Float64()*0.01)
+		volume := int64(baseVolume * (0.5 + r.Float64()))
+
+		p := &dailyPoint{
+			chartPoint: chartPoint{
+				Date:          d.Format("2006-01-02"),
+				Open:          cents(open),
+				High:          cents(high),
+				Low:           cents(low),
+				Close:         cents(cls),
+				Volume:        volume,
+				Change:        cents(cls - prevClose),
+				ChangePercent: cents((cls - prevClose) / prevClose * 100),
+			},
+			date: d,
+		}
+		ps = append(ps, p)
+
+		prevClose = cls
+	}
+
+	factor := 1.0
+	for i := len(ps) - 1; i >= 0; i-- {
+		p := ps[i]
+		p.FOpen = cents(p.Open * factor)
+		p.FHigh = cents(p.High * factor)
+		p.FLow = cents(p.Low * factor)
+		p.FClose = cents(p.Close * factor)
+
+		// Points before the ex-date are adjusted for the dividend.
+		if i%dividendInterval == 0 && i != 0 {
+			factor *= 1 - dividendYield
+		}
+	}
+
+	return ps
+}
+
+// minutePoints returns the symbol's minute points of the trading day up to now or the close, which may be early.
+func minutePoints(symbol string, day, now time.Time) []*chartPoint {
+	ps := dailyPoints(symbol, day)
+	daily := ps[len(ps)-1]
+
+	r := rand.New(rand.NewSource(symbolSeed(symbol) ^ day.Unix()))
+
+	start, end, _ := market.NYSE.Session(day)
+	minutes := int64(end.Sub(start) / time.Minute)
+	if now.Before(end) {
+		end = now
+	}
+
+	var mps []*chartPoint
+	prevClose := daily.Open
+	for t := start; t.Before(end); t = t.Add(time.Minute) {
+		open := prevClose
+		cls := open * (1 + r.NormFloat64()*0.001)
+		mps = append(mps, &chartPoint{
+			Date:          day.Format("2006-01-02"),
+			Minute:        t.Format("15:04"),
+			Open:          cents(open),
+			High:          cents(math.Max(open, cls) * (1 + r.Float64()*0.0005)),
+			Low:           cents(math.Min(open, cls) * (1 - r.Float64()*0.0005)),
+			Close:         cents(cls),
+			Volume:        daily.Volume / minutes,
+			Change:        cents(cls - daily.Open),
+			ChangePercent: cents((cls - daily.Open) / daily.Open * 100),
+		})
+		prevClose = cls
+	}
+	return mps
+}
+
+// lastTradingDay returns midnight of the last trading day that trading started at or before now.
+func lastTradingDay(now time.Time) time.Time {
+	open, _ := market.NYSE.LatestSession(now)
+	return time.Date(open.Year(), open.Month(), open.Day(), 0, 0, 0, 0, loc)
+}
+
+// symbolSeed returns a positive number to generate the symbol's data with.
+func symbolSeed(symbol string) int64 {
+	h := fnv.New64a()
+	h.Write([]byte(symbol))
+	return int64(h.Sum64() >> 1)
+}
+
+// cents rounds the value to two decimal places.
+func cents(v float64) float64 {
+	return math.Round(v*100) / 100
+}
+
+// sleepContext waits for the duration or returns the context's error if it is done first.
+func sleepContext(ctx context.Context, d time.Duration) error {
+	t := time.NewTimer(d)
+	defer t.Stop()
+	select {
+	case <-t.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func mustLoadLocation(name string) *time.Location {
+	loc, err := time.LoadLocation(name)
+	if err != nil {
+		logger.Fatalf("time.LoadLocation(%s) failed: %v", name, err)
+	}
+	return loc
+}
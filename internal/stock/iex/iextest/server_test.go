@@ -0,0 +1,341 @@
+package iextest
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+
+	"github.com/btmura/ponzi2/internal/stock/iex"
+)
+
+// fakeNow is a Thursday during trading hours.
+var fakeNow = time.Date(2018, time.October, 11, 12, 0, 0, 0, loc)
+
+func newTestClient(ts *httptest.Server, opts ...iex.ClientOption) *iex.Client {
+	opts = append([]iex.ClientOption{
+		iex.BaseURL(ts.URL),
+		iex.Backoff(time.Millisecond, time.Millisecond),
+		iex.RateLimit(0, 0),
+	}, opts...)
+	return iex.NewClient(new(iex.NoOpChartCache), opts...)
+}
+
+func TestServer_Quotes(t *testing.T) {
+	ts := httptest.NewServer(NewServer(Now(func() time.Time { return fakeNow })))
+	defer ts.Close()
+
+	c := newTestClient(ts)
+
+	quotes, err := c.GetQuotes(context.Background(), &iex.GetQuotesRequest{Token: Token, Symbols: []string{"AAPL"}})
+	if err != nil {
+		t.Fatalf("GetQuotes should not return an error: %v", err)
+	}
+
+	if len(quotes) != 1 {
+		t.Fatalf("got %d quotes, want 1", len(quotes))
+	}
+	q := quotes[0]
+
+	if diff := cmp.Diff("AAPL Inc.", q.CompanyName); diff != "" {
+		t.Errorf("company name diff (-want, +got)\n%s", diff)
+	}
+
+	if diff := cmp.Diff(iex.Close, q.LatestSource); diff != "" {
+		t.Errorf("source diff (-want, +got)\n%s", diff)
+	}
+
+	if diff := cmp.Diff(time.Date(2018, time.October, 11, 0, 0, 0, 0, loc), q.LatestTime); diff != "" {
+		t.Errorf("time diff (-want, +got)\n%s", diff)
+	}
+
+	charts, err := c.GetCharts(context.Background(), &iex.GetChartsRequest{Token: Token, Symbols: []string{"AAPL"}, Range: iex.TwoYears})
+	if err != nil {
+		t.Fatalf("GetCharts should not return an error: %v", err)
+	}
+
+	ps := charts[0].ChartPoints
+	if diff := cmp.Diff(ps[len(ps)-1].Close, q.LatestPrice); diff != "" {
+		t.Errorf("quote should match the last chart point, diff (-want, +got)\n%s", diff)
+	}
+}
+
+func TestNewChartPoints(t *testing.T) {
+	for _, tt := range []struct {
+		desc      string
+		rng       string
+		now       time.Time
+		wantCount int
+		wantFirst string
+		wantLast  string
+	}{
+		{
+			desc:      "minutes so far today",
+			rng:       "1d",
+			now:       fakeNow,
+			wantCount: 150,
+			wantFirst: "2018-10-11 09:30",
+			wantLast:  "2018-10-11 11:59",
+		},
+		{
+			desc:      "whole previous day before the open",
+			rng:       "1d",
+			now:       time.Date(2018, time.October, 11, 8, 0, 0, 0, loc),
+			wantCount: 390,
+			wantFirst: "2018-10-10 09:30",
+			wantLast:  "2018-10-10 15:59",
+		},
+		{
+			desc:      "early close",
+			rng:       "1d",
+			now:       time.Date(2018, time.November, 23, 18, 0, 0, 0, loc),
+			wantCount: 210,
+			wantFirst: "2018-11-23 09:30",
+			wantLast:  "2018-11-23 12:59",
+		},
+		{
+			desc:      "previous day on holidays",
+			rng:       "1d",
+			now:       time.Date(2018, time.November, 22, 12, 0, 0, 0, loc),
+			wantCount: 390,
+			wantFirst: "2018-11-21 09:30",
+			wantLast:  "2018-11-21 15:59",
+		},
+		{
+			desc:      "friday on weekends",
+			rng:       "2y",
+			now:       time.Date(2018, time.October, 14, 12, 0, 0, 0, loc),
+			wantCount: 504,
+			wantFirst: "2016-10-13",
+			wantLast:  "2018-10-12",
+		},
+		{
+			desc:      "since epoch",
+			rng:       "max",
+			now:       fakeNow,
+			wantFirst: "2000-01-03",
+			wantLast:  "2018-10-11",
+		},
+	} {
+		t.Run(tt.desc, func(t *testing.T) {
+			ps, err := newChartPoints("AAPL", tt.rng, tt.now)
+			if err != nil {
+				t.Fatalf("newChartPoints should not return an error: %v", err)
+			}
+
+			if tt.wantCount != 0 {
+				if diff := cmp.Diff(tt.wantCount, len(ps)); diff != "" {
+					t.Errorf("count diff (-want, +got)\n%s", diff)
+				}
+			}
+
+			date := func(p *chartPoint) string {
+				if p.Minute != "" {
+					return p.Date + " " + p.Minute
+				}
+				return p.Date
+			}
+
+			if diff := cmp.Diff(tt.wantFirst, date(ps[0])); diff != "" {
+				t.Errorf("first diff (-want, +got)\n%s", diff)
+			}
+
+			if diff := cmp.Diff(tt.wantLast, date(ps[len(ps)-1])); diff != "" {
+				t.Errorf("last diff (-want, +got)\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestNewChartPoints_SamePricesForEveryRange(t *testing.T) {
+	twoYears, err := newChartPoints("AAPL", "2y", fakeNow)
+	if err != nil {
+		t.Fatalf("newChartPoints should not return an error: %v", err)
+	}
+
+	fiveYears, err := newChartPoints("AAPL", "5y", fakeNow)
+	if err != nil {
+		t.Fatalf("newChartPoints should not return an error: %v", err)
+	}
+
+	if diff := cmp.Diff(twoYears, fiveYears[len(fiveYears)-len(twoYears):]); diff != "" {
+		t.Errorf("diff (-want, +got)\n%s", diff)
+	}
+
+	msft, err := newChartPoints("MSFT", "2y", fakeNow)
+	if err != nil {
+		t.Fatalf("newChartPoints should not return an error: %v", err)
+	}
+
+	if cmp.Equal(twoYears, msft) {
+		t.Errorf("symbols should have different prices")
+	}
+}
+
+func TestServer_ChartLast(t *testing.T) {
+	ts := httptest.NewServer(NewServer(Now(func() time.Time { return fakeNow })))
+	defer ts.Close()
+
+	resp, err := http.Get(ts.URL + "/stock/market/batch?token=" + Token + "&symbols=AAPL,MSFT&types=chart&range=5y&chartLast=3")
+	if err != nil {
+		t.Fatalf("http.Get: %v", err)
+	}
+	defer resp.Body.Close()
+
+	var got map[string]struct {
+		Chart []*chartPoint `json:"chart"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&got); err != nil {
+		t.Fatalf("json.Decode: %v", err)
+	}
+
+	for _, sym := range []string{"AAPL", "MSFT"} {
+		if diff := cmp.Diff(3, len(got[sym].Chart)); diff != "" {
+			t.Errorf("%s point count diff (-want, +got)\n%s", sym, diff)
+		}
+	}
+}
+
+func TestServer_Unauthorized(t *testing.T) {
+	ts := httptest.NewServer(NewServer())
+	defer ts.Close()
+
+	resp, err := http.Get(ts.URL + "/stock/market/batch?symbols=AAPL&types=quote")
+	if err != nil {
+		t.Fatalf("http.Get: %v", err)
+	}
+	resp.Body.Close()
+
+	if diff := cmp.Diff(http.StatusUnauthorized, resp.StatusCode); diff != "" {
+		t.Errorf("diff (-want, +got)\n%s", diff)
+	}
+}
+
+func TestServer_FailureRate(t *testing.T) {
+	for _, tt := range []struct {
+		desc         string
+		status       int
+		wantErr      error
+		wantRequests int
+	}{
+		{
+			desc:         "server errors are retried",
+			status:       http.StatusServiceUnavailable,
+			wantRequests: 2,
+		},
+		{
+			desc:         "throttled requests are retried",
+			status:       http.StatusTooManyRequests,
+			wantErr:      iex.ErrThrottled,
+			wantRequests: 2,
+		},
+		{
+			desc:         "unauthorized requests are not retried",
+			status:       http.StatusForbidden,
+			wantErr:      iex.ErrUnauthorized,
+			wantRequests: 1,
+		},
+	} {
+		t.Run(tt.desc, func(t *testing.T) {
+			s := NewServer(FailureRate(1, tt.status), Seed(1))
+			ts := httptest.NewServer(s)
+			defer ts.Close()
+
+			c := newTestClient(ts, iex.MaxRetries(1))
+
+			_, err := c.GetQuotes(context.Background(), &iex.GetQuotesRequest{Token: Token, Symbols: []string{"AAPL"}})
+
+			var se *iex.StatusError
+			if !errors.As(err, &se) {
+				t.Fatalf("GetQuotes should return a StatusError, got: %v", err)
+			}
+
+			if diff := cmp.Diff(tt.status, se.StatusCode); diff != "" {
+				t.Errorf("status diff (-want, +got)\n%s", diff)
+			}
+
+			if tt.wantErr != nil && !errors.Is(err, tt.wantErr) {
+				t.Errorf("got error: %v, want error wrapping: %v", err, tt.wantErr)
+			}
+
+			if diff := cmp.Diff(tt.wantRequests, s.RequestCount()); diff != "" {
+				t.Errorf("request count diff (-want, +got)\n%s", diff)
+			}
+
+			s.SetFailureRate(0, 0)
+
+			if _, err := c.GetQuotes(context.Background(), &iex.GetQuotesRequest{Token: Token, Symbols: []string{"AAPL"}}); err != nil {
+				t.Errorf("GetQuotes should not return an error after failures stop: %v", err)
+			}
+		})
+	}
+}
+
+func TestServer_Latency(t *testing.T) {
+	// Record the latencies instead of waiting, so the test doesn't depend on how fast responses are.
+	var mu sync.Mutex
+	var got []time.Duration
+	var block bool
+	sleep := func(ctx context.Context, d time.Duration) error {
+		mu.Lock()
+		got = append(got, d)
+		b := block
+		mu.Unlock()
+
+		if b {
+			<-ctx.Done()
+			return ctx.Err()
+		}
+		return nil
+	}
+
+	s := NewServer(Latency(time.Second), Sleep(sleep))
+	ts := httptest.NewServer(s)
+	defer ts.Close()
+
+	c := newTestClient(ts, iex.MaxRetries(0))
+
+	if _, err := c.GetQuotes(context.Background(), &iex.GetQuotesRequest{Token: Token, Symbols: []string{"AAPL"}}); err != nil {
+		t.Errorf("GetQuotes should not return an error with latency: %v", err)
+	}
+
+	s.SetLatency(0)
+
+	if _, err := c.GetQuotes(context.Background(), &iex.GetQuotesRequest{Token: Token, Symbols: []string{"AAPL"}}); err != nil {
+		t.Errorf("GetQuotes should not return an error without latency: %v", err)
+	}
+
+	mu.Lock()
+	if diff := cmp.Diff([]time.Duration{time.Second}, got); diff != "" {
+		t.Errorf("diff (-want, +got)\n%s", diff)
+	}
+	block = true
+	mu.Unlock()
+
+	s.SetLatency(time.Hour)
+
+	tc := newTestClient(ts, iex.RequestTimeout(10*time.Millisecond), iex.MaxRetries(0))
+	if _, err := tc.GetQuotes(context.Background(), &iex.GetQuotesRequest{Token: Token, Symbols: []string{"AAPL"}}); err == nil {
+		t.Error("GetQuotes should time out while the server waits")
+	}
+}
+
+func TestDailyPoints_Dividends(t *testing.T) {
+	ps := dailyPoints("AAPL", lastTradingDay(fakeNow))
+
+	last := ps[len(ps)-1]
+	if diff := cmp.Diff(last.Close, last.FClose); diff != "" {
+		t.Errorf("latest point should not be adjusted, diff (-want, +got)\n%s", diff)
+	}
+
+	first := ps[0]
+	if first.FClose >= first.Close {
+		t.Errorf("first point should be adjusted for dividends, got close %v and adjusted close %v", first.Close, first.FClose)
+	}
+}
@@ -0,0 +1,178 @@
+package iex
+
+import (
+	"context"
+	"sync"
+
+	"github.com/btmura/ponzi2/internal/logger"
+	"github.com/btmura/ponzi2/internal/stock"
+)
+
+// Provider adapts a Client to the stock.Provider interface.
+type Provider struct {
+	// client makes the IEX API requests.
+	client *Client
+
+	// mu guards token.
+	mu sync.Mutex
+
+	// token is the IEX API token to be included on requests.
+	token string
+}
+
+// NewProvider returns a new Provider that uses the given client.
+// Call SetAPIToken before making requests.
+func NewProvider(client *Client) *Provider {
+	return &Provider{client: client}
+}
+
+// SetAPIToken implements the stock.APITokenSetter interface.
+func (p *Provider) SetAPIToken(token string) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.token = token
+}
+
+// apiToken returns the IEX API token to include on requests.
+func (p *Provider) apiToken() string {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.token
+}
+
+// GetQuotes implements the stock.Provider interface.
+func (p *Provider) GetQuotes(ctx context.Context, req *stock.GetQuotesRequest) ([]*stock.Quote, error) {
+	syms, iex2Symbol := iexSymbols(req.Symbols)
+	if len(syms) == 0 {
+		return nil, nil
+	}
+
+	qs, err := p.client.GetQuotes(ctx, &GetQuotesRequest{
+		Token:   p.apiToken(),
+		Symbols: syms,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	var quotes []*stock.Quote
+	for _, q := range qs {
+		sq := stockQuote(q)
+		sq.Symbol = iex2Symbol[q.Symbol]
+		if sq.Symbol == "" {
+			logger.Errorf("skipping unrequested quote: %s", q.Symbol)
+			continue
+		}
+		quotes = append(quotes, sq)
+	}
+	return quotes, nil
+}
+
+// GetDailyCharts implements the stock.Provider interface.
+func (p *Provider) GetDailyCharts(ctx context.Context, req *stock.GetChartsRequest) ([]*stock.Chart, error) {
+	switch req.Range {
+	case stock.FiveYears:
+		return p.getCharts(ctx, req, FiveYears)
+	case stock.Max:
+		return p.getCharts(ctx, req, Max)
+	default:
+		return p.getCharts(ctx, req, TwoYears)
+	}
+}
+
+// GetIntradayCharts implements the stock.Provider interface.
+func (p *Provider) GetIntradayCharts(ctx context.Context, req *stock.GetChartsRequest) ([]*stock.Chart, error) {
+	return p.getCharts(ctx, req, OneDay)
+}
+
+func (p *Provider) getCharts(ctx context.Context, req *stock.GetChartsRequest, r Range) ([]*stock.Chart, error) {
+	syms, iex2Symbol := iexSymbols(req.Symbols)
+	if len(syms) == 0 {
+		return nil, nil
+	}
+
+	chs, err := p.client.GetCharts(ctx, &GetChartsRequest{
+		Token:   p.apiToken(),
+		Symbols: syms,
+		Range:   r,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	var charts []*stock.Chart
+	for _, ch := range chs {
+		sc := stockChart(ch)
+		sc.Symbol = iex2Symbol[ch.Symbol]
+		if sc.Symbol == "" {
+			logger.Errorf("skipping unrequested chart: %s", ch.Symbol)
+			continue
+		}
+		charts = append(charts, sc)
+	}
+	return charts, nil
+}
+
+func stockQuote(q *Quote) *stock.Quote {
+	return &stock.Quote{
+		Symbol:        q.Symbol,
+		CompanyName:   q.CompanyName,
+		LatestPrice:   q.LatestPrice,
+		LatestSource:  stockSource(q.LatestSource),
+		LatestTime:    q.LatestTime,
+		LatestUpdate:  q.LatestUpdate,
+		LatestVolume:  q.LatestVolume,
+		Open:          q.Open,
+		High:          q.High,
+		Low:           q.Low,
+		Close:         q.Close,
+		Change:        q.Change,
+		ChangePercent: q.ChangePercent,
+	}
+}
+
+func stockSource(src Source) stock.Source {
+	switch src {
+	case SourceUnspecified:
+		return stock.SourceUnspecified
+	case RealTimePrice:
+		return stock.RealTimePrice
+	case FifteenMinuteDelayedPrice:
+		return stock.FifteenMinuteDelayedPrice
+	case Close:
+		return stock.Close
+	case PreviousClose:
+		return stock.PreviousClose
+	case Price:
+		return stock.Price
+	case LastTrade:
+		return stock.LastTrade
+	default:
+		logger.Errorf("unrecognized iex source: %v", src)
+		return stock.SourceUnspecified
+	}
+}
+
+func stockChart(ch *Chart) *stock.Chart {
+	var bars []*stock.Bar
+	for _, p := range ch.ChartPoints {
+		bars = append(bars, &stock.Bar{
+			Date:             p.Date,
+			Open:             p.Open,
+			High:             p.High,
+			Low:              p.Low,
+			Close:            p.Close,
+			Volume:           p.Volume,
+			Change:           p.Change,
+			ChangePercent:    p.ChangePercent,
+			TotalReturnOpen:  p.TotalReturnOpen,
+			TotalReturnHigh:  p.TotalReturnHigh,
+			TotalReturnLow:   p.TotalReturnLow,
+			TotalReturnClose: p.TotalReturnClose,
+		})
+	}
+	return &stock.Chart{
+		Symbol: ch.Symbol,
+		Bars:   bars,
+	}
+}
@@ -0,0 +1,221 @@
+package iex
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+
+	"github.com/btmura/ponzi2/internal/stock"
+)
+
+func TestProviderGetQuotes(t *testing.T) {
+	old := now
+	defer func() { now = old }()
+	now = func() time.Time { return time.Date(2018, time.October, 11, 0, 0, 0, 0, loc) }
+
+	ft := &fakeTransport{
+		responses: []fakeResponse{
+			{status: 200, body: `{
+				"SHOP-CT": {"quote":{"companyName":"Shopify Inc.","latestPrice":150.5,"latestSource":"IEX real time price","latestTime":"12:45:40 PM","latestUpdate":1538153140524,"latestVolume":1000,"open":149,"high":151,"low":148,"close":147,"change":3.5,"changePercent":0.0238}},
+				"XYZ": {"quote":{"companyName":"Unrequested","latestPrice":1,"latestSource":"Close","latestTime":"September 28, 2018"}}
+			}`},
+		},
+	}
+	p := NewProvider(NewClient(new(NoOpChartCache), HTTPTransport(ft), RateLimit(0, 0)))
+	p.SetAPIToken("abc")
+
+	got, err := p.GetQuotes(context.Background(), &stock.GetQuotesRequest{Symbols: []string{"SHOP.TO", "7203.T"}})
+	if err != nil {
+		t.Fatalf("GetQuotes should not return an error: %v", err)
+	}
+
+	want := []*stock.Quote{
+		{
+			Symbol:        "SHOP.TO",
+			CompanyName:   "Shopify Inc.",
+			LatestPrice:   150.5,
+			LatestSource:  stock.RealTimePrice,
+			LatestTime:    time.Date(2018, time.October, 11, 12, 45, 40, 0, loc),
+			LatestUpdate:  time.Unix(1538153140, 524000000),
+			LatestVolume:  1000,
+			Open:          149,
+			High:          151,
+			Low:           148,
+			Close:         147,
+			Change:        3.5,
+			ChangePercent: 0.0238,
+		},
+	}
+
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("diff (-want, +got)\n%s", diff)
+	}
+
+	// Unsupported symbols are dropped and the rest use the IEX spelling.
+	q := ft.requests[0].URL.Query()
+	if diff := cmp.Diff("SHOP-CT", q.Get("symbols")); diff != "" {
+		t.Errorf("symbols diff (-want, +got)\n%s", diff)
+	}
+	if diff := cmp.Diff("abc", q.Get("token")); diff != "" {
+		t.Errorf("token diff (-want, +got)\n%s", diff)
+	}
+}
+
+func TestProviderGetQuotes_NoSupportedSymbols(t *testing.T) {
+	ft := &fakeTransport{responses: []fakeResponse{{status: 200, body: `{}`}}}
+	p := NewProvider(NewClient(new(NoOpChartCache), HTTPTransport(ft), RateLimit(0, 0)))
+	p.SetAPIToken("abc")
+
+	got, err := p.GetQuotes(context.Background(), &stock.GetQuotesRequest{Symbols: []string{"7203.T"}})
+	if err != nil {
+		t.Fatalf("GetQuotes should not return an error: %v", err)
+	}
+
+	if got != nil {
+		t.Errorf("got %v, want nil", got)
+	}
+
+	if n := ft.requestCount(); n != 0 {
+		t.Errorf("got %d requests, want none", n)
+	}
+}
+
+func TestProviderGetCharts(t *testing.T) {
+	old := now
+	defer func() { now = old }()
+	now = func() time.Time { return time.Date(2018, time.October, 11, 0, 0, 0, 0, loc) }
+
+	dailyBody := `{
+		"SHOP-CT": {"chart": [{"date":"2018-10-10","open":1,"high":2,"low":0.5,"close":1.5,"volume":100,"change":0.5,"changePercent":50,"fOpen":0.9,"fHigh":1.8,"fLow":0.45,"fClose":1.35}]}
+	}`
+
+	dailyBars := []*stock.Bar{
+		{
+			Date:             time.Date(2018, time.October, 10, 0, 0, 0, 0, loc),
+			Open:             1,
+			High:             2,
+			Low:              0.5,
+			Close:            1.5,
+			Volume:           100,
+			Change:           0.5,
+			ChangePercent:    50,
+			TotalReturnOpen:  0.9,
+			TotalReturnHigh:  1.8,
+			TotalReturnLow:   0.45,
+			TotalReturnClose: 1.35,
+		},
+	}
+
+	for _, tt := range []struct {
+		desc      string
+		get       func(*Provider, context.Context, *stock.GetChartsRequest) ([]*stock.Chart, error)
+		rng       stock.Range
+		body      string
+		wantRange string
+		want      []*stock.Chart
+	}{
+		{
+			desc:      "daily with unspecified range",
+			get:       (*Provider).GetDailyCharts,
+			body:      dailyBody,
+			wantRange: "2y",
+			want:      []*stock.Chart{{Symbol: "SHOP.TO", Bars: dailyBars}},
+		},
+		{
+			desc:      "daily with two years",
+			get:       (*Provider).GetDailyCharts,
+			rng:       stock.TwoYears,
+			body:      dailyBody,
+			wantRange: "2y",
+			want:      []*stock.Chart{{Symbol: "SHOP.TO", Bars: dailyBars}},
+		},
+		{
+			desc:      "daily with five years",
+			get:       (*Provider).GetDailyCharts,
+			rng:       stock.FiveYears,
+			body:      dailyBody,
+			wantRange: "5y",
+			want:      []*stock.Chart{{Symbol: "SHOP.TO", Bars: dailyBars}},
+		},
+		{
+			desc:      "daily with max",
+			get:       (*Provider).GetDailyCharts,
+			rng:       stock.Max,
+			body:      dailyBody,
+			wantRange: "max",
+			want:      []*stock.Chart{{Symbol: "SHOP.TO", Bars: dailyBars}},
+		},
+		{
+			desc: "intraday ignores range",
+			get:  (*Provider).GetIntradayCharts,
+			rng:  stock.Max,
+			body: `{
+				"SHOP-CT": {"chart": [{"date":"2018-10-10","minute":"15:59","open":1,"high":2,"low":0.5,"close":1.5,"volume":100}]}
+			}`,
+			wantRange: "1d",
+			want: []*stock.Chart{
+				{
+					Symbol: "SHOP.TO",
+					Bars: []*stock.Bar{
+						{
+							Date:   time.Date(2018, time.October, 10, 15, 59, 0, 0, loc),
+							Open:   1,
+							High:   2,
+							Low:    0.5,
+							Close:  1.5,
+							Volume: 100,
+						},
+					},
+				},
+			},
+		},
+	} {
+		t.Run(tt.desc, func(t *testing.T) {
+			ft := &fakeTransport{responses: []fakeResponse{{status: 200, body: tt.body}}}
+			p := NewProvider(NewClient(new(NoOpChartCache), HTTPTransport(ft), RateLimit(0, 0)))
+			p.SetAPIToken("abc")
+
+			got, err := tt.get(p, context.Background(), &stock.GetChartsRequest{
+				Symbols: []string{"SHOP.TO", "7203.T"},
+				Range:   tt.rng,
+			})
+			if err != nil {
+				t.Fatalf("get should not return an error: %v", err)
+			}
+
+			if diff := cmp.Diff(tt.want, got); diff != "" {
+				t.Errorf("diff (-want, +got)\n%s", diff)
+			}
+
+			q := ft.requests[0].URL.Query()
+			if diff := cmp.Diff(tt.wantRange, q.Get("range")); diff != "" {
+				t.Errorf("range diff (-want, +got)\n%s", diff)
+			}
+			if diff := cmp.Diff("SHOP-CT", q.Get("symbols")); diff != "" {
+				t.Errorf("symbols diff (-want, +got)\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestStockSource(t *testing.T) {
+	for _, tt := range []struct {
+		input Source
+		want  stock.Source
+	}{
+		{SourceUnspecified, stock.SourceUnspecified},
+		{RealTimePrice, stock.RealTimePrice},
+		{FifteenMinuteDelayedPrice, stock.FifteenMinuteDelayedPrice},
+		{Close, stock.Close},
+		{PreviousClose, stock.PreviousClose},
+		{Price, stock.Price},
+		{LastTrade, stock.LastTrade},
+		{Source(-1), stock.SourceUnspecified},
+	} {
+		if diff := cmp.Diff(tt.want, stockSource(tt.input)); diff != "" {
+			t.Errorf("stockSource(%v) diff (-want, +got)\n%s", tt.input, diff)
+		}
+	}
+}
@@ -4,17 +4,13 @@ import (
 	"bytes"
 	"context"
 	"encoding/json"
-	"fmt"
 	"io"
 	"io/ioutil"
-	"net/http"
 	"net/url"
-	"sort"
 	"strings"
 	"time"
 
 	"github.com/btmura/ponzi2/internal/errs"
-	"github.com/btmura/ponzi2/internal/logger"
 )
 
 // Quote is a stock quote.
@@ -66,7 +62,7 @@ type GetQuotesRequest struct {
 
 // GetQuotes gets quotes for stock symbols.
 func (c *Client) GetQuotes(ctx context.Context, req *GetQuotesRequest) ([]*Quote, error) {
-	if req.Token == "" {
+	if req.Token == "" && !c.replaying {
 		return nil, ErrMissingAPIToken
 	}
 
@@ -74,7 +70,7 @@ func (c *Client) GetQuotes(ctx context.Context, req *GetQuotesRequest) ([]*Quote
 		return nil, nil
 	}
 
-	u, err := url.Parse("https://cloud.iexapis.com/stable/stock/market/batch")
+	u, err := c.batchURL()
 	if err != nil {
 		return nil, err
 	}
@@ -99,35 +95,12 @@ func (c *Client) GetQuotes(ctx context.Context, req *GetQuotesRequest) ([]*Quote
 	}, ","))
 	u.RawQuery = v.Encode()
 
-	httpReq, err := http.NewRequest(http.MethodGet, u.String(), nil)
+	body, err := c.get(ctx, u)
 	if err != nil {
 		return nil, err
 	}
 
-	httpResp, err := http.DefaultClient.Do(httpReq.WithContext(ctx))
-	if err != nil {
-		return nil, err
-	}
-	defer func() {
-		if err := httpResp.Body.Close(); err != nil {
-			logger.Error(err)
-		}
-	}()
-
-	r := httpResp.Body
-	if c.dumpAPIResponses {
-		ss := make([]string, len(req.Symbols))
-		copy(ss, req.Symbols)
-		sort.Strings(ss)
-
-		rr, err := dumpResponse(fmt.Sprintf("iex-quote-%s.txt", strings.Join(ss, "-")), r)
-		if err != nil {
-			return nil, errs.Errorf("iex: failed to dump quote resp: %v", err)
-		}
-		r = rr
-	}
-
-	quotes, err := decodeQuotes(r)
+	quotes, err := decodeQuotes(bytes.NewReader(body))
 	if err != nil {
 		return nil, errs.Errorf("iex: failed to decode quote resp: %v", err)
 	}
@@ -11,11 +11,13 @@ func _() {
 	_ = x[RangeUnspecified-0]
 	_ = x[OneDay-1]
 	_ = x[TwoYears-2]
+	_ = x[FiveYears-3]
+	_ = x[Max-4]
 }
 
-const _Range_name = "RangeUnspecifiedOneDayTwoYears"
+const _Range_name = "RangeUnspecifiedOneDayTwoYearsFiveYearsMax"
 
-var _Range_index = [...]uint8{0, 16, 22, 30}
+var _Range_index = [...]uint8{0, 16, 22, 30, 39, 42}
 
 func (i Range) String() string {
 	if i < 0 || i >= Range(len(_Range_index)-1) {
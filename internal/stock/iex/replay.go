@@ -0,0 +1,292 @@
+package iex
+
+import (
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/btmura/ponzi2/internal/errs"
+	"github.com/btmura/ponzi2/internal/logger"
+)
+
+// Recordings are saved one file per endpoint, range, and symbol like
+// quote/AAPL.json or chart-5y/AAPL.json, so that replays don't depend
+// on how the app batches symbols into requests.
+
+// DefaultRecordDir is the directory that the deprecated flags to dump API responses record them to.
+const DefaultRecordDir = "iex-responses"
+
+// replayRanges are the chart ranges that can serve requests for each range.
+// Wider ranges have all the points of narrower ones.
+var replayRanges = map[string][]string{
+	"1d":  {"1d"},
+	"2y":  {"2y", "5y", "max"},
+	"5y":  {"5y", "max"},
+	"max": {"max"},
+}
+
+// RecordDir returns an option to save the responses of successful requests to the directory,
+// so that ReplayDir can serve them later. Chart requests get their whole range instead of only
+// the last points, so that replays can serve requests for any number of last points.
+// Pass it after HTTPTransport to record the responses of that transport.
+func RecordDir(dirPath string) ClientOption {
+	return func(c *Client) {
+		base := c.httpClient.Transport
+		if base == nil {
+			base = http.DefaultTransport
+		}
+		c.httpClient = &http.Client{Transport: &recordTransport{dirPath: dirPath, base: base}}
+	}
+}
+
+// ReplayDir returns an option to serve requests from responses saved with RecordDir
+// instead of the network. Requests don't need API tokens and aren't rate limited.
+func ReplayDir(dirPath string) ClientOption {
+	return func(c *Client) {
+		c.httpClient = &http.Client{Transport: &replayTransport{dirPath: dirPath}}
+		c.limiter = nil
+		c.replaying = true
+	}
+}
+
+// recordTransport is an http.RoundTripper that saves batch responses per symbol.
+type recordTransport struct {
+	// dirPath is the directory to save the responses to.
+	dirPath string
+
+	// base makes the requests.
+	base http.RoundTripper
+}
+
+// RoundTrip implements the http.RoundTripper interface.
+func (t *recordTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	v := req.URL.Query()
+	if v.Get("chartLast") != "" {
+		v.Del("chartLast")
+		u := *req.URL
+		u.RawQuery = v.Encode()
+		req = req.Clone(req.Context())
+		req.URL = &u
+	}
+
+	resp, err := t.base.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		return resp, nil
+	}
+
+	body, err := ioutil.ReadAll(resp.Body)
+	resp.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	resp.Body = ioutil.NopCloser(bytes.NewReader(body))
+
+	if err := t.save(v, body); err != nil {
+		logger.Errorf("recording response failed: %v", err)
+	}
+
+	return resp, nil
+}
+
+// save saves each symbol's part of the batch response.
+func (t *recordTransport) save(v url.Values, body []byte) error {
+	dirPath, err := replayEndpointDir(t.dirPath, v)
+	if err != nil {
+		return err
+	}
+
+	var symbol2Data map[string]json.RawMessage
+	if err := json.Unmarshal(body, &symbol2Data); err != nil {
+		return errs.Errorf("decoding batch response failed: %v", err)
+	}
+
+	if err := os.MkdirAll(dirPath, 0755); err != nil {
+		return err
+	}
+
+	for sym, data := range symbol2Data {
+		if err := writeFileAtomically(replaySymbolPath(dirPath, sym), data); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// replayTransport is an http.RoundTripper that serves batch requests from recorded responses.
+type replayTransport struct {
+	// dirPath is the directory with the recorded responses.
+	dirPath string
+}
+
+// RoundTrip implements the http.RoundTripper interface.
+func (t *replayTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	v := req.URL.Query()
+
+	chartLast := 0
+	if s := v.Get("chartLast"); s != "" {
+		n, err := strconv.Atoi(s)
+		if err != nil {
+			return replayResponse(req, http.StatusBadRequest, []byte(err.Error())), nil
+		}
+		chartLast = n
+	}
+
+	dirPaths, err := replayLookupDirs(t.dirPath, v)
+	if err != nil {
+		return replayResponse(req, http.StatusNotFound, []byte(err.Error())), nil
+	}
+
+	// Leave out symbols without recordings like IEX does for unknown symbols.
+	symbol2Data := map[string]json.RawMessage{}
+	for _, sym := range strings.Split(v.Get("symbols"), ",") {
+		if sym == "" {
+			continue
+		}
+
+		data, err := readReplayData(dirPaths, sym)
+		if err != nil {
+			return nil, err
+		}
+		if data == nil {
+			logger.Infof("no recording for %s in %s", sym, t.dirPath)
+			continue
+		}
+
+		if chartLast > 0 {
+			if data, err = lastChartPoints(data, chartLast); err != nil {
+				return nil, err
+			}
+		}
+
+		symbol2Data[sym] = data
+	}
+
+	body, err := json.Marshal(symbol2Data)
+	if err != nil {
+		return nil, err
+	}
+
+	cacheClientVar.Add("replayed-requests", 1)
+
+	return replayResponse(req, http.StatusOK, body), nil
+}
+
+// replayEndpointDir returns the directory to record responses for the request parameters.
+func replayEndpointDir(dirPath string, v url.Values) (string, error) {
+	switch types := v.Get("types"); types {
+	case "quote":
+		return filepath.Join(dirPath, types), nil
+
+	case "chart":
+		r := v.Get("range")
+		if _, ok := replayRanges[r]; !ok {
+			return "", errs.Errorf("unsupported range: %q", r)
+		}
+		return filepath.Join(dirPath, types+"-"+r), nil
+
+	default:
+		return "", errs.Errorf("unsupported types: %q", types)
+	}
+}
+
+// replayLookupDirs returns the directories to look for recorded responses in the order to check them.
+func replayLookupDirs(dirPath string, v url.Values) ([]string, error) {
+	if v.Get("types") != "chart" {
+		d, err := replayEndpointDir(dirPath, v)
+		if err != nil {
+			return nil, err
+		}
+		return []string{d}, nil
+	}
+
+	rs, ok := replayRanges[v.Get("range")]
+	if !ok {
+		return nil, errs.Errorf("unsupported range: %q", v.Get("range"))
+	}
+
+	var ds []string
+	for _, r := range rs {
+		ds = append(ds, filepath.Join(dirPath, "chart-"+r))
+	}
+	return ds, nil
+}
+
+// readReplayData returns the first recorded response for the symbol in the directories.
+// It returns nil if there is none.
+func readReplayData(dirPaths []string, symbol string) (json.RawMessage, error) {
+	for _, d := range dirPaths {
+		data, err := ioutil.ReadFile(replaySymbolPath(d, symbol))
+		if os.IsNotExist(err) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		return data, nil
+	}
+	return nil, nil
+}
+
+// lastChartPoints returns the symbol's response with only the last points of its chart.
+func lastChartPoints(data json.RawMessage, last int) (json.RawMessage, error) {
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return nil, errs.Errorf("decoding recorded response failed: %v", err)
+	}
+
+	var points []json.RawMessage
+	if err := json.Unmarshal(fields["chart"], &points); err != nil {
+		return nil, errs.Errorf("decoding recorded chart failed: %v", err)
+	}
+
+	if len(points) > last {
+		points = points[len(points)-last:]
+	}
+
+	b, err := json.Marshal(points)
+	if err != nil {
+		return nil, err
+	}
+	fields["chart"] = b
+
+	return json.Marshal(fields)
+}
+
+// replaySymbolPath returns the path of the symbol's recorded response in the directory.
+func replaySymbolPath(dirPath, symbol string) string {
+	return filepath.Join(dirPath, url.PathEscape(symbol)+".json")
+}
+
+func replayResponse(req *http.Request, status int, body []byte) *http.Response {
+	return &http.Response{
+		Status:        strconv.Itoa(status) + " " + http.StatusText(status),
+		StatusCode:    status,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        http.Header{"Content-Type": []string{"application/json"}},
+		Body:          ioutil.NopCloser(bytes.NewReader(body)),
+		ContentLength: int64(len(body)),
+		Request:       req,
+	}
+}
+
+// writeFileAtomically writes the data to a temporary file and renames it to the path.
+func writeFileAtomically(path string, data []byte) error {
+	tmpPath := path + ".tmp"
+	if err := ioutil.WriteFile(tmpPath, data, 0644); err != nil {
+		return err
+	}
+	return os.Rename(tmpPath, path)
+}
@@ -0,0 +1,136 @@
+package iex
+
+import (
+	"context"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestRecordAndReplay(t *testing.T) {
+	old := now
+	defer func() { now = old }()
+	now = func() time.Time { return time.Date(2018, time.October, 11, 0, 0, 0, 0, loc) }
+
+	dir := tempDir(t)
+	defer os.RemoveAll(dir)
+
+	ft := &fakeTransport{
+		responses: []fakeResponse{
+			{status: 200, body: `{"AAPL": {"quote": {"symbol":"AAPL","companyName":"Apple Inc.","latestPrice":1.5}}}`},
+			{status: 200, body: `{"AAPL": {"chart": [
+				{"date":"2018-10-08","open":1,"high":2,"low":0.5,"close":1,"volume":100},
+				{"date":"2018-10-09","open":1,"high":2,"low":0.5,"close":2,"volume":200},
+				{"date":"2018-10-10","open":1,"high":2,"low":0.5,"close":3,"volume":300}
+			]}}`},
+		},
+	}
+
+	rc := NewClient(new(NoOpChartCache),
+		HTTPTransport(ft),
+		RecordDir(dir),
+		RateLimit(0, 0))
+
+	if _, err := rc.GetQuotes(context.Background(), &GetQuotesRequest{Token: "abc", Symbols: []string{"AAPL"}}); err != nil {
+		t.Fatalf("GetQuotes should not return an error: %v", err)
+	}
+
+	if _, err := rc.noCacheGetCharts(context.Background(), &GetChartsRequest{Token: "abc", Symbols: []string{"AAPL"}, Range: FiveYears, ChartLast: 2}); err != nil {
+		t.Fatalf("GetCharts should not return an error: %v", err)
+	}
+
+	if diff := cmp.Diff("", ft.requests[len(ft.requests)-1].URL.Query().Get("chartLast")); diff != "" {
+		t.Errorf("recorded requests should ask for the whole range, diff (-want, +got)\n%s", diff)
+	}
+
+	c := NewClient(new(NoOpChartCache), ReplayDir(dir))
+
+	t.Run("quotes without token", func(t *testing.T) {
+		got, err := c.GetQuotes(context.Background(), &GetQuotesRequest{Symbols: []string{"AAPL", "MSFT"}})
+		if err != nil {
+			t.Fatalf("GetQuotes should not return an error: %v", err)
+		}
+
+		var gotPrices []float32
+		for _, q := range got {
+			gotPrices = append(gotPrices, q.LatestPrice)
+		}
+
+		if diff := cmp.Diff([]float32{1.5}, gotPrices); diff != "" {
+			t.Errorf("diff (-want, +got)\n%s", diff)
+		}
+	})
+
+	for _, tt := range []struct {
+		desc       string
+		req        *GetChartsRequest
+		wantCloses []float32
+	}{
+		{
+			desc:       "whole range",
+			req:        &GetChartsRequest{Symbols: []string{"AAPL"}, Range: FiveYears},
+			wantCloses: []float32{1, 2, 3},
+		},
+		{
+			desc:       "last points",
+			req:        &GetChartsRequest{Symbols: []string{"AAPL"}, Range: FiveYears, ChartLast: 2},
+			wantCloses: []float32{2, 3},
+		},
+		{
+			desc:       "narrower range from wider recording",
+			req:        &GetChartsRequest{Symbols: []string{"AAPL"}, Range: TwoYears, ChartLast: 1},
+			wantCloses: []float32{3},
+		},
+		{
+			desc: "wider range not recorded",
+			req:  &GetChartsRequest{Symbols: []string{"AAPL"}, Range: Max},
+		},
+		{
+			desc: "missing symbol",
+			req:  &GetChartsRequest{Symbols: []string{"MSFT"}, Range: FiveYears},
+		},
+	} {
+		t.Run(tt.desc, func(t *testing.T) {
+			got, err := c.noCacheGetCharts(context.Background(), tt.req)
+			if err != nil {
+				t.Fatalf("GetCharts should not return an error: %v", err)
+			}
+
+			var gotCloses []float32
+			for _, ch := range got {
+				for _, p := range ch.ChartPoints {
+					gotCloses = append(gotCloses, p.Close)
+				}
+			}
+
+			if diff := cmp.Diff(tt.wantCloses, gotCloses); diff != "" {
+				t.Errorf("diff (-want, +got)\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestReplayTransport_UnsupportedRequest(t *testing.T) {
+	req, err := http.NewRequest(http.MethodGet, "https://cloud.iexapis.com/stable/stock/market/batch?symbols=AAPL&types=news", nil)
+	if err != nil {
+		t.Fatalf("http.NewRequest: %v", err)
+	}
+
+	resp, err := (&replayTransport{dirPath: "testdata"}).RoundTrip(req)
+	if err != nil {
+		t.Fatalf("RoundTrip should not return an error: %v", err)
+	}
+	defer resp.Body.Close()
+
+	if diff := cmp.Diff(http.StatusNotFound, resp.StatusCode); diff != "" {
+		t.Errorf("diff (-want, +got)\n%s", diff)
+	}
+
+	if _, err := ioutil.ReadAll(resp.Body); err != nil {
+		t.Errorf("ioutil.ReadAll: %v", err)
+	}
+}
@@ -0,0 +1,92 @@
+package iex
+
+import (
+	"strings"
+
+	"github.com/btmura/ponzi2/internal/errs"
+	"github.com/btmura/ponzi2/internal/logger"
+	"github.com/btmura/ponzi2/internal/stock"
+)
+
+// exchangeSuffixes maps the exchange suffixes of stock.Symbol to the ones IEX uses.
+// Exchanges that IEX does not cover are missing.
+var exchangeSuffixes = map[string]string{
+	"CN": "CF",
+	"L":  "LN",
+	"NE": "CN",
+	"TO": "CT",
+	"V":  "CV",
+}
+
+// iexSymbol returns the IEX spelling of a symbol in stock.Symbol form like SHOP-CT for SHOP.TO.
+// IEX separates share classes with a dot like BRK.B and exchanges with a dash.
+func iexSymbol(s string) (string, error) {
+	sym, err := stock.ParseSymbol(s)
+	if err != nil {
+		return "", err
+	}
+
+	str := sym.Base
+	if sym.Class != "" {
+		str += "." + sym.Class
+	}
+
+	if sym.Exchange != "" {
+		suffix, ok := exchangeSuffixes[sym.Exchange]
+		if !ok {
+			return "", errs.Errorf("iex: unsupported exchange for %s", s)
+		}
+		str += "-" + suffix
+	}
+
+	return str, nil
+}
+
+// FormatSymbol returns the IEX spelling of a symbol in any form accepted by stock.ParseSymbol,
+// so that symbols typed like BRK-B or SHOP.TO match the ones in responses and the chart cache.
+func FormatSymbol(s string) (string, error) {
+	n, err := stock.NormalizeSymbol(s)
+	if err != nil {
+		return "", err
+	}
+	return iexSymbol(n)
+}
+
+// parseSymbol parses a symbol spelled by IEX like SHOP-CT or BRK.B.
+func parseSymbol(s string) (stock.Symbol, error) {
+	base, exchange := s, ""
+	if i := strings.LastIndex(s, "-"); i >= 0 {
+		suffix := s[i+1:]
+		for e, es := range exchangeSuffixes {
+			if es == suffix {
+				exchange = e
+				break
+			}
+		}
+		if exchange == "" {
+			return stock.Symbol{}, errs.Errorf("iex: unknown exchange suffix in %s", s)
+		}
+		base = s[:i]
+	}
+
+	if exchange != "" {
+		base += "." + exchange
+	}
+	return stock.ParseSymbol(base)
+}
+
+// iexSymbols returns the IEX spellings of the symbols and a map back to the original ones.
+// Symbols that IEX does not cover are logged and skipped.
+func iexSymbols(symbols []string) (iexSyms []string, iex2Symbol map[string]string) {
+	iex2Symbol = map[string]string{}
+	for _, s := range symbols {
+		is, err := iexSymbol(s)
+		if err != nil {
+			logger.Errorf("skipping symbol: %v", err)
+			continue
+		}
+		iexSyms = append(iexSyms, is)
+		iex2Symbol[is] = s
+	}
+	return iexSyms, iex2Symbol
+}
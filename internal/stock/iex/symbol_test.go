@@ -0,0 +1,110 @@
+package iex
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestIEXSymbol(t *testing.T) {
+	for _, tt := range []struct {
+		desc    string
+		input   string
+		want    string
+		wantErr bool
+	}{
+		{
+			desc:  "plain symbol",
+			input: "SPY",
+			want:  "SPY",
+		},
+		{
+			desc:  "class share",
+			input: "BRK.B",
+			want:  "BRK.B",
+		},
+		{
+			desc:  "toronto listing",
+			input: "SHOP.TO",
+			want:  "SHOP-CT",
+		},
+		{
+			desc:  "class share on toronto",
+			input: "RCI.B.TO",
+			want:  "RCI.B-CT",
+		},
+		{
+			desc:    "unsupported exchange",
+			input:   "7203.T",
+			wantErr: true,
+		},
+	} {
+		t.Run(tt.desc, func(t *testing.T) {
+			got, gotErr := iexSymbol(tt.input)
+
+			if diff := cmp.Diff(tt.want, got); diff != "" {
+				t.Errorf("diff (-want, +got)\n%s", diff)
+			}
+
+			if (gotErr != nil) != tt.wantErr {
+				t.Errorf("got error: %v, wanted err: %t", gotErr, tt.wantErr)
+			}
+
+			if gotErr != nil {
+				return
+			}
+
+			// Parsing the IEX spelling should give back the original symbol.
+			sym, err := parseSymbol(got)
+			if err != nil {
+				t.Fatalf("parseSymbol should not return an error: %v", err)
+			}
+
+			if diff := cmp.Diff(tt.input, sym.String()); diff != "" {
+				t.Errorf("diff (-want, +got)\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestFormatSymbol(t *testing.T) {
+	for _, tt := range []struct {
+		desc    string
+		input   string
+		want    string
+		wantErr bool
+	}{
+		{
+			desc:  "lower case",
+			input: "spy",
+			want:  "SPY",
+		},
+		{
+			desc:  "class share with dash",
+			input: "BRK-B",
+			want:  "BRK.B",
+		},
+		{
+			desc:  "toronto listing",
+			input: "shop.to",
+			want:  "SHOP-CT",
+		},
+		{
+			desc:    "bad symbol",
+			input:   "A B",
+			wantErr: true,
+		},
+	} {
+		t.Run(tt.desc, func(t *testing.T) {
+			got, gotErr := FormatSymbol(tt.input)
+
+			if diff := cmp.Diff(tt.want, got); diff != "" {
+				t.Errorf("diff (-want, +got)\n%s", diff)
+			}
+
+			if (gotErr != nil) != tt.wantErr {
+				t.Errorf("got error: %v, wanted err: %t", gotErr, tt.wantErr)
+			}
+		})
+	}
+}
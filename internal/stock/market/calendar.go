@@ -0,0 +1,133 @@
+// Package market provides exchange calendars with the days and hours that exchanges trade.
+package market
+
+import (
+	"time"
+
+	"github.com/btmura/ponzi2/internal/logger"
+)
+
+// Calendar is an exchange's trading days and hours in the exchange's timezone.
+// Exchanges trade on weekdays except for the holidays in their schedules.
+type Calendar struct {
+	// name is the name of the exchange like NYSE.
+	name string
+
+	// loc is the timezone of the exchange.
+	loc *time.Location
+
+	// open is the time after midnight that the exchange opens.
+	open time.Duration
+
+	// close is the time after midnight that the exchange closes.
+	close time.Duration
+
+	// earlyClose is the time after midnight that the exchange closes on early close days.
+	earlyClose time.Duration
+
+	// schedule returns the exchange's holidays and early closes in a year.
+	schedule func(year int) *Schedule
+}
+
+// Schedule is an exchange's holidays and early closes in a year.
+// Only the years, months, and days of the times are used.
+type Schedule struct {
+	// Holidays are the weekdays that the exchange is closed.
+	Holidays []time.Time
+
+	// EarlyCloses are the days that the exchange closes early.
+	EarlyCloses []time.Time
+}
+
+// NewCalendar returns a calendar for an exchange in the timezone that opens and closes
+// at the times after midnight and follows the schedule returned for each year.
+func NewCalendar(name string, loc *time.Location, open, close, earlyClose time.Duration, schedule func(year int) *Schedule) *Calendar {
+	return &Calendar{
+		name:       name,
+		loc:        loc,
+		open:       open,
+		close:      close,
+		earlyClose: earlyClose,
+		schedule:   schedule,
+	}
+}
+
+// Name returns the name of the exchange.
+func (c *Calendar) Name() string {
+	return c.name
+}
+
+// Location returns the timezone of the exchange.
+func (c *Calendar) Location() *time.Location {
+	return c.loc
+}
+
+// IsTradingDay returns true if the exchange trades on the day of the time in the exchange's timezone.
+func (c *Calendar) IsTradingDay(t time.Time) bool {
+	_, _, ok := c.Session(t)
+	return ok
+}
+
+// Session returns the open and close times of the day of the time in the exchange's timezone.
+// It returns false if the exchange doesn't trade that day.
+func (c *Calendar) Session(t time.Time) (open, close time.Time, ok bool) {
+	t = t.In(c.loc)
+	day := time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, c.loc)
+
+	if day.Weekday() == time.Saturday || day.Weekday() == time.Sunday {
+		return time.Time{}, time.Time{}, false
+	}
+
+	s := c.schedule(day.Year())
+	if containsDay(s.Holidays, day) {
+		return time.Time{}, time.Time{}, false
+	}
+
+	cls := c.close
+	if containsDay(s.EarlyCloses, day) {
+		cls = c.earlyClose
+	}
+
+	return clock(day, c.open), clock(day, cls), true
+}
+
+// IsOpen returns true if the exchange is open at the time.
+func (c *Calendar) IsOpen(t time.Time) bool {
+	open, close, ok := c.Session(t)
+	return ok && !t.Before(open) && t.Before(close)
+}
+
+// LatestSession returns the open and close times of the latest session that opened at or before the time.
+func (c *Calendar) LatestSession(t time.Time) (open, close time.Time) {
+	day := t.In(c.loc)
+	for {
+		if open, close, ok := c.Session(day); ok && !t.Before(open) {
+			return open, close
+		}
+		day = day.AddDate(0, 0, -1 /* day */)
+	}
+}
+
+// containsDay returns true if any of the times are on the same day as the day.
+func containsDay(ts []time.Time, day time.Time) bool {
+	for _, t := range ts {
+		if t.Year() == day.Year() && t.Month() == day.Month() && t.Day() == day.Day() {
+			return true
+		}
+	}
+	return false
+}
+
+// clock returns the time of day on the day. Unlike time.Add, it keeps the
+// time of day the same on days that start or end daylight saving time.
+func clock(day time.Time, d time.Duration) time.Time {
+	return time.Date(day.Year(), day.Month(), day.Day(), int(d/time.Hour), int(d%time.Hour/time.Minute), 0, 0, day.Location())
+}
+
+func mustLoadLocation(name string) *time.Location {
+	loc, err := time.LoadLocation(name)
+	if err != nil {
+		logger.Fatalf("time.LoadLocation(%s) failed: %v", name, err)
+	}
+	return loc
+}
@@ -0,0 +1,234 @@
+package market
+
+import (
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestNYSESchedule(t *testing.T) {
+	for _, tt := range []struct {
+		year            int
+		wantHolidays    []string
+		wantEarlyCloses []string
+	}{
+		{
+			year: 2019,
+			wantHolidays: []string{
+				"2019-01-01",
+				"2019-01-21",
+				"2019-02-18",
+				"2019-04-19",
+				"2019-05-27",
+				"2019-07-04",
+				"2019-09-02",
+				"2019-11-28",
+				"2019-12-25",
+			},
+			wantEarlyCloses: []string{
+				"2019-07-03",
+				"2019-12-24",
+				"2019-11-29",
+			},
+		},
+		{
+			year: 2021,
+			wantHolidays: []string{
+				"2021-01-01",
+				"2021-01-18",
+				"2021-02-15",
+				"2021-04-02",
+				"2021-05-31",
+				"2021-07-05",
+				"2021-09-06",
+				"2021-11-25",
+				"2021-12-24",
+			},
+			wantEarlyCloses: []string{
+				"2021-11-26",
+			},
+		},
+		{
+			year: 2022,
+			wantHolidays: []string{
+				// New Year's Day was on Saturday and wasn't observed on Friday.
+				"2022-01-17",
+				"2022-02-21",
+				"2022-04-15",
+				"2022-05-30",
+				"2022-06-20",
+				"2022-07-04",
+				"2022-09-05",
+				"2022-11-24",
+				"2022-12-26",
+			},
+			wantEarlyCloses: []string{
+				"2022-11-25",
+			},
+		},
+	} {
+		t.Run(strconv.Itoa(tt.year), func(t *testing.T) {
+			s := nyseSchedule(tt.year)
+
+			if diff := cmp.Diff(tt.wantHolidays, dates(s.Holidays)); diff != "" {
+				t.Errorf("holidays diff (-want, +got)\n%s", diff)
+			}
+
+			if diff := cmp.Diff(tt.wantEarlyCloses, dates(s.EarlyCloses)); diff != "" {
+				t.Errorf("early closes diff (-want, +got)\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestCalendar_Session(t *testing.T) {
+	for _, tt := range []struct {
+		desc      string
+		input     time.Time
+		wantOpen  time.Time
+		wantClose time.Time
+		wantOK    bool
+	}{
+		{
+			desc:      "regular day",
+			input:     time.Date(2019, time.October, 17, 12, 0, 0, 0, newYork),
+			wantOpen:  time.Date(2019, time.October, 17, 9, 30, 0, 0, newYork),
+			wantClose: time.Date(2019, time.October, 17, 16, 0, 0, 0, newYork),
+			wantOK:    true,
+		},
+		{
+			desc:      "early close",
+			input:     time.Date(2019, time.November, 29, 8, 0, 0, 0, newYork),
+			wantOpen:  time.Date(2019, time.November, 29, 9, 30, 0, 0, newYork),
+			wantClose: time.Date(2019, time.November, 29, 13, 0, 0, 0, newYork),
+			wantOK:    true,
+		},
+		{
+			desc:  "holiday",
+			input: time.Date(2019, time.July, 4, 12, 0, 0, 0, newYork),
+		},
+		{
+			desc:  "special closing",
+			input: time.Date(2018, time.December, 5, 12, 0, 0, 0, newYork),
+		},
+		{
+			desc:  "weekend",
+			input: time.Date(2019, time.October, 19, 12, 0, 0, 0, newYork),
+		},
+		{
+			desc:      "other timezones use the exchange's day",
+			input:     time.Date(2019, time.October, 18, 1, 0, 0, 0, time.UTC),
+			wantOpen:  time.Date(2019, time.October, 17, 9, 30, 0, 0, newYork),
+			wantClose: time.Date(2019, time.October, 17, 16, 0, 0, 0, newYork),
+			wantOK:    true,
+		},
+	} {
+		t.Run(tt.desc, func(t *testing.T) {
+			gotOpen, gotClose, gotOK := NYSE.Session(tt.input)
+
+			if diff := cmp.Diff(tt.wantOK, gotOK); diff != "" {
+				t.Errorf("ok diff (-want, +got)\n%s", diff)
+			}
+
+			if diff := cmp.Diff(tt.wantOpen, gotOpen); diff != "" {
+				t.Errorf("open diff (-want, +got)\n%s", diff)
+			}
+
+			if diff := cmp.Diff(tt.wantClose, gotClose); diff != "" {
+				t.Errorf("close diff (-want, +got)\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestCalendar_IsOpen(t *testing.T) {
+	for _, tt := range []struct {
+		desc  string
+		input time.Time
+		want  bool
+	}{
+		{
+			desc:  "before open",
+			input: time.Date(2019, time.October, 17, 9, 29, 0, 0, newYork),
+		},
+		{
+			desc:  "at open",
+			input: time.Date(2019, time.October, 17, 9, 30, 0, 0, newYork),
+			want:  true,
+		},
+		{
+			desc:  "at close",
+			input: time.Date(2019, time.October, 17, 16, 0, 0, 0, newYork),
+		},
+		{
+			desc:  "after early close",
+			input: time.Date(2019, time.December, 24, 14, 0, 0, 0, newYork),
+		},
+		{
+			desc:  "holiday",
+			input: time.Date(2019, time.December, 25, 12, 0, 0, 0, newYork),
+		},
+	} {
+		t.Run(tt.desc, func(t *testing.T) {
+			if diff := cmp.Diff(tt.want, NYSE.IsOpen(tt.input)); diff != "" {
+				t.Errorf("diff (-want, +got)\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestCalendar_LatestSession(t *testing.T) {
+	for _, tt := range []struct {
+		desc      string
+		input     time.Time
+		wantOpen  time.Time
+		wantClose time.Time
+	}{
+		{
+			desc:      "during session",
+			input:     time.Date(2019, time.October, 17, 12, 0, 0, 0, newYork),
+			wantOpen:  time.Date(2019, time.October, 17, 9, 30, 0, 0, newYork),
+			wantClose: time.Date(2019, time.October, 17, 16, 0, 0, 0, newYork),
+		},
+		{
+			desc:      "before open",
+			input:     time.Date(2019, time.October, 17, 9, 0, 0, 0, newYork),
+			wantOpen:  time.Date(2019, time.October, 16, 9, 30, 0, 0, newYork),
+			wantClose: time.Date(2019, time.October, 16, 16, 0, 0, 0, newYork),
+		},
+		{
+			desc:      "after holiday weekend",
+			input:     time.Date(2019, time.September, 3, 8, 0, 0, 0, newYork),
+			wantOpen:  time.Date(2019, time.August, 30, 9, 30, 0, 0, newYork),
+			wantClose: time.Date(2019, time.August, 30, 16, 0, 0, 0, newYork),
+		},
+		{
+			desc:      "holiday after early close",
+			input:     time.Date(2019, time.December, 25, 12, 0, 0, 0, newYork),
+			wantOpen:  time.Date(2019, time.December, 24, 9, 30, 0, 0, newYork),
+			wantClose: time.Date(2019, time.December, 24, 13, 0, 0, 0, newYork),
+		},
+	} {
+		t.Run(tt.desc, func(t *testing.T) {
+			gotOpen, gotClose := NYSE.LatestSession(tt.input)
+
+			if diff := cmp.Diff(tt.wantOpen, gotOpen); diff != "" {
+				t.Errorf("open diff (-want, +got)\n%s", diff)
+			}
+
+			if diff := cmp.Diff(tt.wantClose, gotClose); diff != "" {
+				t.Errorf("close diff (-want, +got)\n%s", diff)
+			}
+		})
+	}
+}
+
+func dates(ts []time.Time) []string {
+	var ds []string
+	for _, t := range ts {
+		ds = append(ds, t.Format("2006-01-02"))
+	}
+	return ds
+}
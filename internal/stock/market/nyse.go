@@ -0,0 +1,127 @@
+package market
+
+import "time"
+
+// NYSE is the calendar of the New York Stock Exchange. Nasdaq follows the same calendar.
+var NYSE = NewCalendar("NYSE", newYork, 9*time.Hour+30*time.Minute, 16*time.Hour, 13*time.Hour, nyseSchedule)
+
+// newYork is the timezone of the NYSE.
+var newYork = mustLoadLocation("America/New_York")
+
+// nyseSpecialClosings are the days that the NYSE closed for events besides its regular holidays.
+var nyseSpecialClosings = []struct {
+	year  int
+	month time.Month
+	day   int
+}{
+	{2001, time.September, 11}, // September 11 attacks
+	{2001, time.September, 12},
+	{2001, time.September, 13},
+	{2001, time.September, 14},
+	{2004, time.June, 11},    // Ronald Reagan's funeral
+	{2007, time.January, 2},  // Gerald Ford's funeral
+	{2012, time.October, 29}, // Hurricane Sandy
+	{2012, time.October, 30},
+	{2018, time.December, 5}, // George H.W. Bush's funeral
+	{2025, time.January, 9},  // Jimmy Carter's funeral
+}
+
+// nyseSchedule returns the NYSE's holidays and early closes in the year.
+func nyseSchedule(year int) *Schedule {
+	date := func(month time.Month, day int) time.Time {
+		return time.Date(year, month, day, 0, 0, 0, 0, newYork)
+	}
+
+	s := &Schedule{}
+
+	// Saturday holidays are observed on Friday except for New Year's Day,
+	// since the Friday would be the last trading day of the previous year.
+	if d := date(time.January, 1); d.Weekday() != time.Saturday {
+		s.Holidays = append(s.Holidays, observed(d))
+	}
+
+	if year >= 1998 {
+		s.Holidays = append(s.Holidays, nthWeekday(year, time.January, time.Monday, 3, newYork)) // Martin Luther King Jr. Day
+	}
+
+	s.Holidays = append(s.Holidays,
+		nthWeekday(year, time.February, time.Monday, 3, newYork), // Washington's Birthday
+		easter(year, newYork).AddDate(0, 0, -2),                  // Good Friday
+		lastWeekday(year, time.May, time.Monday, newYork),        // Memorial Day
+	)
+
+	if year >= 2022 {
+		s.Holidays = append(s.Holidays, observed(date(time.June, 19))) // Juneteenth
+	}
+
+	s.Holidays = append(s.Holidays,
+		observed(date(time.July, 4)),                               // Independence Day
+		nthWeekday(year, time.September, time.Monday, 1, newYork),  // Labor Day
+		nthWeekday(year, time.November, time.Thursday, 4, newYork), // Thanksgiving Day
+		observed(date(time.December, 25)),                          // Christmas Day
+	)
+
+	for _, c := range nyseSpecialClosings {
+		if c.year == year {
+			s.Holidays = append(s.Holidays, date(c.month, c.day))
+		}
+	}
+
+	// The days before Independence Day and Christmas close early unless they are weekends or holidays.
+	for _, d := range []time.Time{date(time.July, 3), date(time.December, 24)} {
+		if d.Weekday() != time.Saturday && d.Weekday() != time.Sunday && !containsDay(s.Holidays, d) {
+			s.EarlyCloses = append(s.EarlyCloses, d)
+		}
+	}
+
+	// The day after Thanksgiving closes early.
+	s.EarlyCloses = append(s.EarlyCloses, nthWeekday(year, time.November, time.Thursday, 4, newYork).AddDate(0, 0, 1))
+
+	return s
+}
+
+// observed returns the weekday that a holiday on the day is observed on.
+// Saturday holidays are observed on Friday and Sunday holidays on Monday.
+func observed(day time.Time) time.Time {
+	switch day.Weekday() {
+	case time.Saturday:
+		return day.AddDate(0, 0, -1)
+	case time.Sunday:
+		return day.AddDate(0, 0, 1)
+	default:
+		return day
+	}
+}
+
+// nthWeekday returns the nth weekday of the month like the 3rd Monday of January.
+func nthWeekday(year int, month time.Month, weekday time.Weekday, n int, loc *time.Location) time.Time {
+	d := time.Date(year, month, 1, 0, 0, 0, 0, loc)
+	offset := (int(weekday) - int(d.Weekday()) + 7) % 7
+	return d.AddDate(0, 0, offset+(n-1)*7)
+}
+
+// lastWeekday returns the last weekday of the month like the last Monday of May.
+func lastWeekday(year int, month time.Month, weekday time.Weekday, loc *time.Location) time.Time {
+	d := time.Date(year, month+1, 0, 0, 0, 0, 0, loc)
+	offset := (int(d.Weekday()) - int(weekday) + 7) % 7
+	return d.AddDate(0, 0, -offset)
+}
+
+// easter returns Easter Sunday of the year using the anonymous Gregorian algorithm.
+func easter(year int, loc *time.Location) time.Time {
+	a := year % 19
+	b := year / 100
+	c := year % 100
+	d := b / 4
+	e := b % 4
+	f := (b + 8) / 25
+	g := (b - f + 1) / 3
+	h := (19*a + b - d - g + 15) % 30
+	i := c / 4
+	k := c % 4
+	l := (32 + 2*e + 2*i - h - k) % 7
+	m := (a + 11*h + 22*l) / 451
+	month := (h + l - 7*m + 114) / 31
+	day := (h+l-7*m+114)%31 + 1
+	return time.Date(year, time.Month(month), day, 0, 0, 0, 0, loc)
+}
@@ -0,0 +1,152 @@
+// Package stock defines a provider-neutral interface to get stock data.
+package stock
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+// ErrMissingAPIToken is the error returned when a provider requires an API token but does not have one.
+var ErrMissingAPIToken = errors.New("missing API token")
+
+// ErrUnauthorized is the error returned when a provider rejects the API token.
+var ErrUnauthorized = errors.New("unauthorized")
+
+// ErrThrottled is the error returned when a provider rejects requests for exceeding its limits.
+var ErrThrottled = errors.New("too many requests")
+
+// Provider is implemented by clients that get stock data from a vendor.
+type Provider interface {
+	// GetQuotes gets quotes for stock symbols.
+	GetQuotes(ctx context.Context, req *GetQuotesRequest) ([]*Quote, error)
+
+	// GetDailyCharts gets charts with one bar per trading day.
+	GetDailyCharts(ctx context.Context, req *GetChartsRequest) ([]*Chart, error)
+
+	// GetIntradayCharts gets charts with one bar per minute of the latest trading day.
+	GetIntradayCharts(ctx context.Context, req *GetChartsRequest) ([]*Chart, error)
+}
+
+// APITokenSetter is implemented by providers that require API tokens,
+// so that tokens entered while the app is running take effect right away.
+type APITokenSetter interface {
+	// SetAPIToken sets the API token to include on requests.
+	SetAPIToken(token string)
+}
+
+// GetQuotesRequest is the request for GetQuotes.
+type GetQuotesRequest struct {
+	Symbols []string
+}
+
+// GetChartsRequest is the request for GetDailyCharts and GetIntradayCharts.
+type GetChartsRequest struct {
+	Symbols []string
+
+	// Range is how much history GetDailyCharts should get.
+	// Providers use their default range if unspecified.
+	Range Range
+}
+
+// Range is the range of history to get.
+type Range int
+
+// Range values.
+//go:generate stringer -type=Range
+const (
+	RangeUnspecified Range = iota
+	TwoYears
+	FiveYears
+	Max
+)
+
+// Quote is a stock quote.
+type Quote struct {
+	Symbol        string
+	CompanyName   string
+	LatestPrice   float32
+	LatestSource  Source
+	LatestTime    time.Time
+	LatestUpdate  time.Time
+	LatestVolume  int
+	Open          float32
+	High          float32
+	Low           float32
+	Close         float32
+	Change        float32
+	ChangePercent float32
+}
+
+// DeepCopy returns a deep copy of the quote.
+func (q *Quote) DeepCopy() *Quote {
+	if q == nil {
+		return nil
+	}
+	deep := *q
+	return &deep
+}
+
+// Source is the quote data source.
+type Source int
+
+// Source values.
+//go:generate stringer -type=Source
+const (
+	SourceUnspecified Source = iota
+	RealTimePrice
+	FifteenMinuteDelayedPrice
+	Close
+	PreviousClose
+	Price
+	LastTrade
+)
+
+// Chart is a series of bars for a single stock.
+type Chart struct {
+	Symbol string
+	Bars   []*Bar
+}
+
+// DeepCopy returns a deep copy of the chart.
+func (c *Chart) DeepCopy() *Chart {
+	if c == nil {
+		return nil
+	}
+	deep := *c
+	if len(deep.Bars) != 0 {
+		deep.Bars = make([]*Bar, len(c.Bars))
+		for i, b := range c.Bars {
+			deep.Bars[i] = b.DeepCopy()
+		}
+	}
+	return &deep
+}
+
+// Bar is the price and volume of a stock over a time period like a minute or day.
+type Bar struct {
+	Date          time.Time
+	Open          float32
+	High          float32
+	Low           float32
+	Close         float32
+	Volume        int
+	Change        float32
+	ChangePercent float32
+
+	// TotalReturnOpen, TotalReturnHigh, TotalReturnLow, and TotalReturnClose are adjusted
+	// for dividends as well as splits. Zero if the provider doesn't have them.
+	TotalReturnOpen  float32
+	TotalReturnHigh  float32
+	TotalReturnLow   float32
+	TotalReturnClose float32
+}
+
+// DeepCopy returns a deep copy of the bar.
+func (b *Bar) DeepCopy() *Bar {
+	if b == nil {
+		return nil
+	}
+	deep := *b
+	return &deep
+}
@@ -0,0 +1,135 @@
+package stock
+
+import (
+	"regexp"
+	"strings"
+
+	"github.com/btmura/ponzi2/internal/errs"
+)
+
+var (
+	// usBaseRegexp accepts the base of US symbols. Examples: X, FB, SPY, AAPL
+	usBaseRegexp = regexp.MustCompile("^[A-Z]{1,5}$")
+
+	// foreignBaseRegexp accepts the base of symbols listed on other exchanges,
+	// which can be longer and have digits. Examples: SHOP, 0700, 7203
+	foreignBaseRegexp = regexp.MustCompile("^[A-Z0-9]{1,6}$")
+
+	// classRegexp accepts share classes and other suffixes. Examples: A, B, U, WS
+	classRegexp = regexp.MustCompile("^[A-Z]{1,2}$")
+)
+
+// exchanges are the exchange suffixes that can follow symbols,
+// which are the same as the ones used by Yahoo Finance.
+var exchanges = map[string]bool{
+	"AS": true, // Euronext Amsterdam
+	"AX": true, // Australian Securities Exchange
+	"BR": true, // Euronext Brussels
+	"CN": true, // Canadian Securities Exchange
+	"DE": true, // XETRA
+	"HK": true, // Hong Kong Stock Exchange
+	"L":  true, // London Stock Exchange
+	"MC": true, // Madrid Stock Exchange
+	"MI": true, // Borsa Italiana
+	"NE": true, // NEO Exchange
+	"PA": true, // Euronext Paris
+	"ST": true, // Nasdaq Stockholm
+	"SW": true, // SIX Swiss Exchange
+	"T":  true, // Tokyo Stock Exchange
+	"TO": true, // Toronto Stock Exchange
+	"V":  true, // TSX Venture Exchange
+}
+
+// Symbol is a stock symbol split into its parts.
+//
+// Its String form separates the parts with dots like BRK.B, SHOP.TO, or RCI.B.TO,
+// and it is the form used throughout the app. Providers map it to their own spelling.
+type Symbol struct {
+	// Base is the main part of the symbol like BRK in BRK.B.
+	Base string
+
+	// Class is the optional share class like B in BRK.B.
+	Class string
+
+	// Exchange is the optional exchange suffix like TO in SHOP.TO.
+	// Empty for US exchanges.
+	Exchange string
+}
+
+// ParseSymbol parses a symbol with an optional class after a dot, dash, or slash
+// and an optional exchange suffix after a dot. Examples: SPY, BRK.B, RDS-A, SHOP.TO
+func ParseSymbol(s string) (Symbol, error) {
+	var sym Symbol
+
+	parts := strings.Split(s, ".")
+	if n := len(parts); n > 1 && exchanges[parts[n-1]] {
+		sym.Exchange = parts[n-1]
+		parts = parts[:n-1]
+	}
+
+	// Allow the class to follow a dash or slash like RDS-A or BRK/B.
+	if len(parts) == 1 {
+		if i := strings.IndexAny(parts[0], "-/"); i >= 0 {
+			parts = []string{parts[0][:i], parts[0][i+1:]}
+		}
+	}
+
+	switch len(parts) {
+	case 1:
+		sym.Base = parts[0]
+	case 2:
+		sym.Base, sym.Class = parts[0], parts[1]
+	default:
+		return Symbol{}, errs.Errorf("bad symbol: %q has too many parts", s)
+	}
+
+	baseRegexp := usBaseRegexp
+	if sym.Exchange != "" {
+		baseRegexp = foreignBaseRegexp
+	}
+
+	if !baseRegexp.MatchString(sym.Base) {
+		return Symbol{}, errs.Errorf("bad symbol: %q, want base matching %v", s, baseRegexp)
+	}
+
+	if len(parts) == 2 && !classRegexp.MatchString(sym.Class) {
+		return Symbol{}, errs.Errorf("bad symbol: %q, want class matching %v", s, classRegexp)
+	}
+
+	return sym, nil
+}
+
+// NormalizeSymbol returns the String form of a symbol in any form accepted by ParseSymbol.
+func NormalizeSymbol(s string) (string, error) {
+	sym, err := ParseSymbol(strings.ToUpper(strings.TrimSpace(s)))
+	if err != nil {
+		return "", err
+	}
+	return sym.String(), nil
+}
+
+// ValidateSymbol returns an error if the symbol is not in its String form.
+func ValidateSymbol(s string) error {
+	sym, err := ParseSymbol(s)
+	if err != nil {
+		return err
+	}
+
+	if sym.String() != s {
+		return errs.Errorf("bad symbol: got %s, want %s", s, sym)
+	}
+
+	return nil
+}
+
+// String returns the symbol with its parts separated by dots.
+func (s Symbol) String() string {
+	str := s.Base
+	if s.Class != "" {
+		str += "." + s.Class
+	}
+	if s.Exchange != "" {
+		str += "." + s.Exchange
+	}
+	return str
+}
@@ -0,0 +1,140 @@
+package stock
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestParseSymbol(t *testing.T) {
+	for _, tt := range []struct {
+		desc    string
+		input   string
+		want    Symbol
+		wantErr bool
+	}{
+		{
+			desc:  "plain symbol",
+			input: "SPY",
+			want:  Symbol{Base: "SPY"},
+		},
+		{
+			desc:  "class after dot",
+			input: "BRK.B",
+			want:  Symbol{Base: "BRK", Class: "B"},
+		},
+		{
+			desc:  "class after dash",
+			input: "RDS-A",
+			want:  Symbol{Base: "RDS", Class: "A"},
+		},
+		{
+			desc:  "class after slash",
+			input: "BF/B",
+			want:  Symbol{Base: "BF", Class: "B"},
+		},
+		{
+			desc:  "exchange suffix",
+			input: "SHOP.TO",
+			want:  Symbol{Base: "SHOP", Exchange: "TO"},
+		},
+		{
+			desc:  "class and exchange suffix",
+			input: "RCI-B.TO",
+			want:  Symbol{Base: "RCI", Class: "B", Exchange: "TO"},
+		},
+		{
+			desc:  "digits on foreign exchange",
+			input: "0700.HK",
+			want:  Symbol{Base: "0700", Exchange: "HK"},
+		},
+		{
+			desc:    "digits on US exchange",
+			input:   "0700",
+			wantErr: true,
+		},
+		{
+			desc:    "base too long",
+			input:   "SPYSPY",
+			wantErr: true,
+		},
+		{
+			desc:    "lowercase",
+			input:   "spy",
+			wantErr: true,
+		},
+		{
+			desc:    "too many parts",
+			input:   "A.B.C.D",
+			wantErr: true,
+		},
+		{
+			desc:    "empty",
+			input:   "",
+			wantErr: true,
+		},
+		{
+			desc:    "empty class",
+			input:   "BRK.",
+			wantErr: true,
+		},
+	} {
+		t.Run(tt.desc, func(t *testing.T) {
+			got, gotErr := ParseSymbol(tt.input)
+
+			if diff := cmp.Diff(tt.want, got); diff != "" {
+				t.Errorf("diff (-want, +got)\n%s", diff)
+			}
+
+			if (gotErr != nil) != tt.wantErr {
+				t.Errorf("got error: %v, wanted err: %t", gotErr, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestNormalizeSymbol(t *testing.T) {
+	for _, tt := range []struct {
+		input   string
+		want    string
+		wantErr bool
+	}{
+		{input: "spy", want: "SPY"},
+		{input: "brk-b", want: "BRK.B"},
+		{input: " shop.to ", want: "SHOP.TO"},
+		{input: "RCI/B.TO", want: "RCI.B.TO"},
+		{input: "SPYSPY", wantErr: true},
+	} {
+		t.Run(tt.input, func(t *testing.T) {
+			got, gotErr := NormalizeSymbol(tt.input)
+
+			if got != tt.want {
+				t.Errorf("got %q, want %q", got, tt.want)
+			}
+
+			if (gotErr != nil) != tt.wantErr {
+				t.Errorf("got error: %v, wanted err: %t", gotErr, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestValidateSymbol(t *testing.T) {
+	for _, tt := range []struct {
+		input   string
+		wantErr bool
+	}{
+		{input: "SPY"},
+		{input: "BRK.B"},
+		{input: "SHOP.TO"},
+		{input: "BRK-B", wantErr: true},
+		{input: "spy", wantErr: true},
+	} {
+		t.Run(tt.input, func(t *testing.T) {
+			gotErr := ValidateSymbol(tt.input)
+			if (gotErr != nil) != tt.wantErr {
+				t.Errorf("got error: %v, wanted err: %t", gotErr, tt.wantErr)
+			}
+		})
+	}
+}